@@ -0,0 +1,136 @@
+package main
+
+// server backfill — rebuilds the metadata database from the transcripts
+// already in the output directories (see storage.ScanTranscripts), and
+// with -index pushes them to the search index too. Jobs that already have
+// a row are left alone, so it is safe to run against a live database.
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/search"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+)
+
+// runBackfill runs the backfill command, returning the exit status
+func runBackfill(config *Config, args []string) int {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	dir := flags.String("dir", "", "directory to scan (default: output_dir and every local destination)")
+	index := flags.Bool("index", false, "also index every transcript found into the search cluster")
+	dryRun := flags.Bool("dry-run", false, "report what would be backfilled without writing anything")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	dirs := []string{*dir}
+	if *dir == "" {
+		dirs = []string{config.Storage.OutputDir}
+		for _, dest := range config.Storage.Destinations {
+			if dest.Type == storage.DestinationLocal && !slices.Contains(dirs, dest.Dir) {
+				dirs = append(dirs, dest.Dir)
+			}
+		}
+	}
+
+	db, err := storage.NewMetadataDB(config.Storage.Database)
+	if err != nil {
+		log.Printf("Failed to open database: %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	var indexer *search.Indexer
+	if *index {
+		if config.Search.URL == "" {
+			log.Printf("-index needs search.url to be configured")
+			return 2
+		}
+		if indexer, err = newSearchIndexer(config); err != nil {
+			log.Printf("Invalid search config: %v", err)
+			return 1
+		}
+	}
+
+	local := storage.NewLocalStorage(config.Storage.OutputDir)
+	var found, added, indexed, skipped, failed int
+	for _, d := range dirs {
+		log.Printf("Scanning %s", d)
+		err := storage.ScanTranscripts(d, func(t *storage.StoredTranscript) error {
+			found++
+			if *dryRun {
+				log.Printf("Found %s (%s)", t.JobID, t.TxtPath)
+				return nil
+			}
+			inserted, err := db.BackfillTranscript(t, local.ArtifactBytes(t.TxtPath))
+			if err != nil {
+				log.Printf("Failed to backfill %s: %v", t.TxtPath, err)
+				failed++
+				return nil
+			}
+			if inserted {
+				added++
+			}
+			if indexer != nil {
+				if err := indexStored(indexer, db, t); err != nil {
+					log.Printf("Failed to index %s: %v", t.JobID, err)
+					failed++
+					return nil
+				}
+				indexed++
+			}
+			return nil
+		}, func(path, reason string) error {
+			log.Printf("Skipping %s: %s", path, reason)
+			skipped++
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to scan %s: %v", d, err)
+			return 1
+		}
+	}
+
+	summary := fmt.Sprintf("Backfill: %d transcripts found, %d rows added, %d already present, %d skipped, %d failed",
+		found, added, found-added-failed, skipped, failed)
+	if *dryRun {
+		summary = fmt.Sprintf("Backfill (dry run): %d transcripts found, %d skipped", found, skipped)
+	} else if indexer != nil {
+		summary += fmt.Sprintf(", %d indexed", indexed)
+	}
+	log.Print(summary)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// indexStored indexes a transcript found on disk, under the source type
+// its database row has
+func indexStored(indexer *search.Indexer, db *storage.MetadataDB, t *storage.StoredTranscript) error {
+	text, err := t.Text()
+	if err != nil {
+		return err
+	}
+	doc := search.Document{
+		JobID:       t.JobID,
+		RequestName: t.RequestName,
+		Text:        text,
+		Language:    t.Language,
+		Duration:    t.Duration,
+		WordCount:   t.WordCount,
+		Segments:    t.Segments,
+		Metadata:    t.Metadata,
+		Labels:      t.Labels,
+		CreatedAt:   t.CreatedAt,
+		GDriveURL:   t.GDriveURL,
+	}
+	if row, err := db.GetTranscript(t.JobID); err == nil {
+		doc.SourceType, _ = row["source_type"].(string)
+		doc.Description, _ = row["description"].(string)
+	}
+	return indexer.Index(doc)
+}
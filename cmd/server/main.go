@@ -4,13 +4,17 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
-	"sync"
+	"slices"
+	"strings"
 	"syscall"
+	"time"
+	_ "time/tzdata" // time zones for hosts without a zoneinfo database
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -21,9 +25,17 @@ import (
 
 	"github.com/codebuildervaibhav/audio-transcription/internal/cleanup"
 	"github.com/codebuildervaibhav/audio-transcription/internal/handlers"
-	"github.com/codebuildervaibhav/audio-transcription/internal/queue"
-	"github.com/codebuildervaibhav/audio-transcription/internal/storage"
-	"github.com/codebuildervaibhav/audio-transcription/internal/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/internal/health"
+	"github.com/codebuildervaibhav/audio-transcription/internal/logging"
+	"github.com/codebuildervaibhav/audio-transcription/internal/postprocess"
+	"github.com/codebuildervaibhav/audio-transcription/internal/reports"
+	"github.com/codebuildervaibhav/audio-transcription/internal/search"
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
 )
 
 // Config represents the application configuration
@@ -31,23 +43,148 @@ type Config struct {
 	Server struct {
 		Port int    `yaml:"port"`
 		Host string `yaml:"host"`
+		// DrainSeconds is how long /readyz reports draining before shutdown
+		DrainSeconds int `yaml:"drain_seconds"`
 	} `yaml:"server"`
 
+	Health struct {
+		// MinFreeDiskMB marks the disk unhealthy when temp_dir or output_dir
+		// has less free space than this (0 = not checked)
+		MinFreeDiskMB int `yaml:"min_free_disk_mb"`
+		// GateIntake rejects new jobs while whisper, the database, or the
+		// disk is unhealthy
+		GateIntake bool `yaml:"gate_intake"`
+	} `yaml:"health"`
+
 	Whisper struct {
+		// Backend is "python" (default), "whispercpp", "fasterwhisper",
+		// "deepgram", "assemblyai", "google_stt", "aws_transcribe", or "vosk"
+		Backend   string `yaml:"backend"`
 		Model     string `yaml:"model"`
 		ModelPath string `yaml:"model_path"`
 		Threads   int    `yaml:"threads"`
 		Device    string `yaml:"device"`
+		// Devices spreads the python and fasterwhisper backends over
+		// several GPUs ("cuda:0", "cuda:1"), pinning workers round-robin;
+		// MaxJobsPerDevice caps concurrent runs on each (default 1)
+		Devices          []string `yaml:"devices"`
+		MaxJobsPerDevice int      `yaml:"max_jobs_per_device"`
+		// Persistent keeps a whisper process loaded for the python backend
+		// instead of starting the CLI for every job
+		Persistent bool `yaml:"persistent"`
+		// Prewarm runs a throwaway transcription before reporting ready
+		Prewarm bool `yaml:"prewarm"`
+		// SelfTest transcribes a short sample at startup and reports it in /health
+		SelfTest bool `yaml:"self_test"`
+		// OutputFormats are extra renderings (srt, vtt, tsv) saved per job
+		OutputFormats []string `yaml:"output_formats"`
+		// RepetitionRetryTemperatures are tried when re-decoding a repetition
+		// loop; unset uses the defaults, an empty list only flags loops
+		RepetitionRetryTemperatures []float64 `yaml:"repetition_retry_temperatures"`
+		// Decoding sets the decoding parameters jobs may override
+		Decoding types.DecodingParams `yaml:"decoding"`
+		// Chunking splits long audio into chunks transcribed in parallel
+		Chunking transcription.ChunkingOptions `yaml:"chunking"`
+		// VAD removes long silences before transcription
+		VAD transcription.VADOptions `yaml:"vad"`
+		// Denoise is the noise reduction filter for jobs with denoise set
+		Denoise transcription.DenoiseOptions `yaml:"denoise"`
+		// Diarization labels speakers for jobs with diarize set
+		Diarization transcription.DiarizationOptions `yaml:"diarization"`
+		// Speedup plays jobs that do not choose their own speed-up faster
+		// to whisper (1 to 2; 0 keeps the recorded speed)
+		Speedup float64 `yaml:"speedup"`
+		// FFmpegOnly sends every input through ffprobe and ffmpeg, turning
+		// off the native WAV, MP3, and Ogg Vorbis decoders
+		FFmpegOnly bool `yaml:"ffmpeg_only"`
+		// Python sets the interpreter, virtualenv, extra CLI args, and
+		// environment of the python and fasterwhisper backends
+		Python transcription.PythonOptions `yaml:"python"`
+		// Deepgram, AssemblyAI, GoogleSTT, and AWSTranscribe configure the
+		// cloud backends
+		Deepgram      transcription.DeepgramOptions      `yaml:"deepgram"`
+		AssemblyAI    transcription.AssemblyAIOptions    `yaml:"assemblyai"`
+		GoogleSTT     transcription.GoogleSTTOptions     `yaml:"google_stt"`
+		AWSTranscribe transcription.AWSTranscribeOptions `yaml:"aws_transcribe"`
+		// Vosk maps languages to Vosk model directories for the vosk backend
+		Vosk transcription.VoskOptions `yaml:"vosk"`
 	} `yaml:"whisper"`
 
+	// SourceDefaults are option defaults for jobs by source type (upload,
+	// gdrive, youtube, stream, import)
+	SourceDefaults map[string]queue.SourceDefaults `yaml:"source_defaults"`
+
+	// Formatting picks how numbers, times, and amounts are written
+	Formatting struct {
+		// Profile is the default for jobs that do not choose one
+		Profile  string                                 `yaml:"profile"`
+		Profiles map[string]transcription.FormatProfile `yaml:"profiles"`
+	} `yaml:"formatting"`
+
+	// Replacements fix misheard terms (heard -> written) in every
+	// transcript; Tenants add entries by the "tenant" job label
+	Replacements struct {
+		Global  map[string]string            `yaml:"global"`
+		Tenants map[string]map[string]string `yaml:"tenants"`
+	} `yaml:"replacements"`
+
+	// Captions adds caption presets for subtitle exports (?preset=)
+	Captions struct {
+		Presets map[string]transcription.CaptionPreset `yaml:"presets"`
+	} `yaml:"captions"`
+
 	Workers struct {
 		Count int `yaml:"count"`
+		// MaxAttempts is how many times a failing job is tried before it is
+		// dead-lettered
+		MaxAttempts int `yaml:"max_attempts"`
+		// StallTimeoutMinutes requeues a job that makes no progress for this
+		// long and replaces its worker (0 = never)
+		StallTimeoutMinutes int `yaml:"stall_timeout_minutes"`
+		// CaptureTTLMinutes fails a job still fetching its source (e.g. a
+		// YouTube capture) after this long (0 = never)
+		CaptureTTLMinutes int `yaml:"capture_ttl_minutes"`
+		// MaxQueueWaitMinutes alerts when the oldest queued job has waited
+		// this long, and QueueDepthAlert when more jobs than this stay
+		// queued for QueueDepthAlertMinutes (0 = off)
+		MaxQueueWaitMinutes    int `yaml:"max_queue_wait_minutes"`
+		QueueDepthAlert        int `yaml:"queue_depth_alert"`
+		QueueDepthAlertMinutes int `yaml:"queue_depth_alert_minutes"`
 	} `yaml:"workers"`
 
+	// Resources limits the whisper/ffmpeg/yt-dlp subprocesses (Linux only)
+	Resources struct {
+		Nice          int    `yaml:"nice"`
+		CPUAffinity   string `yaml:"cpu_affinity"`
+		MemoryLimitMB int    `yaml:"memory_limit_mb"`
+		CgroupParent  string `yaml:"cgroup_parent"`
+	} `yaml:"resources"`
+
 	Storage struct {
 		TempDir   string `yaml:"temp_dir"`
 		OutputDir string `yaml:"output_dir"`
 		Database  string `yaml:"database"`
+		// DeadLetterDir keeps the source audio of jobs that failed for good
+		DeadLetterDir string `yaml:"dead_letter_dir"`
+		// Destinations are named output locations jobs may choose instead
+		// of output_dir and the Drive folder
+		Destinations map[string]storage.Destination `yaml:"destinations"`
+		// TextEncoding sets the BOM and line endings of txt and subtitle files
+		TextEncoding storage.TextEncoding `yaml:"text_encoding"`
+		// TimeZone dates output folders, file names, and metadata
+		// timestamps ("" = server local time); TenantTimeZones override it
+		// by the "tenant" job label
+		TimeZone        string            `yaml:"time_zone"`
+		TenantTimeZones map[string]string `yaml:"tenant_time_zones"`
+		// KeepAudio keeps a copy of each job's audio next to its transcript
+		// for playback: "original", "normalized", or "" for none
+		KeepAudio string `yaml:"keep_audio"`
+		// RawOutput keeps whisper's JSON output next to each transcript,
+		// removed RetentionDays after the job (0 = kept with the transcript)
+		RawOutput struct {
+			Keep          bool `yaml:"keep"`
+			RetentionDays int  `yaml:"retention_days"`
+		} `yaml:"raw_output"`
 	} `yaml:"storage"`
 
 	Cleanup struct {
@@ -59,12 +196,130 @@ type Config struct {
 		CredentialsFile string `yaml:"credentials_file"`
 		TokenFile       string `yaml:"token_file"`
 		FolderName      string `yaml:"folder_name"`
+		// UploadFormats limits the extra renderings uploaded to Drive
+		UploadFormats []string `yaml:"upload_formats"`
+		// Credentials and Token are secret references (env:, file:, vault:)
+		// that take precedence over the files above
+		Credentials string `yaml:"credentials"`
+		Token       string `yaml:"token"`
 	} `yaml:"google_drive"`
 
+	YouTube struct {
+		DownloadTimeoutMinutes int `yaml:"download_timeout_minutes"`
+		// MaxDownloadMB caps yt-dlp downloads (0 = limits.max_file_size_mb)
+		MaxDownloadMB int `yaml:"max_download_mb"`
+	} `yaml:"youtube"`
+
+	Recordings struct {
+		// Dir holds chunked recordings until they are assembled
+		Dir string `yaml:"dir"`
+		// TTLHours removes recordings untouched for this long (0 = 168)
+		TTLHours int `yaml:"ttl_hours"`
+	} `yaml:"recordings"`
+
+	Uploads struct {
+		// Dir holds upload sessions until they are committed
+		Dir string `yaml:"dir"`
+		// TTLHours removes sessions untouched for this long (0 = 72)
+		TTLHours int `yaml:"ttl_hours"`
+		// MaxSizeMB caps a session's file (0 = 10240); each part is held
+		// to limits.max_file_size_mb
+		MaxSizeMB int `yaml:"max_size_mb"`
+	} `yaml:"uploads"`
+
+	Integrity struct {
+		// VerifyIntervalHours re-checks stored artifact checksums (0 = off)
+		VerifyIntervalHours int `yaml:"verify_interval_hours"`
+	} `yaml:"integrity"`
+
 	Limits struct {
 		MaxFileSizeMB      int `yaml:"max_file_size_mb"`
 		MaxDurationMinutes int `yaml:"max_duration_minutes"`
+		// TruncateLongAudio transcribes the first max_duration_minutes of
+		// longer audio instead of rejecting it
+		TruncateLongAudio bool `yaml:"truncate_long_audio"`
+		// SyncMaxDurationSeconds caps clips accepted with sync=true (0 = disabled)
+		SyncMaxDurationSeconds int `yaml:"sync_max_duration_seconds"`
+		SyncTimeoutSeconds     int `yaml:"sync_timeout_seconds"`
 	} `yaml:"limits"`
+
+	Quotas struct {
+		WarnPercent int         `yaml:"warn_percent"`
+		Default     QuotaConfig `yaml:"default"`
+		// Per-tenant overrides, keyed by the value of the "tenant" job label
+		Tenants map[string]QuotaConfig `yaml:"tenants"`
+	} `yaml:"quotas"`
+
+	Webhooks struct {
+		MaxAttempts        int `yaml:"max_attempts"`
+		BaseBackoffSeconds int `yaml:"base_backoff_seconds"`
+		MaxBackoffSeconds  int `yaml:"max_backoff_seconds"`
+		TimeoutSeconds     int `yaml:"timeout_seconds"`
+		Endpoints          []struct {
+			URL string `yaml:"url"`
+			// Secrets are secret references; list the new secret first
+			// and keep the old one until receivers have switched
+			Secrets []string `yaml:"secrets"`
+			Events  []string `yaml:"events"`
+			// Template (or TemplateFile, a path to one) shapes the request
+			// body with a Go template, sent as ContentType
+			Template     string `yaml:"template"`
+			TemplateFile string `yaml:"template_file"`
+			ContentType  string `yaml:"content_type"`
+		} `yaml:"endpoints"`
+		// ResultLinks adds signed artifact download URLs to payloads
+		ResultLinks struct {
+			// BaseURL is this server's address as receivers reach it
+			BaseURL string `yaml:"base_url"`
+			// Secret is a secret reference used to sign the links
+			Secret     string `yaml:"secret"`
+			TTLMinutes int    `yaml:"ttl_minutes"`
+		} `yaml:"result_links"`
+	} `yaml:"webhooks"`
+
+	// PostProcess hooks rewrite transcripts before they are stored
+	PostProcess struct {
+		Hooks []struct {
+			Name           string   `yaml:"name"`
+			Command        []string `yaml:"command"`
+			URL            string   `yaml:"url"`
+			TimeoutSeconds int      `yaml:"timeout_seconds"`
+			// FailJob fails the job on hook errors instead of skipping the hook
+			FailJob bool `yaml:"fail_job"`
+		} `yaml:"hooks"`
+	} `yaml:"postprocess"`
+
+	// Reports are activity digests sent daily or weekly
+	Reports struct {
+		SMTP      reports.SMTPOptions `yaml:"smtp"`
+		Schedules []reports.Report    `yaml:"schedules"`
+	} `yaml:"reports"`
+
+	// Search pushes completed transcripts to Elasticsearch/OpenSearch
+	Search struct {
+		URL   string `yaml:"url"`
+		Index string `yaml:"index"`
+		// Credentials are secret references (env:, file:, vault:) or literals
+		Username       string `yaml:"username"`
+		Password       string `yaml:"password"`
+		APIKey         string `yaml:"api_key"`
+		TimeoutSeconds int    `yaml:"timeout_seconds"`
+	} `yaml:"search"`
+}
+
+// QuotaConfig holds per-tenant limits (0 = unlimited)
+type QuotaConfig struct {
+	LocalMB           int `yaml:"local_mb"`
+	DriveMB           int `yaml:"drive_mb"`
+	MaxConcurrentJobs int `yaml:"max_concurrent_jobs"`
+}
+
+// limit converts the configured megabytes to a storage.QuotaLimit
+func (q QuotaConfig) limit() storage.QuotaLimit {
+	return storage.QuotaLimit{
+		LocalBytes: int64(q.LocalMB) * 1024 * 1024,
+		DriveBytes: int64(q.DriveMB) * 1024 * 1024,
+	}
 }
 
 func main() {
@@ -74,6 +329,11 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// "server backfill" rebuilds database rows from the output directory
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		os.Exit(runBackfill(config, os.Args[2:]))
+	}
+
 	// Ensure directories exist
 	if err := cleanup.EnsureTempDirExists(config.Storage.TempDir); err != nil {
 		log.Fatalf("Failed to create temp directory: %v", err)
@@ -82,16 +342,27 @@ func main() {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
-	// Custom logger setup
-	logBuffer := &LogBuffer{
-		lines: make([]string, 0, 1000),
-	}
-	multiWriter := io.MultiWriter(os.Stdout, logBuffer)
+	// Custom logger setup; secrets are redacted from stdout and the buffer
+	logBuffer := logging.NewBuffer(1000)
+	multiWriter := io.MultiWriter(logging.NewRedactWriter(os.Stdout), logBuffer)
 	log.SetOutput(multiWriter)
 
 	// Initialize components
 	log.Println("Initializing components...")
 
+	// Secret providers beyond env: and file: are opt-in
+	if secrets.RegisterVaultFromEnv() {
+		log.Println("Vault secret provider enabled")
+	}
+
+	// Subprocess resource limits
+	transcription.SetResourceLimits(transcription.ResourceLimits{
+		Nice:         config.Resources.Nice,
+		CPUAffinity:  config.Resources.CPUAffinity,
+		MemoryMB:     config.Resources.MemoryLimitMB,
+		CgroupParent: config.Resources.CgroupParent,
+	})
+
 	// Whisper transcriber
 	transcriber, err := transcription.NewWhisperTranscriber(
 		config.Whisper.ModelPath,
@@ -101,15 +372,58 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize Whisper: %v", err)
 	}
+	transcriber.SetDeepgram(config.Whisper.Deepgram)
+	transcriber.SetAssemblyAI(config.Whisper.AssemblyAI)
+	transcriber.SetGoogleSTT(config.Whisper.GoogleSTT)
+	transcriber.SetAWSTranscribe(config.Whisper.AWSTranscribe)
+	transcriber.SetVosk(config.Whisper.Vosk)
+	if err := transcriber.SetPython(config.Whisper.Python); err != nil {
+		log.Fatalf("Invalid whisper.python config: %v", err)
+	}
+	if err := transcriber.SetDevices(config.Whisper.Devices, config.Whisper.MaxJobsPerDevice); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+	transcriber.SetPersistent(config.Whisper.Persistent)
+	if err := transcriber.SetBackend(config.Whisper.Backend); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+	defer transcriber.Close()
+	if err := transcriber.SetOutputFormats(config.Whisper.OutputFormats); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+	if config.Whisper.RepetitionRetryTemperatures != nil {
+		if err := transcriber.SetRepetitionTemperatures(config.Whisper.RepetitionRetryTemperatures); err != nil {
+			log.Fatalf("Invalid whisper config: %v", err)
+		}
+	}
+
+	if err := transcriber.SetDecoding(config.Whisper.Decoding); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+	if err := transcriber.SetChunking(config.Whisper.Chunking); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+	if err := transcriber.SetVAD(config.Whisper.VAD); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+	if err := transcriber.SetDiarization(config.Whisper.Diarization); err != nil {
+		log.Fatalf("Invalid whisper.diarization config: %v", err)
+	}
+	transcription.SetFFmpegOnly(config.Whisper.FFmpegOnly)
 
 	// Local storage
 	localStorage := storage.NewLocalStorage(config.Storage.OutputDir)
 
 	// Google Drive client (optional - may fail if credentials not set up)
 	var driveClient *storage.DriveClient
-	if _, err := os.Stat(config.GoogleDrive.CredentialsFile); err == nil {
+	credentialsJSON, tokenJSON, err := loadDriveSecrets(config)
+	if err != nil {
+		log.Printf("WARNING: Google Drive secrets unavailable: %v", err)
+		log.Println("Transcripts will only be saved locally")
+	} else if credentialsJSON != nil {
 		driveClient, err = storage.NewDriveClient(
-			config.GoogleDrive.CredentialsFile,
+			credentialsJSON,
+			tokenJSON,
 			config.GoogleDrive.TokenFile,
 			config.GoogleDrive.FolderName,
 		)
@@ -119,6 +433,12 @@ func main() {
 			driveClient = nil
 		} else {
 			log.Println("Google Drive integration enabled")
+			// Merge dated folders duplicated by earlier concurrent uploads
+			go func() {
+				if err := driveClient.ReconcileFolders(); err != nil {
+					log.Printf("WARNING: Drive folder reconciliation failed: %v", err)
+				}
+			}()
 		}
 	} else {
 		log.Println("Google Drive credentials not found - saving locally only")
@@ -131,6 +451,12 @@ func main() {
 	}
 	defer db.Close()
 
+	// Periodic artifact integrity verification
+	if config.Integrity.VerifyIntervalHours > 0 {
+		stopVerification := db.StartVerification(time.Duration(config.Integrity.VerifyIntervalHours) * time.Hour)
+		defer stopVerification()
+	}
+
 	// Worker pool
 	workerPool := queue.NewWorkerPool(
 		config.Workers.Count,
@@ -139,7 +465,178 @@ func main() {
 		driveClient,
 		db,
 	)
+
+	// Number/time formatting profiles
+	for name, profile := range config.Formatting.Profiles {
+		if err := transcription.RegisterFormatProfile(name, profile); err != nil {
+			log.Fatalf("Invalid formatting config: %v", err)
+		}
+	}
+	if err := workerPool.SetFormatProfile(config.Formatting.Profile); err != nil {
+		log.Fatalf("Invalid formatting config: %v", err)
+	}
+
+	// Replacement dictionaries for misheard terms
+	if err := workerPool.SetReplacements(config.Replacements.Global, config.Replacements.Tenants); err != nil {
+		log.Fatalf("Invalid replacements config: %v", err)
+	}
+
+	// Caption presets for subtitle exports
+	for name, preset := range config.Captions.Presets {
+		if err := transcription.RegisterCaptionPreset(name, preset); err != nil {
+			log.Fatalf("Invalid captions config: %v", err)
+		}
+	}
+
+	// Retries and the dead-letter list
+	if err := workerPool.SetDeadLetter(config.Storage.DeadLetterDir, config.Workers.MaxAttempts); err != nil {
+		log.Fatalf("Invalid storage config: %v", err)
+	}
+
+	// Stalled-worker detection
+	workerPool.SetStallTimeout(time.Duration(config.Workers.StallTimeoutMinutes) * time.Minute)
+
+	// Queue wait and depth alerts
+	workerPool.SetQueueAlerts(queue.QueueAlerts{
+		MaxWait:  time.Duration(config.Workers.MaxQueueWaitMinutes) * time.Minute,
+		Depth:    config.Workers.QueueDepthAlert,
+		DepthFor: time.Duration(config.Workers.QueueDepthAlertMinutes) * time.Minute,
+	})
+	workerPool.SetCaptureTTL(time.Duration(config.Workers.CaptureTTLMinutes) * time.Minute)
+
+	// Longest audio a job may transcribe
+	workerPool.SetMaxDuration(time.Duration(config.Limits.MaxDurationMinutes)*time.Minute, config.Limits.TruncateLongAudio)
+
+	// Renderings uploaded to Drive
+	if err := workerPool.SetDriveFormats(config.GoogleDrive.UploadFormats); err != nil {
+		log.Fatalf("Invalid google_drive config: %v", err)
+	}
+
+	// Noise reduction for jobs that ask for it
+	if err := workerPool.SetDenoise(config.Whisper.Denoise); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+
+	// Faster playback for quicker turnaround on long recordings
+	if err := workerPool.SetSpeedup(config.Whisper.Speedup); err != nil {
+		log.Fatalf("Invalid whisper config: %v", err)
+	}
+
+	// BOM and line endings of text artifacts
+	if err := workerPool.SetTextEncoding(config.Storage.TextEncoding); err != nil {
+		log.Fatalf("Invalid storage config: %v", err)
+	}
+	if err := workerPool.SetTimeZones(config.Storage.TimeZone, config.Storage.TenantTimeZones); err != nil {
+		log.Fatalf("Invalid storage config: %v", err)
+	}
+
+	// Per-job output destinations
+	if err := workerPool.SetDestinations(config.Storage.Destinations); err != nil {
+		log.Fatalf("Invalid storage config: %v", err)
+	}
+
+	// Audio kept for playback next to transcripts
+	if err := workerPool.SetKeepAudio(config.Storage.KeepAudio); err != nil {
+		log.Fatalf("Invalid storage config: %v", err)
+	}
+
+	// Whisper's raw output kept next to transcripts
+	workerPool.SetKeepRawOutput(config.Storage.RawOutput.Keep)
+
+	// Option defaults per source
+	if err := workerPool.SetSourceDefaults(config.SourceDefaults); err != nil {
+		log.Fatalf("Invalid source_defaults config: %v", err)
+	}
+
+	// Per-tenant storage quotas
+	tenantQuotas := make(map[string]storage.QuotaLimit, len(config.Quotas.Tenants))
+	tenantConcurrency := make(map[string]int, len(config.Quotas.Tenants))
+	for tenant, q := range config.Quotas.Tenants {
+		tenantQuotas[tenant] = q.limit()
+		if q.MaxConcurrentJobs > 0 {
+			tenantConcurrency[tenant] = q.MaxConcurrentJobs
+		}
+	}
+	workerPool.SetQuotaManager(storage.NewQuotaManager(db, config.Quotas.Default.limit(), tenantQuotas, config.Quotas.WarnPercent))
+	workerPool.SetTenantConcurrency(config.Quotas.Default.MaxConcurrentJobs, tenantConcurrency)
+
+	// Webhook outbox
+	var webhookDispatcher *webhooks.Dispatcher
+	if len(config.Webhooks.Endpoints) > 0 {
+		endpoints := make([]webhooks.Endpoint, 0, len(config.Webhooks.Endpoints))
+		for _, e := range config.Webhooks.Endpoints {
+			if e.TemplateFile != "" {
+				text, err := os.ReadFile(e.TemplateFile)
+				if err != nil {
+					log.Fatalf("Invalid webhooks config: %v", err)
+				}
+				e.Template = string(text)
+			}
+			endpoints = append(endpoints, webhooks.Endpoint{URL: e.URL, Secrets: e.Secrets, Events: e.Events,
+				Template: e.Template, ContentType: e.ContentType})
+		}
+		webhookDispatcher, err = webhooks.NewDispatcher(db, endpoints, webhooks.Options{
+			MaxAttempts: config.Webhooks.MaxAttempts,
+			BaseBackoff: time.Duration(config.Webhooks.BaseBackoffSeconds) * time.Second,
+			MaxBackoff:  time.Duration(config.Webhooks.MaxBackoffSeconds) * time.Second,
+			Timeout:     time.Duration(config.Webhooks.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Invalid webhooks config: %v", err)
+		}
+		webhookDispatcher.Start()
+		defer webhookDispatcher.Stop()
+		workerPool.SetWebhooks(webhookDispatcher)
+	}
+
+	// Signed result links in webhook payloads
+	var resultLinks *webhooks.ResultLinks
+	if links := config.Webhooks.ResultLinks; links.BaseURL != "" {
+		if links.Secret == "" {
+			log.Fatal("Invalid webhooks config: result_links.secret is required with base_url")
+		}
+		resultLinks = webhooks.NewResultLinks(links.BaseURL, links.Secret, time.Duration(links.TTLMinutes)*time.Minute)
+		workerPool.SetResultLinks(resultLinks)
+	}
+
+	// Post-processing hooks
+	if len(config.PostProcess.Hooks) > 0 {
+		hooks := make([]postprocess.Hook, 0, len(config.PostProcess.Hooks))
+		for _, h := range config.PostProcess.Hooks {
+			hooks = append(hooks, postprocess.Hook{
+				Name:    h.Name,
+				Command: h.Command,
+				URL:     h.URL,
+				Timeout: time.Duration(h.TimeoutSeconds) * time.Second,
+				FailJob: h.FailJob,
+			})
+		}
+		runner, err := postprocess.NewRunner(hooks)
+		if err != nil {
+			log.Fatalf("Invalid postprocess config: %v", err)
+		}
+		workerPool.SetPostProcessor(runner)
+		log.Printf("Post-processing hooks enabled: %d", runner.Len())
+	}
+
+	// Search index export
+	var searchIndexer *search.Indexer
+	if config.Search.URL != "" {
+		searchIndexer, err = newSearchIndexer(config)
+		if err != nil {
+			log.Fatalf("Invalid search config: %v", err)
+		}
+		if err := searchIndexer.Ping(); err != nil {
+			log.Printf("WARNING: search cluster not reachable yet: %v", err)
+		}
+		workerPool.SetIndexer(searchIndexer)
+		log.Printf("Indexing transcripts into %s", config.Search.URL)
+	}
+
 	workerPool.Start()
+	if _, err := workerPool.Resume(); err != nil {
+		log.Printf("WARNING: could not resume unfinished jobs: %v", err)
+	}
 
 	// Cleanup scheduler
 	cleanupScheduler := cleanup.NewScheduler(
@@ -147,9 +644,85 @@ func main() {
 		config.Cleanup.IntervalMinutes,
 		config.Cleanup.MaxAgeHours,
 	)
+	cleanupScheduler.SetInUseCheck(workerPool.FileInUse)
 	cleanupScheduler.Start()
 	defer cleanupScheduler.Stop()
 
+	// Chunked recordings, swept hourly once abandoned
+	if config.Recordings.Dir == "" {
+		config.Recordings.Dir = "./recordings"
+	}
+	if config.Recordings.TTLHours <= 0 {
+		config.Recordings.TTLHours = 168
+	}
+	recordingStore, err := storage.NewRecordingStore(config.Recordings.Dir)
+	if err != nil {
+		log.Fatalf("Failed to initialize recordings: %v", err)
+	}
+	go func() {
+		ttl := time.Duration(config.Recordings.TTLHours) * time.Hour
+		for range time.Tick(time.Hour) {
+			if n := recordingStore.Sweep(ttl); n > 0 {
+				log.Printf("Removed %d abandoned recording(s)", n)
+			}
+		}
+	}()
+
+	// Upload sessions, swept hourly once abandoned
+	if config.Uploads.Dir == "" {
+		config.Uploads.Dir = "./uploads"
+	}
+	if config.Uploads.TTLHours <= 0 {
+		config.Uploads.TTLHours = 72
+	}
+	if config.Uploads.MaxSizeMB <= 0 {
+		config.Uploads.MaxSizeMB = 10240
+	}
+	uploadStore, err := storage.NewUploadStore(config.Uploads.Dir)
+	if err != nil {
+		log.Fatalf("Failed to initialize upload sessions: %v", err)
+	}
+	go func() {
+		ttl := time.Duration(config.Uploads.TTLHours) * time.Hour
+		for range time.Tick(time.Hour) {
+			if n := uploadStore.Sweep(ttl); n > 0 {
+				log.Printf("Removed %d abandoned upload session(s)", n)
+			}
+		}
+	}()
+
+	// Raw outputs, swept hourly once past their retention
+	if days := config.Storage.RawOutput.RetentionDays; days > 0 {
+		go func() {
+			maxAge := time.Duration(days) * 24 * time.Hour
+			for range time.Tick(time.Hour) {
+				n, err := db.ExpireRawOutputs(maxAge)
+				if err != nil {
+					log.Printf("Failed to expire raw outputs: %v", err)
+				} else if n > 0 {
+					log.Printf("Removed %d expired raw output(s)", n)
+				}
+			}
+		}()
+	}
+
+	// Scheduled activity reports
+	var reportScheduler *reports.Scheduler
+	if len(config.Reports.Schedules) > 0 {
+		var mailer *reports.Mailer
+		if config.Reports.SMTP.Host != "" {
+			if mailer, err = reports.NewMailer(config.Reports.SMTP); err != nil {
+				log.Fatalf("Invalid reports config: %v", err)
+			}
+		}
+		reportScheduler, err = reports.NewScheduler(db, config.Reports.Schedules, mailer, driveClient, webhookDispatcher)
+		if err != nil {
+			log.Fatalf("Invalid reports config: %v", err)
+		}
+		reportScheduler.Start()
+		defer reportScheduler.Stop()
+	}
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		BodyLimit: config.Limits.MaxFileSizeMB * 1024 * 1024,
@@ -160,40 +733,259 @@ func main() {
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept",
+		AllowHeaders: "Origin, Content-Type, Accept, X-Encryption-Key, X-Chunk-SHA256",
 	}))
 
 	// Initialize handlers
 	uploadHandler := handlers.NewUploadHandler(workerPool, config.Limits.MaxFileSizeMB)
+	uploadHandler.SetSyncLimits(
+		time.Duration(config.Limits.SyncMaxDurationSeconds)*time.Second,
+		time.Duration(config.Limits.SyncTimeoutSeconds)*time.Second)
+	importHandler := handlers.NewImportHandler(workerPool, config.Limits.MaxFileSizeMB)
 	gdriveHandler := handlers.NewGDriveHandler(workerPool)
-	youtubeHandler := handlers.NewYouTubeHandler(workerPool)
+	maxDownloadMB := config.YouTube.MaxDownloadMB
+	if maxDownloadMB == 0 {
+		maxDownloadMB = config.Limits.MaxFileSizeMB
+	}
+	youtubeHandler := handlers.NewYouTubeHandler(workerPool,
+		time.Duration(config.YouTube.DownloadTimeoutMinutes)*time.Minute, maxDownloadMB)
 	streamHandler := handlers.NewStreamHandler(workerPool)
+	recordingHandler := handlers.NewRecordingHandler(workerPool, recordingStore, config.Limits.MaxFileSizeMB)
+	uploadSessionHandler := handlers.NewUploadSessionHandler(workerPool, uploadStore, config.Uploads.MaxSizeMB, config.Limits.MaxFileSizeMB)
+	usageHandler := handlers.NewUsageHandler(db)
+	webhookHandler := handlers.NewWebhookHandler(db, webhookDispatcher)
+	resultsHandler := handlers.NewResultsHandler(db, resultLinks)
+	jobHandler := handlers.NewJobHandler(db, workerPool)
+	privacyHandler := handlers.NewPrivacyHandler(db, localStorage, driveClient, searchIndexer)
+	editHandler := handlers.NewEditHandler(db, localStorage, searchIndexer, workerPool)
+	versionHandler := handlers.NewVersionHandler(db)
+	audioHandler := handlers.NewAudioHandler(db)
+	bulkHandler := handlers.NewBulkHandler(db, localStorage, driveClient, searchIndexer)
+	reportHandler := handlers.NewReportHandler(reportScheduler)
+
+	// Health checks
+	healthChecker := health.NewChecker()
+	healthChecker.Register("whisper", transcriber.CheckAvailable)
+	if config.Whisper.SelfTest {
+		healthChecker.Register("whisper_selftest", transcriber.SelfTestStatus)
+	}
+	if err := transcription.CheckFFmpeg(); err == nil || config.Whisper.FFmpegOnly {
+		healthChecker.Register("ffmpeg", transcription.CheckFFmpeg)
+	} else {
+		log.Printf("Warning: %v; only WAV, MP3, and Ogg Vorbis can be decoded, and denoise and speedup are unavailable", err)
+	}
+	healthChecker.Register("database", db.Ping)
+	healthChecker.Register("queue", func() error {
+		queued, capacity := workerPool.QueueDepth()
+		if queued*10 >= capacity*9 {
+			return fmt.Errorf("queue saturated (%d/%d)", queued, capacity)
+		}
+		return nil
+	})
+	if config.Health.MinFreeDiskMB > 0 {
+		tempDisk := health.DiskSpaceCheck(config.Storage.TempDir, config.Health.MinFreeDiskMB)
+		outputDisk := health.DiskSpaceCheck(config.Storage.OutputDir, config.Health.MinFreeDiskMB)
+		healthChecker.Register("disk", func() error {
+			if err := tempDisk(); err != nil {
+				return err
+			}
+			return outputDisk()
+		})
+	}
+	if config.Health.GateIntake {
+		workerPool.SetIntakeCheck(healthChecker.Gate("whisper", "database", "disk"))
+	}
 
 	// Routes
 	app.Get("/health", func(c *fiber.Ctx) error {
+		report := healthChecker.Readiness()
+		status := "healthy"
+		if !report.Ready {
+			status = "unhealthy"
+		}
 		return c.JSON(fiber.Map{
-			"status":  "healthy",
-			"version": "1.0.0",
+			"status":     status,
+			"version":    "1.0.0",
+			"phase":      report.Phase,
+			"components": report.Components,
 		})
 	})
 
+	// Liveness: the process is up and serving requests
+	app.Get("/livez", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"status": "alive", "phase": healthChecker.Phase()})
+	})
+
+	// Readiness: safe to route new work here
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		report := healthChecker.Readiness()
+		if !report.Ready {
+			return c.Status(503).JSON(report)
+		}
+		return c.JSON(report)
+	})
+
 	app.Post("/upload", uploadHandler.Handle)
 	app.Post("/gdrive", gdriveHandler.Handle)
 	app.Post("/youtube", youtubeHandler.Handle)
 
+	// Chunked recordings, uploaded piecewise and resumed after going offline
+	app.Post("/recordings", recordingHandler.Create)
+	app.Put("/recordings/:id/chunks/:n", recordingHandler.PutChunk)
+	app.Get("/recordings/:id", recordingHandler.Status)
+	app.Post("/recordings/:id/complete", recordingHandler.Complete)
+	app.Delete("/recordings/:id", recordingHandler.Delete)
+
+	// Upload sessions, for large files sent in parts from CLI tools
+	app.Post("/uploads", uploadSessionHandler.Create)
+	app.Post("/uploads/:id/parts", uploadSessionHandler.AppendPart)
+	app.Get("/uploads/:id", uploadSessionHandler.Status)
+	app.Post("/uploads/:id/commit", uploadSessionHandler.Commit)
+	app.Delete("/uploads/:id", uploadSessionHandler.Delete)
+
 	// WebSocket route
 	app.Get("/ws/stream", websocket.New(streamHandler.Handle))
 
+	// Job status (DOWNLOADING, QUEUED, PROCESSING, COMPLETED, FAILED)
+	app.Get("/jobs/dead", jobHandler.ListDead)
+	app.Post("/jobs/dead/requeue", jobHandler.RequeueDead)
+	app.Get("/jobs/:id", jobHandler.Status)
+	app.Get("/jobs/:id/events", jobHandler.Events)
+
+	// Cancel a job's in-flight download
+	app.Post("/jobs/:id/cancel", jobHandler.Cancel)
+
+	// Aggregate status of a batch submission's jobs
+	app.Get("/groups/:id", jobHandler.Group)
+
+	// Import transcripts made by other tools
+	app.Post("/transcripts/import", importHandler.Handle)
+
+	// Delete, tag, re-export, or re-upload every transcript matching a filter
+	app.Post("/transcripts/bulk", bulkHandler.Start)
+	app.Get("/transcripts/bulk/:id", bulkHandler.Status)
+
 	// Get transcript metadata
 	app.Get("/transcripts", func(c *fiber.Ctx) error {
 		limit := 50 // Default limit
-		transcripts, err := db.ListTranscripts(limit)
+		transcripts, err := db.ListTranscripts(limit, handlers.TranscriptFilterFromQuery(c))
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 		}
 		return c.JSON(transcripts)
 	})
 
+	// Get a single transcript record (metadata, labels, cost)
+	app.Get("/transcripts/:id", func(c *fiber.Ctx) error {
+		transcript, err := db.GetTranscript(c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+		}
+		return c.JSON(transcript)
+	})
+
+	// Rename a transcript, describe it, or correct its language or segments
+	app.Patch("/transcripts/:id", editHandler.Handle)
+	app.Patch("/transcripts/:id/speakers", editHandler.RenameSpeakers)
+
+	// Transcribe a garbled passage again and splice it back in
+	app.Post("/transcripts/:id/segments/retranscribe", editHandler.Retranscribe)
+
+	// The audio kept next to a transcript, for listening while reading
+	app.Get("/transcripts/:id/audio", audioHandler.Play)
+
+	// Versions of a transcript's segments, and word-level diffs between them
+	app.Get("/transcripts/:id/versions", versionHandler.List)
+	app.Get("/transcripts/:id/diff", versionHandler.Diff)
+
+	// Re-hash a transcript's stored files against their recorded checksums
+	app.Get("/transcripts/:id/verify", func(c *fiber.Ctx) error {
+		jobID := c.Params("id")
+		if _, err := db.GetTranscript(jobID); err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+		}
+
+		checks, err := db.VerifyArtifacts(jobID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		ok := len(checks) > 0
+		for _, check := range checks {
+			if check.Status != storage.ChecksumOK {
+				ok = false
+			}
+		}
+		return c.JSON(fiber.Map{"job_id": jobID, "ok": ok, "artifacts": checks})
+	})
+
+	// Purge a transcript (local files, Drive copy, and database row)
+	app.Delete("/transcripts/:id", func(c *fiber.Ctx) error {
+		jobID := c.Params("id")
+
+		transcript, err := db.GetTranscript(jobID)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+		}
+
+		if localPath, _ := transcript["local_path"].(string); localPath != "" {
+			if err := localStorage.DeleteTranscript(localPath); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+		if gdriveURL, _ := transcript["gdrive_url"].(string); gdriveURL != "" && driveClient != nil {
+			if err := driveClient.Delete(gdriveURL); err != nil {
+				log.Printf("WARNING: failed to delete Drive copy of %s: %v", jobID, err)
+			}
+		}
+		if searchIndexer != nil {
+			if err := searchIndexer.Delete(jobID); err != nil {
+				log.Printf("WARNING: failed to remove %s from search index: %v", jobID, err)
+			}
+		}
+
+		if err := db.DeleteTranscript(jobID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		return c.JSON(fiber.Map{"job_id": jobID, "deleted": true})
+	})
+
+	// Privacy requests: export or erase everything stored for a tenant
+	app.Get("/tenants/:tenant/export", privacyHandler.ExportTenant)
+	app.Delete("/tenants/:tenant", privacyHandler.EraseTenant)
+
+	// Aggregate stats, filterable like /transcripts
+	app.Get("/stats", func(c *fiber.Ctx) error {
+		stats, err := db.Stats(handlers.TranscriptFilterFromQuery(c))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(stats)
+	})
+
+	// Worker pool activity and last-hour throughput
+	app.Get("/queue/stats", func(c *fiber.Ctx) error {
+		return c.JSON(workerPool.Stats())
+	})
+
+	// Usage/cost report export (JSON or CSV)
+	app.Get("/usage/report", usageHandler.Report)
+
+	// Languages transcribed per tenant, with detection and output confidence
+	app.Get("/usage/languages", usageHandler.Languages)
+
+	// Scheduled report preview and manual send
+	app.Get("/reports/:name", reportHandler.Preview)
+	app.Post("/reports/:name/send", reportHandler.Send)
+
+	// Webhook delivery log, manual redelivery, and test deliveries
+	app.Get("/webhooks/deliveries", webhookHandler.ListDeliveries)
+	app.Post("/webhooks/deliveries/:id/redeliver", webhookHandler.Redeliver)
+	app.Post("/webhooks/test", webhookHandler.Test)
+
+	// Signed artifact downloads (links from webhook payloads)
+	app.Get("/results/:id/:artifact", resultsHandler.Download)
+
 	// Get transcript text
 	app.Get("/transcripts/:id/text", func(c *fiber.Ctx) error {
 		jobID := c.Params("id")
@@ -209,21 +1001,94 @@ func main() {
 			return c.Status(404).JSON(fiber.Map{"error": "Transcript file path not found"})
 		}
 
+		// A caption preset re-cuts the stored segments into srt or vtt cues
+		var preset *transcription.CaptionPreset
+		format := c.Query("format", "txt")
+		if name := c.Query("preset"); name != "" {
+			p, ok := transcription.LookupCaptionPreset(name)
+			if !ok {
+				return c.Status(400).JSON(fiber.Map{
+					"error": fmt.Sprintf("Unknown preset %q; available: %s", name, strings.Join(transcription.CaptionPresetNames(), ", ")),
+					"code":  "ERR_INVALID_PRESET",
+				})
+			}
+			if format == "txt" {
+				format = "srt"
+			}
+			if format != "srt" && format != "vtt" {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "Caption presets apply to srt and vtt",
+					"code":  "ERR_INVALID_PRESET",
+				})
+			}
+			preset = &p
+			localPath, _ = storage.ArtifactPath(localPath, "meta")
+		}
+
+		// Whisper's raw JSON output, kept via storage.raw_output
+		if format == "raw" {
+			localPath = storage.RawOutputPath(localPath)
+			if _, err := os.Stat(localPath); err != nil {
+				return c.Status(404).JSON(fiber.Map{
+					"error": "No raw output was kept for this transcript",
+					"code":  "ERR_RAW_OUTPUT_NOT_KEPT",
+				})
+			}
+			c.Type("json")
+		}
+
+		// Optional extra rendering saved via whisper.output_formats
+		if format != "txt" && format != "raw" && preset == nil {
+			if !slices.Contains(types.OutputFormats, format) {
+				return c.Status(400).JSON(fiber.Map{
+					"error": fmt.Sprintf("Unsupported format %q; use txt, raw, %s", format, strings.Join(types.OutputFormats, ", ")),
+					"code":  "ERR_INVALID_FORMAT",
+				})
+			}
+			localPath = storage.FormatPath(localPath, format)
+			if _, err := os.Stat(localPath); err != nil {
+				return c.Status(404).JSON(fiber.Map{"error": "Transcript was not saved in " + format + " format"})
+			}
+		}
+
 		// Read file content
 		content, err := os.ReadFile(localPath)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": "Failed to read transcript file"})
 		}
 
+		// Encrypted transcripts require the client's key
+		if encrypted, _ := transcript["encrypted"].(bool); encrypted {
+			key, err := handlers.ParseEncryptionKey(c.Get(handlers.EncryptionKeyHeader))
+			if err != nil || key == nil {
+				return c.Status(401).JSON(fiber.Map{"error": "Transcript is encrypted; supply the key in " + handlers.EncryptionKeyHeader})
+			}
+			fingerprint, err := db.GetKeyFingerprint(jobID)
+			if err != nil || fingerprint != storage.KeyFingerprint(key) {
+				return c.Status(403).JSON(fiber.Map{"error": "Encryption key does not match"})
+			}
+			if content, err = storage.DecryptArtifact(key, content); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		if preset != nil {
+			var meta struct {
+				Segments []types.Segment `json:"segments"`
+			}
+			if err := json.Unmarshal(content, &meta); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "Failed to read transcript segments"})
+			}
+			return c.SendString(transcription.RenderSegments(format, preset.Apply(meta.Segments)))
+		}
+
 		return c.SendString(string(content))
 	})
 
 	// Get server logs
-	app.Get("/logs", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"logs": logBuffer.GetLogs(),
-		})
-	})
+	logHandler := handlers.NewLogHandler(logBuffer)
+	app.Get("/logs", logHandler.List)
+	app.Get("/logs/stream", logHandler.Stream)
 
 	// Start server
 	addr := fmt.Sprintf("%s:%d", config.Server.Host, config.Server.Port)
@@ -232,11 +1097,44 @@ func main() {
 	log.Println("   POST /upload      - Upload audio file")
 	log.Println("   POST /gdrive      - Process Google Drive link")
 	log.Println("   POST /youtube     - Capture YouTube audio")
+	log.Println("   POST /recordings  - Start a chunked recording upload")
+	log.Println("   PUT  /recordings/:id/chunks/:n - Upload a recording chunk")
+	log.Println("   POST /recordings/:id/complete - Assemble and transcribe a recording")
+	log.Println("   POST /uploads     - Start an upload session for a large file")
+	log.Println("   POST /uploads/:id/parts?offset=N - Append a part")
+	log.Println("   POST /uploads/:id/commit - Transcribe an uploaded file")
 	log.Println("   GET  /ws/stream   - WebSocket audio streaming")
+	log.Println("   GET  /jobs/dead   - Jobs that failed every attempt")
+	log.Println("   POST /jobs/dead/requeue - Requeue dead jobs")
+	log.Println("   GET  /jobs/:id    - Job status, queue position, and ETA")
+	log.Println("   GET  /jobs/:id/events - Live job progress (Server-Sent Events)")
+	log.Println("   POST /jobs/:id/cancel - Cancel a download")
 	log.Println("   GET  /transcripts - List all transcripts")
-	log.Println("   GET  /transcripts/:id/text - Get transcript text")
+	log.Println("   POST /transcripts/import - Import an existing transcript")
+	log.Println("   GET  /transcripts/:id - Get transcript record")
+	log.Println("   DELETE /transcripts/:id - Purge transcript")
+	log.Println("   GET  /transcripts/:id/text - Get transcript text (?format=srt|vtt|tsv, ?preset=youtube|premiere|broadcast)")
+	log.Println("   GET  /transcripts/:id/verify - Verify stored file checksums")
+	log.Println("   GET  /transcripts/:id/audio - Play the kept audio (Range requests)")
+	log.Println("   GET  /transcripts/:id/versions - List transcript versions")
+	log.Println("   GET  /transcripts/:id/diff - Word-level diff between versions (?against=version1)")
+	log.Println("   GET  /stats       - Aggregate transcript stats and cost")
+	log.Println("   GET  /queue/stats - Worker activity and last-hour throughput")
+	log.Println("   GET  /usage/report - Usage report export (JSON/CSV)")
+	log.Println("   GET  /usage/languages - Language coverage per tenant")
+	log.Println("   GET  /tenants/:tenant/export - Export a tenant's data (zip)")
+	log.Println("   DELETE /tenants/:tenant - Erase a tenant's data everywhere")
+	log.Println("   GET  /reports/:name - Preview a scheduled report")
+	log.Println("   POST /reports/:name/send - Send a scheduled report now")
+	log.Println("   GET  /webhooks/deliveries - Webhook delivery log")
+	log.Println("   POST /webhooks/deliveries/:id/redeliver - Retry a delivery")
+	log.Println("   POST /webhooks/test - Send a signed sample event to a receiver")
+	log.Println("   GET  /results/:id/:artifact - Signed artifact download")
 	log.Println("   GET  /logs        - View server logs")
+	log.Println("   GET  /logs/stream - Stream server logs (SSE)")
 	log.Println("   GET  /health      - Health check")
+	log.Println("   GET  /livez       - Liveness probe")
+	log.Println("   GET  /readyz      - Readiness probe")
 
 	// Graceful shutdown
 	go func() {
@@ -244,44 +1142,93 @@ func main() {
 		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
 		<-sigint
 
+		// Fail readiness first so orchestrators stop routing traffic here
 		log.Println("Shutting down gracefully...")
+		healthChecker.SetPhase(health.PhaseDraining)
+		health.SdNotify(health.SdStopping)
+		if config.Server.DrainSeconds > 0 {
+			log.Printf("Draining for %ds before closing listeners", config.Server.DrainSeconds)
+			time.Sleep(time.Duration(config.Server.DrainSeconds) * time.Second)
+		}
 		app.Shutdown()
 	}()
 
+	// Warm the backend (model download/load) while /livez already answers,
+	// then mark the process ready for traffic
+	go func() {
+		if config.Whisper.SelfTest {
+			// The self-test loads the model too, so it doubles as the pre-warm
+			log.Println("Running Whisper self-test...")
+			health.SdNotify("STATUS=Running Whisper self-test")
+			start := time.Now()
+			if err := transcriber.SelfTest(); err != nil {
+				log.Printf("ERROR: Whisper self-test failed: %v", err)
+			} else {
+				log.Printf("Whisper self-test passed (%s)", time.Since(start).Round(time.Millisecond))
+			}
+		} else if config.Whisper.Prewarm {
+			log.Println("Pre-warming Whisper backend...")
+			health.SdNotify("STATUS=Pre-warming Whisper backend")
+			start := time.Now()
+			if err := transcriber.Warmup(); err != nil {
+				log.Printf("WARNING: Whisper pre-warm failed: %v", err)
+			} else {
+				log.Printf("Whisper backend warm (%s)", time.Since(start).Round(time.Millisecond))
+			}
+		}
+
+		healthChecker.SetPhase(health.PhaseReady)
+		if ok, err := health.SdNotify(health.SdReady); err != nil {
+			log.Printf("WARNING: sd_notify failed: %v", err)
+		} else if ok {
+			log.Println("Notified systemd: ready")
+		}
+	}()
+
 	if err := app.Listen(addr); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
 
-// LogBuffer captures logs in memory
-type LogBuffer struct {
-	lines []string
-	mu    sync.Mutex
-}
-
-func (lb *LogBuffer) Write(p []byte) (n int, err error) {
-	lb.mu.Lock()
-	defer lb.mu.Unlock()
+// loadDriveSecrets returns the Drive OAuth credentials and optional token,
+// preferring secret references over the credentials file on disk. It
+// returns nil credentials when Drive is not configured at all.
+func loadDriveSecrets(config *Config) ([]byte, []byte, error) {
+	var credentialsJSON, tokenJSON []byte
 
-	// Append new line
-	lb.lines = append(lb.lines, string(p))
+	if config.GoogleDrive.Credentials != "" {
+		value, err := secrets.Resolve(config.GoogleDrive.Credentials)
+		if err != nil {
+			return nil, nil, err
+		}
+		credentialsJSON = []byte(value)
+	} else if b, err := os.ReadFile(config.GoogleDrive.CredentialsFile); err == nil {
+		credentialsJSON = b
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("unable to read credentials file: %v", err)
+	}
 
-	// Keep last 1000 lines
-	if len(lb.lines) > 1000 {
-		lb.lines = lb.lines[len(lb.lines)-1000:]
+	if config.GoogleDrive.Token != "" {
+		value, err := secrets.Resolve(config.GoogleDrive.Token)
+		if err != nil {
+			return nil, nil, err
+		}
+		tokenJSON = []byte(value)
 	}
 
-	return len(p), nil
+	return credentialsJSON, tokenJSON, nil
 }
 
-func (lb *LogBuffer) GetLogs() []string {
-	lb.mu.Lock()
-	defer lb.mu.Unlock()
-
-	// Return copy of slice
-	logs := make([]string, len(lb.lines))
-	copy(logs, lb.lines)
-	return logs
+// newSearchIndexer creates the indexer for the configured search cluster
+func newSearchIndexer(config *Config) (*search.Indexer, error) {
+	return search.NewIndexer(search.Config{
+		URL:      config.Search.URL,
+		Index:    config.Search.Index,
+		Username: config.Search.Username,
+		Password: config.Search.Password,
+		APIKey:   config.Search.APIKey,
+		Timeout:  time.Duration(config.Search.TimeoutSeconds) * time.Second,
+	})
 }
 
 // loadConfig loads configuration from YAML file
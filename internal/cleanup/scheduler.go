@@ -15,6 +15,9 @@ type Scheduler struct {
 	intervalMinutes int
 	maxAgeHours     int
 	stopChan        chan struct{}
+
+	// inUse reports files that belong to live jobs; they are never deleted
+	inUse func(path string) bool
 }
 
 // NewScheduler creates a new cleanup scheduler
@@ -27,6 +30,13 @@ func NewScheduler(tempDir string, intervalMinutes, maxAgeHours int) *Scheduler {
 	}
 }
 
+// SetInUseCheck registers a function reporting files that belong to live
+// work (queued, downloading, or processing jobs); cleanup skips them
+// regardless of age
+func (s *Scheduler) SetInUseCheck(inUse func(path string) bool) {
+	s.inUse = inUse
+}
+
 // Start begins the cleanup scheduler
 func (s *Scheduler) Start() {
 	// Run initial cleanup on startup
@@ -79,6 +89,10 @@ func (s *Scheduler) cleanOldFiles() {
 		// Check file age
 		age := now.Sub(info.ModTime())
 		if age > maxAge {
+			if s.inUse != nil && s.inUse(path) {
+				log.Printf("Keeping old temp file in use by a live job: %s", filepath.Base(path))
+				return nil
+			}
 			size := info.Size()
 			if err := os.Remove(path); err != nil {
 				log.Printf("Failed to delete old file %s: %v", path, err)
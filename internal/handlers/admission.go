@@ -0,0 +1,46 @@
+package handlers
+
+// Job admission — maps the worker pool's admission errors (quota, health
+// gate, ...) to HTTP responses with machine-readable error codes.
+
+import (
+	"errors"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/health"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// admissionErrorCode returns the HTTP status and error code for a rejected job
+func admissionErrorCode(err error) (int, string) {
+	var quotaErr *storage.QuotaError
+	if errors.As(err, &quotaErr) {
+		return 403, "ERR_QUOTA_EXCEEDED"
+	}
+	var unhealthyErr *health.UnhealthyError
+	if errors.As(err, &unhealthyErr) {
+		return 503, "ERR_UNHEALTHY"
+	}
+	return 503, "ERR_NOT_ACCEPTING"
+}
+
+// admissionError returns the HTTP status and response body for a rejected
+// job; health-gate rejections also list the unhealthy components
+func admissionError(err error) (int, fiber.Map) {
+	status, code := admissionErrorCode(err)
+	body := fiber.Map{
+		"error": err.Error(),
+		"code":  code,
+	}
+	var unhealthyErr *health.UnhealthyError
+	if errors.As(err, &unhealthyErr) {
+		body["unhealthy"] = unhealthyErr.Names()
+	}
+	return status, body
+}
+
+// rejectJob writes the response for a job refused by CheckAdmission
+func rejectJob(c *fiber.Ctx, err error) error {
+	status, body := admissionError(err)
+	return c.Status(status).JSON(body)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+// Audio playback — serves the audio kept next to a transcript (see
+// storage.keep_audio) with Range support, so a reviewer's player can seek
+// to any segment while reading.
+
+import (
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AudioHandler serves /transcripts/:id/audio
+type AudioHandler struct {
+	db *storage.MetadataDB
+}
+
+// NewAudioHandler creates a new audio playback handler
+func NewAudioHandler(db *storage.MetadataDB) *AudioHandler {
+	return &AudioHandler{db: db}
+}
+
+// Play sends a transcript's kept audio; Range requests get 206 Partial
+// Content, so players can seek without downloading the whole file
+func (h *AudioHandler) Play(c *fiber.Ctx) error {
+	transcript, err := h.db.GetTranscript(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+	}
+	localPath, _ := transcript["local_path"].(string)
+	path, ok := storage.AudioPath(localPath)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "No audio was kept for this transcript",
+			"code":  "ERR_AUDIO_NOT_KEPT",
+		})
+	}
+	return c.SendFile(path)
+}
@@ -0,0 +1,368 @@
+package handlers
+
+// Bulk operations — POST /transcripts/bulk deletes, tags, re-exports to
+// the search index, or re-uploads to Drive every transcript matching a
+// filter. The work runs in the background as a task whose progress is
+// served by GET /transcripts/bulk/:id.
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/search"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Bulk operations
+const (
+	BulkDelete        = "delete"         // purge local files, Drive copy, search document, and records
+	BulkTag           = "tag"            // add or replace labels
+	BulkReexport      = "reexport"       // push the search document again
+	BulkReuploadDrive = "reupload_drive" // upload a fresh Drive copy, replacing the old one
+)
+
+var bulkOperations = []string{BulkDelete, BulkTag, BulkReexport, BulkReuploadDrive}
+
+// Bulk task states
+const (
+	BulkRunning   = "running"
+	BulkCompleted = "completed"
+)
+
+// maxFinishedBulkTasks is how many finished tasks are kept for status
+// queries; older ones are forgotten
+const maxFinishedBulkTasks = 100
+
+// bulkRequest is the body of POST /transcripts/bulk
+type bulkRequest struct {
+	Operation string `json:"operation"`
+	Filter    struct {
+		From   string            `json:"from"` // YYYY-MM-DD or RFC3339, inclusive
+		To     string            `json:"to"`   // exclusive
+		Source string            `json:"source"`
+		Labels map[string]string `json:"labels"`
+	} `json:"filter"`
+	// Labels are set on every selected transcript by the tag operation
+	Labels map[string]string `json:"labels"`
+}
+
+// BulkTask is a bulk operation's progress
+type BulkTask struct {
+	ID         string            `json:"task_id"`
+	Operation  string            `json:"operation"`
+	Status     string            `json:"status"`
+	Total      int               `json:"total"`
+	Processed  int               `json:"processed"`
+	Succeeded  int               `json:"succeeded"`
+	Skipped    int               `json:"skipped"`
+	Failed     int               `json:"failed"`
+	Failures   map[string]string `json:"failures"` // job ID -> error
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// errBulkSkipped marks a transcript the operation does not apply to
+type errBulkSkipped struct{ reason string }
+
+func (e errBulkSkipped) Error() string { return e.reason }
+
+// BulkHandler runs and tracks bulk operations
+type BulkHandler struct {
+	db           *storage.MetadataDB
+	localStorage *storage.LocalStorage
+	driveClient  *storage.DriveClient
+	indexer      *search.Indexer
+
+	mu       sync.Mutex
+	tasks    map[string]*BulkTask
+	finished []string // IDs of finished tasks, oldest first
+}
+
+// NewBulkHandler creates a new bulk handler; driveClient and indexer may be nil
+func NewBulkHandler(db *storage.MetadataDB, localStorage *storage.LocalStorage,
+	driveClient *storage.DriveClient, indexer *search.Indexer) *BulkHandler {
+	return &BulkHandler{
+		db:           db,
+		localStorage: localStorage,
+		driveClient:  driveClient,
+		indexer:      indexer,
+		tasks:        make(map[string]*BulkTask),
+	}
+}
+
+// Start validates a bulk request, selects its transcripts, and starts the
+// task in the background
+func (h *BulkHandler) Start(c *fiber.Ctx) error {
+	var req bulkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	sel, err := h.validate(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_BULK",
+		})
+	}
+
+	ids, err := h.db.SelectTranscripts(sel)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	task := &BulkTask{
+		ID:        uuid.New().String(),
+		Operation: req.Operation,
+		Status:    BulkRunning,
+		Total:     len(ids),
+		Failures:  map[string]string{},
+		CreatedAt: time.Now(),
+	}
+	h.mu.Lock()
+	h.tasks[task.ID] = task
+	h.mu.Unlock()
+
+	log.Printf("Bulk %s task %s started on %d transcript(s)", task.Operation, task.ID, task.Total)
+	go h.run(task, ids, req.Labels)
+
+	return c.Status(202).JSON(h.snapshot(task))
+}
+
+// Status returns a bulk task's progress
+func (h *BulkHandler) Status(c *fiber.Ctx) error {
+	h.mu.Lock()
+	task, ok := h.tasks[c.Params("id")]
+	h.mu.Unlock()
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Bulk task not found"})
+	}
+	return c.JSON(h.snapshot(task))
+}
+
+// validate checks the operation and builds the selection
+func (h *BulkHandler) validate(req *bulkRequest) (storage.TranscriptSelection, error) {
+	var sel storage.TranscriptSelection
+
+	switch req.Operation {
+	case BulkDelete, BulkReexport:
+	case BulkTag:
+		if len(req.Labels) == 0 {
+			return sel, fmt.Errorf("tag needs labels to set")
+		}
+		if err := validateLabels(req.Labels); err != nil {
+			return sel, err
+		}
+	case BulkReuploadDrive:
+		if h.driveClient == nil {
+			return sel, fmt.Errorf("Google Drive is not configured")
+		}
+	default:
+		return sel, fmt.Errorf("unknown operation %q (use %s)", req.Operation, strings.Join(bulkOperations, ", "))
+	}
+	if req.Operation == BulkReexport && h.indexer == nil {
+		return sel, fmt.Errorf("search export is not configured")
+	}
+
+	var err error
+	if req.Filter.From != "" {
+		if sel.From, err = parseReportTime(req.Filter.From); err != nil {
+			return sel, fmt.Errorf("invalid filter.from (use YYYY-MM-DD or RFC3339)")
+		}
+	}
+	if req.Filter.To != "" {
+		if sel.To, err = parseReportTime(req.Filter.To); err != nil {
+			return sel, fmt.Errorf("invalid filter.to (use YYYY-MM-DD or RFC3339)")
+		}
+	}
+	if !sel.From.IsZero() && !sel.To.IsZero() && !sel.From.Before(sel.To) {
+		return sel, fmt.Errorf("filter.from must be before filter.to")
+	}
+	if source := req.Filter.Source; source != "" && !slices.Contains(types.SourceTypes, source) {
+		return sel, fmt.Errorf("unknown filter.source %q (use %s)", source, strings.Join(types.SourceTypes, ", "))
+	}
+	sel.SourceType = req.Filter.Source
+	sel.Labels = req.Filter.Labels
+
+	// A filter is required, so a malformed request can't touch everything
+	if sel.IsEmpty() {
+		return sel, fmt.Errorf("filter must set from, to, source, or labels")
+	}
+	return sel, nil
+}
+
+// run applies the operation to each transcript in turn
+func (h *BulkHandler) run(task *BulkTask, ids []string, labels map[string]string) {
+	for _, id := range ids {
+		err := h.apply(task.Operation, id, labels)
+
+		h.mu.Lock()
+		task.Processed++
+		switch err.(type) {
+		case nil:
+			task.Succeeded++
+		case errBulkSkipped:
+			task.Skipped++
+		default:
+			task.Failed++
+			task.Failures[id] = err.Error()
+		}
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	now := time.Now()
+	task.Status, task.FinishedAt = BulkCompleted, &now
+	h.finished = append(h.finished, task.ID)
+	if len(h.finished) > maxFinishedBulkTasks {
+		delete(h.tasks, h.finished[0])
+		h.finished = h.finished[1:]
+	}
+	h.mu.Unlock()
+
+	log.Printf("Bulk %s task %s finished: %d succeeded, %d skipped, %d failed",
+		task.Operation, task.ID, task.Succeeded, task.Skipped, task.Failed)
+}
+
+// apply runs one operation on one transcript
+func (h *BulkHandler) apply(operation, jobID string, labels map[string]string) error {
+	transcript, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return err
+	}
+	switch operation {
+	case BulkDelete:
+		return h.delete(jobID, transcript)
+	case BulkTag:
+		return h.tag(jobID, transcript, labels)
+	case BulkReexport:
+		return h.reexport(jobID, transcript)
+	case BulkReuploadDrive:
+		return h.reupload(jobID, transcript)
+	}
+	return fmt.Errorf("unknown operation %q", operation)
+}
+
+// delete purges a transcript like DELETE /transcripts/:id
+func (h *BulkHandler) delete(jobID string, transcript map[string]interface{}) error {
+	if localPath, _ := transcript["local_path"].(string); localPath != "" {
+		if err := h.localStorage.DeleteTranscript(localPath); err != nil {
+			return err
+		}
+	}
+	if gdriveURL, _ := transcript["gdrive_url"].(string); gdriveURL != "" && h.driveClient != nil {
+		if err := h.driveClient.Delete(gdriveURL); err != nil {
+			log.Printf("WARNING: failed to delete Drive copy of %s: %v", jobID, err)
+		}
+	}
+	if h.indexer != nil {
+		if err := h.indexer.Delete(jobID); err != nil {
+			log.Printf("WARNING: failed to remove %s from search index: %v", jobID, err)
+		}
+	}
+	return h.db.DeleteTranscript(jobID)
+}
+
+// tag merges labels into a transcript's own, keeping the label limit
+func (h *BulkHandler) tag(jobID string, transcript map[string]interface{}, labels map[string]string) error {
+	merged, _ := transcript["labels"].(map[string]string)
+	merged = maps.Clone(merged)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, labels)
+	if err := validateLabels(merged); err != nil {
+		return err
+	}
+	if err := h.db.SaveLabels(jobID, labels); err != nil {
+		return err
+	}
+
+	if encrypted, _ := transcript["encrypted"].(bool); h.indexer != nil && !encrypted {
+		if err := h.indexer.Update(jobID, map[string]interface{}{"labels": merged}); err != nil {
+			log.Printf("WARNING: failed to update %s in search index: %v", jobID, err)
+		}
+	}
+	return nil
+}
+
+// reexport pushes a transcript's search document again, with any edits
+// and labels made since it was first indexed
+func (h *BulkHandler) reexport(jobID string, transcript map[string]interface{}) error {
+	if encrypted, _ := transcript["encrypted"].(bool); encrypted {
+		return errBulkSkipped{"encrypted transcripts are never indexed"}
+	}
+	localPath, _ := transcript["local_path"].(string)
+	result, err := h.localStorage.LoadTranscript(localPath)
+	if err != nil {
+		return err
+	}
+
+	doc := search.Document{
+		JobID:     jobID,
+		Text:      strings.TrimPrefix(result.Text, "\ufeff"), // stored text may carry a BOM
+		Language:  result.Language,
+		Duration:  result.Duration,
+		WordCount: result.WordCount,
+		Segments:  result.Segments,
+	}
+	doc.RequestName, _ = transcript["request_name"].(string)
+	doc.SourceType, _ = transcript["source_type"].(string)
+	doc.Metadata, _ = transcript["metadata"].(map[string]interface{})
+	doc.Labels, _ = transcript["labels"].(map[string]string)
+	doc.CreatedAt, _ = transcript["created_at"].(time.Time)
+	doc.GDriveURL, _ = transcript["gdrive_url"].(string)
+	doc.Description, _ = transcript["description"].(string)
+	if language, _ := transcript["language"].(string); language != "" {
+		doc.Language = language
+	}
+	return h.indexer.Index(doc)
+}
+
+// reupload uploads a fresh Drive copy of a transcript, then removes the
+// old copy
+func (h *BulkHandler) reupload(jobID string, transcript map[string]interface{}) error {
+	if encrypted, _ := transcript["encrypted"].(bool); encrypted {
+		return errBulkSkipped{"encrypted transcripts can't be re-uploaded without the client key"}
+	}
+	localPath, _ := transcript["local_path"].(string)
+	result, err := h.localStorage.LoadTranscript(localPath)
+	if err != nil {
+		return err
+	}
+	requestName, _ := transcript["request_name"].(string)
+
+	// Text artifacts go up as stored, already in their chosen encoding
+	driveURL, err := h.driveClient.Upload(requestName, result, storage.SaveOptions{})
+	if err != nil {
+		return err
+	}
+	if err := h.db.SaveDriveURL(jobID, driveURL); err != nil {
+		return err
+	}
+	if old, _ := transcript["gdrive_url"].(string); old != "" {
+		if err := h.driveClient.Delete(old); err != nil {
+			log.Printf("WARNING: failed to delete old Drive copy of %s: %v", jobID, err)
+		}
+	}
+	return nil
+}
+
+// snapshot copies a task under the lock so it can be serialized
+func (h *BulkHandler) snapshot(task *BulkTask) BulkTask {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	copied := *task
+	copied.Failures = maps.Clone(task.Failures)
+	return copied
+}
@@ -0,0 +1,58 @@
+package handlers
+
+// Duplicate submission handling — builds source keys for the worker pool's
+// claim registry and answers duplicates with the job already in flight.
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/gofiber/fiber/v2"
+)
+
+// claimSource claims key for job unless force is set. It returns the ID of
+// an identical live job when the submission is a duplicate.
+func claimSource(wp *queue.WorkerPool, key string, job *queue.Job, force bool) (string, bool) {
+	if force {
+		return "", false
+	}
+	// A trimmed job is only a duplicate of one with the same range
+	if job.StartTime > 0 || job.EndTime > 0 {
+		key = fmt.Sprintf("%s@%g-%g", key, job.StartTime, job.EndTime)
+	}
+	existingID, ok := wp.ClaimSource(key, job)
+	return existingID, !ok
+}
+
+// respondDuplicate points the client at the live job handling the same source
+func respondDuplicate(c *fiber.Ctx, existingID string) error {
+	return c.JSON(fiber.Map{
+		"job_id":    existingID,
+		"duplicate": true,
+		"message":   "An identical job is already in progress; submit with force=true to start a new one",
+	})
+}
+
+// youtubeSourceKey identifies a YouTube video regardless of URL form
+// (watch?v=, youtu.be/, shorts/, extra query parameters)
+func youtubeSourceKey(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "youtube:" + raw
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	switch {
+	case host == "youtu.be":
+		return "youtube:" + strings.Trim(u.Path, "/")
+	case host == "youtube.com" && u.Query().Get("v") != "":
+		return "youtube:" + u.Query().Get("v")
+	case host == "youtube.com" && strings.HasPrefix(u.Path, "/shorts/"):
+		return "youtube:" + strings.TrimPrefix(u.Path, "/shorts/")
+	}
+	return "youtube:" + raw
+}
@@ -0,0 +1,38 @@
+package handlers
+
+// Duration limit at ingest — sources already on disk when the request is
+// answered are probed there, so over-long audio is turned away before it
+// waits in the queue. Workers enforce the same limit on every job.
+
+import (
+	"errors"
+	"log"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkMaxDuration rejects audio at path that is over the configured
+// maximum duration; it returns true when the job may proceed, including
+// when the audio can't be probed (neither natively nor with ffprobe), and
+// otherwise false with the response written
+func checkMaxDuration(c *fiber.Ctx, wp *queue.WorkerPool, path string, job *queue.Job) (bool, error) {
+	if limit, truncate := wp.MaxDuration(); limit <= 0 || truncate {
+		return true, nil
+	}
+	info, err := transcription.ProbeAudio(path)
+	if err != nil {
+		log.Printf("Could not probe %s for its duration: %v", path, err)
+		return true, nil
+	}
+	err = wp.CheckDuration(info, job)
+	var tooLong *queue.DurationError
+	if errors.As(err, &tooLong) {
+		return false, c.Status(400).JSON(fiber.Map{
+			"error": "Audio too long: " + tooLong.Error(),
+			"code":  "ERR_DURATION_EXCEEDED",
+		})
+	}
+	return true, nil
+}
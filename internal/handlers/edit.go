@@ -0,0 +1,234 @@
+package handlers
+
+// Transcript edits — PATCH /transcripts/:id renames a transcript, sets its
+// description, corrects its language, or replaces its segments after the
+// fact. A new name also renames the local files; the Drive copy keeps its
+// original name. Corrected segments rewrite the local files and are kept
+// as a new version of the transcript (see versions.go).
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/search"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	maxRequestNameLength = 255
+	maxDescriptionLength = 2000
+)
+
+// EditHandler serves transcript edits
+type EditHandler struct {
+	db           *storage.MetadataDB
+	localStorage *storage.LocalStorage
+	indexer      *search.Indexer
+	workerPool   *queue.WorkerPool
+}
+
+// NewEditHandler creates a new edit handler; indexer may be nil. The
+// worker pool's transcriber re-transcribes ranges (see retranscribe.go).
+func NewEditHandler(db *storage.MetadataDB, localStorage *storage.LocalStorage, indexer *search.Indexer, workerPool *queue.WorkerPool) *EditHandler {
+	return &EditHandler{db: db, localStorage: localStorage, indexer: indexer, workerPool: workerPool}
+}
+
+// Handle applies a JSON edit (request_name, description, language,
+// segments) and returns the updated transcript record
+func (h *EditHandler) Handle(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+	transcript, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+	}
+
+	var edit storage.TranscriptEdit
+	if err := c.BodyParser(&edit); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	if err := normalizeEdit(&edit); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_EDIT",
+		})
+	}
+
+	oldPath, _ := transcript["local_path"].(string)
+	encrypted, _ := transcript["encrypted"].(bool)
+	if edit.Segments != nil {
+		if encrypted {
+			return c.Status(409).JSON(fiber.Map{
+				"error": "Segments of a transcript encrypted with a client key can't be edited",
+				"code":  "ERR_ENCRYPTED",
+			})
+		}
+		if err := h.rewriteSegments(jobID, transcript, oldPath, edit.Segments, storage.VersionEdited, ""); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	// Rename the local files first, so a failure leaves the record as is
+	var (
+		newPath string
+		moved   map[string]string
+	)
+	if edit.RequestName != nil && oldPath != "" {
+		if newPath, moved, err = h.localStorage.RenameTranscript(oldPath, *edit.RequestName); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+		if newPath == oldPath {
+			newPath = ""
+		}
+	}
+	if err := h.db.UpdateTranscript(jobID, edit, newPath, moved); err != nil {
+		for from, to := range moved {
+			if undoErr := os.Rename(to, from); undoErr != nil {
+				log.Printf("WARNING: failed to restore %s: %v", from, undoErr)
+			}
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Encrypted transcripts are never indexed
+	if !encrypted {
+		h.reindex(jobID, edit)
+	}
+
+	updated, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(updated)
+}
+
+// reindex pushes the edited fields to the search index, if there is one
+func (h *EditHandler) reindex(jobID string, edit storage.TranscriptEdit) {
+	if h.indexer == nil {
+		return
+	}
+	fields := map[string]interface{}{}
+	if edit.RequestName != nil {
+		fields["request_name"] = *edit.RequestName
+	}
+	if edit.Description != nil {
+		fields["description"] = *edit.Description
+	}
+	if edit.Language != nil {
+		fields["language"] = *edit.Language
+	}
+	if edit.Segments != nil {
+		fields["text"] = types.SegmentText(edit.Segments)
+		fields["segments"] = edit.Segments
+		fields["word_count"] = len(strings.Fields(types.SegmentText(edit.Segments)))
+	}
+	if err := h.indexer.Update(jobID, fields); err != nil {
+		log.Printf("WARNING: failed to update %s in search index: %v", jobID, err)
+	}
+}
+
+// rewriteSegments replaces a transcript's segments on disk, re-rendering
+// the subtitle formats it was stored with, and records them as a new
+// version from source, made by model if one ran. A transcript stored
+// before versions were kept first gets its current segments recorded as
+// version 1, so the change can be diffed.
+func (h *EditHandler) rewriteSegments(jobID string, transcript map[string]interface{}, txtPath string, segments []types.Segment, source, model string) error {
+	stored, err := h.localStorage.LoadTranscript(txtPath)
+	if err != nil {
+		return err
+	}
+	versions, err := h.db.TranscriptVersions(jobID)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		source := storage.VersionTranscribed
+		if sourceType, _ := transcript["source_type"].(string); sourceType == types.SourceImport {
+			source = storage.VersionImported
+		}
+		if _, err := h.db.SaveTranscriptVersion(jobID, source, stored.Cost.Model, stored.Segments); err != nil {
+			return err
+		}
+	}
+
+	formats := make(map[string]string, len(stored.Formats))
+	for format := range stored.Formats {
+		formats[format] = transcription.RenderSegments(format, segments)
+	}
+	if err := h.localStorage.RewriteSegments(txtPath, segments, formats); err != nil {
+		return err
+	}
+	artifacts := make(map[string]string)
+	for _, path := range h.localStorage.ArtifactPaths(txtPath) {
+		sum, err := storage.FileSHA256(path)
+		if err != nil {
+			return err
+		}
+		artifacts[path] = sum
+	}
+	if err := h.db.SaveChecksums(jobID, "", artifacts); err != nil {
+		return err
+	}
+	_, err = h.db.SaveTranscriptVersion(jobID, source, model, segments)
+	return err
+}
+
+// normalizeEdit trims and validates the edited fields
+func normalizeEdit(edit *storage.TranscriptEdit) error {
+	if edit.RequestName == nil && edit.Description == nil && edit.Language == nil && edit.Segments == nil {
+		return fmt.Errorf("nothing to change; set request_name, description, language, or segments")
+	}
+	if edit.RequestName != nil {
+		name := strings.TrimSpace(*edit.RequestName)
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("request_name must be non-empty and contain no path separators")
+		}
+		if len(name) > maxRequestNameLength {
+			return fmt.Errorf("request_name is longer than %d bytes", maxRequestNameLength)
+		}
+		edit.RequestName = &name
+	}
+	if edit.Description != nil {
+		description := strings.TrimSpace(*edit.Description)
+		if len(description) > maxDescriptionLength {
+			return fmt.Errorf("description is longer than %d bytes", maxDescriptionLength)
+		}
+		edit.Description = &description
+	}
+	if edit.Language != nil {
+		language := strings.ToLower(strings.TrimSpace(*edit.Language))
+		if !transcription.IsLanguageCode(language) {
+			return fmt.Errorf("invalid language %q; use a code like \"en\" or \"pt-br\"", *edit.Language)
+		}
+		edit.Language = &language
+	}
+	if edit.Segments != nil {
+		if len(edit.Segments) == 0 {
+			return fmt.Errorf("segments must not be empty")
+		}
+		for i := range edit.Segments {
+			seg := &edit.Segments[i]
+			seg.Text = strings.TrimSpace(seg.Text)
+			seg.Speaker = strings.TrimSpace(seg.Speaker)
+			switch {
+			case seg.Text == "":
+				return fmt.Errorf("segment %d has no text", i)
+			case seg.Start < 0 || seg.End < seg.Start:
+				return fmt.Errorf("segment %d must start at or after 0 and end after it starts", i)
+			case i > 0 && seg.Start < edit.Segments[i-1].Start:
+				return fmt.Errorf("segment %d starts before the one ahead of it", i)
+			case seg.Confidence < 0 || seg.Confidence > 1:
+				return fmt.Errorf("segment %d confidence must be between 0 and 1", i)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+// Client-managed encryption keys — decodes the base64 AES-256 key a client
+// may submit so that its job's artifacts are stored encrypted.
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// EncryptionKeyHeader carries the client key when reading encrypted
+// transcripts, uploading encrypted recording chunks, and committing
+// encrypted upload sessions
+const EncryptionKeyHeader = "X-Encryption-Key"
+
+// ParseEncryptionKey decodes a base64-encoded 32-byte key ("" means none)
+func ParseEncryptionKey(raw string) ([]byte, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("encryption_key must be base64 encoded")
+	}
+	if len(key) != storage.EncryptionKeySize {
+		return nil, fmt.Errorf("encryption_key must decode to %d bytes", storage.EncryptionKeySize)
+	}
+	return key, nil
+}
+
+// requireKey reads the client key of an encrypted recording or upload
+// session (what) from the X-Encryption-Key header and checks it against
+// the stored fingerprint, or writes the error response and returns nil
+func requireKey(c *fiber.Ctx, fingerprint, what string) ([]byte, error) {
+	key, err := ParseEncryptionKey(c.Get(EncryptionKeyHeader))
+	if err != nil || key == nil {
+		return nil, c.Status(401).JSON(fiber.Map{
+			"error": fmt.Sprintf("This %s is encrypted; send its key in %s", what, EncryptionKeyHeader),
+			"code":  "ERR_KEY_REQUIRED",
+		})
+	}
+	if storage.KeyFingerprint(key) != fingerprint {
+		return nil, c.Status(403).JSON(fiber.Map{
+			"error": fmt.Sprintf("Key does not match the %s's", what),
+			"code":  "ERR_KEY_MISMATCH",
+		})
+	}
+	return key, nil
+}
@@ -3,6 +3,8 @@
 package handlers
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,8 +14,9 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/codebuildervaibhav/audio-transcription/internal/queue"
-	"github.com/codebuildervaibhav/audio-transcription/internal/types"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -34,6 +37,7 @@ func NewGDriveHandler(workerPool *queue.WorkerPool) *GDriveHandler {
 type GDriveRequest struct {
 	URL  string `json:"url"`
 	Name string `json:"name"`
+	JobOptions
 }
 
 // Handle processes Google Drive link requests
@@ -54,6 +58,18 @@ func (h *GDriveHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
+	job := &queue.Job{
+		ID:         uuid.New().String(),
+		SourceType: types.SourceGDrive,
+	}
+	if optErr := req.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+
+	if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+		return rejectJob(c, err)
+	}
+
 	// Extract file ID from various Google Drive URL formats
 	fileID := extractGDriveFileID(req.URL)
 	if fileID == "" {
@@ -68,29 +84,55 @@ func (h *GDriveHandler) Handle(c *fiber.Ctx) error {
 		req.Name = "gdrive_file"
 	}
 
-	// Generate job ID
-	jobID := uuid.New().String()
+	// Reuse an identical job that is already in flight
+	if existingID, duplicate := claimSource(h.workerPool, "gdrive:"+fileID, job, req.Force); duplicate {
+		return respondDuplicate(c, existingID)
+	}
+
+	jobID := job.ID
 	tempPath := filepath.Join("temp", fmt.Sprintf("%s.mp3", jobID))
 
+	staged := stageJob(h.workerPool, jobID)
+	defer staged.abandon()
+
 	// Download file from Google Drive
 	log.Printf("Downloading from Google Drive: %s", fileID)
 	if err := downloadGDriveFile(fileID, tempPath); err != nil {
 		log.Printf("Failed to download from Google Drive: %v", err)
+		h.workerPool.ReleaseSource(job)
+		var notAudio *notAudioError
+		if errors.As(err, &notAudio) {
+			return c.Status(422).JSON(fiber.Map{
+				"error": err.Error(),
+				"code":  "ERR_NOT_AUDIO",
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to download file: %v", err),
 			"code":  "ERR_DOWNLOAD_FAILED",
 		})
 	}
 
-	// Create and enqueue job
-	job := &queue.Job{
-		ID:          jobID,
-		RequestName: req.Name,
-		SourceType:  types.SourceGDrive,
-		FilePath:    tempPath,
+	// Confirm ffmpeg will be able to read it before queueing
+	if _, err := transcription.DetectAudioFormat(tempPath); err != nil {
+		os.Remove(tempPath)
+		h.workerPool.ReleaseSource(job)
+		log.Printf("Google Drive file %s failed probe: %v", fileID, err)
+		return c.Status(422).JSON(fiber.Map{
+			"error": "Downloaded file has no readable audio stream",
+			"code":  "ERR_NOT_AUDIO",
+		})
+	}
+
+	if ok, errResp := checkMaxDuration(c, h.workerPool, tempPath, job); !ok {
+		os.Remove(tempPath)
+		h.workerPool.ReleaseSource(job)
+		return errResp
 	}
 
-	h.workerPool.EnqueueJob(job)
+	// Enqueue job
+	job.RequestName = req.Name
+	staged.enqueue(job, tempPath)
 
 	return c.JSON(fiber.Map{
 		"job_id":  jobID,
@@ -164,6 +206,16 @@ func downloadGDriveFile(fileID, destPath string) error {
 		}
 	}
 
+	// Reject anything that is not audio before writing it to disk
+	if ct := resp.Header.Get("Content-Type"); strings.HasPrefix(ct, "text/") || strings.HasPrefix(ct, "application/json") {
+		return &notAudioError{fmt.Sprintf("Google Drive returned %s instead of a media file", ct)}
+	}
+	body := bufio.NewReaderSize(resp.Body, transcription.SniffHeaderSize)
+	header, _ := body.Peek(transcription.SniffHeaderSize)
+	if transcription.SniffAudioFormat(header) == "" {
+		return &notAudioError{fmt.Sprintf("file is not a recognized audio format (detected %s)", http.DetectContentType(header))}
+	}
+
 	// Save to file
 	out, err := os.Create(destPath)
 	if err != nil {
@@ -171,10 +223,19 @@ func downloadGDriveFile(fileID, destPath string) error {
 	}
 	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	_, err = io.Copy(out, body)
 	return err
 }
 
+// notAudioError reports a download whose content is not audio
+type notAudioError struct {
+	reason string
+}
+
+func (e *notAudioError) Error() string {
+	return e.reason
+}
+
 // extractGDriveFileID extracts the file ID from various Google Drive URL formats
 func extractGDriveFileID(url string) string {
 	// Pattern 1: https://drive.google.com/file/d/{ID}/view
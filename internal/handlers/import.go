@@ -0,0 +1,160 @@
+package handlers
+
+// Transcript import handler — accepts transcripts made by other tools
+// (txt, srt, vtt, or Whisper JSON) plus optional audio and stores them
+// like any finished job, so they join search, labels, and exports.
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ImportHandler handles POST /transcripts/import
+type ImportHandler struct {
+	workerPool *queue.WorkerPool
+	maxSizeMB  int
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(workerPool *queue.WorkerPool, maxSizeMB int) *ImportHandler {
+	return &ImportHandler{
+		workerPool: workerPool,
+		maxSizeMB:  maxSizeMB,
+	}
+}
+
+// Handle imports one transcript
+func (h *ImportHandler) Handle(c *fiber.Ctx) error {
+	file, err := c.FormFile("transcript")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "No transcript file uploaded",
+			"code":  "ERR_NO_FILE",
+		})
+	}
+
+	requestName := c.FormValue("name")
+	if requestName == "" {
+		requestName = "imported"
+	}
+
+	opts, optErr := parseFormOptions(c)
+	if optErr != nil {
+		return optErr.respond(c)
+	}
+	if opts.StartTime > 0 || opts.EndTime > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "start_time/end_time are not supported for imports",
+			"code":  "ERR_INVALID_TRIM",
+		})
+	}
+
+	job := &queue.Job{
+		ID:          uuid.New().String(),
+		RequestName: requestName,
+		SourceType:  types.SourceImport,
+	}
+	if optErr := opts.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+	if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+		return rejectJob(c, err)
+	}
+
+	// Parse the transcript
+	data, err := readFormFile(file)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to read transcript file",
+			"code":  "ERR_SAVE_FAILED",
+		})
+	}
+	result, err := transcription.ParseTranscript(file.Filename, data)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_TRANSCRIPT",
+		})
+	}
+	// The language option, validated with the others, labels the import
+	if job.Language != "" && job.Language != transcription.LanguageAuto {
+		result.Language = job.Language
+	}
+
+	// Optional audio: fingerprinted and probed for the record, not retained
+	var sourceSHA256 string
+	if audio, err := c.FormFile("audio"); err == nil {
+		if audio.Size > int64(h.maxSizeMB)*1024*1024 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": fmt.Sprintf("Audio too large (max %dMB)", h.maxSizeMB),
+				"code":  "ERR_FILE_TOO_LARGE",
+			})
+		}
+
+		defer h.workerPool.HoldFiles(job.ID)()
+		audioPath := filepath.Join("temp", job.ID+filepath.Ext(audio.Filename))
+		if err := c.SaveFile(audio, audioPath); err != nil {
+			log.Printf("Failed to save imported audio: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Failed to save file",
+				"code":  "ERR_SAVE_FAILED",
+			})
+		}
+		defer os.Remove(audioPath)
+
+		if sourceSHA256, err = storage.FileSHA256(audioPath); err != nil {
+			log.Printf("Could not checksum imported audio: %v", err)
+		}
+		if ok, errResp := checkAudioContent(c, audioPath, audio.Filename); !ok {
+			return errResp
+		}
+		info, err := transcription.ProbeAudio(audioPath)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Could not probe audio; ffprobe is required to import audio",
+				"code":  "ERR_INVALID_FORMAT",
+			})
+		}
+		result.SourceAudio = info.Source()
+		if result.Duration == 0 {
+			result.Duration = info.Duration
+		}
+	}
+
+	if err := h.workerPool.ImportTranscript(job, result, sourceSHA256); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"job_id": job.ID,
+			"error":  err.Error(),
+			"code":   "ERR_IMPORT_FAILED",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"job_id":           job.ID,
+		"status":           job.Status,
+		"word_count":       result.WordCount,
+		"duration_seconds": result.Duration,
+		"segments":         len(result.Segments),
+	})
+}
+
+// readFormFile reads an uploaded multipart file into memory
+func readFormFile(file *multipart.FileHeader) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,203 @@
+package handlers
+
+// Job status API — reports a job's lifecycle from submission to its final
+// status, with its place in the queue and an ETA while it is waiting or
+// running, streams live progress over SSE, cancels in-flight downloads,
+// sums up job groups, and exposes the dead-letter list of jobs that failed
+// for good.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JobHandler serves /jobs/:id
+type JobHandler struct {
+	db         *storage.MetadataDB
+	workerPool *queue.WorkerPool
+}
+
+// NewJobHandler creates a new job status handler
+func NewJobHandler(db *storage.MetadataDB, workerPool *queue.WorkerPool) *JobHandler {
+	return &JobHandler{
+		db:         db,
+		workerPool: workerPool,
+	}
+}
+
+// Status returns the recorded status of a job plus its live queue state
+func (h *JobHandler) Status(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+	job, err := h.db.GetJob(jobID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Job not found"})
+	}
+
+	if position, ok := h.workerPool.QueuePosition(jobID); ok && position > 0 {
+		job["queue_position"] = position
+	}
+	if remaining, ok := h.workerPool.EstimateCompletion(jobID); ok {
+		job["eta_seconds"] = int(remaining.Seconds())
+		job["eta"] = time.Now().Add(remaining).Format(time.RFC3339)
+	}
+	return c.JSON(job)
+}
+
+// Group returns a job group's aggregate status and progress with the
+// status of each of its jobs
+func (h *JobHandler) Group(c *fiber.Ctx) error {
+	group, err := h.db.GetJobGroup(c.Params("id"))
+	if errors.Is(err, storage.ErrJobGroupNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Job group not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(group)
+}
+
+// eventsKeepAlive is how often an idle event stream sends a comment line,
+// which keeps proxies from closing it and notices departed clients
+const eventsKeepAlive = 15 * time.Second
+
+// Events streams a job's status transitions and progress as Server-Sent
+// Events, starting with its current status and ending after its final one
+func (h *JobHandler) Events(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+
+	// Subscribe before reading the status so no transition falls in between
+	events, unsubscribe := h.workerPool.SubscribeJob(jobID)
+	job, err := h.db.GetJob(jobID)
+	if err != nil {
+		unsubscribe()
+		return c.Status(404).JSON(fiber.Map{"error": "Job not found"})
+	}
+
+	current := queue.JobEvent{
+		Type:   queue.EventStatus,
+		JobID:  jobID,
+		Status: job["status"].(string),
+		Time:   job["updated_at"].(time.Time),
+	}
+	if errText, ok := job["error"].(string); ok {
+		current.Error = errText
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // stop nginx buffering the stream
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		if writeEvent(w, current) != nil || current.Final() {
+			return
+		}
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok || writeEvent(w, event) != nil || event.Final() {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := w.WriteString(": keep-alive\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// writeEvent sends one SSE message named after the event type
+func writeEvent(w *bufio.Writer, event queue.JobEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Cancel aborts a job's in-flight download
+func (h *JobHandler) Cancel(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+	if _, err := h.db.GetJob(jobID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Job not found"})
+	}
+	if !h.workerPool.CancelJob(jobID) {
+		return c.Status(409).JSON(fiber.Map{
+			"error": "Job has no cancellable download in progress",
+			"code":  "ERR_NOT_CANCELLABLE",
+		})
+	}
+	return c.JSON(fiber.Map{"job_id": jobID, "cancelled": true})
+}
+
+// ListDead returns dead-lettered jobs, most recent first (?limit=, default 50)
+func (h *JobHandler) ListDead(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "limit must be between 1 and 500",
+			"code":  "ERR_INVALID_LIMIT",
+		})
+	}
+
+	jobs, err := h.db.ListDeadJobs(limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"jobs": jobs, "count": len(jobs)})
+}
+
+// RequeueDead puts dead-lettered jobs back on the queue. The body lists
+// {"job_ids": [...]}; an empty list requeues every dead job.
+func (h *JobHandler) RequeueDead(c *fiber.Ctx) error {
+	var req struct {
+		JobIDs []string `json:"job_ids"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid request body",
+				"code":  "ERR_INVALID_BODY",
+			})
+		}
+	}
+
+	ids := req.JobIDs
+	if len(ids) == 0 {
+		var err error
+		if ids, err = h.db.DeadJobIDs(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	requeued, errs := h.workerPool.RequeueDead(ids)
+	failures := fiber.Map{}
+	for id, err := range errs {
+		failures[id] = err.Error()
+	}
+	return c.JSON(fiber.Map{
+		"requeued": requeued,
+		"failures": failures,
+	})
+}
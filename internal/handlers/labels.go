@@ -0,0 +1,54 @@
+package handlers
+
+// Job label parsing — labels are a small, indexed set of key=value pairs
+// (team, project, environment) used to slice listings and usage stats.
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxLabels = 10
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,62}$`)
+	labelValuePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{0,63}$`)
+)
+
+// parseLabelsField decodes labels sent as a form value: "team=ml,env=prod"
+func parseLabelsField(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	labels := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid label %q (expected key=value)", pair)
+		}
+		labels[key] = value
+	}
+
+	if err := validateLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// validateLabels checks label count and key/value syntax
+func validateLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return fmt.Errorf("at most %d labels are allowed", maxLabels)
+	}
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("invalid value for label %q", key)
+		}
+	}
+	return nil
+}
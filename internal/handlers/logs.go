@@ -0,0 +1,100 @@
+package handlers
+
+// Server logs — the recent log lines kept in memory, and a live SSE tail
+// of new ones. Secrets are redacted before lines are retained.
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LogHandler serves /logs
+type LogHandler struct {
+	buffer *logging.Buffer
+}
+
+// NewLogHandler creates a new log handler
+func NewLogHandler(buffer *logging.Buffer) *LogHandler {
+	return &LogHandler{buffer: buffer}
+}
+
+// List returns the retained log lines, oldest first
+func (h *LogHandler) List(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"logs": h.buffer.Lines(),
+	})
+}
+
+// Stream sends new log lines as SSE "log" events; ?tail=N replays the last
+// N retained lines first
+func (h *LogHandler) Stream(c *fiber.Ctx) error {
+	tail := 0
+	if raw := c.Query("tail"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "tail must be a non-negative number of lines",
+				"code":  "ERR_INVALID_TAIL",
+			})
+		}
+		tail = n
+	}
+
+	backlog, lines, unsubscribe := h.buffer.Subscribe()
+	backlog = backlog[max(len(backlog)-tail, 0):]
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // stop nginx buffering the stream
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		for _, line := range backlog {
+			if writeLogEvent(w, line) != nil {
+				return
+			}
+		}
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok || writeLogEvent(w, line) != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := w.WriteString(": keep-alive\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// writeLogEvent sends one log entry, one data field per line of it
+func writeLogEvent(w *bufio.Writer, entry string) error {
+	if _, err := w.WriteString("event: log\n"); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimRight(entry, "\n"), "\n") {
+		if _, err := w.WriteString("data: " + line + "\n"); err != nil {
+			return err
+		}
+	}
+	if _, err := w.WriteString("\n"); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,62 @@
+package handlers
+
+// Per-job metadata parsing — validates the free-form JSON object clients
+// attach to a submission (customer id, case number, meeting id, ...).
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+const (
+	maxMetadataBytes = 16 * 1024
+	maxMetadataKeys  = 50
+)
+
+// metadataKeyPattern restricts keys so they can be used as list filters
+var metadataKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// parseMetadataField decodes a metadata JSON object sent as a form value
+func parseMetadataField(raw string) (map[string]interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	if len(raw) > maxMetadataBytes {
+		return nil, fmt.Errorf("metadata exceeds %d bytes", maxMetadataBytes)
+	}
+
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+		return nil, fmt.Errorf("metadata must be a JSON object")
+	}
+
+	if err := validateMetadata(metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// validateMetadata checks key names and overall size of a metadata object
+func validateMetadata(metadata map[string]interface{}) error {
+	if metadata == nil {
+		return nil
+	}
+	if len(metadata) > maxMetadataKeys {
+		return fmt.Errorf("metadata may contain at most %d keys", maxMetadataKeys)
+	}
+	for key := range metadata {
+		if !metadataKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid metadata key %q", key)
+		}
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("metadata is not serializable: %v", err)
+	}
+	if len(encoded) > maxMetadataBytes {
+		return fmt.Errorf("metadata exceeds %d bytes", maxMetadataBytes)
+	}
+	return nil
+}
@@ -0,0 +1,533 @@
+package handlers
+
+// Per-job submission options — the settings every endpoint accepts, read
+// from JSON bodies (gdrive, youtube, stream) or form values (upload),
+// validated once and copied onto the queued job.
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// JobOptions are the optional per-job settings shared by all submission endpoints
+type JobOptions struct {
+	Metadata map[string]interface{} `json:"metadata"`
+	Labels   map[string]string      `json:"labels"`
+
+	// EncryptionKey is an optional base64 AES-256 key for the job's artifacts
+	EncryptionKey string `json:"encryption_key"`
+
+	// StartTime/EndTime select a section of the recording to transcribe
+	StartTime TimeOffset `json:"start_time"`
+	EndTime   TimeOffset `json:"end_time"`
+
+	// Force queues a new job even if an identical one is in progress
+	Force bool `json:"force"`
+
+	// Priority is "low", "normal" (default), or "high"
+	Priority string `json:"priority"`
+
+	// FormatProfile picks how numbers, times, and amounts are written
+	// ("us", "eu", or a configured profile)
+	FormatProfile string `json:"format_profile"`
+
+	// Destinations names configured output destinations that replace the
+	// default output directory and Drive folder for this job
+	Destinations []string `json:"destinations"`
+
+	// DriveFormats picks the extra renderings uploaded to Drive (srt, vtt,
+	// tsv; "txt" for none), overriding google_drive.upload_formats
+	DriveFormats []string `json:"drive_formats"`
+
+	// LineEndings ("lf" or "crlf") and BOM override storage.text_encoding
+	// for the job's txt and subtitle files
+	LineEndings string `json:"line_endings"`
+	BOM         *bool  `json:"bom"`
+
+	// Language is the spoken language ("en", "pt-br", ...); empty or
+	// "auto" has the backend detect it
+	Language string `json:"language"`
+
+	// Task is "transcribe" (default) or "translate" for English text of
+	// non-English speech
+	Task string `json:"task"`
+
+	// InitialPrompt is text for the model to continue from, and Vocabulary
+	// lists domain terms to favour, for jargon-heavy recordings
+	InitialPrompt string   `json:"initial_prompt"`
+	Vocabulary    []string `json:"vocabulary"`
+
+	// Model is a whisper model size (tiny, base, small, medium, large)
+	// replacing the configured one for this job
+	Model string `json:"model"`
+
+	// Denoise applies the noise reduction filter during normalization, for
+	// noisy field recordings and phone calls
+	Denoise *bool `json:"denoise"`
+
+	// Speedup plays the audio up to 2x faster to whisper, for a quicker
+	// transcript of long recordings at a little accuracy; 1 keeps the
+	// recorded speed even when the server speeds jobs up by default
+	Speedup float64 `json:"speedup"`
+
+	// Diarize labels each segment with its speaker; Speakers, when known,
+	// is how many there are
+	Diarize  *bool `json:"diarize"`
+	Speakers int   `json:"speakers"`
+
+	// DualChannel transcribes a stereo call's channels apart and merges
+	// them by time, labelling the speakers with ChannelLabels (default
+	// "Caller" for the first channel, "Agent" for the second)
+	DualChannel   bool     `json:"dual_channel"`
+	ChannelLabels []string `json:"channel_labels"`
+
+	// DecodingParams (temperature, beam_size, best_of,
+	// condition_on_previous_text, no_speech_threshold,
+	// temperature_increment, compression_ratio_threshold,
+	// logprob_threshold) override whisper.decoding for the job
+	types.DecodingParams
+}
+
+// Limits on the prompt options; whisper only reads the last 224 tokens
+// of its prompt anyway
+const (
+	maxInitialPromptLength = 1000 // characters
+	maxVocabularyTerms     = 100
+	maxVocabularyTermLen   = 100 // characters
+)
+
+// optionError is a validation failure with a machine-readable code
+type optionError struct {
+	code string
+	err  error
+}
+
+// respond writes the 400 response for a rejected option
+func (e *optionError) respond(c *fiber.Ctx) error {
+	return c.Status(400).JSON(fiber.Map{
+		"error": e.err.Error(),
+		"code":  e.code,
+	})
+}
+
+// invalidOption wraps an error with its response code
+func invalidOption(code string, err error) *optionError {
+	return &optionError{code: code, err: err}
+}
+
+// parseFormOptions reads JobOptions from multipart form values
+func parseFormOptions(c *fiber.Ctx) (JobOptions, *optionError) {
+	var (
+		opts JobOptions
+		err  error
+	)
+
+	if opts.Metadata, err = parseMetadataField(c.FormValue("metadata")); err != nil {
+		return opts, invalidOption("ERR_INVALID_METADATA", err)
+	}
+	if opts.Labels, err = parseLabelsField(c.FormValue("labels")); err != nil {
+		return opts, invalidOption("ERR_INVALID_LABELS", err)
+	}
+	opts.EncryptionKey = c.FormValue("encryption_key")
+	opts.Force, _ = strconv.ParseBool(c.FormValue("force"))
+	opts.Priority = c.FormValue("priority")
+	opts.FormatProfile = c.FormValue("format_profile")
+	opts.Destinations = parseListField(c.FormValue("destinations"))
+	opts.DriveFormats = parseListField(c.FormValue("drive_formats"))
+	opts.LineEndings = c.FormValue("line_endings")
+	opts.Language = c.FormValue("language")
+	opts.Task = c.FormValue("task")
+	opts.InitialPrompt = c.FormValue("initial_prompt")
+	opts.Vocabulary = parseListField(c.FormValue("vocabulary"))
+	opts.Model = c.FormValue("model")
+	if raw := c.FormValue("denoise"); raw != "" {
+		denoise, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, invalidOption("ERR_INVALID_DENOISE", fmt.Errorf("denoise must be true or false"))
+		}
+		opts.Denoise = &denoise
+	}
+	if raw := c.FormValue("diarize"); raw != "" {
+		diarize, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, invalidOption("ERR_INVALID_DIARIZE", fmt.Errorf("diarize must be true or false"))
+		}
+		opts.Diarize = &diarize
+	}
+	if raw := c.FormValue("speakers"); raw != "" {
+		if opts.Speakers, err = strconv.Atoi(raw); err != nil {
+			return opts, invalidOption("ERR_INVALID_DIARIZE", fmt.Errorf("speakers must be a whole number"))
+		}
+	}
+	if raw := c.FormValue("dual_channel"); raw != "" {
+		if opts.DualChannel, err = strconv.ParseBool(raw); err != nil {
+			return opts, invalidOption("ERR_INVALID_DUAL_CHANNEL", fmt.Errorf("dual_channel must be true or false"))
+		}
+	}
+	opts.ChannelLabels = parseListField(c.FormValue("channel_labels"))
+	if raw := c.FormValue("speedup"); raw != "" {
+		if opts.Speedup, err = strconv.ParseFloat(raw, 64); err != nil {
+			return opts, invalidOption("ERR_INVALID_SPEEDUP", fmt.Errorf("speedup must be a number"))
+		}
+	}
+	if raw := c.FormValue("bom"); raw != "" {
+		bom, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, invalidOption("ERR_INVALID_TEXT_ENCODING", fmt.Errorf("bom must be true or false"))
+		}
+		opts.BOM = &bom
+	}
+
+	if opts.DecodingParams, err = parseDecodingFields(c); err != nil {
+		return opts, invalidOption("ERR_INVALID_DECODING", err)
+	}
+
+	for field, dest := range map[string]*TimeOffset{"start_time": &opts.StartTime, "end_time": &opts.EndTime} {
+		if raw := c.FormValue(field); raw != "" {
+			seconds, err := parseTimeOffset(raw)
+			if err != nil {
+				return opts, invalidOption("ERR_INVALID_TRIM", fmt.Errorf("%s: %v", field, err))
+			}
+			*dest = TimeOffset(seconds)
+		}
+	}
+
+	return opts, nil
+}
+
+// parseDecodingFields reads the decoding parameter form values
+func parseDecodingFields(c *fiber.Ctx) (types.DecodingParams, error) {
+	var (
+		params types.DecodingParams
+		err    error
+	)
+	for field, dest := range map[string]*float64{
+		"temperature":                 &params.Temperature,
+		"no_speech_threshold":         &params.NoSpeechThreshold,
+		"temperature_increment":       &params.TemperatureIncrement,
+		"compression_ratio_threshold": &params.CompressionRatioThreshold,
+		"logprob_threshold":           &params.LogprobThreshold,
+	} {
+		if raw := c.FormValue(field); raw != "" {
+			if *dest, err = strconv.ParseFloat(raw, 64); err != nil {
+				return params, fmt.Errorf("%s must be a number", field)
+			}
+		}
+	}
+	for field, dest := range map[string]*int{"beam_size": &params.BeamSize, "best_of": &params.BestOf} {
+		if raw := c.FormValue(field); raw != "" {
+			if *dest, err = strconv.Atoi(raw); err != nil {
+				return params, fmt.Errorf("%s must be a whole number", field)
+			}
+		}
+	}
+	if raw := c.FormValue("condition_on_previous_text"); raw != "" {
+		condition, err := strconv.ParseBool(raw)
+		if err != nil {
+			return params, fmt.Errorf("condition_on_previous_text must be true or false")
+		}
+		params.ConditionOnPreviousText = &condition
+	}
+	return params, nil
+}
+
+// parseListField splits a comma-separated form value, returning nil when empty
+func parseListField(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
+
+// withDefaults fills in options the client left unset from the defaults
+// configured for the job's source
+func (o JobOptions) withDefaults(d queue.SourceDefaults) JobOptions {
+	if o.Priority == "" {
+		o.Priority = d.Priority
+	}
+	if o.FormatProfile == "" {
+		o.FormatProfile = d.FormatProfile
+	}
+	if o.Destinations == nil {
+		o.Destinations = d.Destinations
+	}
+	if o.DriveFormats == nil {
+		o.DriveFormats = d.DriveFormats
+	}
+	if o.LineEndings == "" {
+		o.LineEndings = d.LineEndings
+	}
+	if o.BOM == nil {
+		o.BOM = d.BOM
+	}
+	if o.Language == "" {
+		o.Language = d.Language
+	}
+	if o.Task == "" {
+		o.Task = d.Task
+	}
+	if o.Model == "" {
+		o.Model = d.Model
+	}
+	if o.Denoise == nil {
+		o.Denoise = d.Denoise
+	}
+	if o.Speedup == 0 {
+		o.Speedup = d.Speedup
+	}
+	if o.Diarize == nil {
+		o.Diarize = d.Diarize
+		if o.Speakers == 0 {
+			o.Speakers = d.Speakers
+		}
+	}
+	// Decoding parameters work together, so the defaults apply as a set
+	if o.DecodingParams == (types.DecodingParams{}) {
+		o.DecodingParams = d.Decoding
+	}
+	if len(d.Labels) > 0 {
+		labels := make(map[string]string, len(d.Labels)+len(o.Labels))
+		maps.Copy(labels, d.Labels)
+		maps.Copy(labels, o.Labels)
+		o.Labels = labels
+	}
+	return o
+}
+
+// applyTo validates the options, with the defaults for the job's source
+// filled in, against the worker pool's configuration and copies them onto
+// a job
+func (o JobOptions) applyTo(job *queue.Job, wp *queue.WorkerPool) *optionError {
+	o = o.withDefaults(wp.SourceDefaults(job.SourceType))
+	if err := validateMetadata(o.Metadata); err != nil {
+		return invalidOption("ERR_INVALID_METADATA", err)
+	}
+	if err := validateLabels(o.Labels); err != nil {
+		return invalidOption("ERR_INVALID_LABELS", err)
+	}
+
+	key, err := ParseEncryptionKey(o.EncryptionKey)
+	if err != nil {
+		return invalidOption("ERR_INVALID_KEY", err)
+	}
+
+	priority, err := queue.ParsePriority(o.Priority)
+	if err != nil {
+		return invalidOption("ERR_INVALID_PRIORITY", err)
+	}
+
+	if _, ok := transcription.LookupFormatProfile(o.FormatProfile); o.FormatProfile != "" && !ok {
+		return invalidOption("ERR_INVALID_FORMAT_PROFILE", fmt.Errorf("unknown format_profile %q (available: %s)",
+			o.FormatProfile, strings.Join(transcription.FormatProfileNames(), ", ")))
+	}
+
+	if err := wp.CheckDestinations(o.Destinations); err != nil {
+		return invalidOption("ERR_INVALID_DESTINATION", err)
+	}
+	if err := storage.CheckDriveFormats(o.DriveFormats); err != nil {
+		return invalidOption("ERR_INVALID_DRIVE_FORMATS", err)
+	}
+
+	var encoding *storage.TextEncoding
+	if o.LineEndings != "" || o.BOM != nil {
+		enc := wp.TextEncoding()
+		if o.LineEndings != "" {
+			enc.LineEndings = o.LineEndings
+		}
+		if o.BOM != nil {
+			enc.BOM = *o.BOM
+		}
+		if err := enc.Check(); err != nil {
+			return invalidOption("ERR_INVALID_TEXT_ENCODING", err)
+		}
+		encoding = &enc
+	}
+
+	language := strings.ToLower(strings.TrimSpace(o.Language))
+	if err := transcription.CheckLanguage(language); err != nil {
+		return invalidOption("ERR_INVALID_LANGUAGE", err)
+	}
+
+	task := strings.ToLower(strings.TrimSpace(o.Task))
+	if err := transcription.CheckTask(task); err != nil {
+		return invalidOption("ERR_INVALID_TASK", err)
+	}
+	switch task {
+	case transcription.TaskTranscribe:
+		task = ""
+	case transcription.TaskTranslate:
+		if !wp.CanTranslate() {
+			return invalidOption("ERR_TRANSLATION_UNSUPPORTED", fmt.Errorf("this server's transcription backend can't translate; only whisper models can"))
+		}
+	}
+
+	prompt := strings.TrimSpace(o.InitialPrompt)
+	var vocabulary []string
+	for _, term := range o.Vocabulary {
+		vocabulary = append(vocabulary, strings.TrimSpace(term))
+	}
+	if err := checkPrompt(prompt, vocabulary); err != nil {
+		return invalidOption("ERR_INVALID_PROMPT", err)
+	}
+	if prompt != "" && !wp.CanPrompt() {
+		return invalidOption("ERR_PROMPT_UNSUPPORTED", fmt.Errorf("this server's transcription backend doesn't take an initial prompt; only whisper models do"))
+	}
+	if len(vocabulary) > 0 && !wp.CanBoostVocabulary() {
+		return invalidOption("ERR_PROMPT_UNSUPPORTED", fmt.Errorf("this server's transcription backend doesn't take a vocabulary"))
+	}
+
+	model := strings.ToLower(strings.TrimSpace(o.Model))
+	if err := transcription.CheckModel(model); err != nil {
+		return invalidOption("ERR_INVALID_MODEL", err)
+	}
+	if model != "" && !wp.CanSelectModel() {
+		return invalidOption("ERR_MODEL_UNSUPPORTED", fmt.Errorf("this server's transcription backend has no model sizes to pick from; only whisper models do"))
+	}
+
+	if err := transcription.CheckDecoding(o.DecodingParams); err != nil {
+		return invalidOption("ERR_INVALID_DECODING", err)
+	}
+	if o.DecodingParams != (types.DecodingParams{}) && !wp.CanTuneDecoding() {
+		return invalidOption("ERR_DECODING_UNSUPPORTED", fmt.Errorf("this server's transcription backend doesn't take decoding parameters; only whisper models do"))
+	}
+
+	if err := transcription.CheckSpeedup(o.Speedup); err != nil {
+		return invalidOption("ERR_INVALID_SPEEDUP", err)
+	}
+
+	start, end := float64(o.StartTime), float64(o.EndTime)
+	if start < 0 || end < 0 {
+		return invalidOption("ERR_INVALID_TRIM", fmt.Errorf("start_time and end_time must not be negative"))
+	}
+	if end > 0 && end <= start {
+		return invalidOption("ERR_INVALID_TRIM", fmt.Errorf("end_time must be after start_time"))
+	}
+
+	diarize := o.Diarize != nil && *o.Diarize
+	if err := transcription.CheckSpeakers(o.Speakers); err != nil {
+		return invalidOption("ERR_INVALID_DIARIZE", err)
+	}
+	switch {
+	case o.Speakers > 0 && !diarize:
+		return invalidOption("ERR_INVALID_DIARIZE", fmt.Errorf("speakers needs diarize"))
+	case diarize && o.DualChannel:
+		return invalidOption("ERR_INVALID_DIARIZE", fmt.Errorf("dual_channel already labels each channel's speaker; drop diarize"))
+	case diarize && !wp.CanDiarize():
+		return invalidOption("ERR_DIARIZATION_UNSUPPORTED", fmt.Errorf("speaker diarization is not enabled on this server (whisper.diarization)"))
+	}
+
+	var channelLabels []string
+	if o.DualChannel {
+		channelLabels = transcription.DefaultChannelLabels
+		if o.ChannelLabels != nil {
+			channelLabels = nil
+			for _, label := range o.ChannelLabels {
+				channelLabels = append(channelLabels, strings.TrimSpace(label))
+			}
+		}
+		if err := transcription.CheckChannelLabels(channelLabels); err != nil {
+			return invalidOption("ERR_INVALID_DUAL_CHANNEL", err)
+		}
+	} else if len(o.ChannelLabels) > 0 {
+		return invalidOption("ERR_INVALID_DUAL_CHANNEL", fmt.Errorf("channel_labels needs dual_channel"))
+	}
+
+	job.Metadata = o.Metadata
+	job.Labels = o.Labels
+	job.EncryptionKey = key
+	job.StartTime = start
+	job.EndTime = end
+	job.Priority = priority
+	job.FormatProfile = o.FormatProfile
+	job.Destinations = o.Destinations
+	job.DriveFormats = o.DriveFormats
+	job.TextEncoding = encoding
+	job.Language = language
+	job.Task = task
+	job.InitialPrompt = prompt
+	job.Vocabulary = vocabulary
+	job.Decoding = o.DecodingParams
+	job.Model = model
+	job.Denoise = o.Denoise != nil && *o.Denoise
+	job.Speedup = o.Speedup
+	job.Diarize = diarize
+	job.Speakers = o.Speakers
+	job.DualChannel = o.DualChannel
+	job.ChannelLabels = channelLabels
+	return nil
+}
+
+// checkPrompt validates the initial prompt and vocabulary lengths
+func checkPrompt(prompt string, vocabulary []string) error {
+	if len(prompt) > maxInitialPromptLength {
+		return fmt.Errorf("initial_prompt is longer than %d characters", maxInitialPromptLength)
+	}
+	if len(vocabulary) > maxVocabularyTerms {
+		return fmt.Errorf("vocabulary has more than %d terms", maxVocabularyTerms)
+	}
+	for _, term := range vocabulary {
+		if term == "" || len(term) > maxVocabularyTermLen {
+			return fmt.Errorf("vocabulary terms must be 1 to %d characters", maxVocabularyTermLen)
+		}
+	}
+	return nil
+}
+
+// TimeOffset is a position in the recording, given in JSON either as
+// seconds (90.5) or as a clock string ("1:30.5", "01:02:03")
+type TimeOffset float64
+
+// UnmarshalJSON accepts a number or a clock string
+func (t *TimeOffset) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*t = TimeOffset(seconds)
+		return nil
+	}
+
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("time offset must be seconds or HH:MM:SS")
+	}
+	seconds, err := parseTimeOffset(raw)
+	if err != nil {
+		return err
+	}
+	*t = TimeOffset(seconds)
+	return nil
+}
+
+// parseTimeOffset parses "90.5", "1:30.5", or "01:02:03.25" into seconds
+func parseTimeOffset(raw string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(raw), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", raw)
+	}
+
+	var seconds float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("invalid time %q", raw)
+		}
+		// Only the last component may be fractional or exceed 59
+		if i < len(parts)-1 && value != float64(int(value)) {
+			return 0, fmt.Errorf("invalid time %q", raw)
+		}
+		seconds = seconds*60 + value
+	}
+	return seconds, nil
+}
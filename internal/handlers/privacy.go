@@ -0,0 +1,209 @@
+package handlers
+
+// Privacy requests — exports everything stored for a tenant as a zip
+// archive, and erases it everywhere: local files, Drive copies, the search
+// index, and the database.
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/search"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PrivacyHandler serves tenant data export and erasure
+type PrivacyHandler struct {
+	db           *storage.MetadataDB
+	localStorage *storage.LocalStorage
+	driveClient  *storage.DriveClient
+	indexer      *search.Indexer
+}
+
+// NewPrivacyHandler creates a new privacy handler; driveClient and indexer may be nil
+func NewPrivacyHandler(db *storage.MetadataDB, localStorage *storage.LocalStorage,
+	driveClient *storage.DriveClient, indexer *search.Indexer) *PrivacyHandler {
+	return &PrivacyHandler{
+		db:           db,
+		localStorage: localStorage,
+		driveClient:  driveClient,
+		indexer:      indexer,
+	}
+}
+
+// exportedJob is one job's entry in an export manifest
+type exportedJob struct {
+	JobID      string                     `json:"job_id"`
+	Job        map[string]interface{}     `json:"job,omitempty"`
+	Transcript map[string]interface{}     `json:"transcript,omitempty"`
+	Webhooks   []*storage.WebhookDelivery `json:"webhook_deliveries"`
+	Files      []string                   `json:"files"`
+}
+
+// ExportTenant streams a zip of every job, transcript, artifact, and webhook
+// delivery recorded for a tenant
+func (h *PrivacyHandler) ExportTenant(c *fiber.Ctx) error {
+	tenant := c.Params("tenant")
+	ids, err := h.db.TenantJobIDs(tenant)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	jobs := make([]exportedJob, 0, len(ids))
+
+	for _, id := range ids {
+		entry := exportedJob{JobID: id, Files: []string{}}
+		entry.Job, _ = h.db.GetJob(id)
+		entry.Transcript, _ = h.db.GetTranscript(id)
+		if entry.Webhooks, err = h.db.JobDeliveries(id); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Artifacts are copied as stored; encrypted ones stay sealed
+		if localPath, _ := entry.Transcript["local_path"].(string); localPath != "" {
+			for _, path := range h.localStorage.ArtifactPaths(localPath) {
+				name := filepath.ToSlash(filepath.Join("jobs", id, filepath.Base(path)))
+				if err := addFileToZip(archive, name, path); err != nil {
+					if os.IsNotExist(err) {
+						continue
+					}
+					return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+				}
+				entry.Files = append(entry.Files, name)
+			}
+		}
+		jobs = append(jobs, entry)
+	}
+
+	manifest, err := json.MarshalIndent(fiber.Map{
+		"tenant":      tenant,
+		"exported_at": time.Now().UTC().Format(time.RFC3339),
+		"jobs":        jobs,
+	}, "", "  ")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	w, err := archive.Create("manifest.json")
+	if err == nil {
+		_, err = w.Write(manifest)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("failed to build archive: %v", err)})
+	}
+
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_export.zip"`, sanitizeArchiveName(tenant)))
+	return c.Send(buf.Bytes())
+}
+
+// EraseTenant deletes everything recorded for a tenant. It refuses while
+// the tenant still has jobs in flight, since those would recreate data.
+func (h *PrivacyHandler) EraseTenant(c *fiber.Ctx) error {
+	tenant := c.Params("tenant")
+
+	active, err := h.db.ActiveTenantJobs(tenant)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if active > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"error": fmt.Sprintf("Tenant has %d job(s) still in progress; retry once they finish", active),
+			"code":  "ERR_JOBS_IN_FLIGHT",
+		})
+	}
+
+	ids, err := h.db.TenantJobIDs(tenant)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	erased := 0
+	failures := fiber.Map{}
+	for _, id := range ids {
+		if err := h.eraseJob(id); err != nil {
+			failures[id] = err.Error()
+			continue
+		}
+		erased++
+	}
+
+	status := 200
+	if len(failures) > 0 {
+		status = 500
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"tenant":   tenant,
+		"erased":   erased,
+		"failures": failures,
+	})
+}
+
+// eraseJob removes a job's data from every store. Remote copies are removed
+// before the database rows that point at them, so a failure can be retried.
+func (h *PrivacyHandler) eraseJob(jobID string) error {
+	if transcript, err := h.db.GetTranscript(jobID); err == nil {
+		if localPath, _ := transcript["local_path"].(string); localPath != "" {
+			if err := h.localStorage.DeleteTranscript(localPath); err != nil {
+				return err
+			}
+		}
+		if gdriveURL, _ := transcript["gdrive_url"].(string); gdriveURL != "" && h.driveClient != nil {
+			if err := h.driveClient.Delete(gdriveURL); err != nil {
+				log.Printf("WARNING: failed to delete Drive copy of %s: %v", jobID, err)
+				return fmt.Errorf("failed to delete Drive copy: %v", err)
+			}
+		}
+	}
+	if h.indexer != nil {
+		if err := h.indexer.Delete(jobID); err != nil {
+			log.Printf("WARNING: failed to remove %s from search index: %v", jobID, err)
+			return fmt.Errorf("failed to remove search document: %v", err)
+		}
+	}
+	if dead, err := h.db.GetDeadJob(jobID); err == nil && dead.SourcePath != "" {
+		if err := os.Remove(dead.SourcePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete retained source audio: %v", err)
+		}
+	}
+	return h.db.EraseJob(jobID)
+}
+
+// addFileToZip copies a file on disk into the archive under name
+func addFileToZip(archive *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// sanitizeArchiveName keeps a download filename to safe characters
+func sanitizeArchiveName(name string) string {
+	safe := []rune(name)
+	for i, r := range safe {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			safe[i] = '_'
+		}
+	}
+	return string(safe)
+}
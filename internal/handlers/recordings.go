@@ -0,0 +1,353 @@
+package handlers
+
+// Chunked recording API — for mobile clients that can't hold a WebSocket
+// open for a whole interview. The client starts a recording, uploads its
+// numbered chunks as connectivity allows (retrying any that failed, after
+// asking which ones arrived), and completes it with the chunk count; the
+// server then assembles the chunks and queues the transcription. With an
+// encryption key, chunks are sealed on the device and only opened for
+// assembly, when the client sends the key again.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxRecordingChunks caps a recording's chunk numbers
+const maxRecordingChunks = 10000
+
+// ChunkSHA256Header optionally carries the hex SHA-256 of a chunk's body,
+// checked before it is stored
+const ChunkSHA256Header = "X-Chunk-SHA256"
+
+// RecordingHandler serves /recordings
+type RecordingHandler struct {
+	workerPool *queue.WorkerPool
+	store      *storage.RecordingStore
+	maxSizeMB  int
+}
+
+// NewRecordingHandler creates a new chunked recording handler; a
+// recording's chunks may add up to maxSizeMB
+func NewRecordingHandler(workerPool *queue.WorkerPool, store *storage.RecordingStore, maxSizeMB int) *RecordingHandler {
+	return &RecordingHandler{
+		workerPool: workerPool,
+		store:      store,
+		maxSizeMB:  maxSizeMB,
+	}
+}
+
+// RecordingRequest starts a recording
+type RecordingRequest struct {
+	Name string `json:"name"`
+	// Format is the audio container the chunks make up once joined, as a
+	// file extension (webm, ogg, m4a, ...)
+	Format string `json:"format"`
+	JobOptions
+}
+
+// Create starts a recording session. The job options are checked now, so
+// a client learns of a mistake before recording, and applied on complete.
+func (h *RecordingHandler) Create(c *fiber.Ctx) error {
+	var req RecordingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+
+	req.Format = strings.TrimPrefix(strings.ToLower(req.Format), ".")
+	if req.Format == "" {
+		req.Format = "webm"
+	}
+	filename := "recording." + req.Format
+	if filepath.Ext(filename) != "."+req.Format || !transcription.ValidateAudioFormat(filename) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported audio format %q", req.Format),
+			"code":  "ERR_INVALID_FORMAT",
+		})
+	}
+
+	job := &queue.Job{ID: uuid.New().String(), SourceType: types.SourceRecording}
+	if optErr := req.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+	if req.Name == "" {
+		req.Name = "recording"
+	}
+
+	// The key stays with the client; chunks are checked against its fingerprint
+	rec := &storage.Recording{ID: uuid.New().String(), Name: req.Name, Format: req.Format}
+	if job.EncryptionKey != nil {
+		rec.KeyFingerprint = storage.KeyFingerprint(job.EncryptionKey)
+		req.EncryptionKey = ""
+	}
+	options, err := json.Marshal(req.JobOptions)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	rec.Options = options
+	if err := h.store.Create(rec); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	log.Printf("Recording %s started (name: %s, format: %s, encrypted: %t)",
+		rec.ID, rec.Name, rec.Format, rec.KeyFingerprint != "")
+	return c.Status(201).JSON(fiber.Map{
+		"recording_id": rec.ID,
+		"encrypted":    rec.KeyFingerprint != "",
+		"max_chunks":   maxRecordingChunks,
+	})
+}
+
+// PutChunk stores chunk :n (from 1). Uploading a chunk again replaces it,
+// so a client unsure whether an upload landed can just repeat it.
+func (h *RecordingHandler) PutChunk(c *fiber.Ctx) error {
+	rec, errResp := h.recording(c)
+	if rec == nil {
+		return errResp
+	}
+	n, err := strconv.Atoi(c.Params("n"))
+	if err != nil || n < 1 || n > maxRecordingChunks {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Chunk number must be between 1 and %d", maxRecordingChunks),
+			"code":  "ERR_INVALID_CHUNK",
+		})
+	}
+
+	chunk := c.Body()
+	if len(chunk) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Chunk is empty",
+			"code":  "ERR_INVALID_CHUNK",
+		})
+	}
+	if want := c.Get(ChunkSHA256Header); want != "" {
+		sum := sha256.Sum256(chunk)
+		if !strings.EqualFold(want, hex.EncodeToString(sum[:])) {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Chunk does not match its " + ChunkSHA256Header,
+				"code":  "ERR_CHUNK_CORRUPT",
+			})
+		}
+	}
+
+	// A sealed chunk must open with the recording's key, but is kept sealed
+	if rec.KeyFingerprint != "" {
+		key, errResp := requireKey(c, rec.KeyFingerprint, "recording")
+		if key == nil {
+			return errResp
+		}
+		if _, err := storage.DecryptArtifact(key, chunk); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": fmt.Sprintf("Chunk is not sealed with the recording's key: %v", err),
+				"code":  "ERR_CHUNK_CORRUPT",
+			})
+		}
+	}
+
+	_, size, err := h.store.Chunks(rec.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if size+int64(len(chunk)) > int64(h.maxSizeMB)*1024*1024 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Recording too large (max %dMB)", h.maxSizeMB),
+			"code":  "ERR_FILE_TOO_LARGE",
+		})
+	}
+
+	if err := h.store.PutChunk(rec.ID, n, chunk); err != nil {
+		if errors.Is(err, storage.ErrRecordingClosed) {
+			return c.Status(409).JSON(fiber.Map{
+				"error":  "Recording was already completed",
+				"code":   "ERR_RECORDING_COMPLETE",
+				"job_id": rec.JobID,
+			})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"recording_id": rec.ID, "chunk": n, "bytes": len(chunk)})
+}
+
+// Status lists the chunks received so far, so a client coming back online
+// knows which to (re)send; ?total=N also lists the missing ones
+func (h *RecordingHandler) Status(c *fiber.Ctx) error {
+	rec, errResp := h.recording(c)
+	if rec == nil {
+		return errResp
+	}
+	chunks, size, err := h.store.Chunks(rec.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	response := fiber.Map{
+		"recording_id": rec.ID,
+		"name":         rec.Name,
+		"format":       rec.Format,
+		"encrypted":    rec.KeyFingerprint != "",
+		"received":     chunks,
+		"bytes":        size,
+		"created_at":   rec.CreatedAt,
+		"updated_at":   rec.UpdatedAt,
+	}
+	if total, err := strconv.Atoi(c.Query("total")); err == nil && total > 0 {
+		response["missing"] = missingChunks(chunks, total)
+	}
+	if rec.JobID != "" {
+		response["job_id"] = rec.JobID
+	}
+	return c.JSON(response)
+}
+
+// Complete closes the manifest: once chunks 1..total_chunks have all
+// arrived, they are joined (and opened, for encrypted recordings) and the
+// recording is queued for transcription. Completing again returns the same
+// job, for clients that lost the first response.
+func (h *RecordingHandler) Complete(c *fiber.Ctx) error {
+	rec, errResp := h.recording(c)
+	if rec == nil {
+		return errResp
+	}
+	if rec.JobID != "" {
+		return c.JSON(fiber.Map{"recording_id": rec.ID, "job_id": rec.JobID, "status": "queued"})
+	}
+
+	var req struct {
+		TotalChunks int `json:"total_chunks"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.TotalChunks < 1 || req.TotalChunks > maxRecordingChunks {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("total_chunks must be between 1 and %d", maxRecordingChunks),
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+
+	chunks, _, err := h.store.Chunks(rec.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if missing := missingChunks(chunks, req.TotalChunks); len(missing) > 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"error":   fmt.Sprintf("%d chunks have not arrived yet", len(missing)),
+			"code":    "ERR_CHUNKS_MISSING",
+			"missing": missing,
+		})
+	}
+
+	// Rebuild the job from the options the recording was started with
+	var opts JobOptions
+	if err := json.Unmarshal(rec.Options, &opts); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("corrupt recording options: %v", err)})
+	}
+	var key []byte
+	if rec.KeyFingerprint != "" {
+		if key, errResp = requireKey(c, rec.KeyFingerprint, "recording"); key == nil {
+			return errResp
+		}
+		opts.EncryptionKey = c.Get(EncryptionKeyHeader)
+	}
+	job := &queue.Job{
+		ID:          uuid.New().String(),
+		RequestName: rec.Name,
+		SourceType:  types.SourceRecording,
+	}
+	if optErr := opts.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+	if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+		return rejectJob(c, err)
+	}
+
+	staged := stageJob(h.workerPool, job.ID)
+	defer staged.abandon()
+
+	tempPath := filepath.Join("temp", job.ID+"."+rec.Format)
+	var open func(int, []byte) ([]byte, error)
+	if key != nil {
+		open = func(n int, chunk []byte) ([]byte, error) {
+			plain, err := storage.DecryptArtifact(key, chunk)
+			if err != nil {
+				return nil, fmt.Errorf("chunk %d: %v", n, err)
+			}
+			return plain, nil
+		}
+	}
+	if err := h.store.Assemble(rec.ID, req.TotalChunks, tempPath, job.ID, open); err != nil {
+		log.Printf("Failed to assemble recording %s: %v", rec.ID, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to assemble recording: %v", err),
+			"code":  "ERR_ASSEMBLY_FAILED",
+		})
+	}
+	// The same chunks would only be too long again, so drop the recording
+	if ok, errResp := checkMaxDuration(c, h.workerPool, tempPath, job); !ok {
+		os.Remove(tempPath)
+		h.store.Delete(rec.ID)
+		return errResp
+	}
+
+	staged.enqueue(job, tempPath)
+	log.Printf("Recording %s assembled from %d chunks as job %s", rec.ID, req.TotalChunks, job.ID)
+	return c.JSON(fiber.Map{
+		"recording_id": rec.ID,
+		"job_id":       job.ID,
+		"status":       "queued",
+	})
+}
+
+// Delete abandons a recording and discards its chunks
+func (h *RecordingHandler) Delete(c *fiber.Ctx) error {
+	if err := h.store.Delete(c.Params("id")); err != nil {
+		if errors.Is(err, storage.ErrRecordingNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Recording not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"recording_id": c.Params("id"), "deleted": true})
+}
+
+// recording loads the :id recording, or writes the error response and
+// returns nil
+func (h *RecordingHandler) recording(c *fiber.Ctx) (*storage.Recording, error) {
+	rec, err := h.store.Get(c.Params("id"))
+	if errors.Is(err, storage.ErrRecordingNotFound) {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "Recording not found"})
+	}
+	if err != nil {
+		return nil, c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return rec, nil
+}
+
+// missingChunks lists the numbers in 1..total not among received (sorted)
+func missingChunks(received []int, total int) []int {
+	missing := []int{}
+	i := 0
+	for n := 1; n <= total; n++ {
+		for i < len(received) && received[i] < n {
+			i++
+		}
+		if i == len(received) || received[i] != n {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
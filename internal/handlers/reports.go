@@ -0,0 +1,66 @@
+package handlers
+
+// Scheduled report handler — previews a configured report's digest or
+// sends it right away, e.g. to check the email settings.
+
+import (
+	"errors"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/reports"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReportHandler serves scheduled reports
+type ReportHandler struct {
+	scheduler *reports.Scheduler // nil when no reports are configured
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(scheduler *reports.Scheduler) *ReportHandler {
+	return &ReportHandler{
+		scheduler: scheduler,
+	}
+}
+
+// Preview handles GET /reports/:name, returning the digest of the
+// report's latest finished period without sending it
+func (h *ReportHandler) Preview(c *fiber.Ctx) error {
+	if h.scheduler == nil {
+		return reportNotFound(c)
+	}
+	digest, err := h.scheduler.Digest(c.Params("name"))
+	if errors.Is(err, reports.ErrUnknownReport) {
+		return reportNotFound(c)
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"report": c.Params("name"), "digest": digest})
+}
+
+// Send handles POST /reports/:name/send, delivering the latest digest now
+// even if it was already sent
+func (h *ReportHandler) Send(c *fiber.Ctx) error {
+	if h.scheduler == nil {
+		return reportNotFound(c)
+	}
+	digest, err := h.scheduler.SendNow(c.Params("name"))
+	if errors.Is(err, reports.ErrUnknownReport) {
+		return reportNotFound(c)
+	}
+	if err != nil {
+		return c.Status(502).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_REPORT_DELIVERY",
+		})
+	}
+	return c.JSON(fiber.Map{"report": c.Params("name"), "sent": true, "digest": digest})
+}
+
+// reportNotFound answers for a report that is not configured
+func reportNotFound(c *fiber.Ctx) error {
+	return c.Status(404).JSON(fiber.Map{
+		"error": "Report not found",
+		"code":  "ERR_REPORT_NOT_FOUND",
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+// Signed result downloads — serves the artifact behind a link issued in a
+// webhook payload. The link's signature is the only credential checked.
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ResultsHandler serves signed artifact downloads
+type ResultsHandler struct {
+	db    *storage.MetadataDB
+	links *webhooks.ResultLinks
+}
+
+// NewResultsHandler creates a new results handler
+func NewResultsHandler(db *storage.MetadataDB, links *webhooks.ResultLinks) *ResultsHandler {
+	return &ResultsHandler{
+		db:    db,
+		links: links,
+	}
+}
+
+// Download sends one artifact of a job:
+// GET /results/:id/:artifact?expires=<unix>&signature=<hex>
+func (h *ResultsHandler) Download(c *fiber.Ctx) error {
+	if h.links == nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Result links are not enabled",
+			"code":  "ERR_LINKS_DISABLED",
+		})
+	}
+
+	jobID, artifact := c.Params("id"), c.Params("artifact")
+	if err := h.links.Verify(jobID, artifact, c.Query("expires"), c.Query("signature")); err != nil {
+		switch {
+		case errors.Is(err, webhooks.ErrLinkExpired):
+			return c.Status(410).JSON(fiber.Map{"error": err.Error(), "code": "ERR_LINK_EXPIRED"})
+		case errors.Is(err, webhooks.ErrLinkInvalid):
+			return c.Status(403).JSON(fiber.Map{"error": err.Error(), "code": "ERR_LINK_INVALID"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	transcript, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+	}
+	localPath, _ := transcript["local_path"].(string)
+	path, ok := storage.ArtifactPath(localPath, artifact)
+	if localPath == "" || !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Artifact not found"})
+	}
+	if _, err := os.Stat(path); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Artifact not found"})
+	}
+
+	return c.Download(path, filepath.Base(path))
+}
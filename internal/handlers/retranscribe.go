@@ -0,0 +1,190 @@
+package handlers
+
+// Range re-transcription — POST /transcripts/:id/segments/retranscribe runs
+// a time range of a transcript's kept audio (see storage.keep_audio)
+// through the transcriber again, optionally with another model size, and
+// splices the new segments in place of the old ones. The result is kept
+// as a new version, like an edit, so it can be diffed against the old.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxRetranscribeSeconds bounds a re-transcribed range; the request waits
+// for it, so it is meant for passages, not whole recordings
+const maxRetranscribeSeconds = 15 * 60
+
+// RetranscribeRequest picks the range of a transcript to transcribe again
+type RetranscribeRequest struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	// Model is a whisper model size to use instead of the configured one
+	Model string `json:"model"`
+}
+
+// Retranscribe transcribes a range of a transcript again and returns the
+// updated transcript record. The range grows to the edges of segments it
+// cuts through, so no segment is replaced in part.
+func (h *EditHandler) Retranscribe(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+	transcript, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+	}
+
+	var req RetranscribeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	if req.Start < 0 || req.End <= req.Start {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "start must be at or after 0 and end after start",
+			"code":  "ERR_INVALID_RANGE",
+		})
+	}
+	if req.End-req.Start > maxRetranscribeSeconds {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Range is longer than %d seconds", maxRetranscribeSeconds),
+			"code":  "ERR_INVALID_RANGE",
+		})
+	}
+	req.Model = strings.ToLower(strings.TrimSpace(req.Model))
+	if err := transcription.CheckModel(req.Model); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_MODEL",
+		})
+	}
+	if req.Model != "" && !h.workerPool.CanSelectModel() {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "this server's transcription backend has no model sizes to pick from; only whisper models do",
+			"code":  "ERR_MODEL_UNSUPPORTED",
+		})
+	}
+	if encrypted, _ := transcript["encrypted"].(bool); encrypted {
+		return c.Status(409).JSON(fiber.Map{
+			"error": "A transcript encrypted with a client key can't be transcribed again",
+			"code":  "ERR_ENCRYPTED",
+		})
+	}
+
+	txtPath, _ := transcript["local_path"].(string)
+	audioPath, ok := storage.AudioPath(txtPath)
+	if !ok {
+		return c.Status(409).JSON(fiber.Map{
+			"error": "No audio was kept for this transcript; enable storage.keep_audio to transcribe ranges again",
+			"code":  "ERR_AUDIO_NOT_KEPT",
+		})
+	}
+	stored, err := h.localStorage.LoadTranscript(txtPath)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if stored.Duration > 0 && req.Start >= stored.Duration {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Range starts after the recording ends at %.1fs", stored.Duration),
+			"code":  "ERR_INVALID_RANGE",
+		})
+	}
+
+	start, end := widenRange(stored.Segments, req.Start, req.End)
+	if stored.Duration > 0 {
+		end = min(end, stored.Duration)
+	}
+	// The language is known by now, and a short slice would detect it worse
+	fresh, model, err := h.workerPool.Retranscribe(audioPath, start, end, transcription.DecodeOptions{
+		Language: stored.Language,
+		Task:     stored.Task,
+		Model:    req.Model,
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": fmt.Sprintf("Re-transcription failed: %v", err),
+			"code":  "ERR_TRANSCRIPTION_FAILED",
+		})
+	}
+	segments := spliceSegments(stored.Segments, start, end, fresh)
+	if len(segments) == 0 {
+		return c.Status(422).JSON(fiber.Map{
+			"error": "No speech was found in the range, and the transcript would be left empty",
+			"code":  "ERR_NO_SPEECH",
+		})
+	}
+
+	edit := storage.TranscriptEdit{Segments: segments}
+	if err := h.rewriteSegments(jobID, transcript, txtPath, segments, storage.VersionRetranscribed, model); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.db.UpdateTranscript(jobID, edit, "", nil); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.reindex(jobID, edit)
+
+	updated, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(updated)
+}
+
+// widenRange extends start and end to the edges of the segments they fall
+// inside
+func widenRange(segments []types.Segment, start, end float64) (float64, float64) {
+	for _, seg := range segments {
+		if seg.Start < start && seg.End > start {
+			start = seg.Start
+		}
+		if seg.Start < end && seg.End > end {
+			end = seg.End
+		}
+	}
+	return start, end
+}
+
+// spliceSegments replaces the segments between start and end with fresh
+// ones. A fresh segment keeps the speaker of the old segment it overlaps
+// most, so diarized transcripts stay labelled.
+func spliceSegments(segments []types.Segment, start, end float64, fresh []types.Segment) []types.Segment {
+	var before, replaced, after []types.Segment
+	for _, seg := range segments {
+		switch {
+		case seg.Start >= start && seg.End <= end:
+			replaced = append(replaced, seg)
+		case seg.Start < start:
+			before = append(before, seg)
+		default:
+			after = append(after, seg)
+		}
+	}
+	spliced := append(before, freshSegments(fresh, replaced)...)
+	return append(spliced, after...)
+}
+
+// freshSegments gives each fresh segment the speaker of the replaced
+// segment it overlaps most, and drops those without text
+func freshSegments(fresh, replaced []types.Segment) []types.Segment {
+	kept := make([]types.Segment, 0, len(fresh))
+	for _, seg := range fresh {
+		seg.Text = strings.TrimSpace(seg.Text)
+		if seg.Text == "" {
+			continue
+		}
+		best := 0.0
+		for _, old := range replaced {
+			if overlap := min(seg.End, old.End) - max(seg.Start, old.Start); old.Speaker != "" && overlap > best {
+				seg.Speaker, best = old.Speaker, overlap
+			}
+		}
+		kept = append(kept, seg)
+	}
+	return kept
+}
@@ -0,0 +1,143 @@
+package handlers
+
+// Speaker renaming — PATCH /transcripts/:id/speakers replaces the generic
+// labels diarization gives speakers ("Speaker 1") with their names once
+// someone has listened in. The segments are rewritten with the new names,
+// re-rendering the txt and subtitle files, and kept as a new version like
+// any other segment edit (see edit.go).
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSpeakerNameLength bounds a speaker's new name, in bytes
+const maxSpeakerNameLength = 64
+
+// SpeakerRename maps speakers, by their label ("speaker_0") or the way
+// they read in the transcript ("Speaker 1"), to new names
+type SpeakerRename struct {
+	Names map[string]string `json:"names"`
+}
+
+// RenameSpeakers renames a transcript's speakers and returns the updated
+// transcript record. Several speakers may be given the same name, which
+// merges speakers diarization split in two.
+func (h *EditHandler) RenameSpeakers(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+	transcript, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+	}
+
+	var req SpeakerRename
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	if len(req.Names) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "nothing to change; map speakers to their names in names",
+			"code":  "ERR_INVALID_EDIT",
+		})
+	}
+	if encrypted, _ := transcript["encrypted"].(bool); encrypted {
+		return c.Status(409).JSON(fiber.Map{
+			"error": "Speakers of a transcript encrypted with a client key can't be renamed",
+			"code":  "ERR_ENCRYPTED",
+		})
+	}
+
+	txtPath, _ := transcript["local_path"].(string)
+	stored, err := h.localStorage.LoadTranscript(txtPath)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	speakers := speakerLabels(stored.Segments)
+	if len(speakers) == 0 {
+		return c.Status(409).JSON(fiber.Map{
+			"error": "Transcript has no speakers; submit it with diarize=true to label them",
+			"code":  "ERR_NO_SPEAKERS",
+		})
+	}
+
+	renames, err := resolveSpeakerNames(req.Names, speakers)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":    err.Error(),
+			"code":     "ERR_INVALID_SPEAKER",
+			"speakers": speakers,
+		})
+	}
+	segments := make([]types.Segment, len(stored.Segments))
+	for i, seg := range stored.Segments {
+		if name, ok := renames[seg.Speaker]; ok {
+			seg.Speaker = name
+		}
+		segments[i] = seg
+	}
+
+	edit := storage.TranscriptEdit{Segments: segments}
+	if err := h.rewriteSegments(jobID, transcript, txtPath, segments, storage.VersionEdited, ""); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.db.UpdateTranscript(jobID, edit, "", nil); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.reindex(jobID, edit)
+
+	updated, err := h.db.GetTranscript(jobID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(updated)
+}
+
+// speakerLabels lists the distinct speaker labels of segments, sorted
+func speakerLabels(segments []types.Segment) []string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, seg := range segments {
+		if seg.Speaker != "" && !seen[seg.Speaker] {
+			seen[seg.Speaker] = true
+			labels = append(labels, seg.Speaker)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// resolveSpeakerNames matches each speaker in names to one of labels, by
+// the label itself or, ignoring case, by how it reads in the transcript,
+// and validates the new name. It returns the new name for each label.
+func resolveSpeakerNames(names map[string]string, labels []string) (map[string]string, error) {
+	renames := make(map[string]string, len(names))
+	for speaker, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || len(name) > maxSpeakerNameLength || strings.ContainsAny(name, "<>\r\n") {
+			return nil, fmt.Errorf("the name for %q must be 1 to %d bytes, on one line, without < or >", speaker, maxSpeakerNameLength)
+		}
+		label := ""
+		for _, l := range labels {
+			if l == speaker || strings.EqualFold(types.SpeakerName(l), strings.TrimSpace(speaker)) {
+				label = l
+				break
+			}
+		}
+		if label == "" {
+			return nil, fmt.Errorf("transcript has no speaker %q", speaker)
+		}
+		if _, dup := renames[label]; dup {
+			return nil, fmt.Errorf("speaker %q is renamed twice", speaker)
+		}
+		renames[label] = name
+	}
+	return renames, nil
+}
@@ -0,0 +1,35 @@
+package handlers
+
+// Job staging — a handler saves and checks a new job's audio before the job
+// is queued. Until then the worker pool knows nothing of the job, so the
+// handler itself holds the file against the cleanup scheduler.
+
+import (
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+)
+
+// stagedJob is a new job whose audio a handler is still writing
+type stagedJob struct {
+	wp      *queue.WorkerPool
+	release func()
+}
+
+// stageJob holds jobID's temp files until the job is queued. Defer abandon,
+// so that the hold also ends when the handler gives the job up.
+func stageJob(wp *queue.WorkerPool, jobID string) *stagedJob {
+	return &stagedJob{wp: wp, release: wp.HoldFiles(jobID)}
+}
+
+// enqueue queues the job with its audio at path; from here on the job
+// holds its files itself
+func (s *stagedJob) enqueue(job *queue.Job, path string) {
+	job.FilePath = path
+	s.wp.EnqueueJob(job)
+	s.release()
+}
+
+// abandon ends the hold of a job that was never queued; after enqueue it
+// does nothing
+func (s *stagedJob) abandon() {
+	s.release()
+}
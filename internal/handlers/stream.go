@@ -2,16 +2,19 @@ package handlers
 
 // WebSocket streaming handler — accepts binary audio chunks and
 // queues them for transcription once the client sends an END signal.
+// Clients that ask for it get the finished transcript on the same
+// connection.
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
-	"github.com/codebuildervaibhav/audio-transcription/internal/queue"
-	"github.com/codebuildervaibhav/audio-transcription/internal/types"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
 	"github.com/gofiber/websocket/v2"
 	"github.com/google/uuid"
 )
@@ -28,6 +31,15 @@ func NewStreamHandler(workerPool *queue.WorkerPool) *StreamHandler {
 	}
 }
 
+// StreamOptions is an optional JSON control message sent before the audio
+type StreamOptions struct {
+	Name string `json:"name"`
+	// PushResult keeps the connection open after END and sends the
+	// finished transcript (or failure) before closing it
+	PushResult bool `json:"push_result"`
+	JobOptions
+}
+
 // Handle processes WebSocket connections
 func (h *StreamHandler) Handle(c *websocket.Conn) {
 	defer c.Close()
@@ -35,7 +47,9 @@ func (h *StreamHandler) Handle(c *websocket.Conn) {
 	var (
 		buffer      bytes.Buffer
 		requestName string
+		pushResult  bool
 		jobID       = uuid.New().String()
+		job         = &queue.Job{ID: jobID, SourceType: types.SourceStream}
 	)
 
 	log.Printf("WebSocket connection established: %s", jobID)
@@ -57,6 +71,26 @@ func (h *StreamHandler) Handle(c *websocket.Conn) {
 				break
 			}
 
+			// JSON options message: {"name": "...", "metadata": {...}}
+			if len(msgStr) > 0 && msgStr[0] == '{' {
+				var opts StreamOptions
+				if err := json.Unmarshal(message, &opts); err != nil {
+					log.Printf("Ignoring malformed stream options: %v", err)
+					continue
+				}
+				if optErr := opts.applyTo(job, h.workerPool); optErr != nil {
+					log.Printf("Rejecting invalid stream options: %v", optErr.err)
+					msg, _ := json.Marshal(map[string]string{"error": optErr.err.Error(), "code": optErr.code})
+					c.WriteMessage(websocket.TextMessage, msg)
+					continue
+				}
+				if opts.Name != "" && len(opts.Name) < 200 {
+					requestName = opts.Name
+				}
+				pushResult = opts.PushResult
+				continue
+			}
+
 			// Set request name
 			if len(msgStr) > 0 && len(msgStr) < 200 {
 				requestName = msgStr
@@ -82,6 +116,16 @@ func (h *StreamHandler) Handle(c *websocket.Conn) {
 		requestName = "stream_recording"
 	}
 
+	if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+		_, body := admissionError(err)
+		msg, _ := json.Marshal(body)
+		c.WriteMessage(websocket.TextMessage, msg)
+		return
+	}
+
+	staged := stageJob(h.workerPool, jobID)
+	defer staged.abandon()
+
 	// Save buffered audio to temp file
 	tempPath := filepath.Join("temp", fmt.Sprintf("%s.webm", jobID))
 
@@ -92,16 +136,49 @@ func (h *StreamHandler) Handle(c *websocket.Conn) {
 
 	log.Printf("Stream saved to %s (%d bytes)", tempPath, buffer.Len())
 
-	// Create and enqueue job
-	job := &queue.Job{
-		ID:          jobID,
-		RequestName: requestName,
-		SourceType:  types.SourceStream,
-		FilePath:    tempPath,
-	}
-
-	h.workerPool.EnqueueJob(job)
+	// Enqueue job
+	job.RequestName = requestName
+	done := job.Done()
+	staged.enqueue(job, tempPath)
 
 	// Send confirmation
 	c.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"job_id":"%s","status":"queued"}`, jobID)))
+
+	if pushResult {
+		h.pushResult(c, job, done)
+	}
+}
+
+// pushResult waits for the job and sends its outcome, unless the client
+// hangs up first
+func (h *StreamHandler) pushResult(c *websocket.Conn, job *queue.Job, done <-chan struct{}) {
+	// Reading is the only way to notice the client leaving
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-gone:
+		log.Printf("Stream client left before job %s finished", job.ID)
+		return
+	}
+
+	_, body := jobOutcome(job.Final())
+	msg, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to encode result of job %s: %v", job.ID, err)
+		return
+	}
+	if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
+		log.Printf("Failed to push result of job %s: %v", job.ID, err)
+		return
+	}
+	c.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
 }
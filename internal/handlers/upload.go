@@ -1,16 +1,20 @@
 package handlers
 
-// File upload handler — validates format and size, saves to temp,
-// and enqueues a transcription job for the worker pool.
+// File upload handler — validates size and format (by content), saves to
+// temp, and enqueues a transcription job for the worker pool.
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
+	"time"
 
-	"github.com/codebuildervaibhav/audio-transcription/internal/queue"
-	"github.com/codebuildervaibhav/audio-transcription/internal/transcription"
-	"github.com/codebuildervaibhav/audio-transcription/internal/types"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
@@ -19,6 +23,10 @@ import (
 type UploadHandler struct {
 	workerPool *queue.WorkerPool
 	maxSizeMB  int
+
+	// syncMaxDuration and syncTimeout bound sync=true uploads (0 = sync disabled)
+	syncMaxDuration time.Duration
+	syncTimeout     time.Duration
 }
 
 // NewUploadHandler creates a new upload handler
@@ -29,6 +37,13 @@ func NewUploadHandler(workerPool *queue.WorkerPool, maxSizeMB int) *UploadHandle
 	}
 }
 
+// SetSyncLimits enables sync=true uploads for clips up to maxDuration long,
+// waiting at most timeout for the transcript before falling back to polling
+func (h *UploadHandler) SetSyncLimits(maxDuration, timeout time.Duration) {
+	h.syncMaxDuration = maxDuration
+	h.syncTimeout = timeout
+}
+
 // Handle processes the upload request
 func (h *UploadHandler) Handle(c *fiber.Ctx) error {
 	// Get uploaded file
@@ -46,6 +61,34 @@ func (h *UploadHandler) Handle(c *fiber.Ctx) error {
 		requestName = "untitled"
 	}
 
+	// Per-job options (metadata, labels, encryption, trim)
+	opts, optErr := parseFormOptions(c)
+	if optErr != nil {
+		return optErr.respond(c)
+	}
+
+	sync := c.FormValue("sync") == "true"
+	if sync && h.syncMaxDuration == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Synchronous uploads are disabled",
+			"code":  "ERR_SYNC_DISABLED",
+		})
+	}
+
+	jobID := uuid.New().String()
+	job := &queue.Job{
+		ID:          jobID,
+		RequestName: requestName,
+		SourceType:  types.SourceUpload,
+	}
+	if optErr := opts.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+
+	if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+		return rejectJob(c, err)
+	}
+
 	// Validate file size
 	maxSize := int64(h.maxSizeMB) * 1024 * 1024
 	if file.Size > maxSize {
@@ -55,19 +98,13 @@ func (h *UploadHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate file format
-	if !transcription.ValidateAudioFormat(file.Filename) {
-		return c.Status(400).JSON(fiber.Map{
-			"error": "Unsupported audio format",
-			"code":  "ERR_INVALID_FORMAT",
-		})
-	}
-
 	// Generate unique filename
-	jobID := uuid.New().String()
 	extension := filepath.Ext(file.Filename)
 	tempPath := filepath.Join("temp", fmt.Sprintf("%s%s", jobID, extension))
 
+	staged := stageJob(h.workerPool, jobID)
+	defer staged.abandon()
+
 	// Save file
 	if err := c.SaveFile(file, tempPath); err != nil {
 		log.Printf("Failed to save uploaded file: %v", err)
@@ -77,15 +114,41 @@ func (h *UploadHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
-	// Create and enqueue job
-	job := &queue.Job{
-		ID:          jobID,
-		RequestName: requestName,
-		SourceType:  types.SourceUpload,
-		FilePath:    tempPath,
+	// Validate file format by content, not extension: a renamed executable
+	// is refused, and real audio under an odd extension is transcoded
+	if ok, errResp := checkAudioContent(c, tempPath, file.Filename); !ok {
+		os.Remove(tempPath)
+		return errResp
+	}
+
+	if ok, errResp := checkMaxDuration(c, h.workerPool, tempPath, job); !ok {
+		os.Remove(tempPath)
+		return errResp
+	}
+
+	// Only short clips may hold the request open
+	if sync {
+		if ok, errResp := h.checkSyncDuration(c, tempPath, job); !ok {
+			os.Remove(tempPath)
+			return errResp
+		}
 	}
 
-	h.workerPool.EnqueueJob(job)
+	// Reuse an identical upload that is already in flight
+	if sum, err := storage.FileSHA256(tempPath); err == nil {
+		if existingID, duplicate := claimSource(h.workerPool, "upload:"+sum, job, opts.Force); duplicate {
+			os.Remove(tempPath)
+			return respondDuplicate(c, existingID)
+		}
+	}
+
+	// Enqueue job
+	done := job.Done()
+	staged.enqueue(job, tempPath)
+
+	if sync {
+		return h.awaitResult(c, job, done)
+	}
 
 	// Return job ID immediately
 	return c.JSON(fiber.Map{
@@ -94,3 +157,110 @@ func (h *UploadHandler) Handle(c *fiber.Ctx) error {
 		"message": "File uploaded successfully, processing started",
 	})
 }
+
+// checkAudioContent rejects a saved file whose content is not audio; it
+// returns true when the file may proceed, and otherwise false with the
+// response written
+func checkAudioContent(c *fiber.Ctx, path, filename string) (bool, error) {
+	info, err := transcription.DetectAudioFormat(path)
+	var notAudio *transcription.NotAudioError
+	if errors.As(err, &notAudio) {
+		log.Printf("Rejecting %s: %v", filename, err)
+		return false, c.Status(422).JSON(fiber.Map{
+			"error": fmt.Sprintf("Unsupported audio format (%v)", err),
+			"code":  "ERR_NOT_AUDIO",
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to inspect %s: %v", filename, err)
+		return false, c.Status(500).JSON(fiber.Map{
+			"error": "Failed to inspect file",
+			"code":  "ERR_INSPECT_FAILED",
+		})
+	}
+	if !transcription.ValidateAudioFormat(filename) {
+		log.Printf("Accepting %s by content via transcoding fallback (%s, codec %s)", filename, info.FormatName, info.Codec)
+	}
+	return true, nil
+}
+
+// checkSyncDuration rejects sync uploads whose (trimmed) audio is longer
+// than the configured maximum; it returns true when the clip may proceed,
+// and otherwise false with the response written
+func (h *UploadHandler) checkSyncDuration(c *fiber.Ctx, path string, job *queue.Job) (bool, error) {
+	info, err := transcription.ProbeAudio(path)
+	if err != nil {
+		log.Printf("Cannot determine duration of sync upload: %v", err)
+		return false, c.Status(400).JSON(fiber.Map{
+			"error": "Could not determine audio duration; submit without sync=true",
+			"code":  "ERR_SYNC_NOT_ALLOWED",
+		})
+	}
+
+	duration := info.Duration
+	if job.EndTime > 0 && job.EndTime < duration {
+		duration = job.EndTime
+	}
+	duration -= job.StartTime
+
+	if time.Duration(duration*float64(time.Second)) > h.syncMaxDuration {
+		return false, c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Audio too long for sync processing (max %s); submit without sync=true", h.syncMaxDuration),
+			"code":  "ERR_SYNC_TOO_LONG",
+		})
+	}
+	return true, nil
+}
+
+// awaitResult waits for a sync job and returns its transcript, or its job
+// ID for polling if it does not finish within the timeout
+func (h *UploadHandler) awaitResult(c *fiber.Ctx, job *queue.Job, done <-chan struct{}) error {
+	select {
+	case <-done:
+		job = job.Final()
+	case <-time.After(h.syncTimeout):
+		return c.Status(202).JSON(fiber.Map{
+			"job_id":  job.ID,
+			"status":  "processing",
+			"message": "Transcription did not finish in time; poll /jobs/" + job.ID,
+		})
+	}
+
+	status, body := jobOutcome(job)
+	return c.Status(status).JSON(body)
+}
+
+// jobOutcome is the response for a finished job: its transcript, or why
+// it has none
+func jobOutcome(job *queue.Job) (int, fiber.Map) {
+	if job.Status != types.StatusCompleted || job.Result == nil {
+		errMsg := "Transcription " + job.Status
+		if job.Error != nil {
+			errMsg = job.Error.Error()
+		}
+		return 500, fiber.Map{
+			"job_id": job.ID,
+			"status": job.Status,
+			"error":  errMsg,
+			"code":   "ERR_TRANSCRIPTION_FAILED",
+		}
+	}
+
+	result := job.Result
+	response := fiber.Map{
+		"job_id":           job.ID,
+		"status":           job.Status,
+		"text":             result.Text,
+		"language":         result.Language,
+		"duration_seconds": result.Duration,
+		"word_count":       result.WordCount,
+		"segments":         result.Segments,
+	}
+	if result.LanguageConfidence > 0 {
+		response["language_confidence"] = result.LanguageConfidence
+	}
+	if result.Translated() {
+		response["task"] = result.Task
+	}
+	return 200, response
+}
@@ -0,0 +1,377 @@
+package handlers
+
+// Upload sessions — for CLI tools sending files too large for one request.
+// The client starts a session, appends the file in parts at the offset the
+// server last acknowledged (each with its SHA-256), and commits; the file
+// then goes through the same checks and queue as a form upload. After a
+// dropped connection the client asks for the offset and carries on from
+// there, so a multi-gigabyte upload never starts over. Plain curl is
+// enough; no tus client is needed.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// UploadSessionHandler serves /uploads
+type UploadSessionHandler struct {
+	workerPool *queue.WorkerPool
+	store      *storage.UploadStore
+	maxSizeMB  int
+	maxPartMB  int
+}
+
+// NewUploadSessionHandler creates a new upload session handler; a file
+// may be up to maxSizeMB, sent in parts of up to maxPartMB
+func NewUploadSessionHandler(workerPool *queue.WorkerPool, store *storage.UploadStore, maxSizeMB, maxPartMB int) *UploadSessionHandler {
+	return &UploadSessionHandler{
+		workerPool: workerPool,
+		store:      store,
+		maxSizeMB:  maxSizeMB,
+		maxPartMB:  maxPartMB,
+	}
+}
+
+// UploadSessionRequest starts an upload session
+type UploadSessionRequest struct {
+	Name string `json:"name"`
+	// Filename is the file's name on the client; its extension is kept
+	Filename string `json:"filename"`
+	// Size is the file's size in bytes, if known; commit then waits for
+	// all of it
+	Size int64 `json:"size"`
+	JobOptions
+}
+
+// Create starts an upload session. The job options are checked now, so a
+// client learns of a mistake before sending gigabytes, and applied on
+// commit.
+func (h *UploadSessionHandler) Create(c *fiber.Ctx) error {
+	var req UploadSessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	if req.Size < 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "size must not be negative",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	if req.Size > h.maxSize() {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("File too large (max %dMB)", h.maxSizeMB),
+			"code":  "ERR_FILE_TOO_LARGE",
+		})
+	}
+
+	job := &queue.Job{ID: uuid.New().String(), SourceType: types.SourceUpload}
+	if optErr := req.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+	if req.Name == "" {
+		req.Name = "untitled"
+	}
+	req.Filename = filepath.Base(req.Filename)
+	if req.Filename == "." || req.Filename == string(filepath.Separator) {
+		req.Filename = ""
+	}
+
+	// The key stays with the client until commit
+	up := &storage.UploadSession{ID: uuid.New().String(), Name: req.Name, Filename: req.Filename, Size: req.Size}
+	if job.EncryptionKey != nil {
+		up.KeyFingerprint = storage.KeyFingerprint(job.EncryptionKey)
+		req.EncryptionKey = ""
+	}
+	options, err := json.Marshal(req.JobOptions)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	up.Options = options
+	if err := h.store.Create(up); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	log.Printf("Upload session %s started (name: %s, size: %d bytes)", up.ID, up.Name, up.Size)
+	return c.Status(201).JSON(fiber.Map{
+		"upload_id":      up.ID,
+		"offset":         0,
+		"max_part_bytes": int64(h.maxPartMB) * 1024 * 1024,
+		"max_size_bytes": h.maxSize(),
+	})
+}
+
+// AppendPart adds the request body to the file at ?offset=, which must be
+// the session's current offset. A part sent again after it landed is
+// refused with the current offset, so a client unsure whether an upload
+// landed can just repeat it and follow the answer.
+func (h *UploadSessionHandler) AppendPart(c *fiber.Ctx) error {
+	up, errResp := h.session(c)
+	if up == nil {
+		return errResp
+	}
+	if up.JobID != "" {
+		return c.Status(409).JSON(fiber.Map{
+			"error":  "Upload was already committed",
+			"code":   "ERR_UPLOAD_COMMITTED",
+			"job_id": up.JobID,
+		})
+	}
+	offset, err := strconv.ParseInt(c.Query("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "offset must be the byte offset the part starts at",
+			"code":  "ERR_INVALID_OFFSET",
+		})
+	}
+
+	part := c.Body()
+	if len(part) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Part is empty",
+			"code":  "ERR_INVALID_PART",
+		})
+	}
+	want := c.Get(ChunkSHA256Header)
+	if want == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Send the part's hex SHA-256 in " + ChunkSHA256Header,
+			"code":  "ERR_CHECKSUM_REQUIRED",
+		})
+	}
+	sum := sha256.Sum256(part)
+	if !strings.EqualFold(want, hex.EncodeToString(sum[:])) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Part does not match its " + ChunkSHA256Header,
+			"code":  "ERR_PART_CORRUPT",
+		})
+	}
+
+	end := offset + int64(len(part))
+	if up.Size > 0 && end > up.Size {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("Part runs past the declared size of %d bytes", up.Size),
+			"code":  "ERR_FILE_TOO_LARGE",
+		})
+	}
+	if end > h.maxSize() {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("File too large (max %dMB)", h.maxSizeMB),
+			"code":  "ERR_FILE_TOO_LARGE",
+		})
+	}
+
+	up, err = h.store.Append(up.ID, offset, part)
+	var mismatch *storage.UploadOffsetError
+	switch {
+	case errors.As(err, &mismatch):
+		return c.Status(409).JSON(fiber.Map{
+			"error":  fmt.Sprintf("Part starts at %d but the upload is at %d", offset, mismatch.Offset),
+			"code":   "ERR_OFFSET_MISMATCH",
+			"offset": mismatch.Offset,
+		})
+	case errors.Is(err, storage.ErrUploadClosed):
+		return c.Status(409).JSON(fiber.Map{
+			"error": "Upload was already committed",
+			"code":  "ERR_UPLOAD_COMMITTED",
+		})
+	case err != nil:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"upload_id": up.ID, "offset": up.Offset, "parts": up.Parts})
+}
+
+// Status reports how much of the file has arrived, so a client coming
+// back knows where to carry on
+func (h *UploadSessionHandler) Status(c *fiber.Ctx) error {
+	up, errResp := h.session(c)
+	if up == nil {
+		return errResp
+	}
+	response := fiber.Map{
+		"upload_id":  up.ID,
+		"name":       up.Name,
+		"filename":   up.Filename,
+		"offset":     up.Offset,
+		"parts":      up.Parts,
+		"encrypted":  up.KeyFingerprint != "",
+		"created_at": up.CreatedAt,
+		"updated_at": up.UpdatedAt,
+	}
+	if up.Size > 0 {
+		response["size"] = up.Size
+	}
+	if up.JobID != "" {
+		response["job_id"] = up.JobID
+	}
+	return c.JSON(response)
+}
+
+// Commit ends the upload and queues the file like a form upload: its
+// content must be audio, its duration within the limit, and an identical
+// file already in flight is reused. An optional "sha256" of the whole file
+// is checked first. Committing again returns the same job, for clients
+// that lost the first response.
+func (h *UploadSessionHandler) Commit(c *fiber.Ctx) error {
+	up, errResp := h.session(c)
+	if up == nil {
+		return errResp
+	}
+	if up.JobID != "" {
+		return c.JSON(fiber.Map{"upload_id": up.ID, "job_id": up.JobID, "status": "queued"})
+	}
+
+	var req struct {
+		SHA256 string `json:"sha256"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Invalid request body",
+				"code":  "ERR_INVALID_BODY",
+			})
+		}
+	}
+	if up.Offset == 0 || up.Size > 0 && up.Offset != up.Size {
+		msg := "No parts have arrived"
+		if up.Offset > 0 {
+			msg = fmt.Sprintf("Only %d of %d bytes have arrived", up.Offset, up.Size)
+		}
+		return c.Status(409).JSON(fiber.Map{
+			"error":  msg,
+			"code":   "ERR_UPLOAD_INCOMPLETE",
+			"offset": up.Offset,
+		})
+	}
+
+	// Rebuild the job from the options the session was started with
+	var opts JobOptions
+	if err := json.Unmarshal(up.Options, &opts); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": fmt.Sprintf("corrupt upload session options: %v", err)})
+	}
+	if up.KeyFingerprint != "" {
+		if key, errResp := requireKey(c, up.KeyFingerprint, "upload"); key == nil {
+			return errResp
+		}
+		opts.EncryptionKey = c.Get(EncryptionKeyHeader)
+	}
+	job := &queue.Job{
+		ID:          uuid.New().String(),
+		RequestName: up.Name,
+		SourceType:  types.SourceUpload,
+	}
+	if optErr := opts.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+	if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+		return rejectJob(c, err)
+	}
+
+	staged := stageJob(h.workerPool, job.ID)
+	defer staged.abandon()
+
+	tempPath := filepath.Join("temp", job.ID+filepath.Ext(up.Filename))
+	if err := h.store.Commit(up.ID, tempPath, job.ID); errors.Is(err, storage.ErrUploadClosed) {
+		// A concurrent commit got there first; answer as a repeated one
+		if up, err := h.store.Get(up.ID); err == nil && up.JobID != "" {
+			return c.JSON(fiber.Map{"upload_id": up.ID, "job_id": up.JobID, "status": "queued"})
+		}
+		return c.Status(409).JSON(fiber.Map{
+			"error": "Upload was already committed",
+			"code":  "ERR_UPLOAD_COMMITTED",
+		})
+	} else if err != nil {
+		log.Printf("Failed to commit upload session %s: %v", up.ID, err)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "Failed to save file",
+			"code":  "ERR_SAVE_FAILED",
+		})
+	}
+	// Whatever is wrong with the file would be wrong again, so drop the
+	// session along with it
+	discard := func() {
+		os.Remove(tempPath)
+		h.store.Delete(up.ID)
+	}
+
+	sum, err := storage.FileSHA256(tempPath)
+	if err != nil {
+		discard()
+		return c.Status(500).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to checksum file: %v", err),
+			"code":  "ERR_SAVE_FAILED",
+		})
+	}
+	if req.SHA256 != "" && !strings.EqualFold(req.SHA256, sum) {
+		discard()
+		return c.Status(400).JSON(fiber.Map{
+			"error": "File does not match its sha256; start a new upload",
+			"code":  "ERR_CHECKSUM_MISMATCH",
+		})
+	}
+	if ok, errResp := checkAudioContent(c, tempPath, up.Filename); !ok {
+		discard()
+		return errResp
+	}
+	if ok, errResp := checkMaxDuration(c, h.workerPool, tempPath, job); !ok {
+		discard()
+		return errResp
+	}
+	if existingID, duplicate := claimSource(h.workerPool, "upload:"+sum, job, opts.Force); duplicate {
+		discard()
+		return respondDuplicate(c, existingID)
+	}
+
+	staged.enqueue(job, tempPath)
+	log.Printf("Upload session %s committed (%d bytes in %d parts) as job %s", up.ID, up.Offset, up.Parts, job.ID)
+	return c.JSON(fiber.Map{
+		"upload_id": up.ID,
+		"job_id":    job.ID,
+		"status":    "queued",
+	})
+}
+
+// Delete abandons an upload session and discards what has arrived
+func (h *UploadSessionHandler) Delete(c *fiber.Ctx) error {
+	if err := h.store.Delete(c.Params("id")); err != nil {
+		if errors.Is(err, storage.ErrUploadNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Upload session not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"upload_id": c.Params("id"), "deleted": true})
+}
+
+// maxSize is the largest file a session may upload, in bytes
+func (h *UploadSessionHandler) maxSize() int64 {
+	return int64(h.maxSizeMB) * 1024 * 1024
+}
+
+// session loads the :id upload session, or writes the error response and
+// returns nil
+func (h *UploadSessionHandler) session(c *fiber.Ctx) (*storage.UploadSession, error) {
+	up, err := h.store.Get(c.Params("id"))
+	if errors.Is(err, storage.ErrUploadNotFound) {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "Upload session not found"})
+	}
+	if err != nil {
+		return nil, c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return up, nil
+}
@@ -0,0 +1,176 @@
+package handlers
+
+// Usage report handler — exports cost-accounting data as JSON or CSV
+// for finance, grouped by source, day, month, tenant, or any label.
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// UsageHandler serves usage reports
+type UsageHandler struct {
+	db *storage.MetadataDB
+}
+
+// NewUsageHandler creates a new usage report handler
+func NewUsageHandler(db *storage.MetadataDB) *UsageHandler {
+	return &UsageHandler{
+		db: db,
+	}
+}
+
+// Report handles GET /usage/report?from=&to=&group_by=&format=
+func (h *UsageHandler) Report(c *fiber.Ctx) error {
+	from, to, err := reportRange(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_RANGE",
+		})
+	}
+
+	// "tenant" is shorthand for grouping by the tenant label
+	groupBy := c.Query("group_by", "day")
+	if groupBy == "tenant" {
+		groupBy = "label.tenant"
+	}
+
+	report, err := h.db.UsageReport(from, to, groupBy, TranscriptFilterFromQuery(c))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_REPORT",
+		})
+	}
+
+	if c.Query("format", "json") == "csv" {
+		body, err := usageCSV(report)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Failed to render CSV",
+				"code":  "ERR_REPORT_FAILED",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "text/csv")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="usage_%s_%s.csv"`,
+			from.Format("20060102"), to.Format("20060102")))
+		return c.Send(body)
+	}
+
+	return c.JSON(fiber.Map{
+		"from":     from,
+		"to":       to,
+		"group_by": groupBy,
+		"rows":     report,
+	})
+}
+
+// Languages handles GET /usage/languages?from=&to=, reporting the
+// languages transcribed per tenant and the backend's confidence in them
+func (h *UsageHandler) Languages(c *fiber.Ctx) error {
+	from, to, err := reportRange(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_RANGE",
+		})
+	}
+
+	stats, err := h.db.LanguageStats(from, to, TranscriptFilterFromQuery(c))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_REPORT_FAILED",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"from": from,
+		"to":   to,
+		"rows": stats,
+	})
+}
+
+// reportRange reads the from and to query parameters; to defaults to now
+// and from to 30 days before it
+func reportRange(c *fiber.Ctx) (from, to time.Time, err error) {
+	to = time.Now()
+	if raw := c.Query("to"); raw != "" {
+		if to, err = parseReportTime(raw); err != nil {
+			return from, to, fmt.Errorf("Invalid 'to' time (use YYYY-MM-DD or RFC3339)")
+		}
+	}
+
+	from = to.AddDate(0, 0, -30)
+	if raw := c.Query("from"); raw != "" {
+		if from, err = parseReportTime(raw); err != nil {
+			return from, to, fmt.Errorf("Invalid 'from' time (use YYYY-MM-DD or RFC3339)")
+		}
+	}
+
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("'from' must be before 'to'")
+	}
+	return from, to, nil
+}
+
+// parseReportTime accepts either a date or a full RFC3339 timestamp
+func parseReportTime(raw string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+// usageCSV renders a usage report as CSV with a header row
+func usageCSV(report []storage.UsageRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	w.Write([]string{"group", "transcripts", "duration_seconds", "audio_minutes", "compute_seconds", "cloud_cost_usd"})
+	for _, row := range report {
+		w.Write([]string{
+			row.Group,
+			strconv.Itoa(row.Transcripts),
+			formatFloat(row.DurationSeconds),
+			formatFloat(row.AudioMinutes),
+			formatFloat(row.ComputeSeconds),
+			formatFloat(row.CloudCostUSD),
+		})
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// TranscriptFilterFromQuery reads metadata.<key>=value and label.<key>=value
+// query parameters into a storage filter
+func TranscriptFilterFromQuery(c *fiber.Ctx) storage.TranscriptFilter {
+	filter := storage.TranscriptFilter{
+		Metadata: map[string]string{},
+		Labels:   map[string]string{},
+	}
+
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		k := string(key)
+		if name, ok := strings.CutPrefix(k, "metadata."); ok && name != "" && !strings.Contains(name, `"`) {
+			filter.Metadata[name] = string(value)
+		} else if name, ok := strings.CutPrefix(k, "label."); ok && name != "" {
+			filter.Labels[name] = string(value)
+		}
+	})
+
+	return filter
+}
@@ -0,0 +1,119 @@
+package handlers
+
+// Transcript versions — lists the versions kept of a transcript (as it
+// was transcribed, then after each segment edit) and diffs any two of
+// them word by word, with timestamps, for reviewing corrections.
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionHandler serves transcript versions and diffs
+type VersionHandler struct {
+	db *storage.MetadataDB
+}
+
+// NewVersionHandler creates a new version handler
+func NewVersionHandler(db *storage.MetadataDB) *VersionHandler {
+	return &VersionHandler{db: db}
+}
+
+// List returns a transcript's versions, oldest first
+func (h *VersionHandler) List(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+	if _, err := h.db.GetTranscript(jobID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+	}
+	versions, err := h.db.TranscriptVersions(jobID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"job_id": jobID, "versions": versions, "count": len(versions)})
+}
+
+// Diff compares ?against=versionN with ?version=M (default: the latest)
+// word by word; ?ignore_punctuation=true ignores case and punctuation
+func (h *VersionHandler) Diff(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+	if _, err := h.db.GetTranscript(jobID); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Transcript not found"})
+	}
+
+	against, err := parseVersion(c.Query("against"))
+	if err != nil || against == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "against must name a version, like version1 or 1",
+			"code":  "ERR_INVALID_VERSION",
+		})
+	}
+	version, err := parseVersion(c.Query("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "version must name a version, like version2 or 2",
+			"code":  "ERR_INVALID_VERSION",
+		})
+	}
+	var opts transcription.DiffOptions
+	if raw := c.Query("ignore_punctuation"); raw != "" {
+		if opts.IgnorePunctuation, err = strconv.ParseBool(raw); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "ignore_punctuation must be true or false",
+				"code":  "ERR_INVALID_DIFF",
+			})
+		}
+	}
+
+	from, err := h.db.GetTranscriptVersion(jobID, against)
+	if err != nil {
+		return versionError(c, err)
+	}
+	to, err := h.db.GetTranscriptVersion(jobID, version)
+	if err != nil {
+		return versionError(c, err)
+	}
+
+	diff := transcription.DiffSegments(from.Segments, to.Segments, opts)
+	from.Segments, to.Segments = nil, nil
+	return c.JSON(fiber.Map{
+		"job_id":  jobID,
+		"from":    from,
+		"to":      to,
+		"summary": diff.Summary,
+		"changes": diff.Changes,
+	})
+}
+
+// versionError responds to a failed version lookup
+func versionError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, storage.ErrVersionNotFound) {
+		return c.Status(404).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_VERSION_NOT_FOUND",
+		})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
+
+// parseVersion reads "versionN", "vN", or "N"; "" is 0, the latest
+func parseVersion(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	raw = strings.ToLower(raw)
+	if trimmed, ok := strings.CutPrefix(raw, "version"); ok {
+		raw = trimmed
+	} else {
+		raw = strings.TrimPrefix(raw, "v")
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, errors.New("invalid version")
+	}
+	return n, nil
+}
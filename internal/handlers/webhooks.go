@@ -0,0 +1,132 @@
+package handlers
+
+// Webhook delivery log — lists outbox entries and lets operators push
+// failed or dead deliveries back onto the queue. Test deliveries send a
+// sample event to any receiver so integrators can check it first.
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookHandler serves the webhook delivery log
+type WebhookHandler struct {
+	db         *storage.MetadataDB
+	dispatcher *webhooks.Dispatcher
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(db *storage.MetadataDB, dispatcher *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{
+		db:         db,
+		dispatcher: dispatcher,
+	}
+}
+
+// ListDeliveries returns recent deliveries, optionally ?status=pending|retrying|delivered|dead
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	status := c.Query("status")
+	switch status {
+	case "", storage.DeliveryPending, storage.DeliveryRetrying, storage.DeliveryDelivered, storage.DeliveryDead:
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"error": "status must be pending, retrying, delivered, or dead",
+			"code":  "ERR_INVALID_STATUS",
+		})
+	}
+
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "limit must be between 1 and 500",
+				"code":  "ERR_INVALID_LIMIT",
+			})
+		}
+		limit = n
+	}
+
+	deliveries, err := h.db.ListDeliveries(status, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(deliveries)
+}
+
+// Redeliver schedules a delivery to be sent again with a fresh attempt budget
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	if h.dispatcher == nil {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "No webhook endpoints configured",
+			"code":  "ERR_WEBHOOKS_DISABLED",
+		})
+	}
+
+	id := c.Params("id")
+	if err := h.dispatcher.Redeliver(id); err != nil {
+		if errors.Is(err, storage.ErrDeliveryNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Delivery not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"id": id, "status": storage.DeliveryPending})
+}
+
+// WebhookTestRequest is the body of POST /webhooks/test
+type WebhookTestRequest struct {
+	URL string `json:"url"`
+	// Event is the sample event to send (default job.completed)
+	Event string `json:"event"`
+	// Secret signs the sample instead of a configured endpoint's secrets
+	Secret string `json:"secret"`
+}
+
+// Test sends a signed sample event to a receiver and reports its reply.
+// A URL of a configured endpoint is sent with that endpoint's template and
+// secrets, unless a secret is given.
+func (h *WebhookHandler) Test(c *fiber.Ctx) error {
+	var req WebhookTestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Invalid request body",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	if err := webhooks.CheckTestURL(req.URL); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+			"code":  "ERR_INVALID_URL",
+		})
+	}
+	if req.Event == "" {
+		req.Event = webhooks.EventJobCompleted
+	}
+	if !slices.Contains(webhooks.TestEvents, req.Event) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("event must be one of %s", strings.Join(webhooks.TestEvents, ", ")),
+			"code":  "ERR_INVALID_EVENT",
+		})
+	}
+
+	var (
+		result *webhooks.TestResult
+		err    error
+	)
+	if h.dispatcher != nil {
+		result, err = h.dispatcher.Test(req.URL, req.Event, req.Secret)
+	} else {
+		result, err = webhooks.SendTest(webhooks.Endpoint{URL: req.URL}, req.Event, req.Secret, webhooks.Options{})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
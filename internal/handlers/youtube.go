@@ -4,39 +4,59 @@ package handlers
 // using yt-dlp with headless Chrome fallback for URL resolution.
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log"
-	"os/exec"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
-	"github.com/codebuildervaibhav/audio-transcription/internal/queue"
-	"github.com/codebuildervaibhav/audio-transcription/internal/types"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 // YouTubeHandler handles YouTube video audio capture
 type YouTubeHandler struct {
-	workerPool *queue.WorkerPool
+	workerPool    *queue.WorkerPool
+	timeout       time.Duration
+	maxDownloadMB int
 }
 
-// NewYouTubeHandler creates a new YouTube handler
-func NewYouTubeHandler(workerPool *queue.WorkerPool) *YouTubeHandler {
+// NewYouTubeHandler creates a new YouTube handler. Captures are aborted
+// after timeout (default 30 minutes) or once the download exceeds
+// maxDownloadMB (0 = no cap).
+func NewYouTubeHandler(workerPool *queue.WorkerPool, timeout time.Duration, maxDownloadMB int) *YouTubeHandler {
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
 	return &YouTubeHandler{
-		workerPool: workerPool,
+		workerPool:    workerPool,
+		timeout:       timeout,
+		maxDownloadMB: maxDownloadMB,
 	}
 }
 
 // YouTubeRequest represents the request body
 type YouTubeRequest struct {
-	URL  string `json:"url"`
-	Name string `json:"name"`
+	URL string `json:"url"`
+	// URLs submits a batch of videos as one job group instead of URL
+	URLs []string `json:"urls"`
+	Name string   `json:"name"`
+	JobOptions
 }
 
+// maxBatchURLs caps a batch submission, which must fit the job queue
+const maxBatchURLs = 100
+
 // Handle processes YouTube video requests
 func (h *YouTubeHandler) Handle(c *fiber.Ctx) error {
 	var req YouTubeRequest
@@ -47,6 +67,15 @@ func (h *YouTubeHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.URL != "" && len(req.URLs) > 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "Give either url or urls, not both",
+			"code":  "ERR_INVALID_BODY",
+		})
+	}
+	if len(req.URLs) > 0 {
+		return h.handleBatch(c, req)
+	}
 	if req.URL == "" {
 		return c.Status(400).JSON(fiber.Map{
 			"error": "URL is required",
@@ -54,47 +83,173 @@ func (h *YouTubeHandler) Handle(c *fiber.Ctx) error {
 		})
 	}
 
+	job := &queue.Job{
+		ID:         uuid.New().String(),
+		SourceType: types.SourceYouTube,
+	}
+	if optErr := req.applyTo(job, h.workerPool); optErr != nil {
+		return optErr.respond(c)
+	}
+
+	if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+		return rejectJob(c, err)
+	}
+
 	if req.Name == "" {
 		req.Name = "youtube_video"
 	}
 
-	// Generate job ID
-	jobID := uuid.New().String()
+	// Reuse an identical capture that is already in flight
+	if existingID, duplicate := claimSource(h.workerPool, youtubeSourceKey(req.URL), job, req.Force); duplicate {
+		return respondDuplicate(c, existingID)
+	}
+
+	job.RequestName = req.Name
+	h.startCapture(job, req.URL)
+
+	response := fiber.Map{
+		"job_id":  job.ID,
+		"status":  types.StatusDownloading,
+		"message": "YouTube audio capture started (this may take a few minutes for long videos)",
+	}
+	// Tell the client how long the capture may take before it is failed
+	if !job.ExpiresAt.IsZero() {
+		response["expires_at"] = job.ExpiresAt
+		response["capture_ttl_seconds"] = int(job.ExpiresAt.Sub(job.CreatedAt).Seconds())
+	}
+	return c.JSON(response)
+}
+
+// handleBatch starts a capture per URL, grouped so the batch can be
+// followed through GET /groups/:id and announced by one group.completed
+// webhook. Videos already being captured are answered with their live
+// job instead, as single submissions are, and left out of the group.
+func (h *YouTubeHandler) handleBatch(c *fiber.Ctx, req YouTubeRequest) error {
+	if len(req.URLs) > maxBatchURLs {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("A batch may hold at most %d URLs", maxBatchURLs),
+			"code":  "ERR_TOO_MANY_URLS",
+		})
+	}
+	if req.Name == "" {
+		req.Name = "youtube_batch"
+	}
+
+	var (
+		jobs       []*queue.Job
+		urls       []string
+		duplicates = fiber.Map{}
+	)
+	for _, url := range req.URLs {
+		if strings.TrimSpace(url) == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "URLs must not be empty",
+				"code":  "ERR_NO_URL",
+			})
+		}
+	}
+	for i, url := range req.URLs {
+		job := &queue.Job{
+			ID:         uuid.New().String(),
+			SourceType: types.SourceYouTube,
+		}
+		if optErr := req.applyTo(job, h.workerPool); optErr != nil {
+			releaseSources(h.workerPool, jobs)
+			return optErr.respond(c)
+		}
+		if i == 0 {
+			if err := h.workerPool.CheckAdmission(job.Labels); err != nil {
+				return rejectJob(c, err)
+			}
+		}
+		if existingID, duplicate := claimSource(h.workerPool, youtubeSourceKey(url), job, req.Force); duplicate {
+			duplicates[url] = existingID
+			continue
+		}
+		job.RequestName = fmt.Sprintf("%s_%d", req.Name, i+1)
+		jobs = append(jobs, job)
+		urls = append(urls, url)
+	}
+	if len(jobs) == 0 {
+		return c.JSON(fiber.Map{
+			"duplicates": duplicates,
+			"message":    "Every video is already being captured; submit with force=true to start new jobs",
+		})
+	}
+
+	groupID, err := h.workerPool.NewGroup(req.Name, types.SourceYouTube, jobs)
+	if err != nil {
+		releaseSources(h.workerPool, jobs)
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	jobIDs := make([]string, len(jobs))
+	for i, job := range jobs {
+		h.startCapture(job, urls[i])
+		jobIDs[i] = job.ID
+	}
+
+	response := fiber.Map{
+		"group_id": groupID,
+		"job_ids":  jobIDs,
+		"status":   types.StatusDownloading,
+		"message":  fmt.Sprintf("YouTube audio capture started for %d videos", len(jobs)),
+	}
+	if len(duplicates) > 0 {
+		response["duplicates"] = duplicates
+	}
+	return c.JSON(response)
+}
+
+// releaseSources gives up the duplicate-detection claims of jobs that
+// will not be submitted after all
+func releaseSources(wp *queue.WorkerPool, jobs []*queue.Job) {
+	for _, job := range jobs {
+		wp.ReleaseSource(job)
+	}
+}
+
+// startCapture records the job and downloads its audio in the background
+// (this can take time for long videos), queueing it once the download is
+// done; POST /jobs/:id/cancel aborts it through the registered cancel func
+func (h *YouTubeHandler) startCapture(job *queue.Job, url string) {
+	jobID := job.ID
 	tempPath := filepath.Join("temp", fmt.Sprintf("%s.opus", jobID))
 
-	// Capture audio in background (this can take time for long videos)
+	// Record the job before the download starts so its ID is pollable
+	h.workerPool.TrackJob(job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	unregister := h.workerPool.RegisterCancel(jobID, cancel)
+
 	go func() {
-		if err := h.captureYouTubeAudio(req.URL, tempPath); err != nil {
-			log.Printf("Failed to capture YouTube audio: %v", err)
-			return
-		}
+		defer cancel()
+		defer unregister()
 
-		// Create and enqueue job after capture completes
-		job := &queue.Job{
-			ID:          jobID,
-			RequestName: req.Name,
-			SourceType:  types.SourceYouTube,
-			FilePath:    tempPath,
+		err := h.captureYouTubeAudio(ctx, job, url, tempPath)
+		if err != nil {
+			log.Printf("Failed to capture YouTube audio for job %s: %v", jobID, err)
+			os.Remove(tempPath)
+			switch ctx.Err() {
+			case context.Canceled:
+				h.workerPool.MarkCancelled(job)
+			case context.DeadlineExceeded:
+				h.workerPool.FailJob(job, fmt.Errorf("YouTube capture timed out after %s", h.timeout))
+			default:
+				h.workerPool.FailJob(job, fmt.Errorf("YouTube capture failed: %v", err))
+			}
+			return
 		}
 
+		// Enqueue job after capture completes
+		job.FilePath = tempPath
 		h.workerPool.EnqueueJob(job)
 	}()
-
-	return c.JSON(fiber.Map{
-		"job_id":  jobID,
-		"status":  "capturing",
-		"message": "YouTube audio capture started (this may take a few minutes for long videos)",
-	})
 }
 
 // captureYouTubeAudio uses headless Chrome to capture YouTube audio
-func (h *YouTubeHandler) captureYouTubeAudio(url, outputPath string) error {
-	// Create Chrome context
-	ctx, cancel := chromedp.NewContext(context.Background())
-	defer cancel()
-
-	// Set timeout for capture (max 30 minutes)
-	ctx, cancel = context.WithTimeout(ctx, 30*time.Minute)
+func (h *YouTubeHandler) captureYouTubeAudio(parent context.Context, job *queue.Job, url, outputPath string) error {
+	// Create Chrome context (bounded by the capture timeout/cancellation)
+	ctx, cancel := chromedp.NewContext(parent)
 	defer cancel()
 
 	log.Printf("Starting YouTube capture: %s", url)
@@ -133,29 +288,87 @@ func (h *YouTubeHandler) captureYouTubeAudio(url, outputPath string) error {
 	// Recommended alternative: use yt-dlp subprocess
 	// See captureWithYtDlp() below for a working implementation
 
-	return h.captureWithYtDlp(url, outputPath)
+	return h.captureWithYtDlp(parent, job, url, outputPath)
 }
 
 // captureWithYtDlp uses yt-dlp to download YouTube audio (recommended)
-func (h *YouTubeHandler) captureWithYtDlp(url, outputPath string) error {
+func (h *YouTubeHandler) captureWithYtDlp(ctx context.Context, job *queue.Job, url, outputPath string) error {
 	// Note: This requires yt-dlp to be installed
 	// Install: pip install yt-dlp
 
 	log.Printf("Using yt-dlp to download: %s", url)
 
-	// Use yt-dlp to extract audio
-	cmd := exec.Command("yt-dlp",
+	args := []string{
 		"-x",                     // Extract audio
 		"--audio-format", "opus", // Opus format
+		"--newline",      // One progress line per update
 		"-o", outputPath, // Output path
-		url,
-	)
+	}
+	if h.maxDownloadMB > 0 {
+		args = append(args, "--max-filesize", fmt.Sprintf("%dM", h.maxDownloadMB))
+	}
+	// Skip videos over the duration limit rather than download them; a
+	// trimmed job only needs its section to fit
+	limit, truncate := h.workerPool.MaxDuration()
+	maxEnd := job.StartTime + limit.Seconds()
+	if limit > 0 && !truncate && (job.EndTime == 0 || job.EndTime > maxEnd) {
+		args = append(args, "--match-filter", "duration <=? "+strconv.FormatFloat(maxEnd, 'f', -1, 64))
+	}
+	args = append(args, url)
 
-	output, err := cmd.CombinedOutput()
+	// Use yt-dlp to extract audio, feeding its progress into the job record
+	progress := &ytdlpProgress{report: func(pct float64) {
+		h.workerPool.ReportProgress(job, queue.PhaseDownload, pct)
+	}}
+	output, usage, err := transcription.RunLimitedContext(ctx, progress, "yt-dlp", args...)
 	if err != nil {
 		return fmt.Errorf("yt-dlp failed: %v\nOutput: %s", err, string(output))
 	}
 
-	log.Printf("YouTube audio downloaded successfully")
+	// yt-dlp exits 0 when --max-filesize or --match-filter skips the download
+	if strings.Contains(string(output), "File is larger than max-filesize") {
+		return fmt.Errorf("download exceeds the %dMB limit", h.maxDownloadMB)
+	}
+	if strings.Contains(string(output), "does not pass filter") {
+		return fmt.Errorf("video is over the %s duration limit", limit)
+	}
+
+	log.Printf("YouTube audio downloaded successfully (cpu: %.1fs, peak memory: %.0fMB)",
+		usage.CPUSeconds, usage.PeakMemoryMB)
 	return nil
 }
+
+// ytdlpProgressPattern matches yt-dlp lines like "[download]  42.7% of 3.1MiB"
+var ytdlpProgressPattern = regexp.MustCompile(`^\[download\]\s+([0-9.]+)%`)
+
+// ytdlpProgress parses yt-dlp's --newline output and reports the download
+// percentage whenever it advances by at least a whole percent
+type ytdlpProgress struct {
+	report  func(float64)
+	partial []byte
+	last    float64
+}
+
+func (p *ytdlpProgress) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexAny(p.partial, "\r\n")
+		if i < 0 {
+			break
+		}
+		line := p.partial[:i]
+		p.partial = p.partial[i+1:]
+
+		m := ytdlpProgressPattern.FindSubmatch(bytes.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		pct, err := strconv.ParseFloat(string(m[1]), 64)
+		if err != nil || pct <= p.last || (pct-p.last < 1 && pct < 100) {
+			continue
+		}
+		p.last = pct
+		p.report(pct)
+	}
+	return len(b), nil
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package health
+
+// Free disk space is only checked on Unix; elsewhere the check only
+// verifies the directory exists.
+
+import (
+	"fmt"
+	"os"
+)
+
+// DiskSpaceCheck reports dir unhealthy when it is missing
+func DiskSpaceCheck(dir string, minFreeMB int) Check {
+	return func() error {
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("cannot stat %s: %v", dir, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package health
+
+// Free disk space check — reports a directory's filesystem unhealthy when
+// its available space drops below a floor.
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// DiskSpaceCheck reports dir unhealthy when less than minFreeMB is available
+func DiskSpaceCheck(dir string, minFreeMB int) Check {
+	return func() error {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(dir, &st); err != nil {
+			return fmt.Errorf("cannot stat %s: %v", dir, err)
+		}
+		freeMB := uint64(st.Bavail) * uint64(st.Bsize) / (1 << 20)
+		if freeMB < uint64(minFreeMB) {
+			return fmt.Errorf("%s has %dMB free (minimum %dMB)", dir, freeMB, minFreeMB)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package health
+
+// Intake gate — turns component checks into an admission decision so new
+// jobs are refused while a component they depend on is down, and accepted
+// again as soon as it recovers.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gateInterval is how long a gate reuses its last verdict, so a burst of
+// submissions doesn't rerun every check
+const gateInterval = 5 * time.Second
+
+// UnhealthyError names the components whose checks failed
+type UnhealthyError struct {
+	// Components maps each unhealthy component to its check error
+	Components map[string]string
+}
+
+func (e *UnhealthyError) Error() string {
+	names := e.Names()
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + e.Components[name]
+	}
+	return fmt.Sprintf("not accepting jobs while unhealthy (%s)", strings.Join(parts, "; "))
+}
+
+// Names lists the unhealthy components, sorted
+func (e *UnhealthyError) Names() []string {
+	names := make([]string, 0, len(e.Components))
+	for name := range e.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Gate returns a check that fails with an *UnhealthyError while any of the
+// named components is unhealthy. Components that aren't registered are
+// ignored.
+func (c *Checker) Gate(components ...string) Check {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		verdict error
+	)
+	return func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Since(checked) < gateInterval {
+			return verdict
+		}
+
+		c.mu.RLock()
+		checks := make(map[string]Check, len(components))
+		for _, name := range components {
+			if check, ok := c.checks[name]; ok {
+				checks[name] = check
+			}
+		}
+		c.mu.RUnlock()
+
+		failed := make(map[string]string)
+		for name, check := range checks {
+			if err := check(); err != nil {
+				failed[name] = err.Error()
+			}
+		}
+
+		checked, verdict = time.Now(), nil
+		if len(failed) > 0 {
+			verdict = &UnhealthyError{Components: failed}
+		}
+		return verdict
+	}
+}
@@ -0,0 +1,102 @@
+// Package health tracks process lifecycle and component checks for the
+// liveness (/livez) and readiness (/readyz) endpoints.
+package health
+
+import (
+	"sort"
+	"sync"
+)
+
+// Lifecycle phases
+const (
+	PhaseStarting = "starting"
+	PhaseReady    = "ready"
+	PhaseDraining = "draining"
+)
+
+// Check reports a component's health; a nil error means healthy
+type Check func() error
+
+// ComponentStatus is the outcome of one check
+type ComponentStatus struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report is the readiness result returned to orchestrators
+type Report struct {
+	Ready      bool                       `json:"ready"`
+	Phase      string                     `json:"phase"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Checker aggregates component checks and the lifecycle phase
+type Checker struct {
+	mu     sync.RWMutex
+	phase  string
+	checks map[string]Check
+}
+
+// NewChecker creates a checker in the starting phase
+func NewChecker() *Checker {
+	return &Checker{
+		phase:  PhaseStarting,
+		checks: make(map[string]Check),
+	}
+}
+
+// Register adds a named component check
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// SetPhase moves the process to a new lifecycle phase
+func (c *Checker) SetPhase(phase string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.phase = phase
+}
+
+// Phase returns the current lifecycle phase
+func (c *Checker) Phase() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.phase
+}
+
+// Readiness runs every check; the process is ready only when it has
+// finished starting, is not draining, and all components are healthy
+func (c *Checker) Readiness() Report {
+	c.mu.RLock()
+	phase := c.phase
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	sort.Strings(names)
+
+	report := Report{
+		Ready:      phase == PhaseReady,
+		Phase:      phase,
+		Components: make(map[string]ComponentStatus, len(names)),
+	}
+
+	for _, name := range names {
+		if err := checks[name](); err != nil {
+			report.Components[name] = ComponentStatus{Healthy: false, Error: err.Error()}
+			report.Ready = false
+		} else {
+			report.Components[name] = ComponentStatus{Healthy: true}
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,41 @@
+package health
+
+// systemd readiness signaling — sends sd_notify messages (READY=1,
+// STOPPING=1, STATUS=...) over $NOTIFY_SOCKET when running under a
+// Type=notify unit. A no-op everywhere else.
+
+import (
+	"net"
+	"os"
+)
+
+// sd_notify states
+const (
+	SdReady    = "READY=1"
+	SdStopping = "STOPPING=1"
+)
+
+// SdNotify sends a state string to systemd. It returns false without error
+// when the process is not supervised by systemd.
+func SdNotify(state string) (bool, error) {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return false, nil
+	}
+
+	// A leading '@' denotes a socket in the Linux abstract namespace
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
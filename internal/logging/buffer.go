@@ -0,0 +1,113 @@
+// Package logging keeps the server's recent log output in memory for the
+// /logs endpoints: a fixed-size ring of lines, live subscribers for
+// streaming, and secret redaction before anything is retained.
+package logging
+
+import (
+	"io"
+	"sync"
+)
+
+// subscriberBuffer is how many undelivered lines a subscriber may lag
+// behind before the oldest are dropped
+const subscriberBuffer = 256
+
+// Buffer is an io.Writer that retains the most recent log lines, redacted,
+// and fans each new line out to subscribers. It is safe for concurrent use.
+type Buffer struct {
+	mu    sync.Mutex
+	lines []string // ring of cap(lines) entries
+	next  int      // where the next line goes once the ring is full
+	subs  map[chan string]struct{}
+}
+
+// NewBuffer creates a buffer keeping the last capacity lines
+func NewBuffer(capacity int) *Buffer {
+	return &Buffer{
+		lines: make([]string, 0, max(capacity, 1)),
+		subs:  make(map[chan string]struct{}),
+	}
+}
+
+// Write redacts and stores one log entry; the standard logger makes one
+// call per entry
+func (b *Buffer) Write(p []byte) (int, error) {
+	line := Redact(string(p))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.lines) < cap(b.lines) {
+		b.lines = append(b.lines, line)
+	} else {
+		b.lines[b.next] = line
+		b.next = (b.next + 1) % len(b.lines)
+	}
+
+	// Drop a lagging subscriber's oldest line rather than block the logger
+	for ch := range b.subs {
+		select {
+		case ch <- line:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- line:
+			default:
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// Lines returns a copy of the retained lines, oldest first
+func (b *Buffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.snapshot()
+}
+
+func (b *Buffer) snapshot() []string {
+	lines := make([]string, 0, len(b.lines))
+	lines = append(lines, b.lines[b.next:]...)
+	return append(lines, b.lines[:b.next]...)
+}
+
+// Subscribe returns the retained lines and a channel of every line written
+// after them; the returned func unsubscribes and closes the channel
+func (b *Buffer) Subscribe() ([]string, <-chan string, func()) {
+	ch := make(chan string, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	backlog := b.snapshot()
+	b.mu.Unlock()
+
+	return backlog, ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// redactWriter redacts secrets before passing writes on
+type redactWriter struct {
+	w io.Writer
+}
+
+// NewRedactWriter wraps w so secrets never reach it, e.g. for stdout
+func NewRedactWriter(w io.Writer) io.Writer {
+	return redactWriter{w: w}
+}
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,168 @@
+// Package postprocess runs user-configured hooks — external commands or
+// HTTP endpoints — on each completed transcript before it is stored, so
+// custom NLP steps (redaction, punctuation, glossary fixes) can rewrite it.
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/stages"
+)
+
+// Hook is one post-processing step. Exactly one of Command or URL is set.
+type Hook struct {
+	Name    string
+	Command []string      // program and arguments; request on stdin, response on stdout
+	URL     string        // endpoint that receives the request as a JSON POST
+	Timeout time.Duration // default 30s
+
+	// FailJob fails the job when the hook errors; otherwise the hook is
+	// skipped and the transcript is stored unchanged
+	FailJob bool
+}
+
+// Request is the JSON document sent to every hook; the worker pool runs
+// hooks through stages.Hooks
+type Request = stages.HookRequest
+
+// Response is what a hook may send back; omitted fields are left as they
+// were, and an empty response leaves the transcript unchanged
+type Response struct {
+	Text     *string                `json:"text"`
+	Language *string                `json:"language"`
+	Segments []types.Segment        `json:"segments"`
+	Metadata map[string]interface{} `json:"metadata"` // merged into the job metadata
+}
+
+// Runner applies hooks in order, each seeing the previous hook's output
+type Runner struct {
+	hooks  []Hook
+	client *http.Client
+}
+
+// NewRunner validates hooks and creates a runner
+func NewRunner(hooks []Hook) (*Runner, error) {
+	for i := range hooks {
+		h := &hooks[i]
+		if (len(h.Command) == 0) == (h.URL == "") {
+			return nil, fmt.Errorf("post-processing hook %d must set exactly one of command or url", i+1)
+		}
+		if h.Name == "" {
+			h.Name = h.URL
+			if h.Name == "" {
+				h.Name = strings.Join(h.Command, " ")
+			}
+		}
+		if h.Timeout <= 0 {
+			h.Timeout = 30 * time.Second
+		}
+	}
+	return &Runner{hooks: hooks, client: &http.Client{}}, nil
+}
+
+// Len returns the number of configured hooks
+func (r *Runner) Len() int {
+	return len(r.hooks)
+}
+
+// Run passes the result through every hook, applying their changes in
+// place. Errors from hooks without FailJob are returned in skipped.
+func (r *Runner) Run(req Request) (skipped []error, err error) {
+	for _, h := range r.hooks {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return skipped, fmt.Errorf("failed to encode hook request: %v", err)
+		}
+
+		resp, err := r.call(h, body)
+		if err == nil {
+			err = apply(req.Result, resp)
+		}
+		if err != nil {
+			err = fmt.Errorf("hook %q: %v", h.Name, err)
+			if h.FailJob {
+				return skipped, err
+			}
+			skipped = append(skipped, err)
+		}
+	}
+	return skipped, nil
+}
+
+// call invokes one hook and returns its raw response
+func (r *Runner) call(h Hook, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+
+	if h.URL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		out, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("returned %d: %s", resp.StatusCode, strings.TrimSpace(string(out)))
+		}
+		return out, nil
+	}
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// apply merges a hook response into the result
+func apply(result *types.TranscriptionResult, raw []byte) error {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+
+	var resp Response
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return fmt.Errorf("invalid response JSON: %v", err)
+	}
+
+	if resp.Text != nil {
+		result.Text = *resp.Text
+	}
+	if resp.Language != nil {
+		result.Language = *resp.Language
+	}
+	if resp.Segments != nil {
+		result.Segments = resp.Segments
+	}
+	if len(resp.Metadata) > 0 {
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		for k, v := range resp.Metadata {
+			result.Metadata[k] = v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package reports
+
+// Email delivery — plain-text messages over SMTP. Port 465 uses implicit
+// TLS; other ports upgrade with STARTTLS when the server offers it.
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+)
+
+// SMTPOptions configures the mail server reports are sent through
+type SMTPOptions struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"` // default 587
+	// Username and Password authenticate with PLAIN auth when set;
+	// Password is a secret reference (env:, file:, vault:) or a literal
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// Mailer sends report emails
+type Mailer struct {
+	opts SMTPOptions
+}
+
+// NewMailer validates the SMTP options
+func NewMailer(opts SMTPOptions) (*Mailer, error) {
+	if opts.Host == "" || opts.From == "" {
+		return nil, fmt.Errorf("reports.smtp needs host and from")
+	}
+	if opts.Port == 0 {
+		opts.Port = 587
+	}
+	return &Mailer{opts: opts}, nil
+}
+
+// Send mails a plain-text message to the recipients
+func (m *Mailer) Send(to []string, subject, body string) error {
+	addr := net.JoinHostPort(m.opts.Host, strconv.Itoa(m.opts.Port))
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if m.opts.Port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: m.opts.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %v", addr, err)
+	}
+	client, err := smtp.NewClient(conn, m.opts.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake with %s failed: %v", addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.opts.Host}); err != nil {
+			return fmt.Errorf("starttls failed: %v", err)
+		}
+	}
+	if m.opts.Username != "" {
+		password, err := secrets.Resolve(m.opts.Password)
+		if err != nil {
+			return fmt.Errorf("failed to resolve smtp password: %v", err)
+		}
+		if err := client.Auth(smtp.PlainAuth("", m.opts.Username, password, m.opts.Host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(m.opts.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s rejected: %v", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message(m.opts.From, to, subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message formats the headers and body with CRLF line endings
+func message(from string, to []string, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,48 @@
+package reports
+
+// Plain-text rendering of a digest, used as the email body and the Drive
+// copy.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+)
+
+// periodLabel names a digest's period, e.g. "2025-01-20" for a day or
+// "2025-01-13 to 2025-01-19" for a week
+func periodLabel(digest *storage.Digest) string {
+	first := digest.From.Format("2006-01-02")
+	last := digest.To.AddDate(0, 0, -1).Format("2006-01-02")
+	if first == last {
+		return first
+	}
+	return first + " to " + last
+}
+
+// renderText writes a digest as a short plain-text summary
+func renderText(name string, digest *storage.Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Transcription report %s\n", name)
+	fmt.Fprintf(&b, "Period: %s\n\n", periodLabel(digest))
+
+	fmt.Fprintf(&b, "New transcripts: %d (%.1f minutes of audio)\n", digest.Transcripts, digest.DurationMinutes)
+	for _, row := range digest.BySource {
+		fmt.Fprintf(&b, "  %-8s %d (%.1f minutes)\n", row.Group, row.Transcripts, row.DurationSeconds/60)
+	}
+	fmt.Fprintf(&b, "Compute time: %.0fs\n", digest.ComputeSeconds)
+	if digest.CloudCostUSD > 0 {
+		fmt.Fprintf(&b, "Cloud cost: $%.2f\n", digest.CloudCostUSD)
+	}
+
+	fmt.Fprintf(&b, "\nFailed jobs: %d\n", digest.Failures)
+	for _, job := range digest.RecentFailures {
+		fmt.Fprintf(&b, "  %s  %s (%s, %s): %s\n",
+			job.FinishedAt.Format("2006-01-02 15:04"), job.RequestName, job.SourceType, job.JobID, job.Error)
+	}
+	if more := digest.Failures - len(digest.RecentFailures); more > 0 {
+		fmt.Fprintf(&b, "  ... and %d more\n", more)
+	}
+	return b.String()
+}
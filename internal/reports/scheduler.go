@@ -0,0 +1,303 @@
+// Package reports sends scheduled digests of transcription activity (new
+// transcripts, audio minutes, failures) for the past day or week, by
+// email, as a file in the Google Drive folder, or as a webhook event.
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+)
+
+// Report periods
+const (
+	PeriodDaily  = "daily"  // the previous calendar day
+	PeriodWeekly = "weekly" // the previous Monday-to-Monday week
+)
+
+// ErrUnknownReport is returned for a report name that is not configured
+var ErrUnknownReport = errors.New("report not found")
+
+// checkInterval is how often the scheduler looks for reports that are due
+const checkInterval = 5 * time.Minute
+
+// Report is one scheduled digest
+type Report struct {
+	Name   string `yaml:"name"`
+	Period string `yaml:"period"` // daily or weekly
+	// Hour is the local hour (0-23) from which the digest of the period
+	// that just ended is sent
+	Hour int `yaml:"hour"`
+	// Email lists recipients; reports.smtp must be configured
+	Email []string `yaml:"email"`
+	// Drive uploads the digest to the "Reports" folder in the Drive folder
+	Drive bool `yaml:"drive"`
+	// Webhook sends a report.digest event to subscribed endpoints
+	Webhook bool `yaml:"webhook"`
+	// SubjectTemplate and BodyTemplate replace the email's subject and
+	// plain-text body; they see the JSON payload's fields plus "period"
+	SubjectTemplate string `yaml:"subject_template"`
+	BodyTemplate    string `yaml:"body_template"`
+}
+
+// lastPeriod returns the latest period of the report's length that ended
+// at or before now
+func (r Report) lastPeriod(now time.Time) (from, to time.Time) {
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if r.Period == PeriodWeekly {
+		// Days since Monday, with Sunday as the seventh day
+		to = to.AddDate(0, 0, -((int(to.Weekday()) + 6) % 7))
+		return to.AddDate(0, 0, -7), to
+	}
+	return to.AddDate(0, 0, -1), to
+}
+
+// Scheduler sends each report once per period
+type Scheduler struct {
+	db       *storage.MetadataDB
+	reports  map[string]Report
+	order    []string
+	mailer   *Mailer
+	drive    *storage.DriveClient
+	webhooks *webhooks.Dispatcher
+	stopChan chan struct{}
+
+	// templates are the reports' parsed email templates, by report name
+	// and then "subject" or "body"
+	templates map[string]map[string]*template.Template
+}
+
+// NewScheduler validates the reports against the available delivery
+// channels; mailer, drive, and dispatcher may be nil when not configured
+func NewScheduler(db *storage.MetadataDB, reports []Report, mailer *Mailer, drive *storage.DriveClient, dispatcher *webhooks.Dispatcher) (*Scheduler, error) {
+	s := &Scheduler{
+		db:        db,
+		reports:   make(map[string]Report, len(reports)),
+		mailer:    mailer,
+		drive:     drive,
+		webhooks:  dispatcher,
+		stopChan:  make(chan struct{}),
+		templates: make(map[string]map[string]*template.Template),
+	}
+	for _, r := range reports {
+		switch {
+		case r.Name == "":
+			return nil, fmt.Errorf("every report needs a name")
+		case s.reports[r.Name].Name != "":
+			return nil, fmt.Errorf("report %q is defined twice", r.Name)
+		case r.Period != PeriodDaily && r.Period != PeriodWeekly:
+			return nil, fmt.Errorf("report %q: period must be %q or %q", r.Name, PeriodDaily, PeriodWeekly)
+		case r.Hour < 0 || r.Hour > 23:
+			return nil, fmt.Errorf("report %q: hour must be between 0 and 23", r.Name)
+		case len(r.Email) == 0 && !r.Drive && !r.Webhook:
+			return nil, fmt.Errorf("report %q: choose email recipients, drive, or webhook", r.Name)
+		case len(r.Email) > 0 && mailer == nil:
+			return nil, fmt.Errorf("report %q: email needs reports.smtp", r.Name)
+		case r.Webhook && dispatcher == nil:
+			return nil, fmt.Errorf("report %q: webhook needs webhooks.endpoints", r.Name)
+		}
+		if err := s.parseTemplates(r); err != nil {
+			return nil, fmt.Errorf("report %q: %v", r.Name, err)
+		}
+		if r.Drive && drive == nil {
+			// Drive may just be unavailable right now; the upload is skipped
+			log.Printf("WARNING: report %q uploads to Google Drive, which is not available", r.Name)
+		}
+		s.reports[r.Name] = r
+		s.order = append(s.order, r.Name)
+	}
+	return s, nil
+}
+
+// Start checks for due reports now and then every few minutes
+func (s *Scheduler) Start() {
+	s.sendDue(time.Now())
+
+	ticker := time.NewTicker(checkInterval)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.sendDue(time.Now())
+			case <-s.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Printf("Report scheduler started (%d reports)", len(s.order))
+}
+
+// Stop stops the scheduler
+func (s *Scheduler) Stop() {
+	close(s.stopChan)
+}
+
+// sendDue sends every report whose latest period has ended, past its
+// hour, and was not sent yet. A failed report is retried on the next
+// check. Periods missed while the server was down are not caught up;
+// only the latest one is sent.
+func (s *Scheduler) sendDue(now time.Time) {
+	for _, name := range s.order {
+		r := s.reports[name]
+		from, to := r.lastPeriod(now)
+		if now.Before(to.Add(time.Duration(r.Hour) * time.Hour)) {
+			continue
+		}
+		sent, err := s.db.ReportSent(name, from)
+		if err != nil {
+			log.Printf("Report %s: %v", name, err)
+			continue
+		}
+		if sent {
+			continue
+		}
+		if err := s.send(r, from, to); err != nil {
+			log.Printf("Report %s failed, will retry: %v", name, err)
+			continue
+		}
+		if err := s.db.MarkReportSent(name, from); err != nil {
+			log.Printf("Report %s: %v", name, err)
+		}
+	}
+}
+
+// Digest builds the named report's digest of its latest finished period
+func (s *Scheduler) Digest(name string) (*storage.Digest, error) {
+	r, ok := s.reports[name]
+	if !ok {
+		return nil, ErrUnknownReport
+	}
+	from, to := r.lastPeriod(time.Now())
+	return s.db.Digest(from, to)
+}
+
+// SendNow sends the named report's latest digest immediately, whether or
+// not it was sent already
+func (s *Scheduler) SendNow(name string) (*storage.Digest, error) {
+	r, ok := s.reports[name]
+	if !ok {
+		return nil, ErrUnknownReport
+	}
+	from, to := r.lastPeriod(time.Now())
+	digest, err := s.db.Digest(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return digest, s.deliver(r, digest)
+}
+
+// send builds the digest for a period and delivers it
+func (s *Scheduler) send(r Report, from, to time.Time) error {
+	digest, err := s.db.Digest(from, to)
+	if err != nil {
+		return err
+	}
+	if err := s.deliver(r, digest); err != nil {
+		return err
+	}
+	log.Printf("Report %s sent for %s", r.Name, periodLabel(digest))
+	return nil
+}
+
+// deliver sends a digest through every channel the report uses; each is
+// tried even if an earlier one fails
+func (s *Scheduler) deliver(r Report, digest *storage.Digest) error {
+	var failed error
+	fail := func(channel string, err error) {
+		log.Printf("Report %s: %s delivery failed: %v", r.Name, channel, err)
+		if failed == nil {
+			failed = fmt.Errorf("%s delivery failed: %v", channel, err)
+		}
+	}
+
+	text := renderText(r.Name, digest)
+	if len(r.Email) > 0 {
+		subject := fmt.Sprintf("Transcription report %s: %s", r.Name, periodLabel(digest))
+		if subject, body, err := s.renderEmail(r, digest, subject, text); err != nil {
+			fail("email", err)
+		} else if err := s.mailer.Send(r.Email, subject, body); err != nil {
+			fail("email", err)
+		}
+	}
+	if r.Drive && s.drive != nil {
+		base := fmt.Sprintf("%s_%s", r.Name, digest.From.Format("2006-01-02"))
+		if _, err := s.drive.UploadReport(base+".txt", []byte(text), "text/plain"); err != nil {
+			fail("drive", err)
+		} else if data, err := json.MarshalIndent(payload(r.Name, digest), "", "  "); err != nil {
+			fail("drive", err)
+		} else if _, err := s.drive.UploadReport(base+".json", data, "application/json"); err != nil {
+			fail("drive", err)
+		}
+	}
+	if r.Webhook {
+		if err := s.webhooks.Notify(webhooks.EventReportDigest, payload(r.Name, digest)); err != nil {
+			fail("webhook", err)
+		}
+	}
+	return failed
+}
+
+// payload is the JSON form of a digest, as uploaded and sent to webhooks
+func payload(name string, digest *storage.Digest) map[string]interface{} {
+	return map[string]interface{}{
+		"report": name,
+		"digest": digest,
+	}
+}
+
+// parseTemplates parses a report's email templates, if it has any
+func (s *Scheduler) parseTemplates(r Report) error {
+	parsed := make(map[string]*template.Template)
+	for part, text := range map[string]string{"subject": r.SubjectTemplate, "body": r.BodyTemplate} {
+		if text == "" {
+			continue
+		}
+		tmpl, err := webhooks.ParseTemplate(r.Name+" "+part, text)
+		if err != nil {
+			return err
+		}
+		parsed[part] = tmpl
+	}
+	s.templates[r.Name] = parsed
+	return nil
+}
+
+// renderEmail applies a report's email templates to a digest, keeping the
+// given subject or body where the report has no template for it
+func (s *Scheduler) renderEmail(r Report, digest *storage.Digest, subject, body string) (string, string, error) {
+	templates := s.templates[r.Name]
+	if len(templates) == 0 {
+		return subject, body, nil
+	}
+	data := payload(r.Name, digest)
+	data["period"] = periodLabel(digest)
+	document, err := json.Marshal(data)
+	if err != nil {
+		return "", "", err
+	}
+	if tmpl := templates["subject"]; tmpl != nil {
+		rendered, err := webhooks.RenderTemplate(tmpl, document)
+		if err != nil {
+			return "", "", err
+		}
+		// A header can't span lines
+		subject = strings.Join(strings.Fields(string(rendered)), " ")
+	}
+	if tmpl := templates["body"]; tmpl != nil {
+		rendered, err := webhooks.RenderTemplate(tmpl, document)
+		if err != nil {
+			return "", "", err
+		}
+		body = string(rendered)
+	}
+	return subject, body, nil
+}
@@ -0,0 +1,150 @@
+// Package search pushes completed transcripts into an Elasticsearch or
+// OpenSearch index so they can be queried alongside other documents.
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/stages"
+)
+
+// Config locates the cluster and index
+type Config struct {
+	URL   string // cluster base URL, e.g. https://localhost:9200
+	Index string // index name (default "transcripts")
+
+	// Username/Password (basic auth) or APIKey authenticate requests; each
+	// is a secret reference (env:, file:, vault:) or a literal
+	Username string
+	Password string
+	APIKey   string
+
+	Timeout time.Duration // per-request timeout (default 10s)
+}
+
+// Document is the indexed form of a transcript; the worker pool indexes
+// through stages.Indexer
+type Document = stages.Document
+
+// Indexer writes transcript documents to one index, keyed by job ID
+type Indexer struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewIndexer creates an indexer for the configured cluster
+func NewIndexer(cfg Config) (*Indexer, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("search URL is required")
+	}
+	if cfg.Index == "" {
+		cfg.Index = "transcripts"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	return &Indexer{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Index creates or replaces the document for a transcript
+func (ix *Indexer) Index(doc Document) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode search document: %v", err)
+	}
+	return ix.do(http.MethodPut, ix.docURL(doc.JobID), body, false)
+}
+
+// Update changes some fields of a transcript's document (JSON names as in
+// Document); a missing document, e.g. an encrypted transcript's, is not an
+// error
+func (ix *Indexer) Update(jobID string, fields map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"doc": fields})
+	if err != nil {
+		return fmt.Errorf("failed to encode search update: %v", err)
+	}
+	target := fmt.Sprintf("%s/%s/_update/%s", ix.cfg.URL, url.PathEscape(ix.cfg.Index), url.PathEscape(jobID))
+	return ix.do(http.MethodPost, target, body, true)
+}
+
+// Delete removes a transcript's document; a missing document is not an error
+func (ix *Indexer) Delete(jobID string) error {
+	return ix.do(http.MethodDelete, ix.docURL(jobID), nil, true)
+}
+
+// Ping checks that the cluster is reachable and the credentials are accepted
+func (ix *Indexer) Ping() error {
+	return ix.do(http.MethodGet, ix.cfg.URL, nil, false)
+}
+
+// docURL returns the document endpoint for a job
+func (ix *Indexer) docURL(jobID string) string {
+	return fmt.Sprintf("%s/%s/_doc/%s", ix.cfg.URL, url.PathEscape(ix.cfg.Index), url.PathEscape(jobID))
+}
+
+// do sends one request, failing on any non-2xx response (and 404 unless allowed)
+func (ix *Indexer) do(method, target string, body []byte, allowNotFound bool) error {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := ix.authorize(req); err != nil {
+		return err
+	}
+
+	resp, err := ix.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("search request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && allowNotFound {
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("search %s %s returned %d: %s", method, target, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// authorize adds API key or basic auth credentials, resolved per request so
+// rotated secrets are picked up
+func (ix *Indexer) authorize(req *http.Request) error {
+	if ix.cfg.APIKey != "" {
+		key, err := secrets.Resolve(ix.cfg.APIKey)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "ApiKey "+key)
+		return nil
+	}
+	if ix.cfg.Username != "" {
+		user, err := secrets.Resolve(ix.cfg.Username)
+		if err != nil {
+			return err
+		}
+		pass, err := secrets.Resolve(ix.cfg.Password)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(user, pass)
+	}
+	return nil
+}
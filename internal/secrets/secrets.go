@@ -0,0 +1,82 @@
+// Package secrets resolves secret references from config (credentials,
+// API keys, webhook secrets) against pluggable providers such as
+// environment variables, files, or HashiCorp Vault.
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Provider fetches a secret by the provider-specific reference that follows
+// the scheme prefix (e.g. "GOOGLE_CREDENTIALS" in "env:GOOGLE_CREDENTIALS")
+type Provider interface {
+	Get(ref string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(ref string) (string, error)
+
+// Get calls f(ref)
+func (f ProviderFunc) Get(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{
+		"env":  ProviderFunc(fromEnv),
+		"file": ProviderFunc(fromFile),
+	}
+)
+
+// Register makes a provider available under the given scheme, replacing
+// any existing provider with that name
+func Register(scheme string, p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[scheme] = p
+}
+
+// Resolve returns the secret a reference points to. References take the form
+// "<scheme>:<ref>"; values without a registered scheme are returned as-is so
+// plain literals in config keep working.
+func Resolve(value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+
+	mu.RLock()
+	p, found := providers[scheme]
+	mu.RUnlock()
+	if !found {
+		return value, nil
+	}
+
+	secret, err := p.Get(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s secret: %v", scheme, err)
+	}
+	return secret, nil
+}
+
+// fromEnv reads a secret from an environment variable
+func fromEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// fromFile reads a secret from a file, e.g. a mounted Kubernetes secret
+func fromFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,90 @@
+package secrets
+
+// HashiCorp Vault provider — reads KV v2 secrets over Vault's HTTP API
+// using VAULT_ADDR and VAULT_TOKEN. References look like
+// "vault:secret/data/transcription#google_credentials".
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultProvider fetches fields from Vault KV v2 secrets
+type VaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewVaultProvider creates a Vault provider for the given server and token
+func NewVaultProvider(addr, token string) *VaultProvider {
+	return &VaultProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// RegisterVaultFromEnv registers the "vault" scheme when VAULT_ADDR is set
+func RegisterVaultFromEnv() bool {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return false
+	}
+	Register("vault", NewVaultProvider(addr, os.Getenv("VAULT_TOKEN")))
+	return true
+}
+
+// Get reads "<path>#<field>" from Vault
+func (vp *VaultProvider) Get(ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault reference must look like <path>#<field>")
+	}
+
+	req, err := http.NewRequest("GET", vp.addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", vp.token)
+
+	resp, err := vp.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("vault returned status %d for %s", resp.StatusCode, path)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode vault response: %v", err)
+	}
+
+	value, ok := body.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("field %s not found at %s", field, path)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	default:
+		// Structured values (e.g. a credentials object) are returned as JSON
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
@@ -0,0 +1,351 @@
+// Package webhooks delivers job notifications to HTTP endpoints through a
+// persistent outbox, with signed payloads, retries with exponential
+// backoff, and dead-lettering of deliveries that keep failing.
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+)
+
+// Event names
+const (
+	EventJobCompleted = "job.completed"
+	EventJobFailed    = "job.failed"
+	EventJobCancelled = "job.cancelled"
+
+	// EventGroupCompleted reports a job group whose jobs have all finished,
+	// whatever their outcome
+	EventGroupCompleted = "group.completed"
+
+	// EventWorkerStalled reports a worker that stopped sending heartbeats
+	// while processing a job
+	EventWorkerStalled = "worker.stalled"
+
+	// EventQueueWaitExceeded reports a job that has waited in the queue
+	// longer than the wait limit, and EventQueueBacklog a queue that has
+	// stayed deeper than the depth threshold
+	EventQueueWaitExceeded = "queue.wait_exceeded"
+	EventQueueBacklog      = "queue.backlog"
+
+	// EventReportDigest carries a scheduled activity report
+	EventReportDigest = "report.digest"
+)
+
+// Endpoint is a configured webhook receiver
+type Endpoint struct {
+	URL string
+	// Secrets are secret references (env:, file:, vault:, or literals) used
+	// to sign payloads; every listed secret produces a signature so the
+	// receiver can rotate keys without missing deliveries
+	Secrets []string
+	// Events limits which events are sent (empty = all)
+	Events []string
+	// Template, when set, renders the request body instead of the standard
+	// JSON envelope (see templates.go), sent as ContentType (default
+	// application/json)
+	Template    string
+	ContentType string
+
+	tmpl *template.Template
+}
+
+// contentType is the Content-Type of the endpoint's requests
+func (e Endpoint) contentType() string {
+	if e.ContentType == "" {
+		return "application/json"
+	}
+	return e.ContentType
+}
+
+// body is the request body of an event for the endpoint
+func (e Endpoint) body(envelope []byte) ([]byte, error) {
+	if e.tmpl == nil {
+		return envelope, nil
+	}
+	return RenderTemplate(e.tmpl, envelope)
+}
+
+// wants reports whether the endpoint subscribes to event
+func (e Endpoint) wants(event string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, name := range e.Events {
+		if name == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Options tunes delivery behaviour
+type Options struct {
+	MaxAttempts int           // attempts before a delivery is dead-lettered (default 8)
+	BaseBackoff time.Duration // delay after the first failure, doubled each time (default 10s)
+	MaxBackoff  time.Duration // cap on the retry delay (default 1h)
+	Timeout     time.Duration // per-request timeout (default 10s)
+}
+
+// withDefaults fills unset options
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 8
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 10 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// pollInterval is how often the outbox is scanned for due deliveries
+const pollInterval = 2 * time.Second
+
+// Dispatcher writes events to the outbox and delivers them in the background
+type Dispatcher struct {
+	db        *storage.MetadataDB
+	endpoints map[string]Endpoint
+	opts      Options
+	client    *http.Client
+
+	wake     chan struct{}
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDispatcher creates a dispatcher for the given endpoints, failing if
+// an endpoint's template doesn't parse
+func NewDispatcher(db *storage.MetadataDB, endpoints []Endpoint, opts Options) (*Dispatcher, error) {
+	opts = opts.withDefaults()
+
+	byURL := make(map[string]Endpoint, len(endpoints))
+	for _, e := range endpoints {
+		if e.Template != "" {
+			tmpl, err := ParseTemplate(e.URL, e.Template)
+			if err != nil {
+				return nil, err
+			}
+			e.tmpl = tmpl
+		}
+		byURL[e.URL] = e
+	}
+
+	return &Dispatcher{
+		db:        db,
+		endpoints: byURL,
+		opts:      opts,
+		client:    &http.Client{Timeout: opts.Timeout},
+		wake:      make(chan struct{}, 1),
+		stopChan:  make(chan struct{}),
+	}, nil
+}
+
+// Notify records event for every subscribed endpoint, rendered with its
+// template if it has one. Delivery happens asynchronously; an error means
+// the event could not be written to the outbox. An endpoint whose template
+// fails is skipped and the failure logged.
+func (d *Dispatcher) Notify(event string, payload interface{}) error {
+	envelope, err := encodeEnvelope(event, payload)
+	if err != nil {
+		return err
+	}
+	var about struct {
+		Data struct {
+			JobID string `json:"job_id"`
+		} `json:"data"`
+	}
+	json.Unmarshal(envelope, &about)
+
+	for url, endpoint := range d.endpoints {
+		if !endpoint.wants(event) {
+			continue
+		}
+		body, err := endpoint.body(envelope)
+		if err != nil {
+			log.Printf("Webhook %s to %s not sent: %v", event, url, err)
+			continue
+		}
+		if err := d.db.EnqueueDelivery(uuid.New().String(), url, event, about.Data.JobID, string(body)); err != nil {
+			return err
+		}
+	}
+
+	d.poke()
+	return nil
+}
+
+// encodeEnvelope wraps an event's payload in the standard JSON envelope
+func encodeEnvelope(event string, payload interface{}) ([]byte, error) {
+	envelope, err := json.Marshal(map[string]interface{}{
+		"event":     event,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"data":      payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+	return envelope, nil
+}
+
+// Redeliver resets a delivery (typically a dead one) and sends it again
+func (d *Dispatcher) Redeliver(id string) error {
+	if err := d.db.ResetDelivery(id); err != nil {
+		return err
+	}
+	d.poke()
+	return nil
+}
+
+// poke wakes the delivery loop without blocking
+func (d *Dispatcher) poke() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start begins delivering from the outbox, including anything left over
+// from a previous run
+func (d *Dispatcher) Start() {
+	ticker := time.NewTicker(pollInterval)
+
+	go func() {
+		for {
+			d.deliverDue()
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			case <-d.stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Printf("Webhook dispatcher started (%d endpoints, max attempts: %d)",
+		len(d.endpoints), d.opts.MaxAttempts)
+}
+
+// Stop halts the delivery loop; undelivered events stay in the outbox
+func (d *Dispatcher) Stop() {
+	d.stopOnce.Do(func() { close(d.stopChan) })
+}
+
+// deliverDue attempts every delivery whose next attempt time has passed
+func (d *Dispatcher) deliverDue() {
+	due, err := d.db.DueDeliveries(time.Now(), 50)
+	if err != nil {
+		log.Printf("Webhook outbox scan failed: %v", err)
+		return
+	}
+	for _, delivery := range due {
+		d.attempt(delivery)
+	}
+}
+
+// attempt sends one delivery and records the outcome
+func (d *Dispatcher) attempt(delivery *storage.WebhookDelivery) {
+	endpoint, ok := d.endpoints[delivery.Endpoint]
+	if !ok {
+		d.record(delivery, storage.DeliveryDead, 0, "endpoint no longer configured")
+		return
+	}
+
+	statusCode, err := d.send(endpoint, delivery)
+	if err == nil {
+		d.record(delivery, storage.DeliveryDelivered, statusCode, "")
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts >= d.opts.MaxAttempts {
+		log.Printf("Webhook delivery %s to %s dead after %d attempts: %v",
+			delivery.ID, delivery.Endpoint, attempts, err)
+		d.record(delivery, storage.DeliveryDead, statusCode, err.Error())
+		return
+	}
+	d.record(delivery, storage.DeliveryRetrying, statusCode, err.Error())
+}
+
+// record stores an attempt outcome, scheduling the next retry with backoff
+func (d *Dispatcher) record(delivery *storage.WebhookDelivery, status string, statusCode int, lastError string) {
+	next := time.Now().Add(d.backoff(delivery.Attempts + 1))
+	if err := d.db.RecordDeliveryAttempt(delivery.ID, status, statusCode, lastError, next); err != nil {
+		log.Printf("Webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// backoff returns the delay before the retry following the given attempt
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.opts.BaseBackoff
+	for i := 1; i < attempt && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.opts.MaxBackoff {
+		delay = d.opts.MaxBackoff
+	}
+	return delay
+}
+
+// send POSTs the payload and returns the response status code
+func (d *Dispatcher) send(endpoint Endpoint, delivery *storage.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	signature, err := signatureHeader(endpoint.Secrets, timestamp, body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := newRequest(endpoint, delivery.ID, delivery.Event, body, timestamp, signature)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// newRequest builds the POST of one delivery with its identifying and
+// signature headers
+func newRequest(endpoint Endpoint, id, event string, body []byte, timestamp int64, signature string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", endpoint.contentType())
+	req.Header.Set("X-Webhook-ID", id)
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	if signature != "" {
+		req.Header.Set(SignatureHeader, signature)
+	}
+	return req, nil
+}
@@ -0,0 +1,101 @@
+package webhooks
+
+// Result links — short-lived signed URLs to a job's artifacts, included in
+// webhook payloads so receivers can fetch results without credentials of
+// their own. A link is "<base>/results/<job>/<artifact>?expires=&signature="
+// where the signature is Sign(secret, expires, "<job>/<artifact>").
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+)
+
+// DefaultLinkTTL is how long result links stay valid unless configured
+const DefaultLinkTTL = time.Hour
+
+// Link verification errors
+var (
+	ErrLinkExpired = errors.New("link has expired")
+	ErrLinkInvalid = errors.New("link signature is invalid")
+)
+
+// ResultLinks issues and checks signed artifact links
+type ResultLinks struct {
+	baseURL   string
+	secretRef string
+	ttl       time.Duration
+}
+
+// NewResultLinks creates a link issuer for the server reachable at baseURL.
+// secretRef is a secret reference (env:, file:, vault:, or a literal),
+// resolved on every use so it can be rotated without a restart.
+func NewResultLinks(baseURL, secretRef string, ttl time.Duration) *ResultLinks {
+	if ttl <= 0 {
+		ttl = DefaultLinkTTL
+	}
+	return &ResultLinks{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		secretRef: secretRef,
+		ttl:       ttl,
+	}
+}
+
+// Sign returns a link to each of a job's artifacts (keyed by artifact) and
+// the time they stop working
+func (l *ResultLinks) Sign(jobID string, artifacts []string) (map[string]string, time.Time, error) {
+	secret, err := l.secret()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	expires := time.Now().Add(l.ttl).Truncate(time.Second)
+	links := make(map[string]string, len(artifacts))
+	for _, artifact := range artifacts {
+		query := url.Values{
+			"expires":   {strconv.FormatInt(expires.Unix(), 10)},
+			"signature": {Sign(secret, expires.Unix(), []byte(jobID+"/"+artifact))},
+		}
+		links[artifact] = fmt.Sprintf("%s/results/%s/%s?%s",
+			l.baseURL, url.PathEscape(jobID), url.PathEscape(artifact), query.Encode())
+	}
+	return links, expires, nil
+}
+
+// Verify checks a link's expiry and signature
+func (l *ResultLinks) Verify(jobID, artifact, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrLinkInvalid
+	}
+	secret, err := l.secret()
+	if err != nil {
+		return err
+	}
+	want := Sign(secret, unix, []byte(jobID+"/"+artifact))
+	if !hmac.Equal([]byte(want), []byte(signature)) {
+		return ErrLinkInvalid
+	}
+	if time.Now().Unix() >= unix {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+// secret resolves the signing secret
+func (l *ResultLinks) secret() (string, error) {
+	secret, err := secrets.Resolve(l.secretRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve result link secret: %v", err)
+	}
+	if secret == "" {
+		return "", errors.New("result link secret is empty")
+	}
+	return secret, nil
+}
@@ -0,0 +1,49 @@
+package webhooks
+
+// Payload signing — HMAC-SHA256 over "<timestamp>.<body>", one signature
+// per configured secret so receivers can rotate keys with overlap.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+)
+
+// SignatureHeader carries "t=<unix>,v1=<hex>[,v1=<hex>...]"
+const SignatureHeader = "X-Webhook-Signature"
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signatureHeader resolves the secret references and signs body with each.
+// Secrets are resolved on every delivery, so rotating the value behind a
+// reference takes effect without a restart.
+func signatureHeader(refs []string, timestamp int64, body []byte) (string, error) {
+	if len(refs) == 0 {
+		return "", nil
+	}
+
+	parts := []string{"t=" + strconv.FormatInt(timestamp, 10)}
+	for _, ref := range refs {
+		secret, err := secrets.Resolve(ref)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve webhook secret: %v", err)
+		}
+		if secret == "" {
+			continue
+		}
+		parts = append(parts, "v1="+Sign(secret, timestamp, body))
+	}
+	return strings.Join(parts, ","), nil
+}
@@ -0,0 +1,64 @@
+package webhooks
+
+// Payload templates — an endpoint may shape its request body with a Go
+// text/template instead of receiving the standard JSON envelope, e.g. to
+// post straight to a Slack incoming webhook. Templates see the envelope as
+// decoded JSON, so fields have the names the JSON payload documents:
+// {{.event}}, {{.timestamp}}, {{.data.job_id}}.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the functions available to notification templates
+var TemplateFuncs = template.FuncMap{
+	// json writes a value as JSON, for embedding text in a JSON body
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// truncate shortens text to n characters, ending it with "..."
+	"truncate": func(n int, v interface{}) string {
+		s := fmt.Sprint(v)
+		if r := []rune(s); len(r) > n {
+			return string(r[:max(n-3, 0)]) + "..."
+		}
+		return s
+	},
+	// default is v, or fallback when v is missing or empty
+	"default": func(fallback, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return fallback
+		}
+		return v
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseTemplate parses a notification template with TemplateFuncs
+func ParseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %v", name, err)
+	}
+	return tmpl, nil
+}
+
+// RenderTemplate executes tmpl on a JSON document decoded into maps, so
+// it sees the same field names as the JSON
+func RenderTemplate(tmpl *template.Template, document []byte) ([]byte, error) {
+	var data interface{}
+	if err := json.Unmarshal(document, &data); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("template %s failed: %v", tmpl.Name(), err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,186 @@
+package webhooks
+
+// Test deliveries — signs and sends a sample event to a receiver right
+// away, outside the outbox, and reports how it answered, so integrators
+// can check their endpoint and signature verification before wiring up
+// real jobs.
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxTestResponse bounds how much of a receiver's reply is reported
+const maxTestResponse = 4096
+
+// TestResult is how a receiver answered a test delivery
+type TestResult struct {
+	URL        string `json:"url"`
+	Event      string `json:"event"`
+	DeliveryID string `json:"delivery_id"`
+	Timestamp  int64  `json:"timestamp"`
+	// Signature is the X-Webhook-Signature header sent; "" when unsigned
+	Signature string `json:"signature,omitempty"`
+	Payload   string `json:"payload"`
+	// Delivered is set when the receiver answered 2xx
+	Delivered  bool   `json:"delivered"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Response   string `json:"response,omitempty"` // the start of the reply body
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// TestEvents are the events a test delivery can send
+var TestEvents = []string{EventJobCompleted, EventJobFailed, EventJobCancelled, EventGroupCompleted, EventWorkerStalled,
+	EventQueueWaitExceeded, EventQueueBacklog}
+
+// samplePayload is a made-up payload for event, shaped like a real one and
+// marked as a test
+func samplePayload(event string) (map[string]interface{}, error) {
+	jobID := "test-" + uuid.New().String()
+	payload := map[string]interface{}{
+		"test":         true,
+		"job_id":       jobID,
+		"request_name": "webhook-test",
+		"source_type":  "upload",
+		"metadata":     map[string]interface{}{},
+		"labels":       map[string]string{},
+	}
+	switch event {
+	case EventJobCompleted:
+		payload["status"] = "completed"
+		payload["duration"] = 42.5
+		payload["word_count"] = 120
+		payload["local_path"] = "outputs/2025/01/23/20250123_143022_webhook-test.txt"
+		payload["gdrive_url"] = ""
+	case EventJobFailed:
+		payload["status"] = "failed"
+		payload["error"] = "Audio normalization failed: sample error"
+	case EventJobCancelled:
+		payload["status"] = "cancelled"
+	case EventGroupCompleted:
+		return map[string]interface{}{
+			"test":         true,
+			"group_id":     "test-" + uuid.New().String(),
+			"request_name": "webhook-test",
+			"source_type":  "upload",
+			"status":       "completed",
+			"counts":       map[string]int{"completed": 1},
+			"jobs":         []map[string]string{{"job_id": jobID, "status": "completed"}},
+		}, nil
+	case EventWorkerStalled:
+		return map[string]interface{}{
+			"test":           true,
+			"worker":         1,
+			"job_id":         jobID,
+			"request_name":   "webhook-test",
+			"last_heartbeat": time.Now().Add(-5 * time.Minute).UTC().Format(time.RFC3339),
+			"quiet_seconds":  300.0,
+			"action":         "requeued",
+		}, nil
+	case EventQueueWaitExceeded:
+		return map[string]interface{}{
+			"test":             true,
+			"job_id":           jobID,
+			"request_name":     "webhook-test",
+			"waited_seconds":   1200.0,
+			"max_wait_seconds": 900.0,
+			"queue_depth":      25,
+		}, nil
+	case EventQueueBacklog:
+		return map[string]interface{}{
+			"test":          true,
+			"queue_depth":   60,
+			"threshold":     50,
+			"above_since":   time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339),
+			"above_seconds": 600.0,
+		}, nil
+	default:
+		return nil, fmt.Errorf("no sample payload for event %q (use %s)", event, strings.Join(TestEvents, ", "))
+	}
+	return payload, nil
+}
+
+// CheckTestURL validates a receiver URL for a test delivery
+func CheckTestURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// Test sends a sample event to receiverURL, treated like the configured
+// endpoint with that URL if there is one (see SendTest)
+func (d *Dispatcher) Test(receiverURL, event, secret string) (*TestResult, error) {
+	endpoint, ok := d.endpoints[receiverURL]
+	if !ok {
+		endpoint = Endpoint{URL: receiverURL}
+	}
+	return SendTest(endpoint, event, secret, d.opts)
+}
+
+// SendTest POSTs a sample event to endpoint as a real delivery would be
+// sent: rendered with its template, with the same headers, and signed.
+// secret, when set, signs it instead of the endpoint's secrets and is used
+// as given, never resolved as a secret reference. Failures to reach the
+// receiver are reported in the result; an error means nothing was sent.
+func SendTest(endpoint Endpoint, event, secret string, opts Options) (*TestResult, error) {
+	if err := CheckTestURL(endpoint.URL); err != nil {
+		return nil, err
+	}
+	payload, err := samplePayload(event)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := encodeEnvelope(event, payload)
+	if err != nil {
+		return nil, err
+	}
+	body, err := endpoint.body(envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TestResult{
+		URL:        endpoint.URL,
+		Event:      event,
+		DeliveryID: uuid.New().String(),
+		Timestamp:  time.Now().Unix(),
+		Payload:    string(body),
+	}
+	if secret != "" {
+		result.Signature = "t=" + strconv.FormatInt(result.Timestamp, 10) + ",v1=" + Sign(secret, result.Timestamp, body)
+	} else if result.Signature, err = signatureHeader(endpoint.Secrets, result.Timestamp, body); err != nil {
+		return nil, err
+	}
+
+	req, err := newRequest(endpoint, result.DeliveryID, event, body, result.Timestamp, result.Signature)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := (&http.Client{Timeout: opts.withDefaults().Timeout}).Do(req)
+	result.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, maxTestResponse))
+	result.StatusCode = resp.StatusCode
+	result.Response = string(reply)
+	result.Delivered = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !result.Delivered {
+		result.Error = fmt.Sprintf("endpoint returned status %d", resp.StatusCode)
+	}
+	return result, nil
+}
@@ -0,0 +1,229 @@
+// Package pipeline embeds the transcription pipeline — normalization,
+// Whisper, local/Drive storage, and the SQLite metadata store — in another
+// Go program without running the HTTP server.
+//
+// The building blocks live in the subpackages (queue, transcription,
+// storage, types); New wires them together the way the server does.
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/queue"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// tempDir is where the pipeline stages its working files
+const tempDir = "temp"
+
+// Options configures an embedded pipeline. Zero values take the same
+// defaults as config/config.yaml.
+type Options struct {
+	// ModelPath selects the Whisper model by the size in its name (tiny,
+	// base, small, medium, large); default small. With the whispercpp
+	// backend it is the ggml model file to load.
+	ModelPath string
+	Threads   int
+	Device    string // "cuda" (default), "cuda:N", or "cpu"
+	// Devices spreads the python and fasterwhisper backends over several
+	// GPUs, each running up to MaxJobsPerDevice jobs at once (default 1)
+	Devices          []string
+	MaxJobsPerDevice int
+	// Backend is "python" (default), "whispercpp", "fasterwhisper",
+	// "deepgram", "assemblyai", "google_stt", "aws_transcribe", or "vosk"
+	Backend string
+	// Persistent keeps a whisper process loaded for the python backend
+	// rather than starting the CLI per job
+	Persistent bool
+
+	// Python sets the interpreter and environment of the python and
+	// fasterwhisper backends
+	Python transcription.PythonOptions
+
+	// Deepgram, AssemblyAI, GoogleSTT, and AWSTranscribe configure the
+	// cloud backends
+	Deepgram      transcription.DeepgramOptions
+	AssemblyAI    transcription.AssemblyAIOptions
+	GoogleSTT     transcription.GoogleSTTOptions
+	AWSTranscribe transcription.AWSTranscribeOptions
+
+	// Vosk maps languages to Vosk model directories for the vosk backend
+	Vosk transcription.VoskOptions
+
+	// OutputFormats are extra renderings (srt, vtt, tsv) saved per job
+	OutputFormats []string
+	// TextEncoding sets the BOM and line endings of text artifacts
+	TextEncoding storage.TextEncoding
+
+	Workers   int    // concurrent transcription workers (default 4)
+	OutputDir string // transcript root (default "./outputs")
+	Database  string // SQLite path (default "./transcription.db")
+
+	// Drive, if set, receives a copy of every transcript
+	Drive *storage.DriveClient
+}
+
+// withDefaults fills in unset options
+func (o Options) withDefaults() Options {
+	if o.Device == "" {
+		o.Device = "cuda"
+	}
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.OutputDir == "" {
+		o.OutputDir = "./outputs"
+	}
+	if o.Database == "" {
+		o.Database = "./transcription.db"
+	}
+	return o
+}
+
+// Pipeline is a running transcription pipeline
+type Pipeline struct {
+	Transcriber *transcription.WhisperTranscriber
+	Storage     *storage.LocalStorage
+	DB          *storage.MetadataDB
+	Workers     *queue.WorkerPool
+}
+
+// New creates the pipeline's storage and database, starts its workers, and
+// resumes any jobs a previous run left unfinished
+func New(opts Options) (*Pipeline, error) {
+	opts = opts.withDefaults()
+
+	for _, dir := range []string{tempDir, opts.OutputDir, filepath.Dir(opts.Database)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	transcriber, err := transcription.NewWhisperTranscriber(opts.ModelPath, opts.Threads, opts.Device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Whisper: %v", err)
+	}
+	transcriber.SetDeepgram(opts.Deepgram)
+	transcriber.SetAssemblyAI(opts.AssemblyAI)
+	transcriber.SetGoogleSTT(opts.GoogleSTT)
+	transcriber.SetAWSTranscribe(opts.AWSTranscribe)
+	transcriber.SetVosk(opts.Vosk)
+	if err := transcriber.SetPython(opts.Python); err != nil {
+		return nil, err
+	}
+	if err := transcriber.SetDevices(opts.Devices, opts.MaxJobsPerDevice); err != nil {
+		return nil, err
+	}
+	transcriber.SetPersistent(opts.Persistent)
+	if err := transcriber.SetBackend(opts.Backend); err != nil {
+		return nil, err
+	}
+	if err := transcriber.SetOutputFormats(opts.OutputFormats); err != nil {
+		return nil, err
+	}
+
+	db, err := storage.NewMetadataDB(opts.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	localStorage := storage.NewLocalStorage(opts.OutputDir)
+	workers := queue.NewWorkerPool(opts.Workers, transcriber, localStorage, opts.Drive, db)
+	if err := workers.SetTextEncoding(opts.TextEncoding); err != nil {
+		db.Close()
+		return nil, err
+	}
+	workers.Start()
+	if _, err := workers.Resume(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to resume unfinished jobs: %v", err)
+	}
+
+	return &Pipeline{
+		Transcriber: transcriber,
+		Storage:     localStorage,
+		DB:          db,
+		Workers:     workers,
+	}, nil
+}
+
+// Submit queues a copy of the audio file at path (the original is left
+// untouched) and returns the job; wait on job.Done(), then read the outcome
+// from job.Final()
+func (p *Pipeline) Submit(path, requestName string) (*queue.Job, error) {
+	if _, err := transcription.DetectAudioFormat(path); err != nil {
+		return nil, fmt.Errorf("unsupported audio format: %w", err)
+	}
+
+	jobID := uuid.New().String()
+	tempPath := filepath.Join(tempDir, jobID+filepath.Ext(path))
+	if err := copyFile(path, tempPath); err != nil {
+		return nil, fmt.Errorf("failed to stage %s: %v", path, err)
+	}
+
+	job := queue.NewJob(jobID, requestName, types.SourceUpload, tempPath)
+	job.Done()
+	p.Workers.EnqueueJob(job)
+	return job, nil
+}
+
+// Transcribe submits an audio file and waits for its transcript. Cancelling
+// ctx stops the wait, not the job.
+func (p *Pipeline) Transcribe(ctx context.Context, path, requestName string) (*types.TranscriptionResult, error) {
+	job, err := p.Submit(path, requestName)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-job.Done():
+		job = job.Final()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if job.Status != types.StatusCompleted {
+		if job.Error != nil {
+			return nil, job.Error
+		}
+		return nil, fmt.Errorf("job %s ended %s", job.ID, job.Status)
+	}
+	return job.Result, nil
+}
+
+// Close stops the transcription backend and releases the metadata
+// database; call it once submitted jobs have finished
+func (p *Pipeline) Close() error {
+	backendErr := p.Transcriber.Close()
+	if err := p.DB.Close(); err != nil {
+		return err
+	}
+	return backendErr
+}
+
+// copyFile copies src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,46 @@
+package queue
+
+// Job cancellation — in-flight work (currently source downloads) registers
+// a cancel function under its job ID so the API can abort it.
+
+import (
+	"context"
+	"sync"
+)
+
+// cancelRegistry maps job IDs to the cancel functions of their in-flight work
+type cancelRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{cancels: make(map[string]context.CancelFunc)}
+}
+
+// RegisterCancel makes a job's in-flight work cancellable through CancelJob.
+// The returned function unregisters it and must be called when the work ends.
+func (wp *WorkerPool) RegisterCancel(jobID string, cancel context.CancelFunc) func() {
+	wp.cancels.mu.Lock()
+	wp.cancels.cancels[jobID] = cancel
+	wp.cancels.mu.Unlock()
+
+	return func() {
+		wp.cancels.mu.Lock()
+		delete(wp.cancels.cancels, jobID)
+		wp.cancels.mu.Unlock()
+	}
+}
+
+// CancelJob aborts a job's in-flight work, reporting false if the job has
+// nothing cancellable running
+func (wp *WorkerPool) CancelJob(jobID string) bool {
+	wp.cancels.mu.Lock()
+	cancel, ok := wp.cancels.cancels[jobID]
+	wp.cancels.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
@@ -0,0 +1,105 @@
+package queue
+
+// Capture expiry — a job whose source is still being fetched (recorded
+// with TrackJob) must reach the queue within the capture TTL. Past it the
+// job is failed, its download cancelled, and its partial files removed, so
+// a hung capture can't leave a job DOWNLOADING forever. Whichever comes
+// first, the capture finishing or expiring, decides the job's outcome; the
+// other is discarded.
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// captureRegistry tracks jobs that are fetching their source
+type captureRegistry struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+func newCaptureRegistry() *captureRegistry {
+	return &captureRegistry{jobs: make(map[string]*Job)}
+}
+
+// SetCaptureTTL fails jobs still fetching their source after ttl (zero
+// means never). Call before Start.
+func (wp *WorkerPool) SetCaptureTTL(ttl time.Duration) {
+	wp.captureTTL = ttl
+}
+
+// trackCapture starts a job's capture TTL
+func (wp *WorkerPool) trackCapture(job *Job) {
+	if wp.captureTTL <= 0 {
+		return
+	}
+	job.ExpiresAt = job.CreatedAt.Add(wp.captureTTL)
+
+	wp.captures.mu.Lock()
+	wp.captures.jobs[job.ID] = job
+	wp.captures.mu.Unlock()
+}
+
+// claimCapture ends a job's capture, reporting false if it already
+// expired, in which case the caller must leave the job alone
+func (wp *WorkerPool) claimCapture(job *Job) bool {
+	wp.captures.mu.Lock()
+	defer wp.captures.mu.Unlock()
+	if job.captureExpired {
+		return false
+	}
+	delete(wp.captures.jobs, job.ID)
+	return true
+}
+
+// monitorCaptures fails expired captures until the process exits
+func (wp *WorkerPool) monitorCaptures() {
+	interval := min(max(wp.captureTTL/10, time.Second), time.Minute)
+	for range time.Tick(interval) {
+		for _, job := range wp.expiredCaptures(time.Now()) {
+			wp.expireCapture(job)
+		}
+	}
+}
+
+// expiredCaptures removes and returns the captures past their deadline,
+// marking them so the capture's own outcome is discarded
+func (wp *WorkerPool) expiredCaptures(now time.Time) []*Job {
+	wp.captures.mu.Lock()
+	defer wp.captures.mu.Unlock()
+
+	var expired []*Job
+	for id, job := range wp.captures.jobs {
+		if now.Before(job.ExpiresAt) {
+			continue
+		}
+		job.captureExpired = true
+		delete(wp.captures.jobs, id)
+		expired = append(expired, job)
+	}
+	return expired
+}
+
+// expireCapture aborts a capture that outlived the TTL, fails its job, and
+// removes what it downloaded so far
+func (wp *WorkerPool) expireCapture(job *Job) {
+	log.Printf("Job %s: source capture did not finish within %s; failing it", job.ID, wp.captureTTL)
+	wp.CancelJob(job.ID)
+	wp.failJob(job, fmt.Errorf("Source capture did not finish within %s", wp.captureTTL))
+	removeCaptureFiles(job.ID)
+}
+
+// removeCaptureFiles deletes a capture's temp files, e.g. "<id>.opus" and
+// yt-dlp's "<id>.opus.part"
+func removeCaptureFiles(jobID string) {
+	matches, _ := filepath.Glob(filepath.Join("temp", jobID+".*"))
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove capture file %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package queue
+
+// Crash-safe checkpointing — each completed pipeline stage is persisted so
+// that jobs interrupted by a crash or restart resume where they left off
+// instead of re-running normalization and transcription.
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// saveCheckpoint records that job has completed stage. Checkpointing is
+// best effort: a failure only means the job would restart from scratch.
+func (wp *WorkerPool) saveCheckpoint(job *Job, stage, normalizedPath, resultPath string) {
+	wp.heartbeat(job)
+	if wp.db == nil {
+		return
+	}
+
+	job.checkpoint = job.checkpointAt(stage, normalizedPath, resultPath)
+	if err := wp.db.SaveCheckpoint(job.checkpoint); err != nil {
+		log.Printf("Failed to checkpoint job %s at %s: %v", job.ID, stage, err)
+	}
+}
+
+// checkpointAt describes the job as having completed stage
+func (j *Job) checkpointAt(stage, normalizedPath, resultPath string) *storage.JobCheckpoint {
+	return &storage.JobCheckpoint{
+		JobID:          j.ID,
+		RequestName:    j.RequestName,
+		SourceType:     j.SourceType,
+		Metadata:       j.Metadata,
+		Labels:         j.Labels,
+		StartTime:      j.StartTime,
+		EndTime:        j.EndTime,
+		Priority:       j.Priority,
+		FormatProfile:  j.FormatProfile,
+		Destinations:   j.Destinations,
+		DriveFormats:   j.DriveFormats,
+		TextEncoding:   j.TextEncoding,
+		Language:       j.Language,
+		Task:           j.Task,
+		InitialPrompt:  j.InitialPrompt,
+		Vocabulary:     j.Vocabulary,
+		Decoding:       j.Decoding,
+		Model:          j.Model,
+		Denoise:        j.Denoise,
+		Speedup:        j.Speedup,
+		Diarize:        j.Diarize,
+		Speakers:       j.Speakers,
+		DualChannel:    j.DualChannel,
+		ChannelLabels:  j.ChannelLabels,
+		GroupID:        j.GroupID,
+		Truncated:      j.truncated,
+		Encrypted:      j.EncryptionKey != nil,
+		Stage:          stage,
+		SourcePath:     j.FilePath,
+		NormalizedPath: normalizedPath,
+		ResultPath:     resultPath,
+	}
+}
+
+// jobFromCheckpoint rebuilds a job from its persisted details
+func jobFromCheckpoint(cp *storage.JobCheckpoint) *Job {
+	return &Job{
+		ID:            cp.JobID,
+		RequestName:   cp.RequestName,
+		SourceType:    cp.SourceType,
+		FilePath:      cp.SourcePath,
+		Metadata:      cp.Metadata,
+		Labels:        cp.Labels,
+		StartTime:     cp.StartTime,
+		EndTime:       cp.EndTime,
+		Priority:      cp.Priority,
+		FormatProfile: cp.FormatProfile,
+		Destinations:  cp.Destinations,
+		DriveFormats:  cp.DriveFormats,
+		TextEncoding:  cp.TextEncoding,
+		Language:      cp.Language,
+		Task:          cp.Task,
+		InitialPrompt: cp.InitialPrompt,
+		Vocabulary:    cp.Vocabulary,
+		Decoding:      cp.Decoding,
+		Model:         cp.Model,
+		Denoise:       cp.Denoise,
+		Speedup:       cp.Speedup,
+		Diarize:       cp.Diarize,
+		Speakers:      cp.Speakers,
+		DualChannel:   cp.DualChannel,
+		ChannelLabels: cp.ChannelLabels,
+		GroupID:       cp.GroupID,
+		truncated:     cp.Truncated,
+	}
+}
+
+// clearCheckpoint drops the checkpoint of a job that has finished
+func (wp *WorkerPool) clearCheckpoint(job *Job) {
+	if wp.db == nil || job.checkpoint == nil {
+		return
+	}
+	if err := wp.db.ClearCheckpoint(job.ID); err != nil {
+		log.Printf("Failed to clear checkpoint of job %s: %v", job.ID, err)
+	}
+	job.checkpoint = nil
+}
+
+// resumableFrom reports whether the job's checkpoint has reached stage and
+// the file that stage produced is still on disk
+func (j *Job) resumableFrom(stage string) bool {
+	if !j.checkpoint.Reached(stage) {
+		return false
+	}
+
+	path := j.checkpoint.NormalizedPath
+	if stage == storage.StageTranscribed {
+		path = j.checkpoint.ResultPath
+	}
+	_, err := os.Stat(path)
+	return path != "" && err == nil
+}
+
+// Resume re-enqueues jobs that were queued or processing when the service
+// last stopped and fails downloads it interrupted. Call it after Start.
+func (wp *WorkerPool) Resume() (int, error) {
+	if wp.db == nil {
+		return 0, nil
+	}
+
+	if n, err := wp.db.FailInterruptedDownloads(); err != nil {
+		return 0, err
+	} else if n > 0 {
+		log.Printf("Marked %d interrupted downloads as failed", n)
+	}
+
+	checkpoints, err := wp.db.UnfinishedCheckpoints()
+	if err != nil {
+		return 0, err
+	}
+
+	var resumed int
+	for _, cp := range checkpoints {
+		job := jobFromCheckpoint(cp)
+		job.checkpoint = cp
+
+		switch {
+		case cp.Encrypted:
+			wp.abandon(job, fmt.Errorf("interrupted by restart; the encryption key is not retained, please resubmit"))
+			continue
+		case !job.resumableFrom(storage.StageTranscribed):
+			if _, err := os.Stat(cp.SourcePath); err != nil {
+				wp.abandon(job, fmt.Errorf("interrupted by restart and source audio is gone"))
+				continue
+			}
+		}
+
+		wp.EnqueueJob(job)
+		resumed++
+	}
+
+	if resumed > 0 {
+		log.Printf("Resumed %d unfinished jobs from checkpoints", resumed)
+	}
+	return resumed, nil
+}
+
+// abandon fails a checkpointed job that cannot be resumed and removes its files
+func (wp *WorkerPool) abandon(job *Job, err error) {
+	log.Printf("Cannot resume job %s: %v", job.ID, err)
+	for _, path := range []string{job.checkpoint.SourcePath, job.checkpoint.NormalizedPath, job.checkpoint.ResultPath} {
+		wp.cleanupTempFile(path)
+	}
+	wp.FailJob(job, err)
+	wp.clearCheckpoint(job)
+}
+
+// saveResult writes a transcription result checkpoint
+func saveResult(path string, result *types.TranscriptionResult) error {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, encoded, 0600)
+}
+
+// loadResult reads a transcription result checkpoint
+func loadResult(path string) (*types.TranscriptionResult, error) {
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var result types.TranscriptionResult
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
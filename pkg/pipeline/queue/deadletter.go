@@ -0,0 +1,166 @@
+package queue
+
+// Retries and the dead-letter list — a failed job is retried with backoff
+// up to its attempt limit, then recorded with its error and its source
+// audio set aside so an operator can inspect it and requeue it later.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// retryBaseDelay is the wait before a failed job's second attempt; later
+// attempts back off quadratically like the Drive upload
+const retryBaseDelay = 5 * time.Second
+
+// SetDeadLetter tries each job up to maxAttempts times (minimum 1) and, when
+// dir is set, moves the source audio of jobs that fail every attempt into
+// dir and records them on the dead-letter list
+func (wp *WorkerPool) SetDeadLetter(dir string, maxAttempts int) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create dead-letter directory: %v", err)
+		}
+	}
+	wp.deadLetterDir = dir
+	wp.maxAttempts = max(maxAttempts, 1)
+	return nil
+}
+
+// PermanentError fails a job that another attempt would fail the same way,
+// such as a recording that doesn't suit the job's options; such jobs are
+// not retried
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// retryLater puts a failed job back on the queue after a backoff, unless
+// it has used up its attempts or failed for good
+func (wp *WorkerPool) retryLater(job *Job) bool {
+	job.attempts++
+	if job.attempts >= wp.maxAttempts {
+		return false
+	}
+	// Another attempt won't make the audio any shorter or change its
+	// channels
+	var permanent *PermanentError
+	var tooLong *DurationError
+	if errors.As(job.Error, &permanent) || errors.As(job.Error, &tooLong) {
+		return false
+	}
+
+	delay := time.Duration(job.attempts*job.attempts) * retryBaseDelay
+	log.Printf("Job %s failed (attempt %d/%d), retrying in %s: %v",
+		job.ID, job.attempts, wp.maxAttempts, delay, job.Error)
+
+	// The attempt's intermediate files are gone, so start over from the source
+	wp.saveCheckpoint(job, storage.StageQueued, "", "")
+	job.Status = types.StatusQueued
+	job.Error = nil
+	job.stack = ""
+	wp.eta.finished(job.ID)
+	wp.eta.queued(job.ID, job.Priority)
+	wp.recordStatus(job)
+	wp.requeueLater(job, delay)
+	return true
+}
+
+// deadLetter records a job that failed for good. Its source audio is kept
+// for a requeue unless the job was encrypted, whose audio is never retained.
+func (wp *WorkerPool) deadLetter(job *Job, encrypted bool) {
+	if wp.deadLetterDir == "" || wp.db == nil {
+		wp.cleanupTempFile(job.FilePath)
+		return
+	}
+
+	dead := &storage.DeadJob{
+		JobID:       job.ID,
+		RequestName: job.RequestName,
+		SourceType:  job.SourceType,
+		Stack:       job.stack,
+		Attempts:    max(job.attempts, 1),
+		FailedAt:    time.Now(),
+		Job:         job.checkpointAt(storage.StageQueued, "", ""),
+	}
+	dead.Job.Encrypted = encrypted
+	if job.Error != nil {
+		dead.Error = job.Error.Error()
+	}
+
+	if encrypted {
+		wp.cleanupTempFile(job.FilePath)
+	} else {
+		kept := filepath.Join(wp.deadLetterDir, job.ID+filepath.Ext(job.FilePath))
+		if err := storage.MoveFile(job.FilePath, kept); err != nil {
+			log.Printf("Could not keep source audio of dead job %s: %v", job.ID, err)
+			wp.cleanupTempFile(job.FilePath)
+		} else {
+			dead.SourcePath = kept
+		}
+	}
+	dead.Job.SourcePath = dead.SourcePath
+
+	if err := wp.db.SaveDeadJob(dead); err != nil {
+		log.Printf("Failed to dead-letter job %s: %v", job.ID, err)
+		return
+	}
+	log.Printf("Job %s dead-lettered after %d attempt(s): %s", job.ID, dead.Attempts, dead.Error)
+}
+
+// RequeueDead puts dead-lettered jobs back on the queue under their
+// original IDs. It returns the IDs requeued and why any others were not.
+func (wp *WorkerPool) RequeueDead(jobIDs []string) ([]string, map[string]error) {
+	requeued := []string{}
+	failures := make(map[string]error)
+	for _, id := range jobIDs {
+		if err := wp.requeueDead(id); err != nil {
+			failures[id] = err
+			continue
+		}
+		requeued = append(requeued, id)
+	}
+	return requeued, failures
+}
+
+// requeueDead restores one dead job's source audio and enqueues it
+func (wp *WorkerPool) requeueDead(jobID string) error {
+	if wp.db == nil {
+		return fmt.Errorf("no metadata database")
+	}
+	dead, err := wp.db.GetDeadJob(jobID)
+	if err != nil {
+		return err
+	}
+	if dead.SourcePath == "" {
+		return fmt.Errorf("source audio was not retained")
+	}
+
+	job := jobFromCheckpoint(dead.Job)
+	job.FilePath = filepath.Join("temp", job.ID+filepath.Ext(dead.SourcePath))
+	if err := storage.MoveFile(dead.SourcePath, job.FilePath); err != nil {
+		return fmt.Errorf("failed to restore source audio: %v", err)
+	}
+	if err := wp.db.DeleteDeadJob(jobID); err != nil {
+		storage.MoveFile(job.FilePath, dead.SourcePath)
+		return err
+	}
+
+	wp.EnqueueJob(job)
+	log.Printf("Dead job %s requeued", jobID)
+	return nil
+}
@@ -0,0 +1,47 @@
+package queue
+
+// Duplicate submission detection — live jobs claim a key identifying their
+// source (Drive file ID, YouTube video, upload checksum) so an identical
+// submission made while the first is still in flight can reuse it.
+
+import "sync"
+
+// sourceClaims maps source keys to the live job holding them
+type sourceClaims struct {
+	mu     sync.Mutex
+	claims map[string]string
+}
+
+func newSourceClaims() *sourceClaims {
+	return &sourceClaims{claims: make(map[string]string)}
+}
+
+// ClaimSource registers job as the live job for key. If another live job
+// already holds key, its ID is returned with ok=false and nothing changes.
+// The claim is released when the job finishes, fails, or is cancelled.
+func (wp *WorkerPool) ClaimSource(key string, job *Job) (existingID string, ok bool) {
+	wp.sources.mu.Lock()
+	defer wp.sources.mu.Unlock()
+
+	if id, held := wp.sources.claims[key]; held {
+		return id, false
+	}
+	wp.sources.claims[key] = job.ID
+	job.sourceKey = key
+	return "", true
+}
+
+// ReleaseSource drops a job's source claim; handlers call it when a job is
+// abandoned before it reaches the queue
+func (wp *WorkerPool) ReleaseSource(job *Job) {
+	if job.sourceKey == "" {
+		return
+	}
+
+	wp.sources.mu.Lock()
+	if wp.sources.claims[job.sourceKey] == job.ID {
+		delete(wp.sources.claims, job.sourceKey)
+	}
+	wp.sources.mu.Unlock()
+	job.sourceKey = ""
+}
@@ -0,0 +1,89 @@
+package queue
+
+// Per-job output destinations — a job may name configured destinations
+// that replace the global output directory and/or Drive folder for its
+// transcripts. Only destinations the operator has set up can be chosen.
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+)
+
+// SetDestinations sets the named destinations jobs may choose from
+func (wp *WorkerPool) SetDestinations(destinations map[string]storage.Destination) error {
+	for name, dest := range destinations {
+		if err := dest.Validate(name); err != nil {
+			return err
+		}
+	}
+	wp.destinations = destinations
+	return nil
+}
+
+// CheckDestinations reports whether a job may use the named destinations:
+// each must be configured, at most one of each type may be chosen, and
+// Drive destinations need the Drive integration
+func (wp *WorkerPool) CheckDestinations(names []string) error {
+	seen := make(map[string]string)
+	for _, name := range names {
+		dest, ok := wp.destinations[name]
+		if !ok {
+			return fmt.Errorf("unknown destination %q (available: %s)", name, strings.Join(wp.destinationNames(), ", "))
+		}
+		if other, dup := seen[dest.Type]; dup {
+			return fmt.Errorf("destinations %q and %q are both %s; choose one", other, name, dest.Type)
+		}
+		if dest.Type == storage.DestinationGDrive && wp.driveClient == nil {
+			return fmt.Errorf("destination %q needs Google Drive, which is not configured", name)
+		}
+		seen[dest.Type] = name
+	}
+	return nil
+}
+
+// destinationNames lists the configured destinations, sorted
+func (wp *WorkerPool) destinationNames() []string {
+	names := make([]string, 0, len(wp.destinations))
+	for name := range wp.destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jobStorage returns where a job's artifacts are saved locally and the
+// save options for its Drive upload, applying its destinations and Drive
+// formats, text encoding, and time zone
+func (wp *WorkerPool) jobStorage(job *Job, opts storage.SaveOptions) (*storage.LocalStorage, storage.SaveOptions) {
+	opts.DriveFormats = wp.driveFormats
+	if job.DriveFormats != nil {
+		opts.DriveFormats = job.DriveFormats
+	}
+	opts.TextEncoding = wp.textEncoding
+	if job.TextEncoding != nil {
+		opts.TextEncoding = *job.TextEncoding
+	}
+	opts.Location = wp.location(job)
+
+	local := wp.localStorage
+	for _, name := range job.Destinations {
+		// A destination removed from the config since the job was queued
+		// (e.g. across a restart) falls back to the defaults
+		dest, ok := wp.destinations[name]
+		if !ok {
+			log.Printf("Job %s: destination %q is no longer configured, using the default", job.ID, name)
+			continue
+		}
+		switch dest.Type {
+		case storage.DestinationLocal:
+			local = storage.NewLocalStorage(dest.Dir)
+		case storage.DestinationGDrive:
+			opts.DriveFolderID = dest.FolderID
+		}
+	}
+	return local, opts
+}
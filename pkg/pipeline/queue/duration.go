@@ -0,0 +1,71 @@
+package queue
+
+// Duration limit — limits.max_duration_minutes caps how much audio a job
+// may transcribe. Sources are probed with ffprobe: over-long audio is
+// rejected, or with truncation on, cut to the limit as if the job had
+// asked for that end time. Sources fetched in the request are checked
+// there (see CheckDuration); every job is checked again by its worker.
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+)
+
+// DurationError rejects audio longer than the limit
+type DurationError struct {
+	Duration time.Duration
+	Limit    time.Duration
+}
+
+func (e *DurationError) Error() string {
+	return fmt.Sprintf("audio is %s long, over the %s limit", e.Duration.Round(time.Second), e.Limit)
+}
+
+// SetMaxDuration limits the audio a job transcribes (zero means no limit).
+// Longer audio is cut to the limit when truncate is set, else rejected.
+func (wp *WorkerPool) SetMaxDuration(limit time.Duration, truncate bool) {
+	wp.maxDuration = limit
+	wp.truncateLong = truncate
+}
+
+// MaxDuration returns the limit set with SetMaxDuration and whether
+// longer audio is truncated
+func (wp *WorkerPool) MaxDuration() (time.Duration, bool) {
+	return wp.maxDuration, wp.truncateLong
+}
+
+// CheckDuration returns a *DurationError when the part of the probed audio
+// the job would transcribe is over the limit and is not to be truncated
+func (wp *WorkerPool) CheckDuration(info *transcription.AudioInfo, job *Job) error {
+	if wp.maxDuration <= 0 || wp.truncateLong {
+		return nil
+	}
+	duration := time.Duration(trimmedDuration(info, job) * float64(time.Second))
+	if duration > wp.maxDuration {
+		return &DurationError{Duration: duration, Limit: wp.maxDuration}
+	}
+	return nil
+}
+
+// enforceDuration rejects or truncates a job's over-long audio. Without a
+// probe there is nothing to go on, and the job runs as submitted.
+func (wp *WorkerPool) enforceDuration(info *transcription.AudioInfo, job *Job) error {
+	if wp.maxDuration <= 0 || info == nil {
+		return nil
+	}
+	if err := wp.CheckDuration(info, job); err != nil {
+		return err
+	}
+	limit := wp.maxDuration.Seconds()
+	if trimmedDuration(info, job) <= limit {
+		return nil
+	}
+	log.Printf("Job %s: audio runs past the %s limit; transcribing only the first %s",
+		job.ID, wp.maxDuration, wp.maxDuration)
+	job.EndTime = job.StartTime + limit
+	job.truncated = true
+	return nil
+}
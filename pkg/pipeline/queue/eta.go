@@ -0,0 +1,224 @@
+package queue
+
+// Completion estimates — projects when each queued or processing job will
+// finish from the model's measured realtime factor and the work ahead of it
+// in the queue.
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+)
+
+const (
+	// defaultRealtimeFactor is the assumed compute seconds per audio second
+	// until the model has finished a job
+	defaultRealtimeFactor = 0.5
+
+	// defaultAudioSeconds is the assumed length of audio not yet probed
+	defaultAudioSeconds = 300
+
+	// realtimeSample is how many recent transcripts seed the realtime factor
+	realtimeSample = 50
+
+	// realtimeSmoothing weights each newly finished job in the running factor
+	realtimeSmoothing = 0.2
+)
+
+// etaEntry is the estimator's view of one queued or processing job
+type etaEntry struct {
+	seq          uint64    // enqueue order
+	priority     int       // higher is picked up first
+	audioSeconds float64   // 0 until the source is probed
+	startedAt    time.Time // zero while queued
+	progress     float64   // 0-100 within processing, if reported
+}
+
+// etaEstimator tracks queued and processing jobs and the model's speed
+type etaEstimator struct {
+	mu       sync.Mutex
+	factor   float64 // compute seconds per audio second
+	avgAudio float64 // typical audio length, for jobs not yet probed
+	seq      uint64
+	jobs     map[string]*etaEntry
+}
+
+// newETAEstimator seeds the realtime factor from the model's recent history
+func newETAEstimator(db *storage.MetadataDB, model string) *etaEstimator {
+	e := &etaEstimator{
+		factor:   defaultRealtimeFactor,
+		avgAudio: defaultAudioSeconds,
+		jobs:     make(map[string]*etaEntry),
+	}
+	if db == nil {
+		return e
+	}
+
+	factor, avgAudio, ok, err := db.RealtimeStats(model, realtimeSample)
+	if err != nil {
+		log.Printf("Could not load realtime factor for %s: %v", model, err)
+	} else if ok {
+		e.factor, e.avgAudio = factor, avgAudio
+		log.Printf("Realtime factor for %s: %.3f (avg audio %.0fs)", model, factor, avgAudio)
+	}
+	return e
+}
+
+// queued starts tracking a job waiting for a worker
+func (e *etaEstimator) queued(jobID string, priority int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.jobs[jobID]; ok {
+		return // requeued behind a tenant cap; keep its place
+	}
+	e.seq++
+	e.jobs[jobID] = &etaEntry{seq: e.seq, priority: priority}
+}
+
+// started marks a job as processing; audioSeconds is 0 if the probe failed
+func (e *etaEstimator) started(jobID string, audioSeconds float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if entry, ok := e.jobs[jobID]; ok {
+		entry.startedAt = time.Now()
+		entry.audioSeconds = audioSeconds
+	}
+}
+
+// progressed records a processing job's reported progress (0-100)
+func (e *etaEstimator) progressed(jobID string, progress float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if entry, ok := e.jobs[jobID]; ok && !entry.startedAt.IsZero() {
+		entry.progress = progress
+	}
+}
+
+// before reports whether a is picked up ahead of b
+func (a *etaEntry) before(b *etaEntry) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+
+// finished stops tracking a job
+func (e *etaEstimator) finished(jobID string) {
+	e.mu.Lock()
+	delete(e.jobs, jobID)
+	e.mu.Unlock()
+}
+
+// observe folds a completed job's measured speed into the running factor
+func (e *etaEstimator) observe(computeSeconds, audioSeconds float64) {
+	if computeSeconds <= 0 || audioSeconds <= 0 {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.factor += realtimeSmoothing * (computeSeconds/audioSeconds - e.factor)
+	e.avgAudio += realtimeSmoothing * (audioSeconds - e.avgAudio)
+}
+
+// cost is the expected compute time of a job from start to finish
+func (e *etaEstimator) cost(entry *etaEntry) float64 {
+	audio := entry.audioSeconds
+	if audio <= 0 {
+		audio = e.avgAudio
+	}
+	return audio * e.factor
+}
+
+// remaining is the expected compute time a job still needs
+func (e *etaEstimator) remaining(entry *etaEntry, now time.Time) float64 {
+	total := e.cost(entry)
+	if entry.startedAt.IsZero() {
+		return total
+	}
+	elapsed := now.Sub(entry.startedAt).Seconds()
+	if entry.progress > 0 {
+		total = elapsed * 100 / entry.progress
+	}
+	if total < elapsed {
+		return 0
+	}
+	return total - elapsed
+}
+
+// estimate returns how long until a job finishes: processing jobs occupy
+// their workers for their remaining time, and queued jobs are handed in
+// order to whichever worker frees up first
+func (e *etaEstimator) estimate(jobID string, workers int) (time.Duration, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	target, ok := e.jobs[jobID]
+	if !ok {
+		return 0, false
+	}
+	now := time.Now()
+	if !target.startedAt.IsZero() {
+		return seconds(e.remaining(target, now)), true
+	}
+
+	var free []float64 // when each worker next becomes free
+	var ahead []*etaEntry
+	for _, entry := range e.jobs {
+		if !entry.startedAt.IsZero() {
+			free = append(free, e.remaining(entry, now))
+		} else if entry.before(target) {
+			ahead = append(ahead, entry)
+		}
+	}
+	for len(free) < workers {
+		free = append(free, 0)
+	}
+	sort.Slice(ahead, func(i, j int) bool { return ahead[i].before(ahead[j]) })
+
+	for _, entry := range append(ahead, target) {
+		sort.Float64s(free)
+		free[0] += e.cost(entry)
+	}
+	return seconds(free[0]), true
+}
+
+// position returns a queued job's 1-based place in line (0 once processing)
+func (e *etaEstimator) position(jobID string) (int, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	target, ok := e.jobs[jobID]
+	if !ok {
+		return 0, false
+	}
+	if !target.startedAt.IsZero() {
+		return 0, true
+	}
+	position := 1
+	for _, entry := range e.jobs {
+		if entry.startedAt.IsZero() && entry.before(target) {
+			position++
+		}
+	}
+	return position, true
+}
+
+// seconds converts fractional seconds to a Duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// QueuePosition returns a queued job's 1-based place in line, or 0 once a
+// worker has picked it up; ok is false for jobs that are not in the queue
+func (wp *WorkerPool) QueuePosition(jobID string) (int, bool) {
+	return wp.eta.position(jobID)
+}
+
+// EstimateCompletion returns how long until a queued or processing job is
+// expected to finish; ok is false for jobs that are not in the queue
+func (wp *WorkerPool) EstimateCompletion(jobID string) (time.Duration, bool) {
+	return wp.eta.estimate(jobID, wp.workerCount)
+}
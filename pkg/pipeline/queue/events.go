@@ -0,0 +1,146 @@
+package queue
+
+// Job event stream — fans status transitions and phase progress out to
+// live subscribers (the /jobs/:id/events SSE endpoint) without ever
+// blocking a worker on a slow reader.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Event types
+const (
+	EventStatus   = "status"
+	EventProgress = "progress"
+)
+
+// Progress phases
+const (
+	PhaseDownload   = "download"
+	PhaseNormalize  = "normalize"
+	PhaseTranscribe = "transcribe"
+)
+
+// JobEvent is one state transition or progress update of a job
+type JobEvent struct {
+	Type     string    `json:"type"`
+	JobID    string    `json:"job_id"`
+	Status   string    `json:"status,omitempty"`
+	Phase    string    `json:"phase,omitempty"`
+	Progress float64   `json:"progress,omitempty"` // 0-100 within Phase
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Final reports whether the event ends the job's stream
+func (e JobEvent) Final() bool {
+	if e.Type != EventStatus {
+		return false
+	}
+	return e.Status == types.StatusCompleted || e.Status == types.StatusFailed ||
+		e.Status == types.StatusCancelled
+}
+
+// subscriberBuffer is how many undelivered events a subscriber may lag
+// behind before the oldest are dropped
+const subscriberBuffer = 32
+
+// eventBroker tracks the subscribers of each job
+type eventBroker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan JobEvent]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subs: make(map[string]map[chan JobEvent]struct{})}
+}
+
+// subscribe returns a channel of the job's future events, closed after its
+// final status; the returned func unsubscribes early
+func (b *eventBroker) subscribe(jobID string) (<-chan JobEvent, func()) {
+	ch := make(chan JobEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[jobID] == nil {
+		b.subs[jobID] = make(map[chan JobEvent]struct{})
+	}
+	b.subs[jobID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[jobID][ch]; ok {
+			delete(b.subs[jobID], ch)
+			if len(b.subs[jobID]) == 0 {
+				delete(b.subs, jobID)
+			}
+			close(ch)
+		}
+	}
+}
+
+// publish delivers an event to the job's subscribers, dropping a lagging
+// subscriber's oldest event rather than waiting; a final event closes
+// every subscription
+func (b *eventBroker) publish(event JobEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subs[event.JobID]
+	for ch := range subs {
+		select {
+		case ch <- event:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+	if event.Final() {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(b.subs, event.JobID)
+	}
+}
+
+// SubscribeJob streams a job's status transitions and progress. The channel
+// is closed after the job's final status; call the returned func to stop
+// listening sooner.
+func (wp *WorkerPool) SubscribeJob(jobID string) (<-chan JobEvent, func()) {
+	return wp.events.subscribe(jobID)
+}
+
+// publishStatus announces a job's current status
+func (wp *WorkerPool) publishStatus(job *Job) {
+	event := JobEvent{
+		Type:   EventStatus,
+		JobID:  job.ID,
+		Status: job.Status,
+		Time:   time.Now(),
+	}
+	if job.Error != nil {
+		event.Error = job.Error.Error()
+	}
+	wp.events.publish(event)
+}
+
+// publishProgress announces how far a job's current phase has got
+func (wp *WorkerPool) publishProgress(job *Job, phase string, progress float64) {
+	wp.events.publish(JobEvent{
+		Type:     EventProgress,
+		JobID:    job.ID,
+		Phase:    phase,
+		Progress: progress,
+		Time:     time.Now(),
+	})
+}
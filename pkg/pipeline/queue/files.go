@@ -0,0 +1,85 @@
+package queue
+
+// Live temp file tracking — jobs hold their temp files from download until
+// the worker finishes, so the cleanup scheduler never deletes input that a
+// queued, downloading, or processing job still needs.
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fileHolds is a reference-counted set of job IDs and paths in use. A job ID
+// covers every temp file whose name starts with it (<id>.mp3, <id>.opus.part).
+type fileHolds struct {
+	mu    sync.Mutex
+	holds map[string]int
+}
+
+func newFileHolds() *fileHolds {
+	return &fileHolds{holds: make(map[string]int)}
+}
+
+// hold marks key as in use until the returned release function is called
+func (f *fileHolds) hold(key string) func() {
+	f.mu.Lock()
+	f.holds[key]++
+	f.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.holds[key]--; f.holds[key] <= 0 {
+				delete(f.holds, key)
+			}
+		})
+	}
+}
+
+// inUse reports whether path is held directly or belongs to a held job ID
+func (f *fileHolds) inUse(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.holds[filepath.Clean(path)] > 0 {
+		return true
+	}
+	base := filepath.Base(path)
+	for key := range f.holds {
+		if strings.HasPrefix(base, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldFiles protects a job's temp files from cleanup while a handler is
+// still writing them; call the returned function once the job is enqueued
+// (or abandoned)
+func (wp *WorkerPool) HoldFiles(jobID string) func() {
+	return wp.files.hold(jobID)
+}
+
+// FileInUse reports whether a temp file belongs to live work; the cleanup
+// scheduler skips such files
+func (wp *WorkerPool) FileInUse(path string) bool {
+	return wp.files.inUse(path)
+}
+
+// holdJobFiles protects a job's temp files until releaseJobFiles is called
+func (wp *WorkerPool) holdJobFiles(job *Job) {
+	if job.releaseFiles == nil {
+		job.releaseFiles = wp.files.hold(job.ID)
+	}
+}
+
+// releaseJobFiles ends the job's hold on its temp files
+func (wp *WorkerPool) releaseJobFiles(job *Job) {
+	if job.releaseFiles != nil {
+		job.releaseFiles()
+		job.releaseFiles = nil
+	}
+}
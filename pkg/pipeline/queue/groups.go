@@ -0,0 +1,76 @@
+package queue
+
+// Job groups — a submission that expands into many jobs records them as a
+// group (see storage.JobGroup). When the last of them finishes, a single
+// group.completed webhook sums up the batch, so receivers need not count
+// the jobs' own notifications.
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// NewGroup records jobs that are about to be submitted as a group and sets
+// their GroupID. Call it before the jobs are tracked or enqueued.
+func (wp *WorkerPool) NewGroup(requestName, sourceType string, jobs []*Job) (string, error) {
+	if wp.db == nil {
+		return "", fmt.Errorf("job groups need the metadata database")
+	}
+	groupID := uuid.New().String()
+	ids := make([]string, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	if err := wp.db.CreateJobGroup(groupID, requestName, sourceType, ids); err != nil {
+		return "", err
+	}
+	for _, job := range jobs {
+		job.GroupID = groupID
+	}
+	log.Printf("Job group %s created with %d jobs (source: %s, name: %s)", groupID, len(jobs), sourceType, requestName)
+	return groupID, nil
+}
+
+// groupJobFinished checks, once one of a group's jobs has finished, whether
+// it was the last, and if so sends the group's webhook
+func (wp *WorkerPool) groupJobFinished(job *Job) {
+	if job.GroupID == "" || wp.db == nil {
+		return
+	}
+	group, err := wp.db.GetJobGroup(job.GroupID)
+	if err != nil {
+		log.Printf("Failed to check job group %s of job %s: %v", job.GroupID, job.ID, err)
+		return
+	}
+	if !group.Finished() || group.FinishedAt != nil {
+		return
+	}
+	// Two jobs finishing at once may both see the group done; one claims it
+	claimed, err := wp.db.FinishJobGroup(group.GroupID)
+	if err != nil {
+		log.Printf("Failed to finish job group %s: %v", group.GroupID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	log.Printf("Job group %s finished: %s (%d jobs)", group.GroupID, group.Status, len(group.Jobs))
+
+	if wp.webhooks == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"group_id":     group.GroupID,
+		"request_name": group.RequestName,
+		"source_type":  group.SourceType,
+		"status":       group.Status,
+		"counts":       group.Counts,
+		"jobs":         group.Jobs,
+	}
+	if err := wp.webhooks.Notify(webhooks.EventGroupCompleted, payload); err != nil {
+		log.Printf("Failed to queue webhook for job group %s: %v", group.GroupID, err)
+	}
+}
@@ -0,0 +1,147 @@
+package queue
+
+// Worker heartbeats — a worker beats when it picks up a job and whenever
+// the job makes progress. A worker whose job goes quiet for longer than the
+// stall timeout is given up on: its job is requeued (or failed, if it keeps
+// stalling), an alert goes out, and a replacement worker takes its slot.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// maxStalls is how many times a job may stall before it is failed instead
+// of requeued
+const maxStalls = 2
+
+// errStalled is the outcome of an attempt the heartbeat monitor gave up on
+var errStalled = errors.New("worker stalled; the job was handed to another worker")
+
+// SetStallTimeout enables the heartbeat monitor: a job that makes no
+// progress for timeout is requeued on a fresh worker. Call before Start.
+func (wp *WorkerPool) SetStallTimeout(timeout time.Duration) {
+	wp.stallTimeout = timeout
+}
+
+// heartbeat records that job is still making progress
+func (wp *WorkerPool) heartbeat(job *Job) {
+	wp.stats.touch(job)
+}
+
+// monitorHeartbeats checks for stalled workers until the process exits
+func (wp *WorkerPool) monitorHeartbeats() {
+	interval := min(max(wp.stallTimeout/4, time.Second), time.Minute)
+	for range time.Tick(interval) {
+		for _, stall := range wp.stats.abandonStalled(wp.stallTimeout) {
+			wp.recoverStalled(stall)
+		}
+	}
+}
+
+// keepPickedUp records the job as a worker picks it up, with its own key
+// and maps, for a retry should the attempt stall. The worker calls it
+// before the monitor can see the job, so the monitor never reads fields
+// the attempt is writing.
+func (wp *WorkerPool) keepPickedUp(job *Job) {
+	if wp.stallTimeout <= 0 {
+		return
+	}
+	picked := *job
+	picked.EncryptionKey = bytes.Clone(job.EncryptionKey)
+	picked.Metadata = maps.Clone(job.Metadata)
+	picked.Labels = maps.Clone(job.Labels)
+	picked.Result = nil
+	picked.handedTo = nil
+	picked.pickedUp = nil
+	job.pickedUp = &picked
+}
+
+// dropPickedUp wipes the copy kept by keepPickedUp once the attempt has
+// ended without stalling; the monitor can no longer take it
+func dropPickedUp(job *Job) {
+	if job.pickedUp != nil {
+		wipeKey(job.pickedUp)
+		job.pickedUp = nil
+	}
+}
+
+// tempPath names one of the job's intermediate files in temp/. A retry
+// after a stall gets names of its own: the stuck attempt may still write
+// its files, and removes them when (if ever) it returns.
+func (j *Job) tempPath(suffix string) string {
+	name := j.ID
+	if j.stalls > 0 {
+		name = fmt.Sprintf("%s_retry%d", j.ID, j.stalls)
+	}
+	return filepath.Join("temp", name+suffix)
+}
+
+// recoverStalled hands a stalled worker's job to the queue and replaces
+// the worker. The stuck goroutine keeps the original job and discards its
+// outcome when (if ever) it returns; the retry is the job as it was picked
+// up, so nothing the stuck attempt writes is read here.
+func (wp *WorkerPool) recoverStalled(stall stalledWorker) {
+	job := stall.job
+	quiet := time.Since(stall.lastBeat).Round(time.Second)
+	retry := job.pickedUp
+	if wp.tenants != nil {
+		wp.tenants.release(retry.Labels[storage.TenantLabel])
+	}
+
+	retry.stalls++
+	job.handedTo = retry
+	encrypted := retry.EncryptionKey != nil
+
+	action := "requeued"
+	if retry.stalls >= maxStalls {
+		action = "failed"
+	}
+	log.Printf("Worker %d: no heartbeat for %s while processing job %s; job %s, starting a replacement worker",
+		stall.worker, quiet, job.ID, action)
+	wp.alertStalled(stall, quiet, action)
+
+	go wp.worker(stall.worker)
+
+	wp.eta.finished(job.ID)
+	if action == "failed" {
+		retry.Status = types.StatusFailed
+		retry.Error = fmt.Errorf("Worker stalled %d times (no progress for %s)", retry.stalls, quiet)
+		wp.finish(retry, encrypted)
+		return
+	}
+
+	// Intermediate files may belong to the stuck attempt, so start over
+	wp.saveCheckpoint(retry, storage.StageQueued, "", "")
+	retry.Status = types.StatusQueued
+	retry.Error = nil
+	wp.eta.queued(retry.ID, retry.Priority)
+	wp.recordStatus(retry)
+	wp.jobQueue.push(retry)
+}
+
+// alertStalled sends a worker.stalled webhook
+func (wp *WorkerPool) alertStalled(stall stalledWorker, quiet time.Duration, action string) {
+	if wp.webhooks == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"worker":         stall.worker,
+		"job_id":         stall.job.ID,
+		"request_name":   stall.job.RequestName,
+		"last_heartbeat": stall.lastBeat.UTC().Format(time.RFC3339),
+		"quiet_seconds":  quiet.Seconds(),
+		"action":         action,
+	}
+	if err := wp.webhooks.Notify(webhooks.EventWorkerStalled, payload); err != nil {
+		log.Printf("Failed to queue stall alert for job %s: %v", stall.job.ID, err)
+	}
+}
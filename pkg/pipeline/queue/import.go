@@ -0,0 +1,38 @@
+package queue
+
+// Transcript import — records transcripts made elsewhere as completed jobs,
+// running the same save steps as the pipeline but skipping transcription.
+
+import (
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// ImportTranscript stores an already-transcribed result as a completed job.
+// sourceSHA256 is the checksum of the accompanying audio, if any.
+func (wp *WorkerPool) ImportTranscript(job *Job, result *types.TranscriptionResult, sourceSHA256 string) error {
+	defer wipeKey(job)
+	job.Status = types.StatusProcessing
+	job.CreatedAt = time.Now()
+	wp.recordStatus(job)
+
+	result.JobID = job.ID
+	result.WordCount = len(strings.Fields(result.Text))
+	result.ProcessedAt = time.Now()
+	result.Metadata = job.Metadata
+	result.Labels = job.Labels
+
+	if err := wp.persist("Import", job, result, sourceSHA256, ""); err != nil {
+		job.Status = types.StatusFailed
+		job.Error = err
+	} else {
+		job.Result = result
+		job.Status = types.StatusCompleted
+	}
+
+	wp.recordStatus(job)
+	wp.notifyFinished(job)
+	return job.Error
+}
@@ -0,0 +1,181 @@
+// Package queue implements a concurrent worker pool for processing
+// transcription jobs with configurable parallelism.
+package queue
+
+import (
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Job represents a transcription job
+type Job struct {
+	ID          string
+	RequestName string
+	SourceType  string
+	FilePath    string
+	Status      string
+	Error       error
+	Result      *types.TranscriptionResult
+	CreatedAt   time.Time
+
+	// Metadata holds arbitrary client-supplied fields (customer id, case
+	// number, ...) that are stored with the transcript and returned as-is.
+	Metadata map[string]interface{}
+
+	// Labels are indexed key=value pairs used for filtering and usage stats
+	Labels map[string]string
+
+	// EncryptionKey is a client-supplied AES-256 key used to seal this job's
+	// artifacts. It lives only in memory and is wiped when the job finishes.
+	EncryptionKey []byte
+
+	// StartTime/EndTime (seconds) restrict transcription to a section of
+	// the recording; zero means from the beginning / to the end
+	StartTime float64
+	EndTime   float64
+
+	// Priority orders the queue: PriorityHigh jobs are picked up before
+	// PriorityNormal and PriorityLow ones
+	Priority int
+
+	// FormatProfile names the transcription.FormatProfile applied to the
+	// transcript; empty uses the pool default (see SetFormatProfile)
+	FormatProfile string
+
+	// Destinations names configured output locations that replace the
+	// default output directory and Drive folder (see destinations.go)
+	Destinations []string
+
+	// DriveFormats overrides which extra renderings are uploaded to Drive
+	// (see SetDriveFormats); nil uses the pool default
+	DriveFormats []string
+
+	// TextEncoding overrides the BOM and line endings of text artifacts
+	// (see SetTextEncoding); nil uses the pool default
+	TextEncoding *storage.TextEncoding
+
+	// Language is the spoken language passed to the transcriber (e.g.
+	// "de"); empty or transcription.LanguageAuto detects it
+	Language string
+
+	// Task is transcription.TaskTranslate for an English translation;
+	// empty transcribes
+	Task string
+
+	// InitialPrompt and Vocabulary steer the model towards the recording's
+	// jargon (see transcription.DecodeOptions)
+	InitialPrompt string
+	Vocabulary    []string
+
+	// Decoding overrides the configured decoding parameters
+	Decoding types.DecodingParams
+
+	// Model is the whisper model size to use; empty for the configured one
+	Model string
+
+	// Denoise passes the audio through the noise reduction filter while it
+	// is normalized (see SetDenoise)
+	Denoise bool
+
+	// Speedup plays the audio that many times faster to whisper; zero
+	// takes the pool's default and 1 keeps the recorded speed (see
+	// SetSpeedup)
+	Speedup float64
+
+	// Diarize labels the transcript's segments with their speakers, of
+	// whom there are Speakers if the submitter knew (see
+	// transcription.WhisperTranscriber.Diarize)
+	Diarize  bool
+	Speakers int
+
+	// DualChannel transcribes each channel of a stereo call on its own and
+	// merges them, labelling speakers with ChannelLabels (see
+	// transcription.MergeChannels)
+	DualChannel   bool
+	ChannelLabels []string
+
+	// GroupID is the job group the job belongs to, if any (see groups.go)
+	GroupID string
+
+	// ExpiresAt is when a job still fetching its source is failed; zero
+	// without a capture TTL (see captures.go)
+	ExpiresAt time.Time
+
+	// queueSeq is the job's arrival order within its priority and
+	// enqueuedAt when it arrived (see priority.go)
+	queueSeq   uint64
+	enqueuedAt time.Time
+
+	// releaseFiles ends the job's hold on its temp files (see files.go)
+	releaseFiles func()
+
+	// checkpoint is the job's last persisted pipeline stage (see checkpoint.go)
+	checkpoint *storage.JobCheckpoint
+
+	// sourceKey is the duplicate-detection claim held by this job (see dedupe.go)
+	sourceKey string
+
+	// done is closed once the job reaches a final status (see Done)
+	done chan struct{}
+
+	// attempts counts failed processing attempts; stack is the panic stack
+	// of the last one, if it panicked (see deadletter.go)
+	attempts int
+	stack    string
+
+	// stalls counts attempts abandoned by the heartbeat monitor, and
+	// handedTo is the copy this attempt was requeued as. pickedUp is the
+	// job as a worker picked it up, which the copy is made from: a stalled
+	// attempt may still be writing the job itself (see heartbeat.go).
+	stalls   int
+	handedTo *Job
+	pickedUp *Job
+
+	// truncated is set when the job's EndTime was moved in to fit the
+	// duration limit (see duration.go)
+	truncated bool
+
+	// captureExpired is set, under the capture registry's lock, once the
+	// capture TTL failed the job (see captures.go)
+	captureExpired bool
+}
+
+// Done returns a channel closed when the job completes, fails, or is
+// cancelled. Call it before the job is enqueued.
+func (j *Job) Done() <-chan struct{} {
+	if j.done == nil {
+		j.done = make(chan struct{})
+	}
+	return j.done
+}
+
+// Final returns the attempt that took the job to its final status: the job
+// itself, or the copy it was requeued as if its worker stalled. Call it
+// once Done is closed.
+func (j *Job) Final() *Job {
+	for j.handedTo != nil {
+		j = j.handedTo
+	}
+	return j
+}
+
+// markDone wakes anyone waiting on Done
+func (j *Job) markDone() {
+	if j.done != nil {
+		close(j.done)
+	}
+}
+
+// NewJob creates a new job with default values
+func NewJob(id, requestName, sourceType, filePath string) *Job {
+	return &Job{
+		ID:          id,
+		RequestName: requestName,
+		SourceType:  sourceType,
+		FilePath:    filePath,
+		Status:      types.StatusQueued,
+		CreatedAt:   time.Now(),
+	}
+}
@@ -0,0 +1,130 @@
+package queue
+
+// Job priorities — a bounded priority queue that hands workers the most
+// urgent job first (e.g. short live recordings ahead of long YouTube
+// backfills), first-in first-out within a priority.
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority levels; higher runs first
+const (
+	PriorityLow    = -1
+	PriorityNormal = 0
+	PriorityHigh   = 1
+)
+
+// ParsePriority converts "low", "normal", or "high" (empty = normal)
+func ParsePriority(name string) (int, error) {
+	switch name {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	}
+	return 0, fmt.Errorf("priority must be low, normal, or high")
+}
+
+// PriorityName is the inverse of ParsePriority
+func PriorityName(priority int) string {
+	switch {
+	case priority < PriorityNormal:
+		return "low"
+	case priority > PriorityNormal:
+		return "high"
+	}
+	return "normal"
+}
+
+// jobHeap orders jobs by priority, then by arrival
+type jobHeap []*Job
+
+func (h jobHeap) Len() int { return len(h) }
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].queueSeq < h[j].queueSeq
+}
+func (h jobHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x interface{}) { *h = append(*h, x.(*Job)) }
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	job := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return job
+}
+
+// jobQueue is a bounded priority queue; push blocks while it is full and
+// pop blocks while it is empty, like a buffered channel
+type jobQueue struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	jobs     jobHeap
+	capacity int
+	seq      uint64
+}
+
+func newJobQueue(capacity int) *jobQueue {
+	q := &jobQueue{capacity: capacity}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a job. A job put back after a deferral keeps its original
+// place among jobs of the same priority.
+func (q *jobQueue) push(job *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) >= q.capacity {
+		q.notFull.Wait()
+	}
+	if job.queueSeq == 0 {
+		q.seq++
+		job.queueSeq = q.seq
+		job.enqueuedAt = time.Now()
+	}
+	heap.Push(&q.jobs, job)
+	q.notEmpty.Signal()
+}
+
+// pop removes the most urgent job
+func (q *jobQueue) pop() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) == 0 {
+		q.notEmpty.Wait()
+	}
+	job := heap.Pop(&q.jobs).(*Job)
+	q.notFull.Signal()
+	return job
+}
+
+// oldest returns the job that has been queued longest, nil when the queue
+// is empty, and the number of queued jobs
+func (q *jobQueue) oldest() (*Job, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var oldest *Job
+	for _, job := range q.jobs {
+		if oldest == nil || job.enqueuedAt.Before(oldest.enqueuedAt) {
+			oldest = job
+		}
+	}
+	return oldest, len(q.jobs)
+}
+
+// len returns the number of queued jobs
+func (q *jobQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
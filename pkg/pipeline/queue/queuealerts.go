@@ -0,0 +1,117 @@
+package queue
+
+// Queue alerts — a monitor watches the queue for capacity problems and
+// sends a webhook before users notice: queue.wait_exceeded when the oldest
+// queued job has waited longer than the wait limit, and queue.backlog when
+// the queue has stayed deeper than the depth threshold for the depth
+// window. Each alert is sent once per episode and re-armed when the
+// condition clears.
+
+import (
+	"log"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+)
+
+// QueueAlerts configures the queue monitor; zero values disable an alert
+type QueueAlerts struct {
+	// MaxWait is how long the oldest queued job may wait
+	MaxWait time.Duration
+	// Depth is the queue depth that, sustained for DepthFor, is a backlog
+	Depth    int
+	DepthFor time.Duration
+}
+
+// enabled reports whether either alert is on
+func (a QueueAlerts) enabled() bool {
+	return a.MaxWait > 0 || a.Depth > 0
+}
+
+// queueAlertState tracks the current episode of each alert
+type queueAlertState struct {
+	waitAlerted  bool
+	deepSince    time.Time // zero while the queue is within the threshold
+	depthAlerted bool
+}
+
+// SetQueueAlerts enables the queue monitor. Call before Start.
+func (wp *WorkerPool) SetQueueAlerts(alerts QueueAlerts) {
+	wp.queueAlerts = alerts
+}
+
+// monitorQueue checks the queue against the alert limits until the
+// process exits
+func (wp *WorkerPool) monitorQueue() {
+	shortest := wp.queueAlerts.MaxWait
+	if wp.queueAlerts.Depth > 0 && (shortest == 0 || wp.queueAlerts.DepthFor < shortest) {
+		shortest = wp.queueAlerts.DepthFor
+	}
+	interval := min(max(shortest/4, time.Second), time.Minute)
+	var state queueAlertState
+	for now := range time.Tick(interval) {
+		wp.checkQueue(&state, now)
+	}
+}
+
+// checkQueue sends the alerts whose condition started since the last check
+func (wp *WorkerPool) checkQueue(state *queueAlertState, now time.Time) {
+	oldest, depth := wp.jobQueue.oldest()
+	alerts := wp.queueAlerts
+
+	if alerts.MaxWait > 0 {
+		waited := time.Duration(0)
+		if oldest != nil {
+			waited = now.Sub(oldest.enqueuedAt)
+		}
+		switch {
+		case waited > alerts.MaxWait && !state.waitAlerted:
+			state.waitAlerted = true
+			log.Printf("WARNING: job %s has waited %s in the queue (limit %s, %d queued)",
+				oldest.ID, waited.Round(time.Second), alerts.MaxWait, depth)
+			wp.alertQueue(webhooks.EventQueueWaitExceeded, map[string]interface{}{
+				"job_id":           oldest.ID,
+				"request_name":     oldest.RequestName,
+				"waited_seconds":   waited.Seconds(),
+				"max_wait_seconds": alerts.MaxWait.Seconds(),
+				"queue_depth":      depth,
+			})
+		case waited <= alerts.MaxWait && state.waitAlerted:
+			state.waitAlerted = false
+			log.Printf("Queue wait back under %s", alerts.MaxWait)
+		}
+	}
+
+	if alerts.Depth > 0 {
+		if depth <= alerts.Depth {
+			if state.depthAlerted {
+				log.Printf("Queue depth back to %d (threshold %d)", depth, alerts.Depth)
+			}
+			state.deepSince, state.depthAlerted = time.Time{}, false
+			return
+		}
+		if state.deepSince.IsZero() {
+			state.deepSince = now
+		}
+		if above := now.Sub(state.deepSince); above >= alerts.DepthFor && !state.depthAlerted {
+			state.depthAlerted = true
+			log.Printf("WARNING: %d jobs queued, above %d for %s", depth, alerts.Depth, above.Round(time.Second))
+			wp.alertQueue(webhooks.EventQueueBacklog, map[string]interface{}{
+				"queue_depth":   depth,
+				"threshold":     alerts.Depth,
+				"above_since":   state.deepSince.UTC().Format(time.RFC3339),
+				"above_seconds": above.Seconds(),
+			})
+		}
+	}
+}
+
+// alertQueue sends a queue alert webhook
+func (wp *WorkerPool) alertQueue(event string, payload map[string]interface{}) {
+	if wp.webhooks == nil {
+		return
+	}
+	if err := wp.webhooks.Notify(event, payload); err != nil {
+		log.Printf("Failed to queue %s alert: %v", event, err)
+	}
+}
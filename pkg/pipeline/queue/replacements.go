@@ -0,0 +1,42 @@
+package queue
+
+// Replacement dictionaries — fixes for misheard terms, applied to every
+// transcript, with tenants adding their own product names and jargon by
+// the job's "tenant" label.
+
+import (
+	"fmt"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+)
+
+// SetReplacements sets the replacement dictionary applied to every job and
+// the entries each tenant adds to it; a tenant's entry wins over a global
+// one for the same term
+func (wp *WorkerPool) SetReplacements(global map[string]string, tenants map[string]map[string]string) error {
+	dictionary, err := transcription.NewReplacementDictionary(global)
+	if err != nil {
+		return err
+	}
+	byTenant := make(map[string]*transcription.ReplacementDictionary, len(tenants))
+	for tenant, entries := range tenants {
+		if len(entries) == 0 {
+			continue
+		}
+		if byTenant[tenant], err = transcription.NewReplacementDictionary(global, entries); err != nil {
+			return fmt.Errorf("tenant %s: %v", tenant, err)
+		}
+	}
+	wp.replacements, wp.tenantReplacements = dictionary, byTenant
+	return nil
+}
+
+// replacementsFor is the dictionary applied to a job's transcript; nil
+// when there is none
+func (wp *WorkerPool) replacementsFor(job *Job) *transcription.ReplacementDictionary {
+	if dictionary, ok := wp.tenantReplacements[job.Labels[storage.TenantLabel]]; ok {
+		return dictionary
+	}
+	return wp.replacements
+}
@@ -0,0 +1,80 @@
+package queue
+
+// Audio retention — keeps a copy of each job's audio next to its
+// transcript for playback while reviewing: the upload as received, or a
+// 16kHz mono WAV of it. Both cover the whole recording, so transcript
+// timestamps line up with them even for trimmed or silence-stripped jobs.
+//
+// Raw output retention — keeps whisper's JSON output next to the
+// transcript, so it can be post-processed again without re-transcribing.
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Audio retention modes
+const (
+	KeepAudioOriginal   = "original"
+	KeepAudioNormalized = "normalized"
+)
+
+// SetKeepAudio sets which audio is kept next to transcripts: "original",
+// "normalized", or "" for none
+func (wp *WorkerPool) SetKeepAudio(mode string) error {
+	switch mode {
+	case "", KeepAudioOriginal, KeepAudioNormalized:
+		wp.keepAudio = mode
+		return nil
+	}
+	return fmt.Errorf("unknown keep_audio %q (use original or normalized)", mode)
+}
+
+// playbackAudio returns the audio to keep for a job, or "" when none is
+// kept, and a func removing any temporary file made for it. Sealed jobs
+// keep none.
+func (wp *WorkerPool) playbackAudio(job *Job, sourceInfo *transcription.AudioInfo) (string, func()) {
+	if wp.keepAudio == "" || job.EncryptionKey != nil {
+		return "", func() {}
+	}
+	if wp.keepAudio == KeepAudioOriginal {
+		return job.FilePath, func() {}
+	}
+
+	path, _, err := transcription.NormalizeAudio(job.FilePath, transcription.NormalizeOptions{
+		Info:       sourceInfo,
+		OutputPath: job.tempPath("_playback.wav"),
+	})
+	if err != nil {
+		log.Printf("Job %s: could not normalize audio for playback: %v", job.ID, err)
+		return "", func() {}
+	}
+	if path == job.FilePath {
+		return path, func() {}
+	}
+	return path, func() { wp.cleanupTempFile(path) }
+}
+
+// SetKeepRawOutput sets whether whisper's JSON output is kept next to
+// transcripts
+func (wp *WorkerPool) SetKeepRawOutput(keep bool) {
+	wp.keepRawOutput = keep
+}
+
+// snapshotRawOutput records a fresh result's raw output, before repairs,
+// speakers, and timeline mapping rewrite it, or drops the backend's when
+// none is kept
+func (wp *WorkerPool) snapshotRawOutput(job *Job, result *types.TranscriptionResult) {
+	if !wp.keepRawOutput {
+		result.RawOutput = nil
+		return
+	}
+	raw, err := transcription.RawOutput(result)
+	if err != nil {
+		log.Printf("Job %s: could not record raw output: %v", job.ID, err)
+	}
+	result.RawOutput = raw
+}
@@ -0,0 +1,44 @@
+package queue
+
+// Range re-transcription — runs a slice of a transcript's kept audio
+// through the transcriber again, possibly with a larger model, so a garbled
+// passage can be fixed without redoing hours of audio. The caller splices
+// the segments into the stored transcript.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/google/uuid"
+)
+
+// Retranscribe transcribes audioPath from start to end seconds and returns
+// the segments, timed against the whole recording, and the model that ran
+func (wp *WorkerPool) Retranscribe(audioPath string, start, end float64, opts transcription.DecodeOptions) ([]types.Segment, string, error) {
+	id := uuid.New().String()
+	defer wp.HoldFiles(id)()
+
+	cutPath, _, err := transcription.NormalizeAudio(audioPath, transcription.NormalizeOptions{
+		StartTime:  start,
+		EndTime:    end,
+		OutputPath: filepath.Join("temp", id+"_range.wav"),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to cut %.1fs-%.1fs: %v", start, end, err)
+	}
+	defer os.Remove(cutPath)
+
+	result, err := wp.transcriber.TranscribeWithOptions(cutPath, opts, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	segments := result.Segments
+	for i := range segments {
+		segments[i].Start = min(segments[i].Start+start, end)
+		segments[i].End = min(segments[i].End+start, end)
+	}
+	return segments, wp.transcriber.ModelNameFor(opts), nil
+}
@@ -0,0 +1,115 @@
+package queue
+
+// Per-source defaults — settings applied to every job from one source
+// (upload, gdrive, youtube, stream, import) that did not choose its own,
+// so clients of a source don't each have to pass the same options.
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// SourceDefaults are the option defaults for jobs from one source. Each
+// field has the meaning of the submission option of the same name.
+type SourceDefaults struct {
+	Priority      string   `yaml:"priority"`
+	FormatProfile string   `yaml:"format_profile"`
+	Destinations  []string `yaml:"destinations"`
+	DriveFormats  []string `yaml:"drive_formats"`
+	LineEndings   string   `yaml:"line_endings"`
+	BOM           *bool    `yaml:"bom"`
+	Language      string   `yaml:"language"`
+	Task          string   `yaml:"task"`
+	Model         string   `yaml:"model"`
+	Denoise       *bool    `yaml:"denoise"`
+	Speedup       float64  `yaml:"speedup"`
+	Diarize       *bool    `yaml:"diarize"`
+	Speakers      int      `yaml:"speakers"`
+	// Labels are merged under the job's own labels, and validated with
+	// them at submission
+	Labels map[string]string `yaml:"labels"`
+	// Decoding replaces whisper.decoding for jobs that set no decoding
+	// parameters of their own
+	Decoding types.DecodingParams `yaml:"decoding"`
+}
+
+// SetSourceDefaults sets the per-source option defaults, keyed by source
+// type. Call after SetDestinations, which the defaults are checked against.
+func (wp *WorkerPool) SetSourceDefaults(defaults map[string]SourceDefaults) error {
+	for source, d := range defaults {
+		if !slices.Contains(types.SourceTypes, source) {
+			return fmt.Errorf("unknown source %q in defaults (use %s)", source, strings.Join(types.SourceTypes, ", "))
+		}
+		if err := d.check(wp); err != nil {
+			return fmt.Errorf("defaults for %s: %v", source, err)
+		}
+	}
+	wp.sourceDefaults = defaults
+	return nil
+}
+
+// SourceDefaults returns the option defaults for jobs from source
+func (wp *WorkerPool) SourceDefaults(source string) SourceDefaults {
+	return wp.sourceDefaults[source]
+}
+
+// check validates the defaults the way submission options are validated
+func (d SourceDefaults) check(wp *WorkerPool) error {
+	if _, err := ParsePriority(d.Priority); err != nil {
+		return err
+	}
+	if _, ok := transcription.LookupFormatProfile(d.FormatProfile); d.FormatProfile != "" && !ok {
+		return fmt.Errorf("unknown format profile %q", d.FormatProfile)
+	}
+	if err := wp.CheckDestinations(d.Destinations); err != nil {
+		return err
+	}
+	if err := storage.CheckDriveFormats(d.DriveFormats); err != nil {
+		return err
+	}
+	if err := (storage.TextEncoding{LineEndings: d.LineEndings}).Check(); err != nil {
+		return err
+	}
+	if err := transcription.CheckLanguage(strings.ToLower(strings.TrimSpace(d.Language))); err != nil {
+		return err
+	}
+	task := strings.ToLower(strings.TrimSpace(d.Task))
+	if err := transcription.CheckTask(task); err != nil {
+		return err
+	}
+	if task == transcription.TaskTranslate && !wp.CanTranslate() {
+		return fmt.Errorf("the transcription backend can't translate")
+	}
+	model := strings.ToLower(strings.TrimSpace(d.Model))
+	if err := transcription.CheckModel(model); err != nil {
+		return err
+	}
+	if model != "" && !wp.CanSelectModel() {
+		return fmt.Errorf("the transcription backend has no model sizes to pick from")
+	}
+	if err := transcription.CheckSpeedup(d.Speedup); err != nil {
+		return err
+	}
+	diarize := d.Diarize != nil && *d.Diarize
+	if diarize && !wp.CanDiarize() {
+		return fmt.Errorf("speaker diarization is not enabled (whisper.diarization)")
+	}
+	if err := transcription.CheckSpeakers(d.Speakers); err != nil {
+		return err
+	}
+	if d.Speakers > 0 && !diarize {
+		return fmt.Errorf("speakers needs diarize")
+	}
+	if err := transcription.CheckDecoding(d.Decoding); err != nil {
+		return err
+	}
+	if d.Decoding != (types.DecodingParams{}) && !wp.CanTuneDecoding() {
+		return fmt.Errorf("the transcription backend doesn't take decoding parameters")
+	}
+	return nil
+}
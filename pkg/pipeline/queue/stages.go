@@ -0,0 +1,82 @@
+package queue
+
+// Registered pipeline stages — runs the pre-processors, post-processors,
+// and notifiers that embedding programs add through pkg/stages.
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/stages"
+)
+
+// stageJob is the view of a job handed to registered stages
+func (j *Job) stageJob() stages.Job {
+	return stages.Job{
+		ID:          j.ID,
+		RequestName: j.RequestName,
+		SourceType:  j.SourceType,
+		Metadata:    j.Metadata,
+		Labels:      j.Labels,
+	}
+}
+
+// runPreProcessors passes the source audio through every registered
+// pre-processor and returns the path to normalize. Intermediate files are
+// removed; the final one is left for the caller to clean up.
+func (wp *WorkerPool) runPreProcessors(job *Job) (string, error) {
+	path := job.FilePath
+	for _, p := range stages.PreProcessors() {
+		next, err := p.PreProcess(context.Background(), job.stageJob(), path)
+		if err != nil {
+			if path != job.FilePath {
+				wp.cleanupTempFile(path)
+			}
+			return "", fmt.Errorf("%s: %v", p.Name(), err)
+		}
+		if path != job.FilePath && next != path {
+			wp.cleanupTempFile(path)
+		}
+		path = next
+	}
+	return path, nil
+}
+
+// runPostProcessors lets every registered post-processor inspect or rewrite the result
+func (wp *WorkerPool) runPostProcessors(job *Job, result *types.TranscriptionResult) error {
+	for _, p := range stages.PostProcessors() {
+		if err := p.PostProcess(context.Background(), job.stageJob(), result); err != nil {
+			return fmt.Errorf("%s: %v", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// notifyStages tells registered notifiers that a job finished
+func (wp *WorkerPool) notifyStages(job *Job) {
+	notifiers := stages.Notifiers()
+	if len(notifiers) == 0 {
+		return
+	}
+
+	event := stages.Event{Type: stages.EventJobCompleted, Job: job.stageJob(), Result: job.Result}
+	switch job.Status {
+	case types.StatusCancelled:
+		event.Type, event.Result = stages.EventJobCancelled, nil
+	case types.StatusFailed:
+		event.Type, event.Result = stages.EventJobFailed, nil
+		if job.Error != nil {
+			event.Error = job.Error.Error()
+		}
+	}
+
+	for _, n := range notifiers {
+		go func(n stages.Notifier) {
+			if err := n.Notify(context.Background(), event); err != nil {
+				log.Printf("Notifier %s failed for job %s: %v", n.Name(), job.ID, err)
+			}
+		}(n)
+	}
+}
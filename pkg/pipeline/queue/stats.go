@@ -0,0 +1,196 @@
+package queue
+
+// Queue statistics — what each worker is doing right now and how fast jobs
+// have been getting through over the last hour, for dashboards and
+// capacity planning.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// statsWindow is how far back throughput and average times look
+const statsWindow = time.Hour
+
+// WorkerStatus is one worker's current job, if any
+type WorkerStatus struct {
+	Worker     int        `json:"worker"`
+	JobID      string     `json:"job_id,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	RunSeconds float64    `json:"run_seconds,omitempty"`
+	// LastHeartbeat is when the job last made progress (see heartbeat.go)
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+}
+
+// QueueStats summarizes the worker pool
+type QueueStats struct {
+	Queued     int            `json:"queued"`
+	Capacity   int            `json:"capacity"`
+	InProgress int            `json:"in_progress"`
+	Workers    []WorkerStatus `json:"workers"`
+
+	// Over the last hour: jobs that finished processing (failed attempts
+	// included), the audio they covered, and their mean processing time
+	Completed            int     `json:"completed_last_hour"`
+	Failed               int     `json:"failed_last_hour"`
+	AudioMinutes         float64 `json:"audio_minutes_last_hour"`
+	AvgProcessingSeconds float64 `json:"avg_processing_seconds"`
+}
+
+// finishedRun is one processing attempt that has ended
+type finishedRun struct {
+	at           time.Time
+	seconds      float64
+	audioSeconds float64
+	failed       bool
+}
+
+// poolStats tracks worker activity
+type poolStats struct {
+	mu       sync.Mutex
+	current  []*Job
+	started  []time.Time
+	beats    []time.Time   // last heartbeat of each worker's current job
+	finished []finishedRun // oldest first, trimmed to statsWindow
+}
+
+func newPoolStats(workerCount int) *poolStats {
+	return &poolStats{
+		current: make([]*Job, workerCount),
+		started: make([]time.Time, workerCount),
+		beats:   make([]time.Time, workerCount),
+	}
+}
+
+// begin records that worker has picked up job
+func (s *poolStats) begin(worker int, job *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current[worker] = job
+	s.started[worker] = time.Now()
+	s.beats[worker] = s.started[worker]
+}
+
+// touch records a heartbeat from whichever worker is running job
+func (s *poolStats) touch(job *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, current := range s.current {
+		if current == job {
+			s.beats[i] = time.Now()
+		}
+	}
+}
+
+// owns reports whether worker is still the one running job
+func (s *poolStats) owns(worker int, job *Job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current[worker] == job
+}
+
+// stalledWorker is a worker whose job stopped sending heartbeats
+type stalledWorker struct {
+	worker   int
+	job      *Job
+	lastBeat time.Time
+}
+
+// abandonStalled frees the slot of every worker that has not sent a
+// heartbeat within timeout, returning them
+func (s *poolStats) abandonStalled(timeout time.Duration) []stalledWorker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stalled []stalledWorker
+	now := time.Now()
+	for i, job := range s.current {
+		if job == nil || now.Sub(s.beats[i]) < timeout {
+			continue
+		}
+		stalled = append(stalled, stalledWorker{worker: i, job: job, lastBeat: s.beats[i]})
+		s.finished = append(s.finished, finishedRun{at: now, seconds: now.Sub(s.started[i]).Seconds(), failed: true})
+		s.current[i] = nil
+	}
+	return stalled
+}
+
+// end records the outcome of worker's current job. It reports false, and
+// records nothing, if the worker was abandoned as stalled meanwhile.
+func (s *poolStats) end(worker int, job *Job) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current[worker] != job {
+		return false
+	}
+
+	run := finishedRun{
+		at:      time.Now(),
+		seconds: time.Since(s.started[worker]).Seconds(),
+		failed:  job.Status != types.StatusCompleted,
+	}
+	if job.Result != nil {
+		run.audioSeconds = job.Result.Duration
+	}
+	s.finished = append(s.finished, run)
+	s.current[worker] = nil
+	s.prune(run.at)
+	return true
+}
+
+// prune drops runs that have left the window
+func (s *poolStats) prune(now time.Time) {
+	cutoff := now.Add(-statsWindow)
+	i := 0
+	for i < len(s.finished) && s.finished[i].at.Before(cutoff) {
+		i++
+	}
+	s.finished = s.finished[i:]
+}
+
+// Stats reports queue depth, what every worker is running, and the last
+// hour's throughput
+func (wp *WorkerPool) Stats() QueueStats {
+	queued, capacity := wp.QueueDepth()
+	stats := QueueStats{
+		Queued:   queued,
+		Capacity: capacity,
+		Workers:  make([]WorkerStatus, wp.workerCount),
+	}
+
+	s := wp.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for i, job := range s.current {
+		stats.Workers[i].Worker = i
+		if job == nil {
+			continue
+		}
+		started, beat := s.started[i], s.beats[i]
+		stats.Workers[i].JobID = job.ID
+		stats.Workers[i].StartedAt = &started
+		stats.Workers[i].LastHeartbeat = &beat
+		stats.Workers[i].RunSeconds = now.Sub(started).Seconds()
+		stats.InProgress++
+	}
+
+	s.prune(now)
+	var totalSeconds float64
+	for _, run := range s.finished {
+		if run.failed {
+			stats.Failed++
+		} else {
+			stats.Completed++
+		}
+		stats.AudioMinutes += run.audioSeconds / 60
+		totalSeconds += run.seconds
+	}
+	if n := len(s.finished); n > 0 {
+		stats.AvgProcessingSeconds = totalSeconds / float64(n)
+	}
+	return stats
+}
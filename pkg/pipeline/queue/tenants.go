@@ -0,0 +1,60 @@
+package queue
+
+// Per-tenant concurrency caps — limits how many jobs from one tenant can be
+// PROCESSING at once, independent of the global worker count.
+
+import "sync"
+
+// tenantLimiter counts in-flight jobs per tenant
+type tenantLimiter struct {
+	mu         sync.Mutex
+	active     map[string]int
+	defaultMax int
+	limits     map[string]int
+}
+
+func newTenantLimiter(defaultMax int, limits map[string]int) *tenantLimiter {
+	return &tenantLimiter{
+		active:     make(map[string]int),
+		defaultMax: defaultMax,
+		limits:     limits,
+	}
+}
+
+// tryAcquire reserves a processing slot for the tenant, returning false if
+// the tenant is already at its cap. Jobs without a tenant are never capped.
+func (tl *tenantLimiter) tryAcquire(tenant string) bool {
+	if tenant == "" {
+		return true
+	}
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	max := tl.defaultMax
+	if limit, ok := tl.limits[tenant]; ok {
+		max = limit
+	}
+	if max > 0 && tl.active[tenant] >= max {
+		return false
+	}
+
+	tl.active[tenant]++
+	return true
+}
+
+// release frees a slot reserved by tryAcquire
+func (tl *tenantLimiter) release(tenant string) {
+	if tenant == "" {
+		return
+	}
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	if tl.active[tenant] <= 1 {
+		delete(tl.active, tenant)
+	} else {
+		tl.active[tenant]--
+	}
+}
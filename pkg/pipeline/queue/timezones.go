@@ -0,0 +1,52 @@
+package queue
+
+// Output time zones — dated folders, file names, and metadata timestamps
+// follow the configured zone, or the job's tenant's, rather than wherever
+// the server happens to run. The database always stores UTC.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+)
+
+// SetTimeZones sets the IANA time zone ("Europe/Berlin") jobs' output is
+// dated in, "" being the server's local time, with overrides by tenant
+func (wp *WorkerPool) SetTimeZones(zone string, tenants map[string]string) error {
+	loc, err := loadTimeZone(zone)
+	if err != nil {
+		return err
+	}
+	byTenant := make(map[string]*time.Location, len(tenants))
+	for tenant, name := range tenants {
+		if name == "" {
+			continue
+		}
+		if byTenant[tenant], err = loadTimeZone(name); err != nil {
+			return fmt.Errorf("tenant %s: %v", tenant, err)
+		}
+	}
+	wp.timeZone, wp.tenantTimeZones = loc, byTenant
+	return nil
+}
+
+// loadTimeZone loads a zone by name; "" is nil, the server's local time
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return loc, nil
+}
+
+// location is the time zone a job's output is dated in
+func (wp *WorkerPool) location(job *Job) *time.Location {
+	if loc, ok := wp.tenantTimeZones[job.Labels[storage.TenantLabel]]; ok {
+		return loc
+	}
+	return wp.timeZone
+}
@@ -0,0 +1,1094 @@
+package queue
+
+// Worker pool implementation — distributes transcription jobs across
+// goroutines with status tracking and error propagation.
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/webhooks"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/storage"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/transcription"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/stages"
+)
+
+// WorkerPool manages a pool of workers processing transcription jobs
+type WorkerPool struct {
+	jobQueue     *jobQueue
+	workerCount  int
+	transcriber  *transcription.WhisperTranscriber
+	localStorage *storage.LocalStorage
+	driveClient  *storage.DriveClient
+	db           *storage.MetadataDB
+	quota        *storage.QuotaManager
+	tenants      *tenantLimiter
+	webhooks     stages.Webhooks
+	resultLinks  stages.LinkSigner
+	indexer      stages.Indexer
+	hooks        stages.Hooks
+	cancels      *cancelRegistry
+	captures     *captureRegistry
+	files        *fileHolds
+	sources      *sourceClaims
+	eta          *etaEstimator
+	events       *eventBroker
+	stats        *poolStats
+
+	// formatProfile is applied to jobs that do not name their own
+	formatProfile string
+
+	// driveFormats are the renderings uploaded to Drive for jobs that do
+	// not choose their own; nil uploads all
+	driveFormats []string
+
+	// textEncoding is the BOM and line ending style of text artifacts for
+	// jobs that do not choose their own
+	textEncoding storage.TextEncoding
+
+	// denoise is the noise reduction filter for jobs that ask for it
+	denoise transcription.DenoiseOptions
+
+	// speedup is how much faster jobs that do not choose their own are
+	// played to whisper; 0 keeps the recorded speed
+	speedup float64
+
+	// keepAudio is which audio is kept next to transcripts (see
+	// SetKeepAudio); "" keeps none
+	keepAudio string
+
+	// keepRawOutput keeps whisper's JSON output next to transcripts (see
+	// SetKeepRawOutput)
+	keepRawOutput bool
+
+	// timeZone and tenantTimeZones date jobs' output (see timezones.go)
+	timeZone        *time.Location
+	tenantTimeZones map[string]*time.Location
+
+	// replacements and tenantReplacements fix misheard terms in
+	// transcripts (see replacements.go)
+	replacements       *transcription.ReplacementDictionary
+	tenantReplacements map[string]*transcription.ReplacementDictionary
+
+	// sourceDefaults are option defaults per source type (see sourcedefaults.go)
+	sourceDefaults map[string]SourceDefaults
+
+	// intakeCheck, when set, must pass before any new job is admitted
+	intakeCheck func() error
+
+	// destinations are the output locations jobs may choose (see destinations.go)
+	destinations map[string]storage.Destination
+
+	// stallTimeout is how long a job may go without a heartbeat before
+	// it is requeued (see heartbeat.go); zero disables the monitor
+	stallTimeout time.Duration
+
+	// captureTTL is how long a job may spend fetching its source before it
+	// is failed (see captures.go); zero disables the monitor
+	captureTTL time.Duration
+
+	// queueAlerts are the queue's wait and depth limits (see
+	// queuealerts.go); zero disables the monitor
+	queueAlerts QueueAlerts
+
+	// maxDuration limits the audio a job transcribes, truncating longer
+	// audio when truncateLong is set (see duration.go); zero is no limit
+	maxDuration  time.Duration
+	truncateLong bool
+
+	// maxAttempts and deadLetterDir govern failed jobs (see deadletter.go)
+	maxAttempts   int
+	deadLetterDir string
+}
+
+// NewWorkerPool creates a new worker pool
+func NewWorkerPool(
+	workerCount int,
+	transcriber *transcription.WhisperTranscriber,
+	localStorage *storage.LocalStorage,
+	driveClient *storage.DriveClient,
+	db *storage.MetadataDB,
+) *WorkerPool {
+	return &WorkerPool{
+		jobQueue:     newJobQueue(100), // Buffer of 100 jobs
+		workerCount:  workerCount,
+		transcriber:  transcriber,
+		localStorage: localStorage,
+		driveClient:  driveClient,
+		db:           db,
+		cancels:      newCancelRegistry(),
+		captures:     newCaptureRegistry(),
+		files:        newFileHolds(),
+		sources:      newSourceClaims(),
+		eta:          newETAEstimator(db, transcriber.ModelName()),
+		events:       newEventBroker(),
+		stats:        newPoolStats(workerCount),
+		maxAttempts:  1,
+	}
+}
+
+// Start initializes all workers
+func (wp *WorkerPool) Start() {
+	log.Printf("Starting worker pool with %d workers", wp.workerCount)
+	for i := 0; i < wp.workerCount; i++ {
+		go wp.worker(i)
+	}
+	if wp.stallTimeout > 0 {
+		go wp.monitorHeartbeats()
+	}
+	if wp.captureTTL > 0 {
+		go wp.monitorCaptures()
+	}
+	if wp.queueAlerts.enabled() {
+		go wp.monitorQueue()
+	}
+}
+
+// SetQuotaManager enables per-tenant storage quota enforcement
+func (wp *WorkerPool) SetQuotaManager(quota *storage.QuotaManager) {
+	wp.quota = quota
+}
+
+// SetWebhooks enables job.completed / job.failed notifications
+func (wp *WorkerPool) SetWebhooks(dispatcher stages.Webhooks) {
+	wp.webhooks = dispatcher
+}
+
+// SetResultLinks adds signed artifact links to job.completed payloads
+func (wp *WorkerPool) SetResultLinks(links stages.LinkSigner) {
+	wp.resultLinks = links
+}
+
+// SetIndexer enables pushing completed transcripts to a search index
+func (wp *WorkerPool) SetIndexer(indexer stages.Indexer) {
+	wp.indexer = indexer
+}
+
+// SetPostProcessor runs hooks on every transcript before it is stored
+func (wp *WorkerPool) SetPostProcessor(hooks stages.Hooks) {
+	wp.hooks = hooks
+}
+
+// SetFormatProfile sets the formatting profile for jobs that do not choose
+// one ("" leaves whisper's output as it is)
+func (wp *WorkerPool) SetFormatProfile(name string) error {
+	if _, ok := transcription.LookupFormatProfile(name); name != "" && !ok {
+		return fmt.Errorf("unknown format profile %q", name)
+	}
+	wp.formatProfile = name
+	return nil
+}
+
+// SetDriveFormats sets which extra renderings are uploaded to Drive for
+// jobs that do not choose their own (see storage.CheckDriveFormats); an
+// empty list uploads every rendering
+func (wp *WorkerPool) SetDriveFormats(formats []string) error {
+	if err := storage.CheckDriveFormats(formats); err != nil {
+		return err
+	}
+	if len(formats) > 0 {
+		wp.driveFormats = formats
+	}
+	return nil
+}
+
+// SetTextEncoding sets the BOM and line endings of text artifacts for
+// jobs that do not choose their own
+func (wp *WorkerPool) SetTextEncoding(enc storage.TextEncoding) error {
+	if err := enc.Check(); err != nil {
+		return err
+	}
+	wp.textEncoding = enc
+	return nil
+}
+
+// SetDenoise configures the noise reduction filter applied to jobs that
+// ask for it; without it they get afftdn's defaults
+func (wp *WorkerPool) SetDenoise(opts transcription.DenoiseOptions) error {
+	opts, err := transcription.CheckDenoise(opts)
+	if err != nil {
+		return err
+	}
+	wp.denoise = opts
+	return nil
+}
+
+// jobDenoise is the noise reduction filter for a job; nil when the job
+// did not ask for one
+func (wp *WorkerPool) jobDenoise(job *Job) *transcription.DenoiseOptions {
+	if !job.Denoise {
+		return nil
+	}
+	opts := wp.denoise
+	return &opts
+}
+
+// SetSpeedup sets how much faster than recorded jobs that do not choose
+// their own speed-up are played to whisper; 0 or 1 keeps the recorded speed
+func (wp *WorkerPool) SetSpeedup(factor float64) error {
+	if err := transcription.CheckSpeedup(factor); err != nil {
+		return err
+	}
+	wp.speedup = factor
+	return nil
+}
+
+// CanTranslate reports whether the transcription backend can translate
+// speech to English
+func (wp *WorkerPool) CanTranslate() bool {
+	return wp.transcriber.CanTranslate()
+}
+
+// CanDiarize reports whether jobs may ask for speaker labels
+func (wp *WorkerPool) CanDiarize() bool {
+	return wp.transcriber.CanDiarize()
+}
+
+// CanSelectModel reports whether jobs may pick the whisper model size
+func (wp *WorkerPool) CanSelectModel() bool {
+	return wp.transcriber.CanSelectModel()
+}
+
+// CanTuneDecoding reports whether the transcription backend takes
+// decoding parameters
+func (wp *WorkerPool) CanTuneDecoding() bool {
+	return wp.transcriber.CanTuneDecoding()
+}
+
+// CanPrompt reports whether the transcription backend takes an initial
+// prompt
+func (wp *WorkerPool) CanPrompt() bool {
+	return wp.transcriber.CanPrompt()
+}
+
+// CanBoostVocabulary reports whether the transcription backend takes a
+// list of domain terms
+func (wp *WorkerPool) CanBoostVocabulary() bool {
+	return wp.transcriber.CanBoostVocabulary()
+}
+
+// TextEncoding returns the default text artifact encoding
+func (wp *WorkerPool) TextEncoding() storage.TextEncoding {
+	return wp.textEncoding
+}
+
+// SetTenantConcurrency caps how many jobs per tenant may process at once
+// (0 = no cap); limits override the default for specific tenants
+func (wp *WorkerPool) SetTenantConcurrency(defaultMax int, limits map[string]int) {
+	wp.tenants = newTenantLimiter(defaultMax, limits)
+}
+
+// SetIntakeCheck refuses new jobs while check fails, e.g. while a
+// component every job needs is down; intake resumes once it passes again
+func (wp *WorkerPool) SetIntakeCheck(check func() error) {
+	wp.intakeCheck = check
+}
+
+// CheckAdmission reports whether a new job with the given labels may be
+// accepted; handlers call it before doing any expensive work
+func (wp *WorkerPool) CheckAdmission(labels map[string]string) error {
+	if wp.intakeCheck != nil {
+		if err := wp.intakeCheck(); err != nil {
+			return err
+		}
+	}
+	if wp.quota != nil {
+		if err := wp.quota.Check(labels[storage.TenantLabel]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueDepth returns the number of queued jobs and the queue capacity
+func (wp *WorkerPool) QueueDepth() (int, int) {
+	return wp.jobQueue.len(), wp.jobQueue.capacity
+}
+
+// EnqueueJob adds a job to the queue
+func (wp *WorkerPool) EnqueueJob(job *Job) {
+	if !wp.claimCapture(job) {
+		// Failed already for taking too long; drop what arrived late
+		os.Remove(job.FilePath)
+		return
+	}
+	job.Status = types.StatusQueued
+	job.CreatedAt = time.Now()
+	wp.holdJobFiles(job)
+	wp.recordStatus(job)
+	wp.eta.queued(job.ID, job.Priority)
+	if job.checkpoint == nil {
+		wp.saveCheckpoint(job, storage.StageQueued, "", "")
+	}
+	wp.jobQueue.push(job)
+	log.Printf("Job %s enqueued (source: %s, name: %s, priority: %s)",
+		job.ID, job.SourceType, job.RequestName, PriorityName(job.Priority))
+}
+
+// worker processes jobs from the queue
+func (wp *WorkerPool) worker(id int) {
+	log.Printf("Worker %d started", id)
+
+	for {
+		job := wp.jobQueue.pop()
+
+		// Hold back jobs whose tenant is already at its concurrency cap
+		tenant := job.Labels[storage.TenantLabel]
+		if wp.tenants != nil && !wp.tenants.tryAcquire(tenant) {
+			wp.requeueLater(job, tenantRetryDelay)
+			continue
+		}
+
+		// The key is wiped once processing ends, so note this for the retry decision
+		encrypted := job.EncryptionKey != nil
+
+		wp.keepPickedUp(job)
+		wp.stats.begin(id, job)
+
+		// Panic recovery
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Worker %d: PANIC processing job %s: %v\n%s",
+						id, job.ID, r, string(debug.Stack()))
+					job.Status = types.StatusFailed
+					job.Error = fmt.Errorf("Worker panic: %v", r)
+					job.stack = string(debug.Stack())
+				}
+			}()
+
+			wp.processJob(id, job)
+		}()
+		if !wp.stats.end(id, job) {
+			// Given up on as stalled: the job was requeued and a replacement
+			// worker holds this slot and the tenant's
+			log.Printf("Worker %d: stalled job %s returned (%s); exiting", id, job.ID, job.Status)
+			return
+		}
+		dropPickedUp(job)
+		if wp.tenants != nil {
+			wp.tenants.release(tenant)
+		}
+
+		// Failed jobs are retried, then dead-lettered
+		if job.Status == types.StatusFailed && !encrypted && wp.retryLater(job) {
+			continue
+		}
+		wp.finish(job, encrypted)
+	}
+}
+
+// finish records a job's final status, dead-lettering it if it failed,
+// and releases everything it held
+func (wp *WorkerPool) finish(job *Job, encrypted bool) {
+	if job.Status == types.StatusFailed {
+		wp.deadLetter(job, encrypted)
+	}
+	wp.releaseJobFiles(job)
+	wp.ReleaseSource(job)
+	wp.eta.finished(job.ID)
+	wp.recordStatus(job)
+	wp.clearCheckpoint(job)
+	wp.notifyFinished(job)
+	job.markDone()
+}
+
+// TrackJob records a job whose source is still being fetched, so its ID
+// can be polled before it reaches the queue. With a capture TTL, the job
+// is failed if it isn't queued by job.ExpiresAt.
+func (wp *WorkerPool) TrackJob(job *Job) {
+	job.Status = types.StatusDownloading
+	job.CreatedAt = time.Now()
+	wp.trackCapture(job)
+	wp.holdJobFiles(job)
+	wp.recordStatus(job)
+}
+
+// FailJob marks a job that never reached the queue (e.g. a failed download)
+// as failed and sends the usual failure notification
+func (wp *WorkerPool) FailJob(job *Job, err error) {
+	if !wp.claimCapture(job) {
+		return
+	}
+	wp.failJob(job, err)
+}
+
+// failJob fails a job that never reached the queue
+func (wp *WorkerPool) failJob(job *Job, err error) {
+	job.Status = types.StatusFailed
+	job.Error = err
+	wipeKey(job)
+	wp.releaseJobFiles(job)
+	wp.ReleaseSource(job)
+	wp.recordStatus(job)
+	wp.notifyFinished(job)
+	job.markDone()
+}
+
+// MarkCancelled records that a job was cancelled before reaching the queue
+func (wp *WorkerPool) MarkCancelled(job *Job) {
+	if !wp.claimCapture(job) {
+		return
+	}
+	job.Status = types.StatusCancelled
+	wipeKey(job)
+	wp.releaseJobFiles(job)
+	wp.ReleaseSource(job)
+	wp.recordStatus(job)
+	wp.notifyFinished(job)
+	job.markDone()
+}
+
+// ReportProgress records progress (0-100) of a job's current phase
+// (PhaseDownload, PhaseNormalize, PhaseTranscribe)
+func (wp *WorkerPool) ReportProgress(job *Job, phase string, progress float64) {
+	wp.heartbeat(job)
+	if phase == PhaseTranscribe {
+		wp.eta.progressed(job.ID, progress)
+	}
+	wp.publishProgress(job, phase, progress)
+	if wp.db == nil {
+		return
+	}
+	if err := wp.db.SaveJobProgress(job.ID, progress); err != nil {
+		log.Printf("Failed to record progress of job %s: %v", job.ID, err)
+	}
+}
+
+// recordStatus persists a job's current status for the /jobs API and
+// announces it to event subscribers
+func (wp *WorkerPool) recordStatus(job *Job) {
+	wp.publishStatus(job)
+	if wp.db == nil {
+		return
+	}
+
+	var errMsg string
+	if job.Error != nil {
+		errMsg = job.Error.Error()
+	}
+	if err := wp.db.SaveJobStatus(job.ID, job.RequestName, job.SourceType,
+		job.Labels[storage.TenantLabel], job.Status, errMsg); err != nil {
+		log.Printf("Failed to record status of job %s: %v", job.ID, err)
+	}
+}
+
+// notifyFinished tells registered notifiers and queues a webhook for a job
+// that completed, failed, or was cancelled
+func (wp *WorkerPool) notifyFinished(job *Job) {
+	wp.notifyStages(job)
+	defer wp.groupJobFinished(job)
+	if wp.webhooks == nil {
+		return
+	}
+
+	payload := map[string]interface{}{
+		"job_id":       job.ID,
+		"request_name": job.RequestName,
+		"source_type":  job.SourceType,
+		"status":       job.Status,
+		"metadata":     job.Metadata,
+		"labels":       job.Labels,
+	}
+
+	event := webhooks.EventJobCompleted
+	if job.Status == types.StatusCancelled {
+		event = webhooks.EventJobCancelled
+	} else if job.Status == types.StatusFailed {
+		event = webhooks.EventJobFailed
+		if job.Error != nil {
+			payload["error"] = job.Error.Error()
+		}
+	} else if job.Result != nil {
+		payload["duration"] = job.Result.Duration
+		payload["word_count"] = job.Result.WordCount
+		payload["local_path"] = job.Result.LocalPath
+		payload["gdrive_url"] = job.Result.GDriveURL
+		wp.addResultLinks(payload, job)
+	}
+
+	if err := wp.webhooks.Notify(event, payload); err != nil {
+		log.Printf("Failed to queue webhook for job %s: %v", job.ID, err)
+	}
+}
+
+// addResultLinks adds signed download links for a completed job's
+// artifacts. Encrypted transcripts get none: receivers can't read them.
+func (wp *WorkerPool) addResultLinks(payload map[string]interface{}, job *Job) {
+	if wp.resultLinks == nil || job.Result.LocalPath == "" || storage.IsEncrypted(job.Result.LocalPath) {
+		return
+	}
+
+	artifacts := []string{"txt", "meta"}
+	for _, format := range types.OutputFormats {
+		if _, ok := job.Result.Formats[format]; ok {
+			artifacts = append(artifacts, format)
+		}
+	}
+	if _, err := os.Stat(storage.RawOutputPath(job.Result.LocalPath)); err == nil {
+		artifacts = append(artifacts, "raw")
+	}
+	links, expires, err := wp.resultLinks.Sign(job.ID, artifacts)
+	if err != nil {
+		log.Printf("Could not sign result links for job %s: %v", job.ID, err)
+		return
+	}
+	payload["artifact_urls"] = links
+	payload["artifact_urls_expire_at"] = expires.UTC().Format(time.RFC3339)
+}
+
+// tenantRetryDelay is how long a job deferred by a tenant cap waits before
+// going back on the queue
+const tenantRetryDelay = 2 * time.Second
+
+// requeueLater puts a job back on the queue after a delay without blocking the worker
+func (wp *WorkerPool) requeueLater(job *Job, delay time.Duration) {
+	go func() {
+		time.Sleep(delay)
+		wp.jobQueue.push(job)
+	}()
+}
+
+// processJob handles the complete transcription pipeline
+func (wp *WorkerPool) processJob(workerID int, job *Job) {
+	log.Printf("Worker %d: Processing job %s", workerID, job.ID)
+	defer wipeKey(job)
+	job.Status = types.StatusProcessing
+	wp.recordStatus(job)
+
+	// Fingerprint the source before anything touches it
+	sourceSHA256, err := storage.FileSHA256(job.FilePath)
+	if err != nil {
+		log.Printf("Worker %d: Could not checksum %s: %v", workerID, job.FilePath, err)
+	}
+
+	// Step 1: Normalize audio (probe first so the original format is recorded)
+	sourceInfo, err := transcription.ProbeAudio(job.FilePath)
+	if err != nil {
+		log.Printf("Worker %d: Could not probe %s: %v", workerID, job.FilePath, err)
+	}
+	if err := wp.enforceDuration(sourceInfo, job); err != nil {
+		log.Printf("Worker %d: Rejecting job %s: %v", workerID, job.ID, err)
+		job.Status = types.StatusFailed
+		job.Error = err
+		return
+	}
+	if job.DualChannel && sourceInfo != nil && sourceInfo.Channels != 2 {
+		log.Printf("Worker %d: Rejecting job %s: dual-channel job has %d channels", workerID, job.ID, sourceInfo.Channels)
+		job.Status = types.StatusFailed
+		job.Error = &PermanentError{Err: fmt.Errorf("dual_channel needs a two-channel recording, but this one has %d", sourceInfo.Channels)}
+		return
+	}
+	wp.eta.started(job.ID, trimmedDuration(sourceInfo, job))
+	// Settled before the first checkpoint, so a resumed job scales its
+	// timestamps by the speed its audio was normalized at
+	if job.Speedup == 0 {
+		job.Speedup = max(wp.speedup, 1)
+	}
+
+	var (
+		normalizedPath   string
+		normalizeUsage   types.ResourceUsage
+		normalizeSeconds float64
+	)
+	if job.resumableFrom(storage.StageNormalized) {
+		normalizedPath = job.checkpoint.NormalizedPath
+		log.Printf("Worker %d: Resuming job %s from %s stage", workerID, job.ID, job.checkpoint.Stage)
+	} else {
+		// Registered pre-processors see the source before normalization
+		inputPath, inputInfo := job.FilePath, sourceInfo
+		if processed, err := wp.runPreProcessors(job); err != nil {
+			log.Printf("Worker %d: Pre-processing failed for job %s: %v", workerID, job.ID, err)
+			job.Status = types.StatusFailed
+			job.Error = fmt.Errorf("Pre-processing failed: %v", err)
+			return
+		} else if processed != job.FilePath {
+			defer wp.cleanupTempFile(processed)
+			inputPath, inputInfo = processed, nil
+		}
+
+		normalizeStart := time.Now()
+		normalizeOpts := transcription.NormalizeOptions{
+			StartTime:  job.StartTime,
+			EndTime:    job.EndTime,
+			Denoise:    wp.jobDenoise(job),
+			Speedup:    job.Speedup,
+			Info:       inputInfo,
+			OutputPath: job.tempPath("_normalized.wav"),
+			VAD:        wp.transcriber.VAD(),
+		}
+		if job.DualChannel {
+			normalizeOpts.Channel = 1
+		}
+		// Diarization reads the audio itself and needs the WAV
+		if !job.Diarize {
+			normalizeOpts.AcceptCodecs = wp.transcriber.NativeCodecs()
+		}
+		normalizedPath, normalizeUsage, err = transcription.NormalizeAudio(inputPath, normalizeOpts)
+		if err == nil && job.DualChannel {
+			// The second channel goes where a resumed job will look for it
+			var usage types.ResourceUsage
+			normalizeOpts.Channel, normalizeOpts.OutputPath = 2, secondChannelPath(normalizedPath)
+			_, usage, err = transcription.NormalizeAudio(inputPath, normalizeOpts)
+			normalizeUsage.Add(usage)
+		}
+		normalizeSeconds = time.Since(normalizeStart).Seconds()
+		if err != nil {
+			log.Printf("Worker %d: Audio normalization failed for job %s: %v", workerID, job.ID, err)
+			job.Status = types.StatusFailed
+			job.Error = fmt.Errorf("Audio normalization failed: %v", err)
+			return
+		}
+		wp.saveCheckpoint(job, storage.StageNormalized, normalizedPath, "")
+		wp.ReportProgress(job, PhaseNormalize, 100)
+	}
+	if normalizedPath != job.FilePath {
+		defer wp.cleanupTempFile(normalizedPath)
+		defer wp.cleanupTempFile(transcription.SpeechMapPath(normalizedPath))
+	}
+	channelPaths := []string{normalizedPath}
+	if job.DualChannel {
+		channelPaths = append(channelPaths, secondChannelPath(normalizedPath))
+		defer wp.cleanupTempFile(channelPaths[1])
+		defer wp.cleanupTempFile(transcription.SpeechMapPath(channelPaths[1]))
+	}
+
+	// Step 2: Transcribe with Whisper (or reload the checkpointed result)
+	var result *types.TranscriptionResult
+	if job.resumableFrom(storage.StageTranscribed) {
+		if result, err = loadResult(job.checkpoint.ResultPath); err != nil {
+			log.Printf("Worker %d: Discarding unreadable checkpoint for job %s: %v", workerID, job.ID, err)
+			result = nil
+		}
+	}
+	if result == nil {
+		transcribeStart := time.Now()
+		decodeOpts := transcription.DecodeOptions{Language: job.Language, DecodingParams: job.Decoding,
+			Task: job.Task, InitialPrompt: job.InitialPrompt, Vocabulary: job.Vocabulary, Model: job.Model,
+			Device: wp.transcriber.DeviceFor(workerID)}
+		results := make([]*types.TranscriptionResult, len(channelPaths))
+		for i, path := range channelPaths {
+			if results[i], err = wp.transcribeAudio(workerID, job, path, trimmedDuration(sourceInfo, job), decodeOpts, i, len(channelPaths)); err != nil {
+				job.Status = types.StatusFailed
+				job.Error = err
+				return
+			}
+		}
+		result = results[0]
+		if job.DualChannel {
+			result = transcription.MergeChannels(results, job.ChannelLabels)
+		}
+		transcribeSeconds := time.Since(transcribeStart).Seconds()
+
+		// Prepare result
+		result.JobID = job.ID
+		result.WordCount = len(strings.Fields(result.Text))
+		result.ProcessedAt = time.Now()
+		result.Metadata = job.Metadata
+		result.Labels = job.Labels
+		result.Cost.NormalizeSeconds = normalizeSeconds
+		result.Cost.TranscribeSeconds = transcribeSeconds
+		result.Cost.ComputeSeconds = normalizeSeconds + transcribeSeconds
+		result.Cost.AudioMinutes = result.Duration / 60
+		result.Cost.Model = wp.transcriber.ModelNameFor(decodeOpts)
+		result.Provenance = wp.transcriber.Provenance(decodeOpts)
+		if job.SourceType == types.SourceYouTube {
+			if v := transcription.ToolVersion("yt-dlp"); v != "" {
+				result.Provenance.Versions["yt-dlp"] = v
+			}
+		}
+		wp.eta.observe(result.Cost.ComputeSeconds, result.Duration)
+		result.Resources.Add(normalizeUsage)
+		if job.StartTime > 0 || job.EndTime > 0 {
+			applyTrimOffset(result, job.StartTime, job.EndTime)
+			result.Trim.Truncated = job.truncated
+		}
+		if sourceInfo != nil {
+			result.SourceAudio = sourceInfo.Source()
+		}
+		wp.applyFormatProfile(job, result)
+		if dictionary := wp.replacementsFor(job); dictionary != nil {
+			dictionary.Apply(result)
+		}
+
+		// Encrypted jobs cannot resume anyway, so never write their text in the clear
+		if job.EncryptionKey == nil {
+			resultPath := job.tempPath("_result.json")
+			if err := saveResult(resultPath, result); err != nil {
+				log.Printf("Worker %d: Could not checkpoint result for job %s: %v", workerID, job.ID, err)
+			} else {
+				defer wp.cleanupTempFile(resultPath)
+				wp.saveCheckpoint(job, storage.StageTranscribed, normalizedPath, resultPath)
+			}
+		}
+	}
+
+	// Post-processing hooks; encrypted transcripts never leave the server in the clear
+	if wp.hooks != nil && job.EncryptionKey == nil {
+		if err := wp.runHooks(job, result); err != nil {
+			log.Printf("Worker %d: Post-processing failed for job %s: %v", workerID, job.ID, err)
+			job.Status = types.StatusFailed
+			job.Error = fmt.Errorf("Post-processing failed: %v", err)
+			return
+		}
+	}
+
+	// Registered Go post-processors
+	if err := wp.runPostProcessors(job, result); err != nil {
+		log.Printf("Worker %d: Post-processing failed for job %s: %v", workerID, job.ID, err)
+		job.Status = types.StatusFailed
+		job.Error = fmt.Errorf("Post-processing failed: %v", err)
+		return
+	}
+
+	// Hooks and stages may have rewritten the text or metadata
+	result.WordCount = len(strings.Fields(result.Text))
+	job.Metadata = result.Metadata
+
+	// A worker given up on as stalled must not store a result its
+	// requeued copy will store too
+	if !wp.stats.owns(workerID, job) {
+		job.Status = types.StatusFailed
+		job.Error = errStalled
+		return
+	}
+
+	// Steps 3-5: Save locally, upload to Drive, record in the database
+	audioPath, cleanupAudio := wp.playbackAudio(job, sourceInfo)
+	defer cleanupAudio()
+	if err := wp.persist(fmt.Sprintf("Worker %d", workerID), job, result, sourceSHA256, audioPath); err != nil {
+		job.Status = types.StatusFailed
+		job.Error = err
+		return
+	}
+
+	// Step 6: Cleanup
+	wp.cleanupTempFile(job.FilePath)
+
+	job.Result = result
+	job.Status = types.StatusCompleted
+	log.Printf("Worker %d: Job %s completed successfully (local: %s, gdrive: %s)",
+		workerID, job.ID, result.LocalPath, result.GDriveURL)
+}
+
+// transcribeProgress turns whisper's segment end times into progress
+// reports, one per whole percent of the audio decoded; nil when the
+// audio's length is unknown
+func (wp *WorkerPool) transcribeProgress(job *Job, audioSeconds float64) func(float64) {
+	if audioSeconds <= 0 {
+		return nil
+	}
+	var last float64
+	return func(end float64) {
+		pct := min(end*100/audioSeconds, 100)
+		if pct-last < 1 {
+			return
+		}
+		last = pct
+		wp.ReportProgress(job, PhaseTranscribe, pct)
+	}
+}
+
+// transcribeAudio transcribes one normalized WAV of a job, mapping the
+// result back onto the original timeline when silence was removed from it.
+// part of parts places it in the job's progress, for jobs that transcribe
+// each channel of a call in turn.
+func (wp *WorkerPool) transcribeAudio(workerID int, job *Job, wavPath string, audioSeconds float64,
+	decodeOpts transcription.DecodeOptions, part, parts int) (*types.TranscriptionResult, error) {
+	// Silence removal shortened the audio; its speech map leads back
+	speech, err := transcription.LoadSpeechMap(wavPath)
+	if err != nil {
+		log.Printf("Worker %d: Could not read the speech map for job %s: %v", workerID, job.ID, err)
+		return nil, fmt.Errorf("Silence removal failed: %v", err)
+	}
+	if speech != nil {
+		audioSeconds = speech.Condensed()
+	} else if job.Speedup > 1 {
+		audioSeconds /= job.Speedup
+	}
+
+	var progress func(float64)
+	if report := wp.transcribeProgress(job, audioSeconds*float64(parts)); report != nil {
+		progress = func(end float64) { report(audioSeconds*float64(part) + end) }
+	}
+	result, err := wp.transcriber.TranscribeLong(wavPath, audioSeconds, decodeOpts, progress)
+	if err != nil {
+		log.Printf("Worker %d: Transcription failed for job %s: %v", workerID, job.ID, err)
+		return nil, fmt.Errorf("Transcription failed: %v", err)
+	}
+	wp.snapshotRawOutput(job, result)
+
+	// Re-decode any stretch where whisper got stuck in a loop
+	result.Resources.Add(wp.transcriber.RepairRepetitions(wavPath, decodeOpts, result))
+	// Diarized on the same audio, so the turns line up with the segments
+	// before either is mapped back to the original timeline
+	if job.Diarize {
+		wp.heartbeat(job)
+		if err := wp.transcriber.Diarize(wavPath, job.Speakers, result); err != nil {
+			log.Printf("Worker %d: Diarization failed for job %s, keeping the transcript without speakers: %v", workerID, job.ID, err)
+		}
+		wp.heartbeat(job)
+	}
+	if speech != nil {
+		speech.Restore(result)
+	}
+	transcription.RestoreSpeed(result, job.Speedup)
+	return result, nil
+}
+
+// secondChannelPath is where the second channel of a dual-channel job is
+// normalized to, next to the first
+func secondChannelPath(normalizedPath string) string {
+	return strings.TrimSuffix(normalizedPath, ".wav") + "_ch2.wav"
+}
+
+// applyFormatProfile rewrites numbers, times, and amounts in the result
+// with the job's profile, or the pool default
+func (wp *WorkerPool) applyFormatProfile(job *Job, result *types.TranscriptionResult) {
+	name := job.FormatProfile
+	if name == "" {
+		name = wp.formatProfile
+	}
+	if name == "" {
+		return
+	}
+	profile, ok := transcription.LookupFormatProfile(name)
+	if !ok {
+		log.Printf("WARNING: job %s asks for unknown format profile %q, leaving text as is", job.ID, name)
+		return
+	}
+	transcription.ApplyFormatProfile(result, profile)
+}
+
+// runHooks passes a result through the post-processing hooks
+func (wp *WorkerPool) runHooks(job *Job, result *types.TranscriptionResult) error {
+	skipped, err := wp.hooks.Run(stages.HookRequest{
+		JobID:       job.ID,
+		RequestName: job.RequestName,
+		SourceType:  job.SourceType,
+		Result:      result,
+	})
+	for _, hookErr := range skipped {
+		log.Printf("WARNING: skipping post-processing for job %s: %v", job.ID, hookErr)
+	}
+	return err
+}
+
+// persist saves a finished result: local artifacts, the Drive copy, the
+// database records, and the search index. audioPath, when set, is kept
+// next to the transcript for playback. who prefixes log lines. Only a
+// failed local save is an error; the other steps log and carry on.
+func (wp *WorkerPool) persist(who string, job *Job, result *types.TranscriptionResult, sourceSHA256, audioPath string) error {
+	// Save locally
+	local, saveOpts := wp.jobStorage(job, storage.SaveOptions{EncryptionKey: job.EncryptionKey})
+	localPath, err := local.SaveTranscript(job.RequestName, result, saveOpts)
+	if err != nil {
+		log.Printf("%s: Local save failed for job %s: %v", who, job.ID, err)
+		return fmt.Errorf("Local save failed: %v", err)
+	}
+	result.LocalPath = localPath
+	if audioPath != "" {
+		if _, err := local.SaveAudio(localPath, audioPath); err != nil {
+			log.Printf("%s: Keeping audio failed for job %s: %v", who, job.ID, err)
+		}
+	}
+	if wp.keepRawOutput && result.RawOutput != nil {
+		if err := local.SaveRawOutput(localPath, result.RawOutput, saveOpts); err != nil {
+			log.Printf("%s: Keeping raw output failed for job %s: %v", who, job.ID, err)
+		}
+	}
+
+	// Upload to Google Drive (with retry)
+	var driveURL string
+	if wp.driveClient != nil {
+		for attempt := 1; attempt <= 3; attempt++ {
+			driveURL, err = wp.driveClient.Upload(job.RequestName, result, saveOpts)
+			if err == nil {
+				result.GDriveURL = driveURL
+				break
+			}
+			log.Printf("%s: Google Drive upload attempt %d/3 failed: %v", who, attempt, err)
+			if attempt < 3 {
+				time.Sleep(time.Duration(attempt*attempt) * time.Second) // Exponential backoff
+			}
+		}
+		if err != nil {
+			log.Printf("%s: WARNING - Google Drive upload failed after 3 attempts, continuing with local save only", who)
+		}
+	}
+
+	// Save metadata to database
+	if wp.db != nil {
+		err = wp.db.SaveTranscript(job.ID, job.RequestName, string(job.SourceType),
+			result.GDriveURL, localPath, result.Duration, result.WordCount, job.Metadata)
+		if err != nil {
+			log.Printf("%s: Database save failed: %v", who, err)
+		} else {
+			if err := wp.db.SaveLabels(job.ID, job.Labels); err != nil {
+				log.Printf("%s: Saving labels failed: %v", who, err)
+			}
+			if err := wp.db.SaveCost(job.ID, result.Cost); err != nil {
+				log.Printf("%s: Saving cost failed: %v", who, err)
+			}
+			if err := wp.db.SaveResourceUsage(job.ID, result.Resources); err != nil {
+				log.Printf("%s: Saving resource usage failed: %v", who, err)
+			}
+			if err := wp.saveChecksums(job.ID, sourceSHA256, localPath); err != nil {
+				log.Printf("%s: Saving checksums failed: %v", who, err)
+			}
+			if err := wp.db.SaveLanguage(job.ID, result.Language, result.LanguageConfidence); err != nil {
+				log.Printf("%s: Saving language failed: %v", who, err)
+			}
+			if err := wp.db.SaveTask(job.ID, result.Task); err != nil {
+				log.Printf("%s: Saving task failed: %v", who, err)
+			}
+			if err := wp.db.SaveConfidence(job.ID, types.MeanConfidence(result.Segments)); err != nil {
+				log.Printf("%s: Saving confidence failed: %v", who, err)
+			}
+			if err := wp.db.SaveProvenance(job.ID, result.Provenance); err != nil {
+				log.Printf("%s: Saving provenance failed: %v", who, err)
+			}
+			if err := wp.db.SaveReplacements(job.ID, result.Replacements); err != nil {
+				log.Printf("%s: Saving replacements failed: %v", who, err)
+			}
+			if result.SourceAudio != nil {
+				if err := wp.db.SaveSourceAudio(job.ID, *result.SourceAudio); err != nil {
+					log.Printf("%s: Saving source audio format failed: %v", who, err)
+				}
+			}
+			if job.EncryptionKey != nil {
+				if err := wp.db.SaveEncryption(job.ID, storage.KeyFingerprint(job.EncryptionKey)); err != nil {
+					log.Printf("%s: Saving key fingerprint failed: %v", who, err)
+				}
+			} else {
+				source := storage.VersionTranscribed
+				if job.SourceType == types.SourceImport {
+					source = storage.VersionImported
+				}
+				if _, err := wp.db.SaveTranscriptVersion(job.ID, source, result.Cost.Model, result.Segments); err != nil {
+					log.Printf("%s: Saving transcript version failed: %v", who, err)
+				}
+			}
+
+			localBytes := wp.localStorage.ArtifactBytes(localPath)
+			var driveBytes int64
+			if result.GDriveURL != "" {
+				driveBytes = localBytes // Drive receives the same artifacts, less the audio and raw output
+				if path, ok := storage.AudioPath(localPath); ok {
+					if info, err := os.Stat(path); err == nil {
+						driveBytes -= info.Size()
+					}
+				}
+				if info, err := os.Stat(storage.RawOutputPath(localPath)); err == nil {
+					driveBytes -= info.Size()
+				}
+			}
+			if err := wp.db.SaveStorageUsage(job.ID, localBytes, driveBytes); err != nil {
+				log.Printf("%s: Saving storage usage failed: %v", who, err)
+			}
+			if wp.quota != nil {
+				wp.quota.WarnIfNearLimit(job.Labels[storage.TenantLabel])
+			}
+		}
+	}
+
+	// Encrypted transcripts stay sealed; never index their text
+	if wp.indexer != nil && job.EncryptionKey == nil {
+		go wp.indexTranscript(job, result)
+	}
+	return nil
+}
+
+// saveChecksums hashes a job's stored artifacts and records them with the
+// source audio checksum
+func (wp *WorkerPool) saveChecksums(jobID, sourceSHA256, localPath string) error {
+	artifacts := make(map[string]string)
+	for _, path := range wp.localStorage.ArtifactPaths(localPath) {
+		sum, err := storage.FileSHA256(path)
+		if err != nil {
+			return err
+		}
+		artifacts[path] = sum
+	}
+	return wp.db.SaveChecksums(jobID, sourceSHA256, artifacts)
+}
+
+// wipeKey zeroes and drops a job's client encryption key once it is no longer needed
+func wipeKey(job *Job) {
+	for i := range job.EncryptionKey {
+		job.EncryptionKey[i] = 0
+	}
+	job.EncryptionKey = nil
+}
+
+// cleanupTempFile removes a temporary file
+func (wp *WorkerPool) cleanupTempFile(filePath string) {
+	if filePath == "" {
+		return
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to cleanup temp file %s: %v", filePath, err)
+	}
+}
+
+// indexTranscript pushes a completed transcript to the search index,
+// retrying with backoff like the Drive upload
+func (wp *WorkerPool) indexTranscript(job *Job, result *types.TranscriptionResult) {
+	doc := stages.Document{
+		JobID:       job.ID,
+		RequestName: job.RequestName,
+		SourceType:  job.SourceType,
+		Text:        result.Text,
+		Language:    result.Language,
+		Duration:    result.Duration,
+		WordCount:   result.WordCount,
+		Segments:    result.Segments,
+		Metadata:    job.Metadata,
+		Labels:      job.Labels,
+		CreatedAt:   result.ProcessedAt,
+		GDriveURL:   result.GDriveURL,
+	}
+
+	var err error
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err = wp.indexer.Index(doc); err == nil {
+			return
+		}
+		log.Printf("Search indexing attempt %d/3 for job %s failed: %v", attempt, job.ID, err)
+		if attempt < 3 {
+			time.Sleep(time.Duration(attempt*attempt) * time.Second)
+		}
+	}
+	log.Printf("WARNING: job %s was not indexed for search: %v", job.ID, err)
+}
+
+// trimmedDuration is how much of the probed source a job will transcribe
+// (0 when the source could not be probed)
+func trimmedDuration(info *transcription.AudioInfo, job *Job) float64 {
+	if info == nil {
+		return 0
+	}
+	end := info.Duration
+	if job.EndTime > 0 && job.EndTime < end {
+		end = job.EndTime
+	}
+	return max(end-job.StartTime, 0)
+}
+
+// applyTrimOffset shifts segment timestamps from the cut back onto the
+// original recording's timeline and records the trimmed range
+func applyTrimOffset(result *types.TranscriptionResult, start, end float64) {
+	for i := range result.Segments {
+		result.Segments[i].Start += start
+		result.Segments[i].End += start
+	}
+	for format, content := range result.Formats {
+		result.Formats[format] = transcription.ShiftTimestamps(format, content, start)
+	}
+	result.Trim = &types.TrimRange{StartTime: start, EndTime: end}
+}
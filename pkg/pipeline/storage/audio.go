@@ -0,0 +1,84 @@
+package storage
+
+// Retained audio — a copy of a job's audio kept next to its transcript as
+// <name>_audio.<ext>, so reviewers can listen while reading. Sealed
+// transcripts never keep audio: it is too large to seal with the client's
+// key and would otherwise sit on disk in the clear.
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// audioPrefix is the start of the retained audio's name for a transcript;
+// the audio's own extension follows
+func audioPrefix(txtPath string) string {
+	return strings.TrimSuffix(txtPath, ".txt") + "_audio."
+}
+
+// SaveAudio copies the audio file at src next to a transcript, keeping its
+// extension, and returns where it went
+func (ls *LocalStorage) SaveAudio(txtPath, src string) (string, error) {
+	if IsEncrypted(txtPath) {
+		return "", fmt.Errorf("sealed transcripts do not keep audio")
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(src)), ".")
+	if ext == "" {
+		ext = "audio"
+	}
+	dst := audioPrefix(txtPath) + ext
+	if err := copyFile(src, dst); err != nil {
+		return "", fmt.Errorf("failed to save audio: %v", err)
+	}
+	return dst, nil
+}
+
+// AudioPath returns the audio kept next to a transcript, if any
+func AudioPath(txtPath string) (string, bool) {
+	if txtPath == "" || IsEncrypted(txtPath) {
+		return "", false
+	}
+	prefix := audioPrefix(txtPath)
+	entries, err := os.ReadDir(filepath.Dir(prefix))
+	if err != nil {
+		return "", false
+	}
+	base := filepath.Base(prefix)
+	for _, entry := range entries {
+		ext, ok := strings.CutPrefix(entry.Name(), base)
+		// Another transcript's artifacts may share the prefix (a request
+		// named "x_audio"), but never with an audio extension
+		if !ok || entry.IsDir() || ext == "" || strings.Contains(ext, ".") ||
+			ext == "txt" || ext == "json" || slices.Contains(types.OutputFormats, ext) {
+			continue
+		}
+		return filepath.Join(filepath.Dir(prefix), entry.Name()), true
+	}
+	return "", false
+}
+
+// copyFile copies src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
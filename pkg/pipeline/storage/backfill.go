@@ -0,0 +1,169 @@
+package storage
+
+// Historical backfill — rebuilds transcripts rows from the transcript and
+// _meta.json pairs in an output directory, for transcripts written before
+// the database existed or after it was lost. The metadata file holds
+// nearly everything the row does; what it lacks (the source type) is taken
+// from the jobs table when the job is still there.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// sourceUnknown is the source type of backfilled transcripts whose job is
+// not in the jobs table
+const sourceUnknown = "unknown"
+
+// StoredTranscript is a transcript found on disk with its metadata file
+type StoredTranscript struct {
+	TxtPath  string
+	MetaPath string
+
+	JobID              string                 `json:"job_id"`
+	RequestName        string                 `json:"request_name"`
+	Duration           float64                `json:"duration_seconds"`
+	WordCount          int                    `json:"word_count"`
+	Language           string                 `json:"language"`
+	LanguageConfidence float64                `json:"language_confidence"`
+	Task               string                 `json:"task"`
+	CreatedAt          time.Time              `json:"created_at"`
+	Segments           []types.Segment        `json:"segments"`
+	Metadata           map[string]interface{} `json:"metadata"`
+	Labels             map[string]string      `json:"labels"`
+	Cost               types.JobCost          `json:"cost"`
+	Resources          types.ResourceUsage    `json:"resources"`
+	SourceAudio        *types.SourceAudio     `json:"source_audio"`
+	Provenance         *types.Provenance      `json:"provenance"`
+	Replacements       []types.Replacement    `json:"replacements"`
+	GDriveURL          string                 `json:"gdrive_url"`
+}
+
+// Text reads the transcript's text, without the byte order mark or CRLF
+// line endings it may have been written with
+func (t *StoredTranscript) Text() (string, error) {
+	data, err := os.ReadFile(t.TxtPath)
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimPrefix(string(data), utf8BOM)
+	return strings.ReplaceAll(text, "\r\n", "\n"), nil
+}
+
+// ScanTranscripts walks dir for transcripts with a readable metadata file,
+// calling fn for each. Sealed transcripts can't be read without their
+// client's key and are passed to skip instead, as are metadata files with
+// no transcript or no job ID; either callback may stop the walk with an
+// error.
+func ScanTranscripts(dir string, fn func(t *StoredTranscript) error, skip func(path, reason string) error) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(name, "_meta.json"+encryptedSuffix) {
+			return skip(path, "encrypted with a client key")
+		}
+		base, ok := strings.CutSuffix(path, "_meta.json")
+		if !ok {
+			return nil
+		}
+
+		t := &StoredTranscript{TxtPath: base + ".txt", MetaPath: path}
+		if _, err := os.Stat(t.TxtPath); err != nil {
+			return skip(path, "no transcript next to it")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return skip(path, err.Error())
+		}
+		if err := json.Unmarshal(data, t); err != nil {
+			return skip(path, fmt.Sprintf("unreadable metadata: %v", err))
+		}
+		if t.JobID == "" {
+			return skip(path, "no job_id")
+		}
+		if t.RequestName == "" {
+			t.RequestName = filepath.Base(base)
+		}
+		if t.CreatedAt.IsZero() {
+			if info, err := os.Stat(path); err == nil {
+				t.CreatedAt = info.ModTime()
+			}
+		}
+		return fn(t)
+	})
+}
+
+// BackfillTranscript inserts the transcripts row for a transcript found on
+// disk, with its labels, cost, language, and the other details its
+// metadata file records. It reports false, changing nothing, when the job
+// already has a row.
+func (mdb *MetadataDB) BackfillTranscript(t *StoredTranscript, storageBytes int64) (bool, error) {
+	var metadataJSON interface{}
+	if len(t.Metadata) > 0 {
+		encoded, err := json.Marshal(t.Metadata)
+		if err != nil {
+			return false, fmt.Errorf("failed to encode metadata: %v", err)
+		}
+		metadataJSON = string(encoded)
+	}
+
+	res, err := mdb.db.Exec(`
+	INSERT INTO transcripts (job_id, request_name, source_type, gdrive_url, local_path, created_at, duration, word_count, metadata)
+	VALUES (?, ?, COALESCE((SELECT source_type FROM jobs WHERE job_id = ?), ?), ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (job_id) DO NOTHING`,
+		t.JobID, t.RequestName, t.JobID, sourceUnknown, t.GDriveURL, t.TxtPath,
+		t.CreatedAt.UTC(), t.Duration, t.WordCount, metadataJSON)
+	if err != nil {
+		return false, fmt.Errorf("failed to backfill transcript %s: %v", t.JobID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := mdb.SaveLabels(t.JobID, t.Labels); err != nil {
+		return true, err
+	}
+	if err := mdb.SaveCost(t.JobID, t.Cost); err != nil {
+		return true, err
+	}
+	if err := mdb.SaveResourceUsage(t.JobID, t.Resources); err != nil {
+		return true, err
+	}
+	if err := mdb.SaveLanguage(t.JobID, t.Language, t.LanguageConfidence); err != nil {
+		return true, err
+	}
+	if err := mdb.SaveTask(t.JobID, t.Task); err != nil {
+		return true, err
+	}
+	if err := mdb.SaveConfidence(t.JobID, types.MeanConfidence(t.Segments)); err != nil {
+		return true, err
+	}
+	if err := mdb.SaveProvenance(t.JobID, t.Provenance); err != nil {
+		return true, err
+	}
+	if err := mdb.SaveReplacements(t.JobID, t.Replacements); err != nil {
+		return true, err
+	}
+	if t.SourceAudio != nil {
+		if err := mdb.SaveSourceAudio(t.JobID, *t.SourceAudio); err != nil {
+			return true, err
+		}
+	}
+	var driveBytes int64
+	if t.GDriveURL != "" {
+		driveBytes = storageBytes // Drive received the same artifacts
+	}
+	return true, mdb.SaveStorageUsage(t.JobID, storageBytes, driveBytes)
+}
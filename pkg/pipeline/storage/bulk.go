@@ -0,0 +1,149 @@
+package storage
+
+// Bulk selection — finds the transcripts a bulk operation applies to (by
+// creation time, source, and labels) and reloads stored transcripts so
+// they can be pushed to Drive or the search index again.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// TranscriptSelection picks transcripts for a bulk operation; zero fields
+// don't restrict the selection
+type TranscriptSelection struct {
+	From       time.Time         // created at or after
+	To         time.Time         // created before
+	SourceType string            // upload, gdrive, youtube, stream, or import
+	Labels     map[string]string // every label must match
+}
+
+// IsEmpty reports whether the selection would match every transcript
+func (s TranscriptSelection) IsEmpty() bool {
+	return s.From.IsZero() && s.To.IsZero() && s.SourceType == "" && len(s.Labels) == 0
+}
+
+// SelectTranscripts returns the job IDs of the transcripts in a selection,
+// oldest first
+func (mdb *MetadataDB) SelectTranscripts(sel TranscriptSelection) ([]string, error) {
+	where, args := TranscriptFilter{Labels: sel.Labels}.whereClause()
+	and := func(condition string, arg interface{}) {
+		if where == "" {
+			where = ` WHERE `
+		} else {
+			where += ` AND `
+		}
+		where += condition
+		args = append(args, arg)
+	}
+	if !sel.From.IsZero() {
+		and(`substr(created_at, 1, 19) >= ?`, dbTime(sel.From))
+	}
+	if !sel.To.IsZero() {
+		and(`substr(created_at, 1, 19) < ?`, dbTime(sel.To))
+	}
+	if sel.SourceType != "" {
+		and(`source_type = ?`, sel.SourceType)
+	}
+
+	rows, err := mdb.db.Query(`SELECT job_id FROM transcripts`+where+` ORDER BY created_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select transcripts: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to select transcripts: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SaveDriveURL records where a transcript's Drive copy now is
+func (mdb *MetadataDB) SaveDriveURL(jobID, driveURL string) error {
+	if _, err := mdb.db.Exec(`UPDATE transcripts SET gdrive_url = ? WHERE job_id = ?`, driveURL, jobID); err != nil {
+		return fmt.Errorf("failed to save Drive URL: %v", err)
+	}
+	return nil
+}
+
+// LoadTranscript reads a stored transcript back from its text, metadata
+// JSON, and renderings. Text artifacts are returned as stored, with any
+// BOM and CRLF line endings. Encrypted transcripts can't be read without
+// the client's key.
+func (ls *LocalStorage) LoadTranscript(txtPath string) (*types.TranscriptionResult, error) {
+	if IsEncrypted(txtPath) {
+		return nil, fmt.Errorf("transcript is encrypted with a client key")
+	}
+	text, err := os.ReadFile(txtPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %v", err)
+	}
+	metaJSON, err := os.ReadFile(metaPathFor(txtPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %v", err)
+	}
+
+	var meta struct {
+		JobID              string                   `json:"job_id"`
+		Duration           float64                  `json:"duration_seconds"`
+		WordCount          int                      `json:"word_count"`
+		Language           string                   `json:"language"`
+		LanguageConfidence float64                  `json:"language_confidence"`
+		Task               string                   `json:"task"`
+		CreatedAt          time.Time                `json:"created_at"`
+		Segments           []types.Segment          `json:"segments"`
+		Metadata           map[string]interface{}   `json:"metadata"`
+		Labels             map[string]string        `json:"labels"`
+		Cost               types.JobCost            `json:"cost"`
+		Resources          types.ResourceUsage      `json:"resources"`
+		Repetitions        []types.RepetitionRegion `json:"repetitions"`
+		Provenance         *types.Provenance        `json:"provenance"`
+		Replacements       []types.Replacement      `json:"replacements"`
+	}
+	if err := json.Unmarshal(metaJSON, &meta); err != nil {
+		return nil, fmt.Errorf("corrupt metadata %s: %v", metaPathFor(txtPath), err)
+	}
+
+	result := &types.TranscriptionResult{
+		JobID:              meta.JobID,
+		Text:               string(text),
+		Language:           meta.Language,
+		LanguageConfidence: meta.LanguageConfidence,
+		Task:               meta.Task,
+		Duration:           meta.Duration,
+		Segments:           meta.Segments,
+		WordCount:          meta.WordCount,
+		ProcessedAt:        meta.CreatedAt,
+		LocalPath:          txtPath,
+		Metadata:           meta.Metadata,
+		Labels:             meta.Labels,
+		Cost:               meta.Cost,
+		Resources:          meta.Resources,
+		Repetitions:        meta.Repetitions,
+		Provenance:         meta.Provenance,
+		Replacements:       meta.Replacements,
+	}
+	for _, format := range types.OutputFormats {
+		content, err := os.ReadFile(FormatPath(txtPath, format))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s transcript: %v", format, err)
+		}
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = string(content)
+	}
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package storage
+
+// Stage checkpoints — enough of each in-flight job's state to resume it
+// from its last completed pipeline stage after a crash or restart.
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Pipeline stages, in order
+const (
+	StageQueued      = "queued"      // source audio on disk
+	StageNormalized  = "normalized"  // normalized WAV on disk
+	StageTranscribed = "transcribed" // transcription result saved as JSON
+)
+
+// stageOrder ranks stages so "at least stage X" can be compared
+var stageOrder = map[string]int{StageQueued: 1, StageNormalized: 2, StageTranscribed: 3}
+
+// JobCheckpoint is the persisted state of an unfinished job
+type JobCheckpoint struct {
+	JobID       string                 `json:"job_id"`
+	RequestName string                 `json:"request_name"`
+	SourceType  string                 `json:"source_type"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Labels      map[string]string      `json:"labels,omitempty"`
+	StartTime   float64                `json:"start_time,omitempty"`
+	EndTime     float64                `json:"end_time,omitempty"`
+	Priority    int                    `json:"priority,omitempty"`
+	// FormatProfile is the job's number/time formatting profile, if set
+	FormatProfile string `json:"format_profile,omitempty"`
+	// Destinations are the job's chosen output destinations, if any
+	Destinations []string `json:"destinations,omitempty"`
+	// DriveFormats are the job's chosen Drive renderings, if any
+	DriveFormats []string `json:"drive_formats,omitempty"`
+	// TextEncoding is the job's chosen text artifact encoding, if any
+	TextEncoding *TextEncoding `json:"text_encoding,omitempty"`
+	// Language is the job's chosen spoken language, if any
+	Language string `json:"language,omitempty"`
+	// Task is the job's chosen task, if not transcription
+	Task string `json:"task,omitempty"`
+	// InitialPrompt and Vocabulary are the job's prompt and domain terms
+	InitialPrompt string   `json:"initial_prompt,omitempty"`
+	Vocabulary    []string `json:"vocabulary,omitempty"`
+	// Decoding holds the job's decoding parameter overrides
+	Decoding types.DecodingParams `json:"decoding"`
+	Model    string               `json:"model,omitempty"`
+	// Denoise is set when the job asked for noise reduction
+	Denoise bool `json:"denoise,omitempty"`
+	// Speedup is the job's playback speed-up, if any
+	Speedup float64 `json:"speedup,omitempty"`
+	// Diarize is set when the job asked for speaker labels, with Speakers
+	// the number of speakers it gave
+	Diarize  bool `json:"diarize,omitempty"`
+	Speakers int  `json:"speakers,omitempty"`
+	// DualChannel is set when the job transcribes a call's channels apart,
+	// with ChannelLabels naming their speakers
+	DualChannel   bool     `json:"dual_channel,omitempty"`
+	ChannelLabels []string `json:"channel_labels,omitempty"`
+	// GroupID is the job group the job belongs to, if any
+	GroupID string `json:"group_id,omitempty"`
+	// Truncated is set when EndTime was moved in to fit the duration limit
+	Truncated bool `json:"truncated,omitempty"`
+	// Encrypted jobs cannot resume: their key is never persisted
+	Encrypted bool `json:"encrypted,omitempty"`
+
+	Stage          string `json:"stage"`
+	SourcePath     string `json:"source_path"`
+	NormalizedPath string `json:"normalized_path,omitempty"`
+	ResultPath     string `json:"result_path,omitempty"`
+}
+
+// Reached reports whether the checkpoint is at or past stage
+func (cp *JobCheckpoint) Reached(stage string) bool {
+	return cp != nil && stageOrder[cp.Stage] >= stageOrder[stage]
+}
+
+// SaveCheckpoint records the latest checkpoint for a job
+func (mdb *MetadataDB) SaveCheckpoint(cp *JobCheckpoint) error {
+	encoded, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %v", err)
+	}
+	_, err = mdb.db.Exec(`UPDATE jobs SET checkpoint = ?, updated_at = ? WHERE job_id = ?`,
+		string(encoded), time.Now().UTC(), cp.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %v", err)
+	}
+	return nil
+}
+
+// ClearCheckpoint drops a job's checkpoint once it has finished
+func (mdb *MetadataDB) ClearCheckpoint(jobID string) error {
+	if _, err := mdb.db.Exec(`UPDATE jobs SET checkpoint = NULL WHERE job_id = ?`, jobID); err != nil {
+		return fmt.Errorf("failed to clear checkpoint: %v", err)
+	}
+	return nil
+}
+
+// UnfinishedCheckpoints returns the checkpoints of jobs that were queued or
+// processing when the service stopped, oldest first
+func (mdb *MetadataDB) UnfinishedCheckpoints() ([]*JobCheckpoint, error) {
+	rows, err := mdb.db.Query(`SELECT checkpoint FROM jobs
+		WHERE checkpoint IS NOT NULL AND status IN (?, ?)
+		ORDER BY created_at`, types.StatusQueued, types.StatusProcessing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoints: %v", err)
+	}
+	defer rows.Close()
+
+	var checkpoints []*JobCheckpoint
+	for rows.Next() {
+		var encoded string
+		if err := rows.Scan(&encoded); err != nil {
+			return nil, err
+		}
+		var cp JobCheckpoint
+		if err := json.Unmarshal([]byte(encoded), &cp); err != nil {
+			return nil, fmt.Errorf("corrupt checkpoint: %v", err)
+		}
+		checkpoints = append(checkpoints, &cp)
+	}
+	return checkpoints, rows.Err()
+}
+
+// FailInterruptedDownloads marks jobs that were still downloading their
+// source when the service stopped as failed; returns how many were affected
+func (mdb *MetadataDB) FailInterruptedDownloads() (int64, error) {
+	result, err := mdb.db.Exec(`UPDATE jobs SET status = ?, error = ?, updated_at = ? WHERE status = ?`,
+		types.StatusFailed, "download interrupted by service restart", time.Now().UTC(), types.StatusDownloading)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail interrupted downloads: %v", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,167 @@
+package storage
+
+// Integrity checks — SHA-256 of each job's source audio and stored
+// artifacts, recorded at save time and re-verified on demand or on a
+// schedule to catch silent corruption or tampering.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// Artifact verification outcomes
+const (
+	ChecksumOK       = "ok"
+	ChecksumMismatch = "mismatch"
+	ChecksumMissing  = "missing"
+)
+
+// ArtifactCheck is the verification result for one stored file
+type ArtifactCheck struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	Status   string `json:"status"`
+}
+
+// FileSHA256 returns the hex SHA-256 of a file's contents
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SaveChecksums records the source audio hash and the hash of each stored
+// artifact (path -> sha256) for a job
+func (mdb *MetadataDB) SaveChecksums(jobID, sourceSHA256 string, artifacts map[string]string) error {
+	tx, err := mdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save checksums: %v", err)
+	}
+	defer tx.Rollback()
+
+	if sourceSHA256 != "" {
+		if _, err := tx.Exec(`UPDATE transcripts SET source_sha256 = ? WHERE job_id = ?`, sourceSHA256, jobID); err != nil {
+			return fmt.Errorf("failed to save source checksum: %v", err)
+		}
+	}
+	for _, path := range sortedKeys(artifacts) {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO artifact_checksums (job_id, path, sha256) VALUES (?, ?, ?)`,
+			jobID, path, artifacts[path])
+		if err != nil {
+			return fmt.Errorf("failed to save checksum for %s: %v", path, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save checksums: %v", err)
+	}
+	return nil
+}
+
+// VerifyArtifacts re-hashes a job's stored artifacts and compares them with
+// the recorded checksums
+func (mdb *MetadataDB) VerifyArtifacts(jobID string) ([]ArtifactCheck, error) {
+	rows, err := mdb.db.Query(`SELECT path, sha256 FROM artifact_checksums WHERE job_id = ? ORDER BY path`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checksums: %v", err)
+	}
+
+	var checks []ArtifactCheck
+	for rows.Next() {
+		var c ArtifactCheck
+		if err := rows.Scan(&c.Path, &c.Expected); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	rows.Close()
+
+	for i := range checks {
+		checks[i].Actual, err = FileSHA256(checks[i].Path)
+		switch {
+		case err != nil:
+			checks[i].Status = ChecksumMissing
+		case checks[i].Actual != checks[i].Expected:
+			checks[i].Status = ChecksumMismatch
+		default:
+			checks[i].Status = ChecksumOK
+		}
+	}
+	return checks, nil
+}
+
+// VerifyAllArtifacts checks every recorded artifact, logging each failure,
+// and returns how many jobs were checked and how many failed
+func (mdb *MetadataDB) VerifyAllArtifacts() (int, int, error) {
+	rows, err := mdb.db.Query(`SELECT DISTINCT job_id FROM artifact_checksums`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list checksummed jobs: %v", err)
+	}
+	var jobIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		jobIDs = append(jobIDs, id)
+	}
+	rows.Close()
+
+	var failed int
+	for _, id := range jobIDs {
+		checks, err := mdb.VerifyArtifacts(id)
+		if err != nil {
+			return len(jobIDs), failed, err
+		}
+		for _, c := range checks {
+			if c.Status != ChecksumOK {
+				log.Printf("INTEGRITY: job %s artifact %s is %s", id, c.Path, c.Status)
+				failed++
+				break
+			}
+		}
+	}
+	return len(jobIDs), failed, nil
+}
+
+// StartVerification runs VerifyAllArtifacts every interval until the
+// returned stop function is called
+func (mdb *MetadataDB) StartVerification(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	stop := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				checked, failed, err := mdb.VerifyAllArtifacts()
+				if err != nil {
+					log.Printf("Integrity check failed: %v", err)
+				} else {
+					log.Printf("Integrity check complete: %d jobs checked, %d with corrupt or missing artifacts", checked, failed)
+				}
+			case <-stop:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(stop) }
+}
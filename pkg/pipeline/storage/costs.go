@@ -0,0 +1,59 @@
+package storage
+
+// Per-job cost accounting — compute time, audio minutes, and cloud spend
+// recorded alongside each transcript for internal chargeback.
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// SaveCost records the resources consumed by a completed job
+func (mdb *MetadataDB) SaveCost(jobID string, cost types.JobCost) error {
+	query := `
+	UPDATE transcripts
+	SET normalize_seconds = ?, transcribe_seconds = ?, audio_minutes = ?, cloud_cost_usd = ?, model = ?
+	WHERE job_id = ?
+	`
+
+	_, err := mdb.db.Exec(query, cost.NormalizeSeconds, cost.TranscribeSeconds,
+		cost.AudioMinutes, cost.CloudCostUSD, cost.Model, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to save job cost: %v", err)
+	}
+	return nil
+}
+
+// SaveResourceUsage records the CPU time and peak memory of a job's subprocesses
+func (mdb *MetadataDB) SaveResourceUsage(jobID string, usage types.ResourceUsage) error {
+	_, err := mdb.db.Exec(`UPDATE transcripts SET cpu_seconds = ?, peak_memory_mb = ? WHERE job_id = ?`,
+		usage.CPUSeconds, usage.PeakMemoryMB, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to save resource usage: %v", err)
+	}
+	return nil
+}
+
+// RealtimeStats measures a model's speed over its most recent jobs: compute
+// seconds per second of audio, and the average audio length in seconds.
+// ok is false when the model has no history yet.
+func (mdb *MetadataDB) RealtimeStats(model string, sample int) (factor, avgAudioSeconds float64, ok bool, err error) {
+	var compute, audio sql.NullFloat64
+	var jobs int
+	err = mdb.db.QueryRow(`
+	SELECT SUM(normalize_seconds + transcribe_seconds), SUM(audio_minutes) * 60, COUNT(*)
+	FROM (
+		SELECT normalize_seconds, transcribe_seconds, audio_minutes FROM transcripts
+		WHERE model = ? AND audio_minutes > 0
+		ORDER BY created_at DESC LIMIT ?
+	)`, model, sample).Scan(&compute, &audio, &jobs)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("failed to measure realtime factor: %v", err)
+	}
+	if jobs == 0 || audio.Float64 <= 0 {
+		return 0, 0, false, nil
+	}
+	return compute.Float64 / audio.Float64, audio.Float64 / float64(jobs), true, nil
+}
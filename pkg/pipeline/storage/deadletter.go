@@ -0,0 +1,138 @@
+package storage
+
+// Dead-letter list — jobs that failed every attempt, kept with their error,
+// any panic stack, and the details needed to put them back on the queue.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeadJobNotFound is returned when a job is not on the dead-letter list
+var ErrDeadJobNotFound = errors.New("dead job not found")
+
+// DeadJob is a permanently failed job
+type DeadJob struct {
+	JobID       string `json:"job_id"`
+	RequestName string `json:"request_name"`
+	SourceType  string `json:"source_type"`
+	// SourcePath is the retained source audio; empty when it could not be
+	// kept (e.g. encrypted jobs), in which case the job cannot be requeued
+	SourcePath string    `json:"source_path,omitempty"`
+	Error      string    `json:"error"`
+	Stack      string    `json:"stack,omitempty"`
+	Attempts   int       `json:"attempts"`
+	FailedAt   time.Time `json:"failed_at"`
+
+	// Job holds the options the job was submitted with (metadata, labels,
+	// trim, priority) in checkpoint form
+	Job *JobCheckpoint `json:"job"`
+}
+
+const deadJobColumns = `job_id, request_name, source_type, COALESCE(source_path, ''), error,
+	COALESCE(stack, ''), attempts, job, failed_at`
+
+// scanDeadJob reads a row selected with deadJobColumns
+func scanDeadJob(row rowScanner) (*DeadJob, error) {
+	var (
+		d       DeadJob
+		encoded string
+	)
+	err := row.Scan(&d.JobID, &d.RequestName, &d.SourceType, &d.SourcePath, &d.Error,
+		&d.Stack, &d.Attempts, &encoded, &d.FailedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(encoded), &d.Job); err != nil {
+		return nil, fmt.Errorf("corrupt dead job %s: %v", d.JobID, err)
+	}
+	return &d, nil
+}
+
+// SaveDeadJob adds a job to the dead-letter list, replacing any earlier
+// entry for the same job
+func (mdb *MetadataDB) SaveDeadJob(d *DeadJob) error {
+	encoded, err := json.Marshal(d.Job)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead job: %v", err)
+	}
+
+	var sourcePath, stack sql.NullString
+	if d.SourcePath != "" {
+		sourcePath = sql.NullString{String: d.SourcePath, Valid: true}
+	}
+	if d.Stack != "" {
+		stack = sql.NullString{String: d.Stack, Valid: true}
+	}
+
+	_, err = mdb.db.Exec(`
+	INSERT OR REPLACE INTO dead_jobs (job_id, request_name, source_type, source_path, error, stack, attempts, job, failed_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.JobID, d.RequestName, d.SourceType, sourcePath, d.Error, stack, d.Attempts, string(encoded), d.FailedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save dead job: %v", err)
+	}
+	return nil
+}
+
+// GetDeadJob returns one dead-lettered job
+func (mdb *MetadataDB) GetDeadJob(jobID string) (*DeadJob, error) {
+	d, err := scanDeadJob(mdb.db.QueryRow(`SELECT `+deadJobColumns+` FROM dead_jobs WHERE job_id = ?`, jobID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeadJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead job: %v", err)
+	}
+	return d, nil
+}
+
+// ListDeadJobs returns dead-lettered jobs, most recent failure first
+func (mdb *MetadataDB) ListDeadJobs(limit int) ([]*DeadJob, error) {
+	rows, err := mdb.db.Query(`SELECT `+deadJobColumns+` FROM dead_jobs
+		ORDER BY failed_at DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %v", err)
+	}
+	defer rows.Close()
+
+	jobs := []*DeadJob{}
+	for rows.Next() {
+		d, err := scanDeadJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, d)
+	}
+	return jobs, rows.Err()
+}
+
+// DeadJobIDs returns the IDs of every dead-lettered job, oldest first
+func (mdb *MetadataDB) DeadJobIDs() ([]string, error) {
+	rows, err := mdb.db.Query(`SELECT job_id FROM dead_jobs ORDER BY failed_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteDeadJob removes a job from the dead-letter list
+func (mdb *MetadataDB) DeleteDeadJob(jobID string) error {
+	if _, err := mdb.db.Exec(`DELETE FROM dead_jobs WHERE job_id = ?`, jobID); err != nil {
+		return fmt.Errorf("failed to delete dead job: %v", err)
+	}
+	return nil
+}
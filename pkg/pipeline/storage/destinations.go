@@ -0,0 +1,49 @@
+package storage
+
+// Output destinations — named places, set up by the operator, that a job
+// may send its transcripts to instead of the global output directory and
+// Drive folder (e.g. a client's own Drive folder or an archive volume).
+
+import (
+	"fmt"
+	"os"
+)
+
+// Destination types
+const (
+	// DestinationLocal saves the job's artifacts under Dir instead of the
+	// configured output directory
+	DestinationLocal = "local"
+
+	// DestinationGDrive uploads the job's artifacts under the Drive folder
+	// FolderID instead of the configured transcripts folder
+	DestinationGDrive = "gdrive"
+)
+
+// Destination is one configured output location
+type Destination struct {
+	Type     string `yaml:"type" json:"type"`
+	Dir      string `yaml:"dir" json:"dir,omitempty"`
+	FolderID string `yaml:"folder_id" json:"folder_id,omitempty"`
+}
+
+// Validate checks the destination's settings and creates its directory
+func (d Destination) Validate(name string) error {
+	switch d.Type {
+	case DestinationLocal:
+		if d.Dir == "" {
+			return fmt.Errorf("destination %q: dir is required", name)
+		}
+		if err := os.MkdirAll(d.Dir, 0755); err != nil {
+			return fmt.Errorf("destination %q: failed to create dir: %v", name, err)
+		}
+	case DestinationGDrive:
+		if d.FolderID == "" {
+			return fmt.Errorf("destination %q: folder_id is required", name)
+		}
+	default:
+		return fmt.Errorf("destination %q: unsupported type %q (use %q or %q)",
+			name, d.Type, DestinationLocal, DestinationGDrive)
+	}
+	return nil
+}
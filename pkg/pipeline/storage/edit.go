@@ -0,0 +1,217 @@
+package storage
+
+// Transcript edits — corrections made after a transcript is stored: a new
+// request name (which also renames its local files), a description, the
+// language it is actually in, or corrected segments (which rewrite its
+// text, renderings, and metadata file).
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// timestampPrefix is the "20060102_150405_" start of every artifact name
+const timestampPrefix = "20060102_150405_"
+
+// TranscriptEdit is a correction to a stored transcript; nil fields are
+// left as they are
+type TranscriptEdit struct {
+	RequestName *string `json:"request_name"`
+	Description *string `json:"description"`
+	Language    *string `json:"language"`
+	// Segments replace the transcript's segments; nil leaves them
+	Segments []types.Segment `json:"segments"`
+}
+
+// RenameTranscript moves a transcript's local files to names built from
+// requestName, keeping their timestamp prefix, and returns the new text
+// path with each moved file's old and new path. Files that are already
+// named for requestName are left alone.
+func (ls *LocalStorage) RenameTranscript(txtPath, requestName string) (string, map[string]string, error) {
+	dir, base := filepath.Split(txtPath)
+	prefix := ""
+	if len(base) > len(timestampPrefix) {
+		if _, err := time.Parse(timestampPrefix, base[:len(timestampPrefix)]); err == nil {
+			prefix = base[:len(timestampPrefix)]
+		}
+	}
+	newPath := filepath.Join(dir, prefix+sanitizeFilename(requestName)+".txt")
+	if IsEncrypted(txtPath) {
+		newPath += encryptedSuffix
+	}
+	if newPath == txtPath {
+		return txtPath, nil, nil
+	}
+
+	audioPath, _ := AudioPath(txtPath)
+	moves := make(map[string]string)
+	for _, path := range ls.ArtifactPaths(txtPath) {
+		switch {
+		case path == txtPath:
+			moves[path] = newPath
+		case path == metaPathFor(txtPath):
+			moves[path] = metaPathFor(newPath)
+		case path == RawOutputPath(txtPath):
+			moves[path] = RawOutputPath(newPath)
+		case path == audioPath:
+			moves[path] = audioPrefix(newPath) + strings.TrimPrefix(path, audioPrefix(txtPath))
+		default:
+			for _, format := range types.OutputFormats {
+				if path == FormatPath(txtPath, format) {
+					moves[path] = FormatPath(newPath, format)
+				}
+			}
+		}
+	}
+	for _, to := range moves {
+		if _, err := os.Stat(to); err == nil {
+			return "", nil, fmt.Errorf("%s already exists", to)
+		}
+	}
+
+	moved := make(map[string]string, len(moves))
+	for from, to := range moves {
+		if err := os.Rename(from, to); err != nil {
+			if os.IsNotExist(err) {
+				continue // e.g. a metadata file that was never written
+			}
+			// Put back what was already moved
+			for done, back := range moved {
+				os.Rename(back, done)
+			}
+			return "", nil, fmt.Errorf("failed to rename %s: %v", from, err)
+		}
+		moved[from] = to
+	}
+	return newPath, moved, nil
+}
+
+// UpdateTranscript applies an edit to a transcript's record. localPath
+// and moved, from RenameTranscript, record where its files now are; an
+// empty localPath leaves the files' record unchanged.
+func (mdb *MetadataDB) UpdateTranscript(jobID string, edit TranscriptEdit, localPath string, moved map[string]string) error {
+	var (
+		sets []string
+		args []interface{}
+	)
+	if edit.RequestName != nil {
+		sets = append(sets, "request_name = ?")
+		args = append(args, *edit.RequestName)
+	}
+	if edit.Description != nil {
+		sets = append(sets, "description = ?")
+		args = append(args, *edit.Description)
+	}
+	if edit.Language != nil {
+		sets = append(sets, "language = ?", "language_confidence = NULL") // declared, not detected
+		args = append(args, *edit.Language)
+	}
+	if edit.Segments != nil {
+		sets = append(sets, "word_count = ?", "confidence = ?")
+		var confidence interface{}
+		if mean := types.MeanConfidence(edit.Segments); mean > 0 {
+			confidence = mean
+		}
+		args = append(args, len(strings.Fields(types.SegmentText(edit.Segments))), confidence)
+	}
+	if localPath != "" {
+		sets = append(sets, "local_path = ?")
+		args = append(args, localPath)
+	}
+	if len(sets) == 0 {
+		return nil
+	}
+
+	tx, err := mdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update transcript: %v", err)
+	}
+	defer tx.Rollback()
+
+	args = append(args, jobID)
+	if _, err := tx.Exec(`UPDATE transcripts SET `+strings.Join(sets, ", ")+` WHERE job_id = ?`, args...); err != nil {
+		return fmt.Errorf("failed to update transcript: %v", err)
+	}
+	if edit.RequestName != nil {
+		if _, err := tx.Exec(`UPDATE jobs SET request_name = ? WHERE job_id = ?`, *edit.RequestName, jobID); err != nil {
+			return fmt.Errorf("failed to update job: %v", err)
+		}
+	}
+	for from, to := range moved {
+		_, err := tx.Exec(`UPDATE artifact_checksums SET path = ? WHERE job_id = ? AND path = ?`, to, jobID, from)
+		if err != nil {
+			return fmt.Errorf("failed to update checksum path: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update transcript: %v", err)
+	}
+	return nil
+}
+
+// RewriteSegments replaces a stored transcript's segments: the text is
+// rebuilt from them, the renderings in formats (srt, vtt, tsv) replace
+// those on disk, and the metadata file's segments, word count, and speakers
+// are updated. Text keeps the BOM and line endings it was written with.
+// Encrypted transcripts can't be rewritten without the client's key.
+func (ls *LocalStorage) RewriteSegments(txtPath string, segments []types.Segment, formats map[string]string) error {
+	if IsEncrypted(txtPath) {
+		return fmt.Errorf("transcript is encrypted with a client key")
+	}
+	old, err := os.ReadFile(txtPath)
+	if err != nil {
+		return fmt.Errorf("failed to read transcript: %v", err)
+	}
+	encoding := TextEncoding{BOM: strings.HasPrefix(string(old), utf8BOM), LineEndings: LineEndingsLF}
+	if strings.Contains(string(old), "\r\n") {
+		encoding.LineEndings = LineEndingsCRLF
+	}
+
+	metaJSON, err := os.ReadFile(metaPathFor(txtPath))
+	if err != nil {
+		return fmt.Errorf("failed to read metadata: %v", err)
+	}
+	var meta map[string]json.RawMessage
+	if err := json.Unmarshal(metaJSON, &meta); err != nil {
+		return fmt.Errorf("corrupt metadata %s: %v", metaPathFor(txtPath), err)
+	}
+	result := &types.TranscriptionResult{Text: types.SegmentText(segments), Segments: segments}
+	fields := map[string]interface{}{
+		"segments":   segments,
+		"word_count": len(strings.Fields(result.Text)),
+	}
+	delete(meta, "speakers")
+	if speakers := types.SpeakerTurns(segments); speakers != nil {
+		fields["speakers"] = speakers
+	}
+	for name, value := range fields {
+		if meta[name], err = json.Marshal(value); err != nil {
+			return fmt.Errorf("failed to marshal metadata: %v", err)
+		}
+	}
+	if metaJSON, err = json.MarshalIndent(meta, "", "  "); err != nil {
+		return fmt.Errorf("failed to marshal metadata: %v", err)
+	}
+
+	if err := os.WriteFile(txtPath, encoding.encode(result.TranscriptText()), 0644); err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+	for _, format := range types.OutputFormats {
+		if content, ok := formats[format]; ok {
+			if err := os.WriteFile(FormatPath(txtPath, format), encoding.encode(content), 0644); err != nil {
+				return fmt.Errorf("failed to save %s transcript: %v", format, err)
+			}
+		}
+	}
+	if err := os.WriteFile(metaPathFor(txtPath), metaJSON, 0644); err != nil {
+		return fmt.Errorf("failed to save metadata: %v", err)
+	}
+	return nil
+}
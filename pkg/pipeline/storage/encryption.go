@@ -0,0 +1,104 @@
+package storage
+
+// Client-managed encryption — artifacts of jobs submitted with a client key
+// are sealed with AES-256-GCM so the service operator cannot read them.
+// Only a SHA-256 fingerprint of the key is ever persisted.
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// EncryptionKeySize is the required client key length (AES-256)
+const EncryptionKeySize = 32
+
+// encryptedMagic prefixes every encrypted artifact
+var encryptedMagic = []byte("ATENC1")
+
+// ErrNotEncrypted is returned when decrypting data that has no encryption header
+var ErrNotEncrypted = errors.New("artifact is not encrypted")
+
+// KeyFingerprint returns the hex SHA-256 of a key, used to verify that a
+// retrieval request presents the same key the job was submitted with
+func KeyFingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+// EncryptArtifact seals plaintext with AES-256-GCM
+func EncryptArtifact(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	out := make([]byte, 0, len(encryptedMagic)+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, encryptedMagic), nil
+}
+
+// DecryptArtifact opens data produced by EncryptArtifact
+func DecryptArtifact(key, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		return nil, ErrNotEncrypted
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(encryptedMagic):]
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted artifact is truncated")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, encryptedMagic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt artifact (wrong key?)")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != EncryptionKeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes", EncryptionKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SaveEncryption records the fingerprint of the key that sealed a job's artifacts
+func (mdb *MetadataDB) SaveEncryption(jobID, fingerprint string) error {
+	_, err := mdb.db.Exec(`UPDATE transcripts SET key_fingerprint = ? WHERE job_id = ?`, fingerprint, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to save key fingerprint: %v", err)
+	}
+	return nil
+}
+
+// GetKeyFingerprint returns the key fingerprint for a job ("" if unencrypted)
+func (mdb *MetadataDB) GetKeyFingerprint(jobID string) (string, error) {
+	var fingerprint string
+	err := mdb.db.QueryRow(`SELECT COALESCE(key_fingerprint, '') FROM transcripts WHERE job_id = ?`, jobID).Scan(&fingerprint)
+	if err != nil {
+		return "", fmt.Errorf("failed to get key fingerprint: %v", err)
+	}
+	return fingerprint, nil
+}
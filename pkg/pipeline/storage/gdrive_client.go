@@ -0,0 +1,429 @@
+// Package storage provides local filesystem and Google Drive persistence
+// for transcription results, plus SQLite metadata tracking.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// DriveClient handles uploading to Google Drive
+type DriveClient struct {
+	service    *drive.Service
+	folderName string
+	folderID   string
+
+	// folders caches folder IDs by parent and name (see folderKey), so
+	// concurrent uploads agree on one dated folder; foldersMu serializes
+	// their lookup and creation
+	foldersMu sync.Mutex
+	folders   map[string]string
+}
+
+// NewDriveClient creates a new Google Drive client from OAuth client
+// credentials JSON. If tokenJSON is non-empty it is used as the OAuth token
+// (e.g. supplied from a secret store); otherwise the token is read from, or
+// obtained interactively and cached in, tokenFile.
+func NewDriveClient(credentialsJSON, tokenJSON []byte, tokenFile, folderName string) (*DriveClient, error) {
+	ctx := context.Background()
+
+	config, err := google.ConfigFromJSON(credentialsJSON, drive.DriveFileScope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse credentials: %v", err)
+	}
+
+	var client *http.Client
+	if len(tokenJSON) > 0 {
+		tok := &oauth2.Token{}
+		if err := json.Unmarshal(tokenJSON, tok); err != nil {
+			return nil, fmt.Errorf("unable to parse token: %v", err)
+		}
+		client = config.Client(ctx, tok)
+	} else {
+		client = getClient(config, tokenFile)
+	}
+
+	srv, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Drive service: %v", err)
+	}
+
+	dc := &DriveClient{
+		service:    srv,
+		folderName: folderName,
+		folders:    make(map[string]string),
+	}
+
+	// Find or create the root folder
+	if err := dc.ensureFolder(); err != nil {
+		return nil, err
+	}
+
+	return dc, nil
+}
+
+// getClient retrieves a token, saves the token, then returns the generated client
+func getClient(config *oauth2.Config, tokenFile string) *http.Client {
+	tok, err := tokenFromFile(tokenFile)
+	if err != nil {
+		tok = getTokenFromWeb(config)
+		saveToken(tokenFile, tok)
+	}
+	return config.Client(context.Background(), tok)
+}
+
+// getTokenFromWeb requests a token from the web
+func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser:\n%v\n", authURL)
+	fmt.Print("Enter authorization code: ")
+
+	var authCode string
+	if _, err := fmt.Scan(&authCode); err != nil {
+		panic(fmt.Sprintf("Unable to read authorization code: %v", err))
+	}
+
+	tok, err := config.Exchange(context.TODO(), authCode)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to retrieve token from web: %v", err))
+	}
+	return tok
+}
+
+// tokenFromFile retrieves a token from a local file
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tok := &oauth2.Token{}
+	err = json.NewDecoder(f).Decode(tok)
+	return tok, err
+}
+
+// saveToken saves a token to a file path
+func saveToken(path string, token *oauth2.Token) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to cache oauth token: %v", err))
+	}
+	defer f.Close()
+	json.NewEncoder(f).Encode(token)
+}
+
+// driveQueryString quotes s for a Drive search query, escaping the
+// backslashes and single quotes a transcript or folder name may contain
+func driveQueryString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// ensureFolder finds or creates the root folder
+func (dc *DriveClient) ensureFolder() error {
+	query := fmt.Sprintf("name=%s and mimeType='application/vnd.google-apps.folder' and trashed=false",
+		driveQueryString(dc.folderName))
+
+	r, err := dc.service.Files.List().Q(query).Spaces("drive").Fields("files(id, name)").Do()
+	if err != nil {
+		return fmt.Errorf("unable to search for folder: %v", err)
+	}
+
+	if len(r.Files) > 0 {
+		dc.folderID = r.Files[0].Id
+		return nil
+	}
+
+	// Create folder
+	folder := &drive.File{
+		Name:     dc.folderName,
+		MimeType: "application/vnd.google-apps.folder",
+	}
+
+	file, err := dc.service.Files.Create(folder).Fields("id").Do()
+	if err != nil {
+		return fmt.Errorf("unable to create folder: %v", err)
+	}
+
+	dc.folderID = file.Id
+	return nil
+}
+
+// Upload uploads transcript and metadata to Google Drive
+func (dc *DriveClient) Upload(requestName string, result *types.TranscriptionResult, opts SaveOptions) (string, error) {
+	// Create dated folder structure: Transcripts/2025/01/23/
+	now := opts.in(time.Now())
+	rootID := dc.folderID
+	if opts.DriveFolderID != "" {
+		rootID = opts.DriveFolderID
+	}
+	folderID, err := dc.ensureDateFolder(rootID, now)
+	if err != nil {
+		return "", err
+	}
+
+	// Generate filename
+	timestamp := now.Format("20060102_150405")
+	baseFilename := fmt.Sprintf("%s_%s", timestamp, sanitizeFilename(requestName))
+
+	// Upload transcript text
+	txtFile := &drive.File{
+		Name:    baseFilename + ".txt" + opts.suffix(),
+		Parents: []string{folderID},
+	}
+
+	txtData, err := opts.text(result.TranscriptText())
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt transcript: %v", err)
+	}
+
+	_, err = dc.service.Files.Create(txtFile).Media(
+		bytes.NewReader(txtData), mediaType(opts, "text/plain")).Do()
+	if err != nil {
+		dc.forgetFolders(err)
+		return "", fmt.Errorf("failed to upload transcript: %v", err)
+	}
+
+	// Upload extra renderings (srt, vtt, tsv) that were chosen for Drive
+	for _, format := range types.OutputFormats {
+		content, ok := result.Formats[format]
+		if !ok || opts.DriveFormats != nil && !slices.Contains(opts.DriveFormats, format) {
+			continue
+		}
+		data, err := opts.text(content)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt %s transcript: %v", format, err)
+		}
+		formatFile := &drive.File{
+			Name:    baseFilename + "." + format + opts.suffix(),
+			Parents: []string{folderID},
+		}
+		_, err = dc.service.Files.Create(formatFile).Media(
+			bytes.NewReader(data), mediaType(opts, formatContentTypes[format])).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to upload %s transcript: %v", format, err)
+		}
+	}
+
+	// Upload metadata JSON
+	metadata := map[string]interface{}{
+		"job_id":           result.JobID,
+		"request_name":     requestName,
+		"duration_seconds": result.Duration,
+		"word_count":       result.WordCount,
+		"model_used":       result.Cost.Model,
+		"language":         result.Language,
+		"created_at":       opts.in(result.ProcessedAt),
+		"segments":         result.Segments,
+		"metadata":         result.Metadata,
+		"labels":           result.Labels,
+		"cost":             result.Cost,
+		"resources":        result.Resources,
+	}
+	if result.LanguageConfidence > 0 {
+		metadata["language_confidence"] = result.LanguageConfidence
+	}
+	if result.Task != "" {
+		metadata["task"] = result.Task
+	}
+	metadata["original_language"] = !result.Translated()
+	if result.Provenance != nil {
+		metadata["provenance"] = result.Provenance
+	}
+	if speakers := types.SpeakerTurns(result.Segments); speakers != nil {
+		metadata["speakers"] = speakers
+	}
+
+	metaJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata: %v", err)
+	}
+	if metaJSON, err = opts.seal(metaJSON); err != nil {
+		return "", fmt.Errorf("failed to encrypt metadata: %v", err)
+	}
+
+	metaFile := &drive.File{
+		Name:    baseFilename + "_meta.json" + opts.suffix(),
+		Parents: []string{folderID},
+	}
+
+	createdMeta, err := dc.service.Files.Create(metaFile).Media(
+		bytes.NewReader(metaJSON), mediaType(opts, "application/json")).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to upload metadata: %v", err)
+	}
+
+	// Return shareable link
+	fileURL := fmt.Sprintf("https://drive.google.com/file/d/%s/view", createdMeta.Id)
+	return fileURL, nil
+}
+
+// UploadReport uploads a report file to the "Reports" folder under the
+// root folder and returns its link
+func (dc *DriveClient) UploadReport(name string, content []byte, contentType string) (string, error) {
+	folderID, err := dc.findOrCreateFolder("Reports", dc.folderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to create reports folder: %v", err)
+	}
+	file := &drive.File{Name: sanitizeFilename(name), Parents: []string{folderID}}
+	created, err := dc.service.Files.Create(file).Media(bytes.NewReader(content), googleapi.ContentType(contentType)).Do()
+	if err != nil {
+		dc.forgetFolders(err)
+		return "", fmt.Errorf("failed to upload report: %v", err)
+	}
+	return fmt.Sprintf("https://drive.google.com/file/d/%s/view", created.Id), nil
+}
+
+// CheckDriveFormats validates a list of formats to upload to Drive: any of
+// types.OutputFormats, or "txt" alone for just the text and metadata, which
+// are always uploaded
+func CheckDriveFormats(formats []string) error {
+	for _, format := range formats {
+		if format != "txt" && !slices.Contains(types.OutputFormats, format) {
+			return fmt.Errorf("unsupported Drive format %q (use txt, %s)", format, strings.Join(types.OutputFormats, ", "))
+		}
+	}
+	return nil
+}
+
+// driveFileIDPattern extracts the file ID from a shareable Drive link
+var driveFileIDPattern = regexp.MustCompile(`/file/d/([a-zA-Z0-9_-]+)`)
+
+// Delete removes an uploaded metadata file (identified by the URL returned
+// from Upload) and its sibling transcript text file
+func (dc *DriveClient) Delete(fileURL string) error {
+	matches := driveFileIDPattern.FindStringSubmatch(fileURL)
+	if len(matches) < 2 {
+		return fmt.Errorf("not a Drive file URL: %s", fileURL)
+	}
+	metaID := matches[1]
+
+	meta, err := dc.service.Files.Get(metaID).Fields("name, parents").Do()
+	if err != nil {
+		return fmt.Errorf("failed to look up Drive file: %v", err)
+	}
+
+	// The transcript text and extra renderings share the metadata file's base name
+	if len(meta.Parents) > 0 {
+		base, suffix := strings.TrimSuffix(meta.Name, "_meta.json"), ""
+		if b, ok := strings.CutSuffix(meta.Name, "_meta.json"+encryptedSuffix); ok {
+			base, suffix = b, encryptedSuffix
+		}
+		exts := []string{".txt"}
+		for _, format := range types.OutputFormats {
+			exts = append(exts, "."+format)
+		}
+		for _, ext := range exts {
+			query := fmt.Sprintf("name=%s and %s in parents and trashed=false",
+				driveQueryString(base+ext+suffix), driveQueryString(meta.Parents[0]))
+			r, err := dc.service.Files.List().Q(query).Spaces("drive").Fields("files(id)").Do()
+			if err != nil {
+				return fmt.Errorf("failed to find Drive transcript: %v", err)
+			}
+			for _, f := range r.Files {
+				if err := dc.service.Files.Delete(f.Id).Do(); err != nil {
+					return fmt.Errorf("failed to delete Drive transcript: %v", err)
+				}
+			}
+		}
+	}
+
+	if err := dc.service.Files.Delete(metaID).Do(); err != nil {
+		return fmt.Errorf("failed to delete Drive metadata: %v", err)
+	}
+	return nil
+}
+
+// ensureDateFolder creates nested year/month/day folders under rootID
+func (dc *DriveClient) ensureDateFolder(rootID string, t time.Time) (string, error) {
+	// Create year folder
+	yearID, err := dc.findOrCreateFolder(fmt.Sprintf("%d", t.Year()), rootID)
+	if err != nil {
+		return "", err
+	}
+
+	// Create month folder
+	monthID, err := dc.findOrCreateFolder(fmt.Sprintf("%02d", t.Month()), yearID)
+	if err != nil {
+		return "", err
+	}
+
+	// Create day folder
+	dayID, err := dc.findOrCreateFolder(fmt.Sprintf("%02d", t.Day()), monthID)
+	if err != nil {
+		return "", err
+	}
+
+	return dayID, nil
+}
+
+// findOrCreateFolder finds or creates a folder with the given parent. The
+// ID is cached, and the lock keeps concurrent uploads from each creating
+// the folder; duplicates found anyway are merged into the oldest.
+func (dc *DriveClient) findOrCreateFolder(name, parentID string) (string, error) {
+	dc.foldersMu.Lock()
+	defer dc.foldersMu.Unlock()
+
+	key := folderKey(parentID, name)
+	if id, ok := dc.folders[key]; ok {
+		return id, nil
+	}
+
+	found, err := dc.listFolders(parentID, name)
+	if err != nil {
+		return "", err
+	}
+
+	if len(found) > 0 {
+		if len(found) > 1 {
+			dc.mergeFolders(name, found[0].Id, found[1:])
+		}
+		dc.folders[key] = found[0].Id
+		return found[0].Id, nil
+	}
+
+	folder := &drive.File{
+		Name:     name,
+		MimeType: folderMimeType,
+		Parents:  []string{parentID},
+	}
+
+	file, err := dc.service.Files.Create(folder).Fields("id").Do()
+	if err != nil {
+		return "", err
+	}
+
+	dc.folders[key] = file.Id
+	return file.Id, nil
+}
+
+// formatContentTypes maps extra renderings to their upload content types
+var formatContentTypes = map[string]string{
+	"srt": "application/x-subrip",
+	"vtt": "text/vtt",
+	"tsv": "text/tab-separated-values",
+}
+
+// mediaType sets the upload content type; sealed artifacts are opaque bytes
+func mediaType(opts SaveOptions, plain string) googleapi.MediaOption {
+	if opts.EncryptionKey != nil {
+		return googleapi.ContentType("application/octet-stream")
+	}
+	return googleapi.ContentType(plain)
+}
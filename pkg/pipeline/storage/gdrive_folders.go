@@ -0,0 +1,138 @@
+package storage
+
+// Drive folder reconciliation — merges folders that share a name and
+// parent, e.g. dated folders created twice by uploads racing before the
+// folder cache existed or from two servers sharing a Drive folder. The
+// oldest folder is kept; the others' contents are moved into it and the
+// emptied duplicates are trashed, so nothing is deleted outright.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// folderMimeType is the MIME type Drive gives folders
+const folderMimeType = "application/vnd.google-apps.folder"
+
+// folderKey identifies a folder in the folder cache
+func folderKey(parentID, name string) string {
+	return parentID + "/" + name
+}
+
+// forgetFolders empties the folder cache when Drive reports a missing
+// folder, e.g. one deleted by hand, so the next upload looks it up again
+func (dc *DriveClient) forgetFolders(err error) {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+		return
+	}
+	dc.foldersMu.Lock()
+	dc.folders = make(map[string]string)
+	dc.foldersMu.Unlock()
+}
+
+// listFolders lists the folders under parentID, oldest first; a non-empty
+// name restricts them to that name
+func (dc *DriveClient) listFolders(parentID, name string) ([]*drive.File, error) {
+	query := fmt.Sprintf("%s in parents and mimeType='%s' and trashed=false", driveQueryString(parentID), folderMimeType)
+	if name != "" {
+		query = fmt.Sprintf("name=%s and ", driveQueryString(name)) + query
+	}
+	return dc.listFiles(query, "createdTime")
+}
+
+// listFiles runs a Drive query, following pagination
+func (dc *DriveClient) listFiles(query, orderBy string) ([]*drive.File, error) {
+	var files []*drive.File
+	call := dc.service.Files.List().Q(query).Spaces("drive").
+		Fields("nextPageToken, files(id, name, mimeType)").PageSize(1000)
+	if orderBy != "" {
+		call = call.OrderBy(orderBy)
+	}
+	for pageToken := ""; ; {
+		r, err := call.PageToken(pageToken).Do()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, r.Files...)
+		if pageToken = r.NextPageToken; pageToken == "" {
+			return files, nil
+		}
+	}
+}
+
+// mergeFolders moves the contents of each duplicate into keepID and
+// trashes the duplicate. Failures are logged and the rest carried on
+// with; a duplicate that could not be emptied is left in place.
+func (dc *DriveClient) mergeFolders(name, keepID string, duplicates []*drive.File) {
+	for _, dup := range duplicates {
+		children, err := dc.listFiles(fmt.Sprintf("%s in parents and trashed=false", driveQueryString(dup.Id)), "")
+		if err != nil {
+			log.Printf("Drive: failed to list duplicate folder %s (%s): %v", name, dup.Id, err)
+			continue
+		}
+		moved := 0
+		for _, child := range children {
+			_, err := dc.service.Files.Update(child.Id, &drive.File{}).
+				AddParents(keepID).RemoveParents(dup.Id).Fields("id").Do()
+			if err != nil {
+				log.Printf("Drive: failed to move %s out of duplicate folder %s: %v", child.Name, name, err)
+				continue
+			}
+			moved++
+		}
+		if moved < len(children) {
+			continue
+		}
+		if _, err := dc.service.Files.Update(dup.Id, &drive.File{Trashed: true}).Fields("id").Do(); err != nil {
+			log.Printf("Drive: failed to trash duplicate folder %s (%s): %v", name, dup.Id, err)
+			continue
+		}
+		log.Printf("Drive: merged duplicate folder %s (%s) into %s, moving %d items", name, dup.Id, keepID, moved)
+	}
+}
+
+// ReconcileFolders merges duplicate folders anywhere under the root
+// folder. Merging two dated folders can leave duplicates one level down,
+// so each kept folder is reconciled in turn.
+func (dc *DriveClient) ReconcileFolders() error {
+	return dc.reconcile(dc.folderID)
+}
+
+func (dc *DriveClient) reconcile(parentID string) error {
+	folders, err := dc.listFolders(parentID, "")
+	if err != nil {
+		return fmt.Errorf("failed to list Drive folders: %v", err)
+	}
+
+	// Group by name, oldest first as listed
+	var names []string
+	byName := make(map[string][]*drive.File)
+	for _, f := range folders {
+		if _, ok := byName[f.Name]; !ok {
+			names = append(names, f.Name)
+		}
+		byName[f.Name] = append(byName[f.Name], f)
+	}
+
+	for _, name := range names {
+		group := byName[name]
+		if len(group) > 1 {
+			dc.mergeFolders(name, group[0].Id, group[1:])
+
+			// Uploads may have cached a duplicate
+			dc.foldersMu.Lock()
+			dc.folders[folderKey(parentID, name)] = group[0].Id
+			dc.foldersMu.Unlock()
+		}
+		if err := dc.reconcile(group[0].Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
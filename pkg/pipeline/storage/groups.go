@@ -0,0 +1,174 @@
+package storage
+
+// Job groups — a submission that expands into many jobs (e.g. a batch of
+// URLs) is recorded as a group, whose status and progress are derived
+// from its jobs' records so clients can follow the batch as a whole.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// ErrJobGroupNotFound is returned for an unknown group ID
+var ErrJobGroupNotFound = errors.New("job group not found")
+
+// JobGroup is a group's aggregate state and the state of each of its jobs
+type JobGroup struct {
+	GroupID     string `json:"group_id"`
+	RequestName string `json:"request_name"`
+	SourceType  string `json:"source_type"`
+	// Status is types.StatusCompleted, StatusFailed, StatusCancelled, or
+	// StatusPartial once every job has finished; until then PROCESSING once
+	// any job is processing or done, else DOWNLOADING or QUEUED
+	Status string `json:"status"`
+	// Progress (0-100) counts finished jobs as 100 and processing jobs at
+	// their recorded progress
+	Progress float64 `json:"progress"`
+	// Counts is the number of jobs in each status
+	Counts     map[string]int `json:"counts"`
+	CreatedAt  time.Time      `json:"created_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Jobs       []GroupJob     `json:"jobs"`
+}
+
+// GroupJob is one job of a group
+type GroupJob struct {
+	JobID       string  `json:"job_id"`
+	RequestName string  `json:"request_name,omitempty"`
+	Status      string  `json:"status"`
+	Progress    float64 `json:"progress"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// Finished reports whether every job of the group has reached a final status
+func (g *JobGroup) Finished() bool {
+	switch g.Status {
+	case types.StatusCompleted, types.StatusFailed, types.StatusCancelled, types.StatusPartial:
+		return true
+	}
+	return false
+}
+
+// CreateJobGroup records a group of jobs, listed in submission order. Call
+// it before the jobs are tracked or queued so none can finish unnoticed.
+func (mdb *MetadataDB) CreateJobGroup(groupID, requestName, sourceType string, jobIDs []string) error {
+	tx, err := mdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create job group: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO job_groups (group_id, request_name, source_type, created_at)
+		VALUES (?, ?, ?, ?)`, groupID, requestName, sourceType, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to create job group: %v", err)
+	}
+	for i, jobID := range jobIDs {
+		if _, err := tx.Exec(`INSERT INTO job_group_members (group_id, job_id, position) VALUES (?, ?, ?)`,
+			groupID, jobID, i); err != nil {
+			return fmt.Errorf("failed to add job %s to group: %v", jobID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create job group: %v", err)
+	}
+	return nil
+}
+
+// GetJobGroup returns a group with its jobs in submission order. A job not
+// yet recorded counts as queued.
+func (mdb *MetadataDB) GetJobGroup(groupID string) (*JobGroup, error) {
+	g := &JobGroup{GroupID: groupID, Counts: make(map[string]int)}
+	var finishedAt sql.NullTime
+	err := mdb.db.QueryRow(`SELECT request_name, source_type, created_at, finished_at
+		FROM job_groups WHERE group_id = ?`, groupID).
+		Scan(&g.RequestName, &g.SourceType, &g.CreatedAt, &finishedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrJobGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job group: %v", err)
+	}
+	if finishedAt.Valid {
+		g.FinishedAt = &finishedAt.Time
+	}
+
+	rows, err := mdb.db.Query(`SELECT m.job_id, COALESCE(j.request_name, ''), COALESCE(j.status, ?),
+		COALESCE(j.progress, 0), COALESCE(j.error, '')
+		FROM job_group_members m LEFT JOIN jobs j ON j.job_id = m.job_id
+		WHERE m.group_id = ? ORDER BY m.position`, types.StatusQueued, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job group: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job GroupJob
+		if err := rows.Scan(&job.JobID, &job.RequestName, &job.Status, &job.Progress, &job.Error); err != nil {
+			return nil, fmt.Errorf("failed to read job group: %v", err)
+		}
+		g.Jobs = append(g.Jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read job group: %v", err)
+	}
+
+	g.aggregate()
+	return g, nil
+}
+
+// aggregate derives the group's status, progress, and counts from its jobs
+func (g *JobGroup) aggregate() {
+	var progress float64
+	for i, job := range g.Jobs {
+		g.Counts[job.Status]++
+		switch job.Status {
+		case types.StatusCompleted, types.StatusFailed, types.StatusCancelled:
+			g.Jobs[i].Progress = 100
+		case types.StatusDownloading, types.StatusQueued:
+			g.Jobs[i].Progress = 0 // download progress says nothing of the job's
+		}
+		progress += g.Jobs[i].Progress
+	}
+	if len(g.Jobs) > 0 {
+		g.Progress = progress / float64(len(g.Jobs))
+	}
+
+	finished := g.Counts[types.StatusCompleted] + g.Counts[types.StatusFailed] + g.Counts[types.StatusCancelled]
+	switch {
+	case finished < len(g.Jobs):
+		g.Status = types.StatusProcessing
+		if g.Counts[types.StatusProcessing] == 0 && finished == 0 {
+			g.Status = types.StatusQueued
+			if g.Counts[types.StatusDownloading] > 0 {
+				g.Status = types.StatusDownloading
+			}
+		}
+	case g.Counts[types.StatusCompleted] == len(g.Jobs):
+		g.Status = types.StatusCompleted
+	case g.Counts[types.StatusCancelled] == len(g.Jobs):
+		g.Status = types.StatusCancelled
+	case g.Counts[types.StatusCompleted] > 0:
+		g.Status = types.StatusPartial
+	default:
+		g.Status = types.StatusFailed
+	}
+}
+
+// FinishJobGroup marks a group finished, reporting false if it already was,
+// so that exactly one of its last jobs to finish announces it
+func (mdb *MetadataDB) FinishJobGroup(groupID string) (bool, error) {
+	res, err := mdb.db.Exec(`UPDATE job_groups SET finished_at = ? WHERE group_id = ? AND finished_at IS NULL`,
+		time.Now().UTC(), groupID)
+	if err != nil {
+		return false, fmt.Errorf("failed to finish job group: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to finish job group: %v", err)
+	}
+	return n == 1, nil
+}
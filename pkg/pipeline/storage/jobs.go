@@ -0,0 +1,104 @@
+package storage
+
+// Job records — the lifecycle state of every submitted job, from download
+// through completion or failure, so clients can poll a job ID even when
+// it never produced a transcript.
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// SaveJobStatus records a job's current status, creating the row on first use.
+// tenant is the job's tenant label, if any.
+func (mdb *MetadataDB) SaveJobStatus(jobID, requestName, sourceType, tenant, status, errMsg string) error {
+	var errText, tenantText sql.NullString
+	if errMsg != "" {
+		errText = sql.NullString{String: errMsg, Valid: true}
+	}
+	if tenant != "" {
+		tenantText = sql.NullString{String: tenant, Valid: true}
+	}
+
+	// started_at marks the first move to PROCESSING; finished_at the final status
+	now := time.Now().UTC()
+	var startedAt, finishedAt sql.NullTime
+	switch status {
+	case types.StatusProcessing:
+		startedAt = sql.NullTime{Time: now, Valid: true}
+	case types.StatusCompleted, types.StatusFailed, types.StatusCancelled:
+		finishedAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	_, err := mdb.db.Exec(`
+	INSERT INTO jobs (job_id, request_name, source_type, tenant, status, error, created_at, updated_at, started_at, finished_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(job_id) DO UPDATE SET
+		request_name = excluded.request_name,
+		tenant = excluded.tenant,
+		status = excluded.status,
+		error = excluded.error,
+		updated_at = excluded.updated_at,
+		started_at = COALESCE(jobs.started_at, excluded.started_at),
+		finished_at = excluded.finished_at
+	`, jobID, requestName, sourceType, tenantText, status, errText, now, now, startedAt, finishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save job status: %v", err)
+	}
+	return nil
+}
+
+// GetJob returns the recorded status of a job
+func (mdb *MetadataDB) GetJob(jobID string) (map[string]interface{}, error) {
+	var (
+		jid, name, source, status string
+		errText                   sql.NullString
+		createdAt, updatedAt      time.Time
+	)
+
+	var progress sql.NullFloat64
+	var startedAt, finishedAt sql.NullTime
+
+	err := mdb.db.QueryRow(`SELECT job_id, request_name, source_type, status, error, progress,
+		created_at, updated_at, started_at, finished_at
+		FROM jobs WHERE job_id = ?`, jobID).
+		Scan(&jid, &name, &source, &status, &errText, &progress, &createdAt, &updatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %v", err)
+	}
+
+	job := map[string]interface{}{
+		"job_id":       jid,
+		"request_name": name,
+		"source_type":  source,
+		"status":       status,
+		"created_at":   createdAt,
+		"updated_at":   updatedAt,
+	}
+	if errText.Valid {
+		job["error"] = errText.String
+	}
+	if progress.Valid {
+		job["progress"] = progress.Float64
+	}
+	if startedAt.Valid {
+		job["started_at"] = startedAt.Time
+	}
+	if finishedAt.Valid {
+		job["finished_at"] = finishedAt.Time
+	}
+	return job, nil
+}
+
+// SaveJobProgress records how far a job's current phase has got (0-100)
+func (mdb *MetadataDB) SaveJobProgress(jobID string, progress float64) error {
+	_, err := mdb.db.Exec(`UPDATE jobs SET progress = ?, updated_at = ? WHERE job_id = ?`,
+		progress, time.Now().UTC(), jobID)
+	if err != nil {
+		return fmt.Errorf("failed to save job progress: %v", err)
+	}
+	return nil
+}
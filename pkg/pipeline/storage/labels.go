@@ -0,0 +1,32 @@
+package storage
+
+// Job labels — a small set of indexed key=value pairs per transcript,
+// kept in their own table so list and stats queries can filter cheaply.
+
+import "fmt"
+
+// SaveLabels stores the labels for a job, replacing any existing values
+func (mdb *MetadataDB) SaveLabels(jobID string, labels map[string]string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	tx, err := mdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save labels: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, key := range sortedKeys(labels) {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO transcript_labels (job_id, key, value) VALUES (?, ?, ?)`,
+			jobID, key, labels[key])
+		if err != nil {
+			return fmt.Errorf("failed to save label %s: %v", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save labels: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+// Language coverage — which languages each tenant's transcripts are in,
+// how sure the backend was of the detected ones, and how confident it was
+// in its output, to show where a larger model or another backend is worth
+// enabling.
+
+import (
+	"fmt"
+	"time"
+)
+
+// LanguageRow is one tenant and language in a language coverage report
+type LanguageRow struct {
+	Tenant          string  `json:"tenant"`   // empty for transcripts without a tenant label
+	Language        string  `json:"language"` // empty when the backend reported none
+	Transcripts     int     `json:"transcripts"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Detected counts the transcripts whose language was detected with a
+	// score; AvgLanguageConfidence averages those scores
+	Detected              int     `json:"detected"`
+	AvgLanguageConfidence float64 `json:"avg_language_confidence"`
+	// Scored counts the transcripts whose backend scored its segments;
+	// AvgConfidence averages their mean segment confidence
+	Scored        int     `json:"scored"`
+	AvgConfidence float64 `json:"avg_confidence"`
+}
+
+// LanguageStats reports language coverage per tenant for transcripts
+// created between from (inclusive) and to (exclusive), with each tenant's
+// most transcribed language first
+func (mdb *MetadataDB) LanguageStats(from, to time.Time, filter TranscriptFilter) ([]LanguageRow, error) {
+	where, args := filter.whereClause()
+	if where == "" {
+		where = ` WHERE `
+	} else {
+		where += ` AND `
+	}
+	where += `substr(created_at, 1, 19) >= ? AND substr(created_at, 1, 19) < ?`
+	args = append(args, dbTime(from), dbTime(to))
+
+	query := `
+	SELECT COALESCE((SELECT value FROM transcript_labels l WHERE l.job_id = transcripts.job_id AND l.key = ?), '') AS tenant,
+		COALESCE(language, '') AS lang, COUNT(*), COALESCE(SUM(duration), 0),
+		COUNT(language_confidence), COALESCE(AVG(language_confidence), 0),
+		COUNT(confidence), COALESCE(AVG(confidence), 0)
+	FROM transcripts` + where + ` GROUP BY tenant, lang ORDER BY tenant, COUNT(*) DESC, lang`
+
+	rows, err := mdb.db.Query(query, append([]interface{}{TenantLabel}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build language stats: %v", err)
+	}
+	defer rows.Close()
+
+	stats := []LanguageRow{}
+	for rows.Next() {
+		var row LanguageRow
+		if err := rows.Scan(&row.Tenant, &row.Language, &row.Transcripts, &row.DurationSeconds,
+			&row.Detected, &row.AvgLanguageConfidence, &row.Scored, &row.AvgConfidence); err != nil {
+			return nil, fmt.Errorf("failed to build language stats: %v", err)
+		}
+		stats = append(stats, row)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,279 @@
+package storage
+
+// Local filesystem storage — saves transcripts in a date-organized
+// directory structure (outputs/YYYY/MM/DD/) with metadata JSON files.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// LocalStorage handles saving transcripts to the local filesystem
+type LocalStorage struct {
+	outputDir string
+}
+
+// NewLocalStorage creates a new local storage handler
+func NewLocalStorage(outputDir string) *LocalStorage {
+	return &LocalStorage{
+		outputDir: outputDir,
+	}
+}
+
+// SaveOptions are per-job settings applied when persisting artifacts
+type SaveOptions struct {
+	// EncryptionKey, when set, seals every artifact with the client's key
+	EncryptionKey []byte
+
+	// DriveFolderID, when set, uploads under this Drive folder instead of
+	// the configured transcripts folder
+	DriveFolderID string
+
+	// DriveFormats limits which extra renderings are uploaded to Drive
+	// (see CheckDriveFormats); nil uploads every rendering
+	DriveFormats []string
+
+	// TextEncoding sets the BOM and line endings of text artifacts
+	TextEncoding TextEncoding
+
+	// Location is the time zone of dated folders, file names, and metadata
+	// timestamps; nil is the server's local time
+	Location *time.Location
+}
+
+// in converts t to the options' time zone
+func (o SaveOptions) in(t time.Time) time.Time {
+	if o.Location == nil {
+		return t.Local()
+	}
+	return t.In(o.Location)
+}
+
+// encryptedSuffix is appended to artifact names sealed with a client key
+const encryptedSuffix = ".enc"
+
+// seal encrypts data when the options carry a client key
+func (o SaveOptions) seal(data []byte) ([]byte, error) {
+	if o.EncryptionKey == nil {
+		return data, nil
+	}
+	return EncryptArtifact(o.EncryptionKey, data)
+}
+
+// text encodes a text artifact and seals it if required
+func (o SaveOptions) text(content string) ([]byte, error) {
+	return o.seal(o.TextEncoding.encode(content))
+}
+
+// suffix returns the extra file extension for sealed artifacts
+func (o SaveOptions) suffix() string {
+	if o.EncryptionKey == nil {
+		return ""
+	}
+	return encryptedSuffix
+}
+
+// SaveTranscript saves the transcript and metadata to local disk
+func (ls *LocalStorage) SaveTranscript(requestName string, result *types.TranscriptionResult, opts SaveOptions) (string, error) {
+	// Create dated directory structure: outputs/2025/01/23/
+	now := opts.in(time.Now())
+	dateDir := filepath.Join(ls.outputDir,
+		fmt.Sprintf("%d", now.Year()),
+		fmt.Sprintf("%02d", now.Month()),
+		fmt.Sprintf("%02d", now.Day()))
+
+	if err := os.MkdirAll(dateDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create date directory: %v", err)
+	}
+
+	// Generate filename: 20250123_143022_podcast_episode.txt
+	timestamp := now.Format("20060102_150405")
+	baseFilename := fmt.Sprintf("%s_%s", timestamp, sanitizeFilename(requestName))
+
+	txtPath := filepath.Join(dateDir, baseFilename+".txt"+opts.suffix())
+	metaPath := filepath.Join(dateDir, baseFilename+"_meta.json"+opts.suffix())
+
+	// Save transcript text
+	txtData, err := opts.text(result.TranscriptText())
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt transcript: %v", err)
+	}
+	if err := os.WriteFile(txtPath, txtData, 0644); err != nil {
+		return "", fmt.Errorf("failed to save transcript: %v", err)
+	}
+
+	// Save extra renderings (srt, vtt, tsv) next to the text
+	for _, format := range types.OutputFormats {
+		content, ok := result.Formats[format]
+		if !ok {
+			continue
+		}
+		data, err := opts.text(content)
+		if err != nil {
+			return "", fmt.Errorf("failed to encrypt %s transcript: %v", format, err)
+		}
+		if err := os.WriteFile(FormatPath(txtPath, format), data, 0644); err != nil {
+			return "", fmt.Errorf("failed to save %s transcript: %v", format, err)
+		}
+	}
+
+	// Save metadata JSON
+	metadata := map[string]interface{}{
+		"job_id":           result.JobID,
+		"request_name":     requestName,
+		"duration_seconds": result.Duration,
+		"word_count":       result.WordCount,
+		"model_used":       result.Cost.Model,
+		"language":         result.Language,
+		"created_at":       opts.in(result.ProcessedAt),
+		"segments":         result.Segments,
+		"metadata":         result.Metadata,
+		"labels":           result.Labels,
+		"cost":             result.Cost,
+		"resources":        result.Resources,
+		"trim":             result.Trim,
+		"source_audio":     result.SourceAudio,
+		"repetitions":      result.Repetitions,
+		"local_path":       txtPath,
+		"gdrive_url":       result.GDriveURL,
+	}
+	if result.LanguageConfidence > 0 {
+		metadata["language_confidence"] = result.LanguageConfidence
+	}
+	if result.Task != "" {
+		metadata["task"] = result.Task
+	}
+	metadata["original_language"] = !result.Translated()
+	if result.Provenance != nil {
+		metadata["provenance"] = result.Provenance
+	}
+	if len(result.Replacements) > 0 {
+		metadata["replacements"] = result.Replacements
+	}
+	if result.Speedup > 0 {
+		metadata["speedup"] = result.Speedup
+	}
+	if speakers := types.SpeakerTurns(result.Segments); speakers != nil {
+		metadata["speakers"] = speakers
+	}
+
+	metaJSON, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal metadata: %v", err)
+	}
+
+	if metaJSON, err = opts.seal(metaJSON); err != nil {
+		return "", fmt.Errorf("failed to encrypt metadata: %v", err)
+	}
+
+	if err := os.WriteFile(metaPath, metaJSON, 0644); err != nil {
+		return "", fmt.Errorf("failed to save metadata: %v", err)
+	}
+
+	return txtPath, nil
+}
+
+// siblingPath swaps a transcript's ".txt" extension for another, keeping
+// the encrypted suffix if the transcript has one
+func siblingPath(txtPath, ext string) string {
+	if base, ok := strings.CutSuffix(txtPath, ".txt"+encryptedSuffix); ok {
+		return base + ext + encryptedSuffix
+	}
+	return strings.TrimSuffix(txtPath, ".txt") + ext
+}
+
+// metaPathFor returns the metadata JSON path that sits next to a transcript
+func metaPathFor(txtPath string) string {
+	return siblingPath(txtPath, "_meta.json")
+}
+
+// FormatPath returns where an extra rendering (srt, vtt, tsv) of a transcript is stored
+func FormatPath(txtPath, format string) string {
+	return siblingPath(txtPath, "."+format)
+}
+
+// ArtifactPath returns where one artifact of a transcript is stored:
+// "txt", "meta", an extra rendering (srt, vtt, tsv), whisper's kept "raw"
+// output, or the kept "audio"
+func ArtifactPath(txtPath, artifact string) (string, bool) {
+	switch {
+	case artifact == "txt":
+		return txtPath, true
+	case artifact == "meta":
+		return metaPathFor(txtPath), true
+	case artifact == "raw":
+		return RawOutputPath(txtPath), true
+	case artifact == "audio":
+		return AudioPath(txtPath)
+	case slices.Contains(types.OutputFormats, artifact):
+		return FormatPath(txtPath, artifact), true
+	}
+	return "", false
+}
+
+// IsEncrypted reports whether a transcript was sealed with a client key
+func IsEncrypted(txtPath string) bool {
+	return strings.HasSuffix(txtPath, encryptedSuffix)
+}
+
+// ArtifactPaths lists the files stored for a transcript: the text, its
+// metadata JSON, and any extra renderings, raw output, and kept audio
+// present on disk
+func (ls *LocalStorage) ArtifactPaths(txtPath string) []string {
+	paths := []string{txtPath, metaPathFor(txtPath)}
+	for _, format := range types.OutputFormats {
+		path := FormatPath(txtPath, format)
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if _, err := os.Stat(RawOutputPath(txtPath)); err == nil {
+		paths = append(paths, RawOutputPath(txtPath))
+	}
+	if path, ok := AudioPath(txtPath); ok {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// ArtifactBytes returns the combined size of a transcript and its metadata file
+func (ls *LocalStorage) ArtifactBytes(txtPath string) int64 {
+	var total int64
+	for _, path := range ls.ArtifactPaths(txtPath) {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// DeleteTranscript removes a transcript and its metadata file from disk
+func (ls *LocalStorage) DeleteTranscript(txtPath string) error {
+	for _, path := range ls.ArtifactPaths(txtPath) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// sanitizeFilename removes invalid characters from filename
+func sanitizeFilename(name string) string {
+	// Replace invalid characters with underscore
+	invalid := []string{"/", "\\", ":", "*", "?", "\"", "<", ">", "|"}
+	result := name
+	for range invalid {
+		result = filepath.Base(result) // Remove path separators
+	}
+	if len(result) > 100 {
+		result = result[:100] // Limit length
+	}
+	return result
+}
@@ -0,0 +1,721 @@
+package storage
+
+// SQLite metadata database — stores job history, transcript metadata,
+// duration, word count, and source info for API queries.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// MetadataDB handles SQLite database operations
+type MetadataDB struct {
+	db *sql.DB
+}
+
+// NewMetadataDB creates a new metadata database
+func NewMetadataDB(dbPath string) (*MetadataDB, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Create table if not exists
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS transcripts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id TEXT NOT NULL UNIQUE,
+		request_name TEXT NOT NULL,
+		source_type TEXT NOT NULL,
+		gdrive_url TEXT,
+		local_path TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		duration REAL,
+		word_count INTEGER
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_created_at ON transcripts(created_at);
+	CREATE INDEX IF NOT EXISTS idx_request_name ON transcripts(request_name);
+
+	CREATE TABLE IF NOT EXISTS transcript_labels (
+		job_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		PRIMARY KEY (job_id, key)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_labels_key_value ON transcript_labels(key, value);
+
+	CREATE TABLE IF NOT EXISTS artifact_checksums (
+		job_id TEXT NOT NULL,
+		path TEXT NOT NULL,
+		sha256 TEXT NOT NULL,
+		PRIMARY KEY (job_id, path)
+	);
+
+	CREATE TABLE IF NOT EXISTS jobs (
+		job_id TEXT PRIMARY KEY,
+		request_name TEXT NOT NULL,
+		source_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id TEXT PRIMARY KEY,
+		endpoint TEXT NOT NULL,
+		event TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at INTEGER NOT NULL,
+		last_status_code INTEGER,
+		last_error TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_due ON webhook_deliveries(status, next_attempt_at);
+
+	CREATE TABLE IF NOT EXISTS report_runs (
+		report TEXT NOT NULL,
+		period_start TEXT NOT NULL,
+		sent_at DATETIME NOT NULL,
+		PRIMARY KEY (report, period_start)
+	);
+
+	CREATE TABLE IF NOT EXISTS dead_jobs (
+		job_id TEXT PRIMARY KEY,
+		request_name TEXT NOT NULL,
+		source_type TEXT NOT NULL,
+		source_path TEXT,
+		error TEXT NOT NULL,
+		stack TEXT,
+		attempts INTEGER NOT NULL,
+		job TEXT NOT NULL,
+		failed_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS job_groups (
+		group_id TEXT PRIMARY KEY,
+		request_name TEXT NOT NULL,
+		source_type TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		finished_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS job_group_members (
+		group_id TEXT NOT NULL,
+		job_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		PRIMARY KEY (group_id, job_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS transcript_versions (
+		job_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		model TEXT,
+		word_count INTEGER NOT NULL,
+		segments TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (job_id, version)
+	);
+	`
+
+	if _, err := db.Exec(createTableSQL); err != nil {
+		return nil, fmt.Errorf("failed to create table: %v", err)
+	}
+
+	mdb := &MetadataDB{db: db}
+	if err := mdb.migrate(); err != nil {
+		return nil, err
+	}
+
+	return mdb, nil
+}
+
+// migrate adds columns introduced after the initial schema
+func (mdb *MetadataDB) migrate() error {
+	columns := []struct{ name, definition string }{
+		{"metadata", "TEXT"},
+		{"normalize_seconds", "REAL"},
+		{"transcribe_seconds", "REAL"},
+		{"audio_minutes", "REAL"},
+		{"cloud_cost_usd", "REAL"},
+		{"storage_bytes", "INTEGER"},
+		{"gdrive_bytes", "INTEGER"},
+		{"key_fingerprint", "TEXT"},
+		{"cpu_seconds", "REAL"},
+		{"peak_memory_mb", "REAL"},
+		{"source_format", "TEXT"},
+		{"source_codec", "TEXT"},
+		{"source_sha256", "TEXT"},
+		{"model", "TEXT"},
+		{"language", "TEXT"},
+		{"description", "TEXT"},
+		{"language_confidence", "REAL"},
+		{"confidence", "REAL"},
+		{"task", "TEXT"},
+		{"provenance", "TEXT"},
+		{"source_duration", "REAL"},
+		{"source_bit_rate", "INTEGER"},
+		{"replacements", "TEXT"},
+		{"raw_output_expired", "INTEGER"},
+	}
+
+	for _, col := range columns {
+		if err := mdb.addColumnIfMissing("transcripts", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+	// Raw output expiry only looks at transcripts it hasn't handled yet
+	if _, err := mdb.db.Exec(`CREATE INDEX IF NOT EXISTS idx_raw_output_live ON transcripts(created_at)
+		WHERE raw_output_expired IS NULL`); err != nil {
+		return fmt.Errorf("failed to index raw outputs: %v", err)
+	}
+
+	for _, col := range []struct{ name, definition string }{
+		{"progress", "REAL"},
+		{"checkpoint", "TEXT"},
+		{"started_at", "DATETIME"},
+		{"finished_at", "DATETIME"},
+		{"tenant", "TEXT"},
+	} {
+		if err := mdb.addColumnIfMissing("jobs", col.name, col.definition); err != nil {
+			return err
+		}
+	}
+
+	// Deliveries name their job, as templated payloads may not
+	if err := mdb.addColumnIfMissing("webhook_deliveries", "job_id", "TEXT"); err != nil {
+		return err
+	}
+	if _, err := mdb.db.Exec(`UPDATE webhook_deliveries
+		SET job_id = COALESCE(CASE WHEN json_valid(payload) THEN json_extract(payload, '$.data.job_id') END, '')
+		WHERE job_id IS NULL`); err != nil {
+		return fmt.Errorf("failed to backfill webhook delivery jobs: %v", err)
+	}
+	if _, err := mdb.db.Exec(`CREATE INDEX IF NOT EXISTS idx_webhook_job ON webhook_deliveries(job_id)`); err != nil {
+		return fmt.Errorf("failed to index webhook delivery jobs: %v", err)
+	}
+	return mdb.normalizeTimes()
+}
+
+// timeColumns are the timestamp columns, by table
+var timeColumns = map[string][]string{
+	"transcripts":         {"created_at"},
+	"jobs":                {"created_at", "updated_at", "started_at", "finished_at"},
+	"webhook_deliveries":  {"created_at", "updated_at"},
+	"report_runs":         {"sent_at"},
+	"dead_jobs":           {"failed_at"},
+	"job_groups":          {"created_at", "finished_at"},
+	"transcript_versions": {"created_at"},
+}
+
+// storedUTCSuffix ends a timestamp the driver stored in UTC
+const storedUTCSuffix = " +0000 UTC"
+
+// normalizeTimes rewrites timestamps stored with a local offset, as they
+// were before timestamps were stored in UTC, so they compare correctly
+func (mdb *MetadataDB) normalizeTimes() error {
+	for table, columns := range timeColumns {
+		for _, column := range columns {
+			if err := mdb.normalizeTimeColumn(table, column); err != nil {
+				return fmt.Errorf("failed to convert %s.%s to UTC: %v", table, column, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (mdb *MetadataDB) normalizeTimeColumn(table, column string) error {
+	rows, err := mdb.db.Query(fmt.Sprintf(`SELECT rowid, %[1]s FROM %[2]s
+		WHERE %[1]s IS NOT NULL AND %[1]s NOT LIKE ?`, column, table), "%"+storedUTCSuffix)
+	if err != nil {
+		return err
+	}
+	converted := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			rowid int64
+			t     time.Time
+		)
+		if err := rows.Scan(&rowid, &t); err != nil {
+			rows.Close()
+			return err
+		}
+		converted[rowid] = t.UTC()
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for rowid, t := range converted {
+		if _, err := mdb.db.Exec(fmt.Sprintf(`UPDATE %s SET %s = ? WHERE rowid = ?`, table, column), t, rowid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already present
+func (mdb *MetadataDB) addColumnIfMissing(table, column, definition string) error {
+	rows, err := mdb.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			defaultValue     sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to inspect table %s: %v", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	rows.Close()
+
+	if _, err := mdb.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %v", table, column, err)
+	}
+	return nil
+}
+
+// SaveTranscript saves transcript metadata to the database
+func (mdb *MetadataDB) SaveTranscript(
+	jobID, requestName, sourceType, gdriveURL, localPath string,
+	duration float64, wordCount int, metadata map[string]interface{},
+) error {
+	query := `
+	INSERT INTO transcripts (job_id, request_name, source_type, gdrive_url, local_path, created_at, duration, word_count, metadata)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var metadataJSON sql.NullString
+	if len(metadata) > 0 {
+		encoded, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata: %v", err)
+		}
+		metadataJSON = sql.NullString{String: string(encoded), Valid: true}
+	}
+
+	_, err := mdb.db.Exec(query, jobID, requestName, sourceType, gdriveURL, localPath,
+		time.Now().UTC(), duration, wordCount, metadataJSON)
+	if err != nil {
+		return fmt.Errorf("failed to save transcript metadata: %v", err)
+	}
+
+	return nil
+}
+
+// SaveSourceAudio records the container, codec, length, and bit rate of
+// the audio the job was submitted with
+func (mdb *MetadataDB) SaveSourceAudio(jobID string, source types.SourceAudio) error {
+	_, err := mdb.db.Exec(`UPDATE transcripts SET source_format = ?, source_codec = ?, source_duration = ?,
+		source_bit_rate = ? WHERE job_id = ?`,
+		source.Format, source.Codec, source.Duration, source.BitRate, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to save source audio format: %v", err)
+	}
+	return nil
+}
+
+// SaveLanguage records the language a transcript was detected or declared
+// in, and the backend's confidence when it was detected (zero otherwise)
+func (mdb *MetadataDB) SaveLanguage(jobID, language string, confidence float64) error {
+	var conf interface{}
+	if confidence > 0 {
+		conf = confidence
+	}
+	_, err := mdb.db.Exec(`UPDATE transcripts SET language = ?, language_confidence = ? WHERE job_id = ?`, language, conf, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to save language: %v", err)
+	}
+	return nil
+}
+
+// SaveTask records whether a transcript is a transcription or an English
+// translation; empty means transcription
+func (mdb *MetadataDB) SaveTask(jobID, task string) error {
+	if task == "" {
+		task = "transcribe"
+	}
+	if _, err := mdb.db.Exec(`UPDATE transcripts SET task = ? WHERE job_id = ?`, task, jobID); err != nil {
+		return fmt.Errorf("failed to save task: %v", err)
+	}
+	return nil
+}
+
+// SaveConfidence records a transcript's mean segment confidence, for
+// backends that score their output; zero is not recorded
+func (mdb *MetadataDB) SaveConfidence(jobID string, confidence float64) error {
+	if confidence <= 0 {
+		return nil
+	}
+	if _, err := mdb.db.Exec(`UPDATE transcripts SET confidence = ? WHERE job_id = ?`, confidence, jobID); err != nil {
+		return fmt.Errorf("failed to save confidence: %v", err)
+	}
+	return nil
+}
+
+// SaveProvenance records the backend, model, tool versions, and decoding
+// options a transcript was produced with; nil is not recorded
+func (mdb *MetadataDB) SaveProvenance(jobID string, provenance *types.Provenance) error {
+	if provenance == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(provenance)
+	if err != nil {
+		return fmt.Errorf("failed to encode provenance: %v", err)
+	}
+	if _, err := mdb.db.Exec(`UPDATE transcripts SET provenance = ? WHERE job_id = ?`, string(encoded), jobID); err != nil {
+		return fmt.Errorf("failed to save provenance: %v", err)
+	}
+	return nil
+}
+
+// SaveReplacements records the replacement dictionary terms applied to a
+// transcript; none is not recorded
+func (mdb *MetadataDB) SaveReplacements(jobID string, replacements []types.Replacement) error {
+	if len(replacements) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(replacements)
+	if err != nil {
+		return fmt.Errorf("failed to encode replacements: %v", err)
+	}
+	if _, err := mdb.db.Exec(`UPDATE transcripts SET replacements = ? WHERE job_id = ?`, string(encoded), jobID); err != nil {
+		return fmt.Errorf("failed to save replacements: %v", err)
+	}
+	return nil
+}
+
+// transcriptColumns is the column list shared by all transcript queries
+const transcriptColumns = `job_id, request_name, source_type, gdrive_url, local_path, created_at, duration, word_count, metadata,
+	(SELECT json_group_object(key, value) FROM transcript_labels l WHERE l.job_id = transcripts.job_id),
+	COALESCE(normalize_seconds, 0), COALESCE(transcribe_seconds, 0), COALESCE(audio_minutes, 0), COALESCE(cloud_cost_usd, 0),
+	COALESCE(key_fingerprint, ''), COALESCE(cpu_seconds, 0), COALESCE(peak_memory_mb, 0),
+	COALESCE(source_format, ''), COALESCE(source_codec, ''), COALESCE(source_sha256, ''),
+	COALESCE(source_duration, 0), COALESCE(source_bit_rate, 0),
+	COALESCE(language, ''), COALESCE(description, ''), COALESCE(language_confidence, 0), COALESCE(confidence, 0),
+	COALESCE(task, 'transcribe'), provenance, replacements`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTranscript converts one transcripts row into the API representation
+func scanTranscript(row rowScanner) (map[string]interface{}, error) {
+	var (
+		jid, name, source, gdrive, local string
+		createdAt                        time.Time
+		duration                         float64
+		wordCount                        int
+		metadataJSON, labelsJSON         sql.NullString
+		cost                             types.JobCost
+		keyFingerprint                   string
+		resources                        types.ResourceUsage
+		sourceFormat, sourceCodec        string
+		sourceSHA256                     string
+		sourceDuration                   float64
+		sourceBitRate                    int64
+		language, description            string
+		languageConfidence, confidence   float64
+		task                             string
+		provenanceJSON                   sql.NullString
+		replacementsJSON                 sql.NullString
+	)
+
+	if err := row.Scan(&jid, &name, &source, &gdrive, &local, &createdAt, &duration, &wordCount, &metadataJSON, &labelsJSON,
+		&cost.NormalizeSeconds, &cost.TranscribeSeconds, &cost.AudioMinutes, &cost.CloudCostUSD, &keyFingerprint,
+		&resources.CPUSeconds, &resources.PeakMemoryMB, &sourceFormat, &sourceCodec, &sourceSHA256,
+		&sourceDuration, &sourceBitRate, &language, &description, &languageConfidence, &confidence, &task, &provenanceJSON,
+		&replacementsJSON); err != nil {
+		return nil, err
+	}
+	cost.ComputeSeconds = cost.NormalizeSeconds + cost.TranscribeSeconds
+
+	labels := map[string]string{}
+	if labelsJSON.Valid && labelsJSON.String != "" {
+		if err := json.Unmarshal([]byte(labelsJSON.String), &labels); err != nil {
+			return nil, fmt.Errorf("corrupt labels for job %s: %v", jid, err)
+		}
+	}
+
+	var provenance *types.Provenance
+	if provenanceJSON.Valid && provenanceJSON.String != "" {
+		if err := json.Unmarshal([]byte(provenanceJSON.String), &provenance); err != nil {
+			return nil, fmt.Errorf("corrupt provenance for job %s: %v", jid, err)
+		}
+	}
+
+	var replacements []types.Replacement
+	if replacementsJSON.Valid && replacementsJSON.String != "" {
+		if err := json.Unmarshal([]byte(replacementsJSON.String), &replacements); err != nil {
+			return nil, fmt.Errorf("corrupt replacements for job %s: %v", jid, err)
+		}
+	}
+
+	metadata := map[string]interface{}{}
+	if metadataJSON.Valid && metadataJSON.String != "" {
+		if err := json.Unmarshal([]byte(metadataJSON.String), &metadata); err != nil {
+			return nil, fmt.Errorf("corrupt metadata for job %s: %v", jid, err)
+		}
+	}
+
+	transcript := map[string]interface{}{
+		"job_id":       jid,
+		"request_name": name,
+		"source_type":  source,
+		"gdrive_url":   gdrive,
+		"local_path":   local,
+		"created_at":   createdAt,
+		"duration":     duration,
+		"word_count":   wordCount,
+		"metadata":     metadata,
+		"labels":       labels,
+		"cost":         cost,
+		"encrypted":    keyFingerprint != "",
+		"resources":    resources,
+		"language":     language,
+		"task":         task,
+		// false for translations, whose text is English whatever the
+		// spoken language
+		"original_language": task != "translate",
+	}
+	if description != "" {
+		transcript["description"] = description
+	}
+	if languageConfidence > 0 {
+		transcript["language_confidence"] = languageConfidence
+	}
+	if confidence > 0 {
+		transcript["confidence"] = confidence
+	}
+	if sourceCodec != "" {
+		sourceAudio := map[string]interface{}{"format": sourceFormat, "codec": sourceCodec}
+		if sourceDuration > 0 {
+			sourceAudio["duration_seconds"] = sourceDuration
+		}
+		if sourceBitRate > 0 {
+			sourceAudio["bit_rate"] = sourceBitRate
+		}
+		transcript["source_audio"] = sourceAudio
+	}
+	if sourceSHA256 != "" {
+		transcript["source_sha256"] = sourceSHA256
+	}
+	if provenance != nil {
+		transcript["provenance"] = provenance
+	}
+	if len(replacements) > 0 {
+		transcript["replacements"] = replacements
+	}
+	return transcript, nil
+}
+
+// GetTranscript retrieves transcript metadata by job ID
+func (mdb *MetadataDB) GetTranscript(jobID string) (map[string]interface{}, error) {
+	query := `SELECT ` + transcriptColumns + ` FROM transcripts WHERE job_id = ?`
+
+	transcript, err := scanTranscript(mdb.db.QueryRow(query, jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transcript: %v", err)
+	}
+
+	return transcript, nil
+}
+
+// TranscriptFilter restricts list and stats queries. Metadata matches the
+// free-form JSON fields; Labels matches indexed key=value labels.
+type TranscriptFilter struct {
+	Metadata map[string]string
+	Labels   map[string]string
+}
+
+// whereClause builds the SQL condition and arguments for a filter
+func (f TranscriptFilter) whereClause() (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	for _, key := range sortedKeys(f.Metadata) {
+		conditions = append(conditions, `CAST(json_extract(metadata, ?) AS TEXT) = ?`)
+		args = append(args, fmt.Sprintf(`$."%s"`, key), f.Metadata[key])
+	}
+
+	for _, key := range sortedKeys(f.Labels) {
+		conditions = append(conditions, `job_id IN (SELECT job_id FROM transcript_labels WHERE key = ? AND value = ?)`)
+		args = append(args, key, f.Labels[key])
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return ` WHERE ` + strings.Join(conditions, " AND "), args
+}
+
+// sortedKeys returns map keys in order so the generated SQL is stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ListTranscripts returns the most recent transcripts matching the filter
+func (mdb *MetadataDB) ListTranscripts(limit int, filter TranscriptFilter) ([]map[string]interface{}, error) {
+	where, args := filter.whereClause()
+
+	query := `SELECT ` + transcriptColumns + ` FROM transcripts` + where + ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := mdb.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transcripts: %v", err)
+	}
+	defer rows.Close()
+
+	var transcripts []map[string]interface{}
+
+	for rows.Next() {
+		transcript, err := scanTranscript(rows)
+		if err != nil {
+			continue
+		}
+		transcripts = append(transcripts, transcript)
+	}
+
+	return transcripts, nil
+}
+
+// Stats returns aggregate counts for transcripts matching the filter
+func (mdb *MetadataDB) Stats(filter TranscriptFilter) (map[string]interface{}, error) {
+	where, args := filter.whereClause()
+
+	query := `
+	SELECT source_type, COUNT(*), COALESCE(SUM(duration), 0), COALESCE(SUM(word_count), 0),
+		COALESCE(SUM(normalize_seconds), 0) + COALESCE(SUM(transcribe_seconds), 0),
+		COALESCE(SUM(audio_minutes), 0), COALESCE(SUM(cloud_cost_usd), 0)
+	FROM transcripts` + where + ` GROUP BY source_type`
+
+	rows, err := mdb.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute stats: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		totalCount    int
+		totalDuration float64
+		totalWords    int
+		totalCompute  float64
+		totalMinutes  float64
+		totalCloud    float64
+		bySource      = map[string]interface{}{}
+	)
+
+	for rows.Next() {
+		var (
+			source   string
+			count    int
+			duration float64
+			words    int
+			compute  float64
+			minutes  float64
+			cloud    float64
+		)
+		if err := rows.Scan(&source, &count, &duration, &words, &compute, &minutes, &cloud); err != nil {
+			return nil, fmt.Errorf("failed to compute stats: %v", err)
+		}
+
+		totalCount += count
+		totalDuration += duration
+		totalWords += words
+		totalCompute += compute
+		totalMinutes += minutes
+		totalCloud += cloud
+		bySource[source] = map[string]interface{}{
+			"transcripts":      count,
+			"duration_seconds": duration,
+			"word_count":       words,
+			"compute_seconds":  compute,
+			"audio_minutes":    minutes,
+			"cloud_cost_usd":   cloud,
+		}
+	}
+
+	return map[string]interface{}{
+		"transcripts":      totalCount,
+		"duration_seconds": totalDuration,
+		"word_count":       totalWords,
+		"compute_seconds":  totalCompute,
+		"audio_minutes":    totalMinutes,
+		"cloud_cost_usd":   totalCloud,
+		"by_source":        bySource,
+	}, nil
+}
+
+// DeleteTranscript removes a transcript row, its labels, versions, and job record
+func (mdb *MetadataDB) DeleteTranscript(jobID string) error {
+	tx, err := mdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete transcript: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM transcript_labels WHERE job_id = ?`, jobID); err != nil {
+		return fmt.Errorf("failed to delete labels: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM artifact_checksums WHERE job_id = ?`, jobID); err != nil {
+		return fmt.Errorf("failed to delete checksums: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM transcript_versions WHERE job_id = ?`, jobID); err != nil {
+		return fmt.Errorf("failed to delete transcript versions: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM jobs WHERE job_id = ?`, jobID); err != nil {
+		return fmt.Errorf("failed to delete job record: %v", err)
+	}
+	res, err := tx.Exec(`DELETE FROM transcripts WHERE job_id = ?`, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to delete transcript: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("transcript %s not found", jobID)
+	}
+
+	return tx.Commit()
+}
+
+// Ping verifies the database is reachable
+func (mdb *MetadataDB) Ping() error {
+	return mdb.db.Ping()
+}
+
+// Close closes the database connection
+func (mdb *MetadataDB) Close() error {
+	return mdb.db.Close()
+}
@@ -0,0 +1,92 @@
+package storage
+
+// Per-tenant data requests — finds every job, transcript, and webhook
+// delivery tied to a tenant so it can be exported or erased.
+
+import (
+	"fmt"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// TenantJobIDs returns every job recorded for a tenant, whether it produced
+// a transcript or not
+func (mdb *MetadataDB) TenantJobIDs(tenant string) ([]string, error) {
+	rows, err := mdb.db.Query(`
+	SELECT job_id FROM jobs WHERE tenant = ?
+	UNION
+	SELECT job_id FROM transcript_labels WHERE key = ? AND value = ?
+	ORDER BY job_id
+	`, tenant, TenantLabel, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant jobs: %v", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ActiveTenantJobs counts a tenant's jobs that have not reached a final status
+func (mdb *MetadataDB) ActiveTenantJobs(tenant string) (int, error) {
+	var count int
+	err := mdb.db.QueryRow(`SELECT COUNT(*) FROM jobs WHERE tenant = ? AND status NOT IN (?, ?, ?)`,
+		tenant, types.StatusCompleted, types.StatusFailed, types.StatusCancelled).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active tenant jobs: %v", err)
+	}
+	return count, nil
+}
+
+// JobDeliveries returns the webhook deliveries sent about a job
+func (mdb *MetadataDB) JobDeliveries(jobID string) ([]*WebhookDelivery, error) {
+	rows, err := mdb.db.Query(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE job_id = ? ORDER BY created_at`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// EraseJob removes every database record of a job: transcript, labels,
+// checksums, versions, job status, webhook deliveries, and any dead-letter
+// entry. Missing rows are not an error.
+func (mdb *MetadataDB) EraseJob(jobID string) error {
+	tx, err := mdb.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to erase job: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		`DELETE FROM transcript_labels WHERE job_id = ?`,
+		`DELETE FROM artifact_checksums WHERE job_id = ?`,
+		`DELETE FROM transcript_versions WHERE job_id = ?`,
+		`DELETE FROM jobs WHERE job_id = ?`,
+		`DELETE FROM transcripts WHERE job_id = ?`,
+		`DELETE FROM webhook_deliveries WHERE job_id = ?`,
+		`DELETE FROM dead_jobs WHERE job_id = ?`,
+	} {
+		if _, err := tx.Exec(stmt, jobID); err != nil {
+			return fmt.Errorf("failed to erase job %s: %v", jobID, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,137 @@
+package storage
+
+// Per-tenant storage quotas — limits on local output and Google Drive bytes
+// for jobs carrying a "tenant" label, with a warning threshold.
+
+import (
+	"fmt"
+	"log"
+)
+
+// TenantLabel is the job label that identifies the owning tenant
+const TenantLabel = "tenant"
+
+// QuotaLimit is a storage allowance in bytes (0 means unlimited)
+type QuotaLimit struct {
+	LocalBytes int64
+	DriveBytes int64
+}
+
+// QuotaError is returned when a tenant has used up its storage allowance
+type QuotaError struct {
+	Tenant string
+	Kind   string // "local" or "drive"
+	Used   int64
+	Limit  int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("tenant %q has exceeded its %s storage quota (%.1fMB of %.1fMB used); purge old transcripts to continue",
+		e.Tenant, e.Kind, float64(e.Used)/(1024*1024), float64(e.Limit)/(1024*1024))
+}
+
+// QuotaManager enforces per-tenant storage limits using recorded artifact sizes
+type QuotaManager struct {
+	db          *MetadataDB
+	defaults    QuotaLimit
+	tenants     map[string]QuotaLimit
+	warnPercent int
+}
+
+// NewQuotaManager creates a quota manager; tenants without an explicit
+// entry fall back to the default limit
+func NewQuotaManager(db *MetadataDB, defaults QuotaLimit, tenants map[string]QuotaLimit, warnPercent int) *QuotaManager {
+	if warnPercent <= 0 || warnPercent > 100 {
+		warnPercent = 80
+	}
+	return &QuotaManager{
+		db:          db,
+		defaults:    defaults,
+		tenants:     tenants,
+		warnPercent: warnPercent,
+	}
+}
+
+// limitFor returns the configured limit for a tenant
+func (qm *QuotaManager) limitFor(tenant string) QuotaLimit {
+	if limit, ok := qm.tenants[tenant]; ok {
+		return limit
+	}
+	return qm.defaults
+}
+
+// Check returns a *QuotaError if the tenant may not submit new jobs
+func (qm *QuotaManager) Check(tenant string) error {
+	if tenant == "" {
+		return nil
+	}
+
+	limit := qm.limitFor(tenant)
+	if limit.LocalBytes == 0 && limit.DriveBytes == 0 {
+		return nil
+	}
+
+	local, drive, err := qm.db.TenantStorageBytes(tenant)
+	if err != nil {
+		return err
+	}
+
+	if limit.LocalBytes > 0 && local >= limit.LocalBytes {
+		return &QuotaError{Tenant: tenant, Kind: "local", Used: local, Limit: limit.LocalBytes}
+	}
+	if limit.DriveBytes > 0 && drive >= limit.DriveBytes {
+		return &QuotaError{Tenant: tenant, Kind: "drive", Used: drive, Limit: limit.DriveBytes}
+	}
+	return nil
+}
+
+// WarnIfNearLimit logs a warning once a tenant crosses the warning threshold
+func (qm *QuotaManager) WarnIfNearLimit(tenant string) {
+	if tenant == "" {
+		return
+	}
+
+	limit := qm.limitFor(tenant)
+	local, drive, err := qm.db.TenantStorageBytes(tenant)
+	if err != nil {
+		log.Printf("Quota check failed for tenant %s: %v", tenant, err)
+		return
+	}
+
+	warn := func(kind string, used, max int64) {
+		if max <= 0 {
+			return
+		}
+		if percent := used * 100 / max; percent >= int64(qm.warnPercent) {
+			log.Printf("WARNING: tenant %s is at %d%% of its %s storage quota (%.1fMB of %.1fMB)",
+				tenant, percent, kind, float64(used)/(1024*1024), float64(max)/(1024*1024))
+		}
+	}
+	warn("local", local, limit.LocalBytes)
+	warn("drive", drive, limit.DriveBytes)
+}
+
+// SaveStorageUsage records the bytes a job occupies locally and on Drive
+func (mdb *MetadataDB) SaveStorageUsage(jobID string, localBytes, driveBytes int64) error {
+	_, err := mdb.db.Exec(`UPDATE transcripts SET storage_bytes = ?, gdrive_bytes = ? WHERE job_id = ?`,
+		localBytes, driveBytes, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to save storage usage: %v", err)
+	}
+	return nil
+}
+
+// TenantStorageBytes sums recorded local and Drive bytes for a tenant
+func (mdb *MetadataDB) TenantStorageBytes(tenant string) (int64, int64, error) {
+	query := `
+	SELECT COALESCE(SUM(storage_bytes), 0), COALESCE(SUM(gdrive_bytes), 0)
+	FROM transcripts
+	WHERE job_id IN (SELECT job_id FROM transcript_labels WHERE key = ? AND value = ?)
+	`
+
+	var local, drive int64
+	if err := mdb.db.QueryRow(query, TenantLabel, tenant).Scan(&local, &drive); err != nil {
+		return 0, 0, fmt.Errorf("failed to compute tenant storage: %v", err)
+	}
+	return local, drive, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+// Raw output — whisper's JSON for a job, kept next to its transcript as
+// <name>_raw.json so the transcript can be reformatted or post-processed
+// again without transcribing the audio anew. Edits never touch it. It is
+// sealed like the other artifacts, and removed after storage.raw_output's
+// retention while the transcript itself stays.
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// RawOutputPath returns where the raw output of a transcript is kept
+func RawOutputPath(txtPath string) string {
+	return siblingPath(txtPath, "_raw.json")
+}
+
+// SaveRawOutput writes a job's raw output next to its transcript
+func (ls *LocalStorage) SaveRawOutput(txtPath string, raw []byte, opts SaveOptions) error {
+	data, err := opts.seal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt raw output: %v", err)
+	}
+	if err := os.WriteFile(RawOutputPath(txtPath), data, 0644); err != nil {
+		return fmt.Errorf("failed to save raw output: %v", err)
+	}
+	return nil
+}
+
+// ExpireRawOutputs removes the raw output of transcripts created more than
+// maxAge ago, with its recorded checksum, and returns how many were removed.
+// Each transcript is marked once handled, whether it kept a raw output or
+// not, so a pass only looks at transcripts that aged since the last.
+func (mdb *MetadataDB) ExpireRawOutputs(maxAge time.Duration) (int, error) {
+	rows, err := mdb.db.Query(`SELECT job_id, local_path FROM transcripts
+		WHERE raw_output_expired IS NULL AND substr(created_at, 1, 19) < ?`,
+		dbTime(time.Now().Add(-maxAge)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list transcripts: %v", err)
+	}
+	var jobIDs, paths []string
+	for rows.Next() {
+		var jobID, localPath string
+		if err := rows.Scan(&jobID, &localPath); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		jobIDs = append(jobIDs, jobID)
+		paths = append(paths, RawOutputPath(localPath))
+	}
+	rows.Close()
+
+	removed := 0
+	for i, path := range paths {
+		err := os.Remove(path)
+		switch {
+		case err == nil:
+			// Otherwise integrity checks would report it missing
+			if _, err := mdb.db.Exec(`DELETE FROM artifact_checksums WHERE job_id = ? AND path = ?`, jobIDs[i], path); err != nil {
+				log.Printf("Failed to forget checksum of job %s raw output: %v", jobIDs[i], err)
+			}
+			removed++
+		case !os.IsNotExist(err):
+			// Left unmarked, to be tried again next pass
+			log.Printf("Failed to remove raw output of job %s: %v", jobIDs[i], err)
+			continue
+		}
+		if _, err := mdb.db.Exec(`UPDATE transcripts SET raw_output_expired = 1 WHERE job_id = ?`, jobIDs[i]); err != nil {
+			log.Printf("Failed to mark raw output of job %s expired: %v", jobIDs[i], err)
+		}
+	}
+	return removed, nil
+}
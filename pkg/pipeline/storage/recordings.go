@@ -0,0 +1,303 @@
+package storage
+
+// Chunked recordings — a client with unreliable connectivity uploads a
+// recording as numbered chunks whenever it is online, in any order and as
+// often as it needs to, and the recording is assembled once its manifest
+// is complete. Each recording is a directory holding its session file and
+// the chunks received so far, so uploads resume across server restarts.
+// Chunks of encrypted recordings stay sealed on disk until assembly.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRecordingNotFound is returned for an unknown recording ID
+	ErrRecordingNotFound = errors.New("recording not found")
+
+	// ErrRecordingClosed is returned for chunks sent after assembly
+	ErrRecordingClosed = errors.New("recording was already assembled")
+)
+
+// recordingSessionFile holds a recording's Recording, as JSON
+const recordingSessionFile = "session.json"
+
+// chunkPrefix names chunk files, e.g. "chunk-000001"
+const chunkPrefix = "chunk-"
+
+// Recording is a chunked recording's session
+type Recording struct {
+	ID   string `json:"recording_id"`
+	Name string `json:"name"`
+	// Format is the file extension of the assembled audio (webm, m4a, ...)
+	Format string `json:"format"`
+	// KeyFingerprint identifies the client key the chunks are sealed with;
+	// empty when they are not encrypted. The key itself is never stored.
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+	// Options are the job options the recording was started with, as JSON
+	Options json.RawMessage `json:"options,omitempty"`
+	// JobID is the job transcribing the assembled recording, once there is one
+	JobID     string    `json:"job_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RecordingStore keeps chunked recordings under a directory
+type RecordingStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewRecordingStore creates a store in dir
+func NewRecordingStore(dir string) (*RecordingStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %v", err)
+	}
+	return &RecordingStore{dir: dir}, nil
+}
+
+// path returns the directory of a recording. IDs are generated by the
+// server, so anything that could leave the store is simply not found.
+func (s *RecordingStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", ErrRecordingNotFound
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+// Create starts a recording session
+func (s *RecordingStore) Create(rec *Recording) error {
+	dir, err := s.path(rec.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create recording: %v", err)
+	}
+	rec.CreatedAt = time.Now()
+	return s.save(dir, rec)
+}
+
+// save writes a recording's session file
+func (s *RecordingStore) save(dir string, rec *Recording) error {
+	rec.UpdatedAt = time.Now()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %v", err)
+	}
+	return writeFileAtomic(filepath.Join(dir, recordingSessionFile), data)
+}
+
+// Get returns a recording's session
+func (s *RecordingStore) Get(id string) (*Recording, error) {
+	dir, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(dir)
+}
+
+func (s *RecordingStore) load(dir string) (*Recording, error) {
+	data, err := os.ReadFile(filepath.Join(dir, recordingSessionFile))
+	if os.IsNotExist(err) {
+		return nil, ErrRecordingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %v", err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("corrupt recording %s: %v", filepath.Base(dir), err)
+	}
+	return &rec, nil
+}
+
+// PutChunk stores chunk n (from 1) of a recording, replacing any earlier
+// upload of it
+func (s *RecordingStore) PutChunk(id string, n int, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	rec, err := s.load(dir)
+	if err != nil {
+		return err
+	}
+	if rec.JobID != "" {
+		return ErrRecordingClosed
+	}
+	if err := writeFileAtomic(filepath.Join(dir, chunkName(n)), data); err != nil {
+		return fmt.Errorf("failed to store chunk %d: %v", n, err)
+	}
+	return s.save(dir, rec)
+}
+
+// Chunks lists the chunk numbers received for a recording, in order, and
+// their total size in bytes
+func (s *RecordingStore) Chunks(id string) ([]int, int64, error) {
+	dir, err := s.path(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, 0, ErrRecordingNotFound
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list chunks: %v", err)
+	}
+
+	chunks := []int{}
+	var size int64
+	for _, entry := range entries {
+		n, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), chunkPrefix))
+		if !strings.HasPrefix(entry.Name(), chunkPrefix) || err != nil {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		chunks = append(chunks, n)
+	}
+	sort.Ints(chunks)
+	return chunks, size, nil
+}
+
+// Assemble writes chunks 1..total of a recording, in order, to dst, each
+// passed through open first (e.g. to decrypt it; nil keeps chunks as they
+// are), then marks the recording as transcribed by jobID and drops its
+// chunks. It fails, writing nothing, if a chunk is missing or won't open.
+func (s *RecordingStore) Assemble(id string, total int, dst, jobID string, open func(n int, chunk []byte) ([]byte, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	rec, err := s.load(dir)
+	if err != nil {
+		return err
+	}
+	if rec.JobID != "" {
+		return ErrRecordingClosed
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create assembled recording: %v", err)
+	}
+	if err := appendChunks(out, dir, total, open); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to write assembled recording: %v", err)
+	}
+
+	rec.JobID = jobID
+	if err := s.save(dir, rec); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	for n := 1; n <= total; n++ {
+		os.Remove(filepath.Join(dir, chunkName(n)))
+	}
+	return nil
+}
+
+// appendChunks copies chunks 1..total from dir to out
+func appendChunks(out io.Writer, dir string, total int, open func(n int, chunk []byte) ([]byte, error)) error {
+	for n := 1; n <= total; n++ {
+		chunk, err := os.ReadFile(filepath.Join(dir, chunkName(n)))
+		if err != nil {
+			return fmt.Errorf("chunk %d is missing", n)
+		}
+		if open != nil {
+			if chunk, err = open(n, chunk); err != nil {
+				return err
+			}
+		}
+		if _, err := out.Write(chunk); err != nil {
+			return fmt.Errorf("failed to write assembled recording: %v", err)
+		}
+	}
+	return nil
+}
+
+// Delete removes a recording and its chunks
+func (s *RecordingStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, recordingSessionFile)); os.IsNotExist(err) {
+		return ErrRecordingNotFound
+	}
+	return os.RemoveAll(dir)
+}
+
+// Sweep removes recordings untouched for longer than maxIdle, returning
+// how many were removed
+func (s *RecordingStore) Sweep(maxIdle time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("Failed to list recordings: %v", err)
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		dir := filepath.Join(s.dir, entry.Name())
+		rec, err := s.load(dir)
+		if err != nil || time.Since(rec.UpdatedAt) < maxIdle {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Failed to remove expired recording %s: %v", rec.ID, err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+// chunkName is the file name of chunk n
+func chunkName(n int) string {
+	return fmt.Sprintf("%s%06d", chunkPrefix, n)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over
+// path, so a crash never leaves a partial file behind
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+// Report digests — what happened over a reporting period (new transcripts,
+// audio minutes, failures), and a record of which periods each scheduled
+// report has already been sent for.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// digestFailureLimit caps the failed jobs listed in a digest; the count
+// covers all of them
+const digestFailureLimit = 20
+
+// Digest summarizes one reporting period
+type Digest struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	Transcripts     int     `json:"transcripts"`
+	DurationMinutes float64 `json:"duration_minutes"`
+	ComputeSeconds  float64 `json:"compute_seconds"`
+	CloudCostUSD    float64 `json:"cloud_cost_usd"`
+	// BySource breaks the transcripts down by source type
+	BySource []UsageRow `json:"by_source"`
+
+	Failures int `json:"failures"`
+	// RecentFailures are the latest failed jobs, newest first
+	RecentFailures []FailedJob `json:"recent_failures"`
+}
+
+// FailedJob is a job that failed within a digest's period
+type FailedJob struct {
+	JobID       string    `json:"job_id"`
+	RequestName string    `json:"request_name"`
+	SourceType  string    `json:"source_type"`
+	Error       string    `json:"error"`
+	FinishedAt  time.Time `json:"finished_at"`
+}
+
+// Digest summarizes the transcripts created and the jobs that failed
+// between from (inclusive) and to (exclusive)
+func (mdb *MetadataDB) Digest(from, to time.Time) (*Digest, error) {
+	bySource, err := mdb.UsageReport(from, to, "source", TranscriptFilter{})
+	if err != nil {
+		return nil, err
+	}
+	digest := &Digest{From: from, To: to, BySource: bySource, RecentFailures: []FailedJob{}}
+	for _, row := range bySource {
+		digest.Transcripts += row.Transcripts
+		digest.DurationMinutes += row.DurationSeconds / 60
+		digest.ComputeSeconds += row.ComputeSeconds
+		digest.CloudCostUSD += row.CloudCostUSD
+	}
+
+	window := `status = ? AND substr(finished_at, 1, 19) >= ? AND substr(finished_at, 1, 19) < ?`
+	args := []interface{}{types.StatusFailed, dbTime(from), dbTime(to)}
+	if err := mdb.db.QueryRow(`SELECT COUNT(*) FROM jobs WHERE `+window, args...).Scan(&digest.Failures); err != nil {
+		return nil, fmt.Errorf("failed to count failed jobs: %v", err)
+	}
+
+	rows, err := mdb.db.Query(`
+	SELECT job_id, request_name, source_type, COALESCE(error, ''), finished_at
+	FROM jobs WHERE `+window+` ORDER BY finished_at DESC LIMIT ?`, append(args, digestFailureLimit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed jobs: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var job FailedJob
+		if err := rows.Scan(&job.JobID, &job.RequestName, &job.SourceType, &job.Error, &job.FinishedAt); err != nil {
+			return nil, fmt.Errorf("failed to list failed jobs: %v", err)
+		}
+		digest.RecentFailures = append(digest.RecentFailures, job)
+	}
+	return digest, rows.Err()
+}
+
+// ReportSent reports whether a report was already sent for the period
+// starting at periodStart
+func (mdb *MetadataDB) ReportSent(report string, periodStart time.Time) (bool, error) {
+	var count int
+	err := mdb.db.QueryRow(`SELECT COUNT(*) FROM report_runs WHERE report = ? AND period_start = ?`,
+		report, periodStart.Format(usageTimeLayout)).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check report runs: %v", err)
+	}
+	return count > 0, nil
+}
+
+// MarkReportSent records that a report was sent for the period starting
+// at periodStart
+func (mdb *MetadataDB) MarkReportSent(report string, periodStart time.Time) error {
+	_, err := mdb.db.Exec(`INSERT OR REPLACE INTO report_runs (report, period_start, sent_at) VALUES (?, ?, ?)`,
+		report, periodStart.Format(usageTimeLayout), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record report run: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+// Text artifact encoding — transcripts are UTF-8 with bare LF line endings
+// unless configured otherwise. Captioning and editing tools on Windows
+// often expect a UTF-8 byte order mark and CRLF line endings, so both can
+// be switched on for the txt and subtitle files written for a job.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Line ending styles
+const (
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// utf8BOM is the byte order mark written at the start of a UTF-8 file
+const utf8BOM = "\ufeff"
+
+// TextEncoding controls how text artifacts (txt, srt, vtt, tsv) are written
+type TextEncoding struct {
+	// BOM prefixes each file with a UTF-8 byte order mark
+	BOM bool `yaml:"bom" json:"bom"`
+	// LineEndings is "lf" (default) or "crlf"
+	LineEndings string `yaml:"line_endings" json:"line_endings"`
+}
+
+// Check validates the line ending style
+func (e TextEncoding) Check() error {
+	switch strings.ToLower(e.LineEndings) {
+	case "", LineEndingsLF, LineEndingsCRLF:
+		return nil
+	}
+	return fmt.Errorf("unknown line_endings %q (use %s or %s)", e.LineEndings, LineEndingsLF, LineEndingsCRLF)
+}
+
+// encode converts text to the file's bytes
+func (e TextEncoding) encode(text string) []byte {
+	if strings.EqualFold(e.LineEndings, LineEndingsCRLF) {
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+	}
+	if e.BOM && !strings.HasPrefix(text, utf8BOM) {
+		text = utf8BOM + text
+	}
+	return []byte(text)
+}
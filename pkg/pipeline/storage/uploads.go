@@ -0,0 +1,291 @@
+package storage
+
+// Upload sessions — a CLI tool sends a large file as consecutive parts
+// appended at known offsets, and resumes from the last offset the server
+// acknowledged after a dropped connection or a restart on either side.
+// Each session is a directory holding its session file and the bytes
+// received so far; committing hands the file to a transcription job.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUploadNotFound is returned for an unknown upload session ID
+	ErrUploadNotFound = errors.New("upload session not found")
+
+	// ErrUploadClosed is returned for parts sent after the commit
+	ErrUploadClosed = errors.New("upload session was already committed")
+)
+
+// UploadOffsetError is returned for a part that does not start where the
+// upload so far ends
+type UploadOffsetError struct {
+	// Offset is where the next part must start
+	Offset int64
+}
+
+func (e *UploadOffsetError) Error() string {
+	return fmt.Sprintf("next part must start at offset %d", e.Offset)
+}
+
+// uploadSessionFile holds a session's UploadSession, as JSON, and
+// uploadDataFile the bytes received
+const (
+	uploadSessionFile = "session.json"
+	uploadDataFile    = "data"
+)
+
+// UploadSession is an incremental upload's session
+type UploadSession struct {
+	ID   string `json:"upload_id"`
+	Name string `json:"name"`
+	// Filename is the client's name for the file; its extension is kept
+	Filename string `json:"filename"`
+	// Size is the file's size in bytes as declared when the session
+	// started (0 = not declared)
+	Size int64 `json:"size,omitempty"`
+	// Offset is how many bytes have been received
+	Offset int64 `json:"offset"`
+	Parts  int   `json:"parts"`
+	// KeyFingerprint identifies the client key the job's artifacts are to
+	// be encrypted with; the key itself is never stored
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+	// Options are the job options the session was started with, as JSON
+	Options json.RawMessage `json:"options,omitempty"`
+	// JobID is the job transcribing the file, once it is committed
+	JobID     string    `json:"job_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UploadStore keeps upload sessions under a directory
+type UploadStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewUploadStore creates a store in dir
+func NewUploadStore(dir string) (*UploadStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create uploads directory: %v", err)
+	}
+	return &UploadStore{dir: dir}, nil
+}
+
+// path returns the directory of a session. IDs are generated by the
+// server, so anything that could leave the store is simply not found.
+func (s *UploadStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", ErrUploadNotFound
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+// Create starts an upload session
+func (s *UploadStore) Create(up *UploadSession) error {
+	dir, err := s.path(up.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create upload session: %v", err)
+	}
+	up.CreatedAt = time.Now()
+	return s.save(dir, up)
+}
+
+// save writes a session's session file
+func (s *UploadStore) save(dir string, up *UploadSession) error {
+	up.UpdatedAt = time.Now()
+	data, err := json.Marshal(up)
+	if err != nil {
+		return fmt.Errorf("failed to encode upload session: %v", err)
+	}
+	return writeFileAtomic(filepath.Join(dir, uploadSessionFile), data)
+}
+
+// Get returns an upload session
+func (s *UploadStore) Get(id string) (*UploadSession, error) {
+	dir, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(dir)
+}
+
+func (s *UploadStore) load(dir string) (*UploadSession, error) {
+	data, err := os.ReadFile(filepath.Join(dir, uploadSessionFile))
+	if os.IsNotExist(err) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload session: %v", err)
+	}
+	var up UploadSession
+	if err := json.Unmarshal(data, &up); err != nil {
+		return nil, fmt.Errorf("corrupt upload session %s: %v", filepath.Base(dir), err)
+	}
+	return &up, nil
+}
+
+// Append adds a part at offset, which must be where the upload so far
+// ends (an *UploadOffsetError otherwise), and returns the updated session.
+// The part is on disk before the new offset is recorded, so an
+// acknowledged offset survives a crash.
+func (s *UploadStore) Append(id string, offset int64, part []byte) (*UploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	up, err := s.load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if up.JobID != "" {
+		return nil, ErrUploadClosed
+	}
+	if offset != up.Offset {
+		return nil, &UploadOffsetError{Offset: up.Offset}
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, uploadDataFile), os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload data: %v", err)
+	}
+	defer f.Close()
+	// Drop whatever a part interrupted before its offset was recorded left
+	if err := f.Truncate(up.Offset); err != nil {
+		return nil, fmt.Errorf("failed to store part: %v", err)
+	}
+	if _, err := f.WriteAt(part, up.Offset); err != nil {
+		return nil, fmt.Errorf("failed to store part: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to store part: %v", err)
+	}
+
+	up.Offset += int64(len(part))
+	up.Parts++
+	if err := s.save(dir, up); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// Commit moves the received file to dst and marks the session as
+// transcribed by jobID
+func (s *UploadStore) Commit(id, dst, jobID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	up, err := s.load(dir)
+	if err != nil {
+		return err
+	}
+	if up.JobID != "" {
+		return ErrUploadClosed
+	}
+
+	data := filepath.Join(dir, uploadDataFile)
+	if err := os.Truncate(data, up.Offset); err != nil {
+		return fmt.Errorf("failed to read upload data: %v", err)
+	}
+	if err := MoveFile(data, dst); err != nil {
+		return fmt.Errorf("failed to move upload data: %v", err)
+	}
+	up.JobID = jobID
+	if err := s.save(dir, up); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
+
+// MoveFile renames src to dst, copying across filesystems when needed. A
+// copy is created owner-only, since what is moved is usually someone's
+// audio.
+func MoveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// Delete removes an upload session and its data
+func (s *UploadStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, uploadSessionFile)); os.IsNotExist(err) {
+		return ErrUploadNotFound
+	}
+	return os.RemoveAll(dir)
+}
+
+// Sweep removes sessions untouched for longer than maxIdle, returning how
+// many were removed
+func (s *UploadStore) Sweep(maxIdle time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		log.Printf("Failed to list upload sessions: %v", err)
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		dir := filepath.Join(s.dir, entry.Name())
+		up, err := s.load(dir)
+		if err != nil || time.Since(up.UpdatedAt) < maxIdle {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Failed to remove expired upload session %s: %v", up.ID, err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
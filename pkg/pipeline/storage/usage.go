@@ -0,0 +1,84 @@
+package storage
+
+// Usage reporting — aggregates cost-accounting data over a time window,
+// grouped by source, day, month, or a label such as tenant or team.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UsageRow is one group in a usage report
+type UsageRow struct {
+	Group           string  `json:"group"`
+	Transcripts     int     `json:"transcripts"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	AudioMinutes    float64 `json:"audio_minutes"`
+	ComputeSeconds  float64 `json:"compute_seconds"`
+	CloudCostUSD    float64 `json:"cloud_cost_usd"`
+}
+
+// usageTimeLayout matches the prefix of created_at as stored by the driver
+const usageTimeLayout = "2006-01-02 15:04:05"
+
+// dbTime formats t for comparing with the prefix of a stored timestamp;
+// timestamps are stored in UTC
+func dbTime(t time.Time) string {
+	return t.UTC().Format(usageTimeLayout)
+}
+
+// UsageReport aggregates usage between from (inclusive) and to (exclusive).
+// groupBy is one of "source", "day", "month", or "label.<key>".
+func (mdb *MetadataDB) UsageReport(from, to time.Time, groupBy string, filter TranscriptFilter) ([]UsageRow, error) {
+	var (
+		groupExpr string
+		groupArgs []interface{}
+	)
+
+	switch {
+	case groupBy == "source":
+		groupExpr = `source_type`
+	case groupBy == "day":
+		groupExpr = `substr(created_at, 1, 10)`
+	case groupBy == "month":
+		groupExpr = `substr(created_at, 1, 7)`
+	case strings.HasPrefix(groupBy, "label.") && len(groupBy) > len("label."):
+		groupExpr = `COALESCE((SELECT value FROM transcript_labels l WHERE l.job_id = transcripts.job_id AND l.key = ?), '')`
+		groupArgs = append(groupArgs, strings.TrimPrefix(groupBy, "label."))
+	default:
+		return nil, fmt.Errorf("unsupported group_by %q", groupBy)
+	}
+
+	where, args := filter.whereClause()
+	if where == "" {
+		where = ` WHERE `
+	} else {
+		where += ` AND `
+	}
+	where += `substr(created_at, 1, 19) >= ? AND substr(created_at, 1, 19) < ?`
+	args = append(args, dbTime(from), dbTime(to))
+
+	query := `
+	SELECT ` + groupExpr + ` AS grp, COUNT(*), COALESCE(SUM(duration), 0), COALESCE(SUM(audio_minutes), 0),
+		COALESCE(SUM(normalize_seconds), 0) + COALESCE(SUM(transcribe_seconds), 0), COALESCE(SUM(cloud_cost_usd), 0)
+	FROM transcripts` + where + ` GROUP BY grp ORDER BY grp`
+
+	rows, err := mdb.db.Query(query, append(groupArgs, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build usage report: %v", err)
+	}
+	defer rows.Close()
+
+	report := []UsageRow{}
+	for rows.Next() {
+		var row UsageRow
+		if err := rows.Scan(&row.Group, &row.Transcripts, &row.DurationSeconds, &row.AudioMinutes,
+			&row.ComputeSeconds, &row.CloudCostUSD); err != nil {
+			return nil, fmt.Errorf("failed to build usage report: %v", err)
+		}
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
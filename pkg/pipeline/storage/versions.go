@@ -0,0 +1,105 @@
+package storage
+
+// Transcript versions — the segments of every revision of a transcript:
+// version 1 as it was transcribed (or imported), then one version per
+// human correction. Diffing two of them shows what a model got wrong or
+// what an editor changed. Sealed transcripts keep no versions, since the
+// segments would be stored in the clear.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Version sources
+const (
+	VersionTranscribed   = "transcription"
+	VersionImported      = "import"
+	VersionEdited        = "edit"
+	VersionRetranscribed = "retranscription"
+)
+
+// ErrVersionNotFound is returned for a version a transcript does not have
+var ErrVersionNotFound = errors.New("transcript version not found")
+
+// TranscriptVersion is one revision of a transcript's segments
+type TranscriptVersion struct {
+	JobID   string `json:"job_id"`
+	Version int    `json:"version"`
+	// Source is what produced the version: transcription, import, edit,
+	// or retranscription
+	Source    string          `json:"source"`
+	Model     string          `json:"model,omitempty"`
+	WordCount int             `json:"word_count"`
+	CreatedAt time.Time       `json:"created_at"`
+	Segments  []types.Segment `json:"segments,omitempty"`
+}
+
+// SaveTranscriptVersion records segments as a transcript's next version
+// and returns its number
+func (mdb *MetadataDB) SaveTranscriptVersion(jobID, source, model string, segments []types.Segment) (int, error) {
+	encoded, err := json.Marshal(segments)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode segments: %v", err)
+	}
+	var version int
+	err = mdb.db.QueryRow(`
+	INSERT INTO transcript_versions (job_id, version, source, model, word_count, segments, created_at)
+	SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ? FROM transcript_versions WHERE job_id = ?
+	RETURNING version`,
+		jobID, source, model, len(strings.Fields(types.SegmentText(segments))), string(encoded), time.Now().UTC(), jobID).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save transcript version: %v", err)
+	}
+	return version, nil
+}
+
+// TranscriptVersions lists a transcript's versions, oldest first, without
+// their segments
+func (mdb *MetadataDB) TranscriptVersions(jobID string) ([]TranscriptVersion, error) {
+	rows, err := mdb.db.Query(`
+	SELECT version, source, COALESCE(model, ''), word_count, created_at
+	FROM transcript_versions WHERE job_id = ? ORDER BY version`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transcript versions: %v", err)
+	}
+	defer rows.Close()
+
+	versions := []TranscriptVersion{}
+	for rows.Next() {
+		v := TranscriptVersion{JobID: jobID}
+		if err := rows.Scan(&v.Version, &v.Source, &v.Model, &v.WordCount, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read transcript version: %v", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// GetTranscriptVersion returns one version of a transcript with its
+// segments; version 0 is the latest
+func (mdb *MetadataDB) GetTranscriptVersion(jobID string, version int) (*TranscriptVersion, error) {
+	v := TranscriptVersion{JobID: jobID}
+	var segments string
+	err := mdb.db.QueryRow(`
+	SELECT version, source, COALESCE(model, ''), word_count, created_at, segments
+	FROM transcript_versions WHERE job_id = ? AND (version = ? OR ? = 0)
+	ORDER BY version DESC LIMIT 1`, jobID, version, version).
+		Scan(&v.Version, &v.Source, &v.Model, &v.WordCount, &v.CreatedAt, &segments)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript version: %v", err)
+	}
+	if err := json.Unmarshal([]byte(segments), &v.Segments); err != nil {
+		return nil, fmt.Errorf("corrupt transcript version %d of %s: %v", v.Version, jobID, err)
+	}
+	return &v, nil
+}
@@ -0,0 +1,157 @@
+package storage
+
+// Webhook outbox — every notification is written here before it is sent,
+// so deliveries survive restarts, can be retried with backoff, and leave
+// an inspectable log of attempts.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeliveryNotFound is returned when a delivery id does not exist
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"   // not yet attempted
+	DeliveryRetrying  = "retrying"  // failed at least once, another attempt scheduled
+	DeliveryDelivered = "delivered" // endpoint answered 2xx
+	DeliveryDead      = "dead"      // gave up after the maximum number of attempts
+)
+
+// WebhookDelivery is one event queued for one endpoint
+type WebhookDelivery struct {
+	ID             string    `json:"id"`
+	Endpoint       string    `json:"endpoint"`
+	Event          string    `json:"event"`
+	Payload        string    `json:"payload"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      string    `json:"created_at"`
+	UpdatedAt      string    `json:"updated_at"`
+}
+
+const deliveryColumns = `id, endpoint, event, payload, status, attempts, next_attempt_at,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at, updated_at`
+
+// scanDelivery reads a row selected with deliveryColumns
+func scanDelivery(row rowScanner) (*WebhookDelivery, error) {
+	var (
+		d         WebhookDelivery
+		nextEpoch int64
+	)
+	err := row.Scan(&d.ID, &d.Endpoint, &d.Event, &d.Payload, &d.Status, &d.Attempts, &nextEpoch,
+		&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.NextAttemptAt = time.Unix(nextEpoch, 0)
+	return &d, nil
+}
+
+// EnqueueDelivery adds a pending delivery to the outbox, due immediately;
+// jobID is the job the event is about, "" for none
+func (mdb *MetadataDB) EnqueueDelivery(id, endpoint, event, jobID, payload string) error {
+	now := time.Now().UTC()
+	_, err := mdb.db.Exec(`
+	INSERT INTO webhook_deliveries (id, endpoint, event, job_id, payload, status, attempts, next_attempt_at, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, 0, ?, ?, ?)
+	`, id, endpoint, event, jobID, payload, DeliveryPending, now.Unix(), now, now)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook delivery: %v", err)
+	}
+	return nil
+}
+
+// DueDeliveries returns pending or retrying deliveries whose next attempt is due
+func (mdb *MetadataDB) DueDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	rows, err := mdb.db.Query(`SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE status IN (?, ?) AND next_attempt_at <= ?
+		ORDER BY next_attempt_at LIMIT ?`,
+		DeliveryPending, DeliveryRetrying, now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load due deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt. status is the new
+// state; nextAttempt is ignored unless status is DeliveryRetrying.
+func (mdb *MetadataDB) RecordDeliveryAttempt(id, status string, statusCode int, lastError string, nextAttempt time.Time) error {
+	var errText sql.NullString
+	if lastError != "" {
+		errText = sql.NullString{String: lastError, Valid: true}
+	}
+
+	_, err := mdb.db.Exec(`
+	UPDATE webhook_deliveries
+	SET status = ?, attempts = attempts + 1, last_status_code = ?, last_error = ?, next_attempt_at = ?, updated_at = ?
+	WHERE id = ?
+	`, status, statusCode, errText, nextAttempt.Unix(), time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %v", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries, optionally filtered by status
+func (mdb *MetadataDB) ListDeliveries(status string, limit int) ([]*WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := mdb.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ResetDelivery schedules a delivery for immediate redelivery with a fresh
+// attempt budget
+func (mdb *MetadataDB) ResetDelivery(id string) error {
+	now := time.Now().UTC()
+	result, err := mdb.db.Exec(`
+	UPDATE webhook_deliveries
+	SET status = ?, attempts = 0, next_attempt_at = ?, updated_at = ?
+	WHERE id = ?
+	`, DeliveryPending, now.Unix(), now, id)
+	if err != nil {
+		return fmt.Errorf("failed to reset webhook delivery: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
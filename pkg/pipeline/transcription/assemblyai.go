@@ -0,0 +1,261 @@
+package transcription
+
+// AssemblyAI backend — uploads each file to AssemblyAI, submits it for
+// transcription, and polls until the transcript is ready. With speaker
+// labels on, utterances become segments carrying the speaker and its
+// confidence; otherwise sentences do.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+const (
+	assemblyAIURL = "https://api.assemblyai.com"
+
+	// assemblyAIPollInterval is how often a submitted transcript is checked
+	assemblyAIPollInterval = 3 * time.Second
+)
+
+// AssemblyAIOptions configures the AssemblyAI backend
+type AssemblyAIOptions struct {
+	// APIKey is a secret reference (env:, file:, vault:) or a literal,
+	// resolved per request so rotated keys are picked up
+	APIKey string `yaml:"api_key"`
+	// SpeechModel picks AssemblyAI's model, e.g. "best" or "nano" ("" =
+	// the account default)
+	SpeechModel string `yaml:"speech_model"`
+	// SpeakerLabels labels each segment with its speaker
+	SpeakerLabels bool `yaml:"speaker_labels"`
+	// PricePerMinute is the USD rate used to record each job's cloud cost
+	PricePerMinute float64 `yaml:"price_per_minute"`
+	// URL overrides the API base URL, e.g. https://api.eu.assemblyai.com
+	URL            string `yaml:"url"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"` // per job, upload to result (default 30m)
+}
+
+// SetAssemblyAI configures the AssemblyAI backend; call it before
+// SetBackend(BackendAssemblyAI)
+func (wt *WhisperTranscriber) SetAssemblyAI(opts AssemblyAIOptions) {
+	wt.assemblyAI = opts
+}
+
+// assemblyAIClient calls the upload and transcript APIs
+type assemblyAIClient struct {
+	opts    AssemblyAIOptions
+	timeout time.Duration
+	client  *http.Client
+}
+
+func newAssemblyAIClient(opts AssemblyAIOptions) (*assemblyAIClient, error) {
+	if opts.APIKey == "" {
+		return nil, errors.New("assemblyai backend needs whisper.assemblyai.api_key")
+	}
+	if opts.URL == "" {
+		opts.URL = assemblyAIURL
+	}
+	opts.URL = strings.TrimSuffix(opts.URL, "/")
+	timeout := 30 * time.Minute
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	a := &assemblyAIClient{opts: opts, timeout: timeout, client: &http.Client{Timeout: timeout}}
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	log.Printf("Transcribing with AssemblyAI (speech model: %s, speaker labels: %t)",
+		a.modelName(), opts.SpeakerLabels)
+	return a, nil
+}
+
+func (a *assemblyAIClient) modelName() string {
+	if a.opts.SpeechModel == "" {
+		return "default"
+	}
+	return a.opts.SpeechModel
+}
+
+// check reports an API key that can't be resolved
+func (a *assemblyAIClient) check() error {
+	_, err := resolveAPIKey(a.opts.APIKey, "AssemblyAI")
+	return err
+}
+
+func (a *assemblyAIClient) Close() error {
+	return nil
+}
+
+// assemblyAISegment is an utterance or sentence; times are in milliseconds
+type assemblyAISegment struct {
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+	Speaker    string  `json:"speaker"`
+}
+
+// segment converts to our type, keeping the speaker only if labels were requested
+func (s assemblyAISegment) segment(withSpeaker bool) types.Segment {
+	seg := types.Segment{
+		Start:      s.Start / 1000,
+		End:        s.End / 1000,
+		Text:       strings.TrimSpace(s.Text),
+		Confidence: s.Confidence,
+	}
+	if withSpeaker && s.Speaker != "" {
+		seg.Speaker = "speaker_" + s.Speaker
+	}
+	return seg
+}
+
+// assemblyAITranscript is the part of a transcript resource we use
+type assemblyAITranscript struct {
+	ID                 string              `json:"id"`
+	Status             string              `json:"status"`
+	Error              string              `json:"error"`
+	Text               string              `json:"text"`
+	LanguageCode       string              `json:"language_code"`
+	LanguageConfidence float64             `json:"language_confidence"` // set when detected
+	AudioDuration      float64             `json:"audio_duration"`
+	Utterances         []assemblyAISegment `json:"utterances"`
+}
+
+// decode uploads the file, waits for the transcript, and converts it;
+// AssemblyAI has no sampling temperature or prompt, so only opts.Language
+// and opts.Vocabulary are used
+func (a *assemblyAIClient) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	deadline := time.Now().Add(a.timeout)
+
+	audio, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %v", err)
+	}
+	var upload struct {
+		UploadURL string `json:"upload_url"`
+	}
+	err = a.do(http.MethodPost, "/v2/upload", "application/octet-stream", audio, &upload)
+	audio.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]interface{}{
+		"audio_url":      upload.UploadURL,
+		"punctuate":      true,
+		"format_text":    true,
+		"speaker_labels": a.opts.SpeakerLabels,
+	}
+	if a.opts.SpeechModel != "" {
+		request["speech_model"] = a.opts.SpeechModel
+	}
+	if language := opts.language(); language == LanguageAuto {
+		request["language_detection"] = true
+	} else {
+		request["language_code"] = language
+	}
+	if len(opts.Vocabulary) > 0 {
+		request["word_boost"] = opts.Vocabulary
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var transcript assemblyAITranscript
+	if err := a.do(http.MethodPost, "/v2/transcript", "application/json", bytes.NewReader(body), &transcript); err != nil {
+		return nil, err
+	}
+	log.Printf("AssemblyAI transcript %s submitted", transcript.ID)
+
+	// Poll until it is done
+	for transcript.Status != "completed" {
+		switch {
+		case transcript.Status == "error":
+			return nil, fmt.Errorf("assemblyai transcription failed: %s", transcript.Error)
+		case time.Now().After(deadline):
+			return nil, fmt.Errorf("assemblyai transcript %s not ready within %s", transcript.ID, a.timeout)
+		}
+		time.Sleep(assemblyAIPollInterval)
+		if err := a.do(http.MethodGet, "/v2/transcript/"+url.PathEscape(transcript.ID), "", nil, &transcript); err != nil {
+			return nil, err
+		}
+	}
+
+	segments := transcript.Utterances
+	if !a.opts.SpeakerLabels || len(segments) == 0 {
+		var sentences struct {
+			Sentences []assemblyAISegment `json:"sentences"`
+		}
+		if err := a.do(http.MethodGet, "/v2/transcript/"+url.PathEscape(transcript.ID)+"/sentences", "", nil, &sentences); err != nil {
+			return nil, err
+		}
+		segments = sentences.Sentences
+	}
+
+	result := &types.TranscriptionResult{
+		Text:               strings.TrimSpace(transcript.Text),
+		Language:           transcript.LanguageCode,
+		LanguageConfidence: transcript.LanguageConfidence,
+		Duration:           transcript.AudioDuration,
+	}
+	if result.Language == "" {
+		result.Language = opts.language()
+	}
+	for _, s := range segments {
+		seg := s.segment(a.opts.SpeakerLabels)
+		result.Segments = append(result.Segments, seg)
+		if onSegment != nil {
+			onSegment(seg.End)
+		}
+	}
+	result.Cost.CloudCostUSD = result.Duration / 60 * a.opts.PricePerMinute
+
+	for _, format := range formats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(result.Segments), result.Duration)
+	return result, nil
+}
+
+// do sends one API request and decodes the JSON response into out
+func (a *assemblyAIClient) do(method, path, contentType string, body io.Reader, out interface{}) error {
+	key, err := resolveAPIKey(a.opts.APIKey, "AssemblyAI")
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, a.opts.URL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", key)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("assemblyai request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("assemblyai %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse assemblyai response: %v", err)
+	}
+	return nil
+}
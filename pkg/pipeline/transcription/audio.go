@@ -0,0 +1,183 @@
+// Package transcription wraps OpenAI Whisper for GPU-accelerated
+// speech-to-text, with FFmpeg audio normalization and format validation.
+package transcription
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// NormalizeOptions tunes audio normalization for a job
+type NormalizeOptions struct {
+	// AcceptCodecs lists codecs the transcription backend decodes natively
+	// (e.g. "opus" for cloud APIs); such inputs are passed through untouched
+	AcceptCodecs []string
+
+	// StartTime/EndTime (seconds) cut the input to a section; zero means
+	// from the beginning / to the end. A cut always forces re-encoding.
+	StartTime float64
+	EndTime   float64
+
+	// Denoise, when set, passes the audio through a noise reduction filter,
+	// which also forces re-encoding
+	Denoise *DenoiseOptions
+
+	// Channel, when set, keeps only that channel of the input (1 for the
+	// first, 2 for the second) instead of mixing them down; it also forces
+	// re-encoding
+	Channel int
+
+	// Speedup, when above 1, plays the audio that many times faster (see
+	// RestoreSpeed); it also forces re-encoding
+	Speedup float64
+
+	// Info is the input's probe result, if the caller already has it
+	Info *AudioInfo
+
+	// OutputPath is where to write the normalized WAV (default: a unique
+	// file in temp/)
+	OutputPath string
+
+	// VAD, when set, removes long silences from the normalized audio; the
+	// kept speech is recorded in a speech map (see LoadSpeechMap)
+	VAD *VADOptions
+}
+
+// filtered reports whether a cut or a filter was requested, either of
+// which needs the audio re-encoded
+func (o NormalizeOptions) filtered() bool {
+	return o.StartTime > 0 || o.EndTime > 0 || o.Denoise != nil || o.Channel > 0 || o.Speedup > 1
+}
+
+// NormalizeAudio converts any audio file to 16kHz mono WAV format and
+// reports the resources ffmpeg used. Inputs that already conform (or that
+// the backend accepts natively) are returned as-is without re-encoding, in
+// which case the returned path equals inputPath. With opts.VAD, silence
+// removal runs on the normalized audio; inputs passed through natively are
+// normalized first, unless already whisper-ready.
+func NormalizeAudio(inputPath string, opts NormalizeOptions) (string, types.ResourceUsage, error) {
+	// Generate output path
+	outputPath := opts.OutputPath
+	if outputPath == "" {
+		outputPath = filepath.Join("temp", fmt.Sprintf("normalized_%s.wav", uuid.New().String()))
+	}
+	if opts.VAD != nil {
+		// Never mistake an earlier run's speech map for this one's
+		os.Remove(SpeechMapPath(outputPath))
+	}
+
+	info := opts.Info
+	if info == nil {
+		info, _ = ProbeAudio(inputPath)
+	}
+	if info != nil && !opts.filtered() && (info.IsWhisperReady() || opts.VAD == nil && acceptsCodec(opts.AcceptCodecs, info.Codec)) {
+		log.Printf("Skipping normalization for %s (%s, %dHz, %dch)",
+			filepath.Base(inputPath), info.Codec, info.SampleRate, info.Channels)
+		if opts.VAD != nil {
+			return removeSilence(inputPath, outputPath, *opts.VAD)
+		}
+		return inputPath, types.ResourceUsage{}, nil
+	}
+
+	// The ffmpeg filters have no native equivalent
+	if opts.Denoise == nil && opts.Speedup <= 1 {
+		err := normalizeNative(inputPath, outputPath, opts)
+		if err == nil {
+			if opts.VAD != nil {
+				return removeSilence(outputPath, outputPath, *opts.VAD)
+			}
+			return outputPath, types.ResourceUsage{}, nil
+		}
+		if err != errNoNativeDecoder {
+			log.Printf("Warning: native decoding of %s failed, trying ffmpeg: %v", filepath.Base(inputPath), err)
+		}
+	}
+
+	// Seek before -i so ffmpeg skips straight to the section
+	var args []string
+	if opts.StartTime > 0 {
+		args = append(args, "-ss", formatSeconds(opts.StartTime))
+	}
+	args = append(args, "-i", inputPath)
+	if opts.EndTime > 0 {
+		args = append(args, "-t", formatSeconds(opts.EndTime-opts.StartTime))
+	}
+	var filters []string
+	if opts.Channel > 0 {
+		filters = append(filters, channelFilter(opts.Channel))
+	}
+	if opts.Denoise != nil {
+		filters = append(filters, opts.Denoise.filter())
+	}
+	if opts.Speedup > 1 {
+		filters = append(filters, speedupFilter(opts.Speedup))
+	}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+
+	// FFmpeg command: convert to 16kHz mono WAV
+	args = append(args,
+		"-ar", "16000", // 16kHz sample rate
+		"-ac", "1", // Mono
+		"-c:a", "pcm_s16le", // 16-bit PCM
+		"-y", // Overwrite output
+		outputPath,
+	)
+	output, usage, err := RunLimited("ffmpeg", args...)
+	if err != nil {
+		return "", usage, fmt.Errorf("ffmpeg failed: %v\nOutput: %s", err, string(output))
+	}
+
+	if opts.VAD != nil {
+		path, vadUsage, err := removeSilence(outputPath, outputPath, *opts.VAD)
+		usage.Add(vadUsage)
+		return path, usage, err
+	}
+	return outputPath, usage, nil
+}
+
+// formatSeconds renders seconds for ffmpeg time options
+func formatSeconds(seconds float64) string {
+	return strconv.FormatFloat(seconds, 'f', 3, 64)
+}
+
+// acceptsCodec reports whether codec appears in the accepted list
+func acceptsCodec(accepted []string, codec string) bool {
+	for _, c := range accepted {
+		if c == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFFmpeg verifies ffmpeg is installed
+func CheckFFmpeg() error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH")
+	}
+	return nil
+}
+
+// ValidateAudioFormat checks if the file format is supported
+func ValidateAudioFormat(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	supportedFormats := []string{".mp3", ".wav", ".m4a", ".ogg", ".flac", ".webm", ".aac", ".wma"}
+
+	for _, format := range supportedFormats {
+		if ext == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,458 @@
+package transcription
+
+// AWS Transcribe backend — uploads each normalized WAV to an S3 bucket,
+// starts a batch transcription job on it, and polls until the job
+// finishes. The transcript JSON Transcribe writes is fetched from its
+// presigned URL and its audio segments become our segments. The staged
+// object and the Transcribe job are deleted afterwards.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/transcribe"
+	transcribetypes "github.com/aws/aws-sdk-go-v2/service/transcribe/types"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+const (
+	// awsTranscribePollInterval is how often a transcription job is checked
+	awsTranscribePollInterval = 5 * time.Second
+
+	// awsCredentialsLifetime is how long resolved static keys are cached
+	// before the secret references are read again
+	awsCredentialsLifetime = 5 * time.Minute
+)
+
+// awsMediaFormats are the file extensions Transcribe takes as a MediaFormat
+var awsMediaFormats = []string{"amr", "flac", "m4a", "mp3", "mp4", "ogg", "wav", "webm"}
+
+// awsJobNameInvalid matches characters Transcribe job names may not contain
+var awsJobNameInvalid = regexp.MustCompile(`[^0-9A-Za-z._-]+`)
+
+// AWSTranscribeOptions configures the AWS Transcribe backend
+type AWSTranscribeOptions struct {
+	Region string `yaml:"region"` // default: the SDK's (AWS_REGION, ~/.aws/config)
+	// Bucket stages audio for Transcribe to read; objects are deleted once
+	// the transcript is back
+	Bucket string `yaml:"bucket"`
+	Prefix string `yaml:"prefix"` // object key prefix (default "transcription-staging/")
+	// AccessKeyID and SecretAccessKey are secret references (env:, file:,
+	// vault:) or literals; empty uses the SDK's default credential chain
+	// (environment, shared config, instance or task role)
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	Language        string `yaml:"language"` // Transcribe language code, default "en-US"
+	// SpeakerLabels labels each segment with its speaker
+	SpeakerLabels bool `yaml:"speaker_labels"`
+	MaxSpeakers   int  `yaml:"max_speakers"` // 2-30 (default 10)
+	// PricePerMinute is the USD rate used to record each job's cloud cost
+	PricePerMinute float64 `yaml:"price_per_minute"`
+	TimeoutSeconds int     `yaml:"timeout_seconds"` // per job, upload to result (default 30m)
+}
+
+// SetAWSTranscribe configures the AWS Transcribe backend; call it before
+// SetBackend(BackendAWSTranscribe)
+func (wt *WhisperTranscriber) SetAWSTranscribe(opts AWSTranscribeOptions) {
+	wt.awsTranscribe = opts
+}
+
+// awsTranscribeClient stages audio in S3 and runs Transcribe jobs on it
+type awsTranscribeClient struct {
+	opts       AWSTranscribeOptions
+	timeout    time.Duration
+	creds      aws.CredentialsProvider
+	s3         *s3.Client
+	transcribe *transcribe.Client
+	client     *http.Client // fetches transcripts from their presigned URLs
+}
+
+func newAWSTranscribeClient(opts AWSTranscribeOptions) (*awsTranscribeClient, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("aws_transcribe backend needs whisper.aws_transcribe.bucket")
+	}
+	if (opts.AccessKeyID == "") != (opts.SecretAccessKey == "") {
+		return nil, errors.New("aws_transcribe needs both access_key_id and secret_access_key, or neither")
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "transcription-staging/"
+	}
+	if opts.Language == "" {
+		opts.Language = "en-US"
+	}
+	if opts.SpeakerLabels && opts.MaxSpeakers == 0 {
+		opts.MaxSpeakers = 10
+	}
+	if opts.SpeakerLabels && (opts.MaxSpeakers < 2 || opts.MaxSpeakers > 30) {
+		return nil, fmt.Errorf("aws_transcribe max_speakers must be between 2 and 30, got %d", opts.MaxSpeakers)
+	}
+	timeout := 30 * time.Minute
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(opts.Region))
+	}
+	if opts.AccessKeyID != "" {
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(aws.NewCredentialsCache(awsStaticKeys(opts))))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background(), loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("aws_transcribe backend needs whisper.aws_transcribe.region (or AWS_REGION)")
+	}
+
+	a := &awsTranscribeClient{
+		opts:       opts,
+		timeout:    timeout,
+		creds:      cfg.Credentials,
+		s3:         s3.NewFromConfig(cfg),
+		transcribe: transcribe.NewFromConfig(cfg),
+		client:     &http.Client{Timeout: 5 * time.Minute},
+	}
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	log.Printf("Transcribing with AWS Transcribe (region: %s, bucket: %s, speaker labels: %t)",
+		cfg.Region, opts.Bucket, opts.SpeakerLabels)
+	return a, nil
+}
+
+// awsStaticKeys resolves the configured key references whenever the
+// cached credentials expire, so rotated keys are picked up
+func awsStaticKeys(opts AWSTranscribeOptions) aws.CredentialsProviderFunc {
+	return func(ctx context.Context) (aws.Credentials, error) {
+		id, err := secrets.Resolve(opts.AccessKeyID)
+		if err != nil {
+			return aws.Credentials{}, fmt.Errorf("failed to resolve AWS access key ID: %v", err)
+		}
+		secret, err := secrets.Resolve(opts.SecretAccessKey)
+		if err != nil {
+			return aws.Credentials{}, fmt.Errorf("failed to resolve AWS secret access key: %v", err)
+		}
+		if id == "" || secret == "" {
+			return aws.Credentials{}, errors.New("AWS access key is empty")
+		}
+		return aws.Credentials{
+			AccessKeyID:     id,
+			SecretAccessKey: secret,
+			Source:          "aws_transcribe config",
+			CanExpire:       true,
+			Expires:         time.Now().Add(awsCredentialsLifetime),
+		}, nil
+	}
+}
+
+// modelName is fixed: Transcribe picks its model by language
+func (a *awsTranscribeClient) modelName() string {
+	return "standard"
+}
+
+// check reports credentials that can't be resolved
+func (a *awsTranscribeClient) check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := a.creds.Retrieve(ctx); err != nil {
+		return fmt.Errorf("no AWS credentials: %v", err)
+	}
+	return nil
+}
+
+func (a *awsTranscribeClient) Close() error {
+	return nil
+}
+
+// awsTranscript is the part of Transcribe's output JSON we use; times and
+// confidences are decimal strings
+type awsTranscript struct {
+	Results struct {
+		Transcripts []struct {
+			Transcript string `json:"transcript"`
+		} `json:"transcripts"`
+		Items []struct {
+			ID           int    `json:"id"`
+			Type         string `json:"type"` // pronunciation or punctuation
+			StartTime    string `json:"start_time"`
+			EndTime      string `json:"end_time"`
+			SpeakerLabel string `json:"speaker_label"`
+			Alternatives []struct {
+				Content    string `json:"content"`
+				Confidence string `json:"confidence"`
+			} `json:"alternatives"`
+		} `json:"items"`
+		AudioSegments []struct {
+			Transcript   string `json:"transcript"`
+			StartTime    string `json:"start_time"`
+			EndTime      string `json:"end_time"`
+			SpeakerLabel string `json:"speaker_label"`
+			Items        []int  `json:"items"`
+		} `json:"audio_segments"`
+	} `json:"results"`
+}
+
+// decode stages the file, runs a transcription job on it, and converts
+// the transcript; Transcribe has no sampling temperature, so only
+// opts.Language is used
+func (a *awsTranscribeClient) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	base := strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))
+	jobName := fmt.Sprintf("%s-%d", awsJobNameInvalid.ReplaceAllString(base, "_"), time.Now().UnixNano())
+	if len(jobName) > 200 {
+		jobName = jobName[len(jobName)-200:]
+	}
+	key := a.opts.Prefix + jobName + filepath.Ext(audioPath)
+
+	audio, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %v", err)
+	}
+	_, err = a.s3.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(a.opts.Bucket), Key: aws.String(key), Body: audio})
+	audio.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage audio in s3://%s: %v", a.opts.Bucket, err)
+	}
+	defer func() {
+		_, err := a.s3.DeleteObject(context.Background(), &s3.DeleteObjectInput{Bucket: aws.String(a.opts.Bucket), Key: aws.String(key)})
+		if err != nil {
+			log.Printf("Failed to delete staged audio s3://%s/%s: %v", a.opts.Bucket, key, err)
+		}
+	}()
+
+	input := &transcribe.StartTranscriptionJobInput{
+		TranscriptionJobName: aws.String(jobName),
+		Media:                &transcribetypes.Media{MediaFileUri: aws.String(fmt.Sprintf("s3://%s/%s", a.opts.Bucket, key))},
+	}
+	language := localeLanguage(a.opts.Language, opts)
+	if language == LanguageAuto {
+		input.IdentifyLanguage = aws.Bool(true)
+	} else {
+		input.LanguageCode = transcribetypes.LanguageCode(language)
+	}
+	// Audio passed through as submitted may carry any extension; without
+	// a format AWS detects it
+	if format := strings.TrimPrefix(strings.ToLower(filepath.Ext(audioPath)), "."); slices.Contains(awsMediaFormats, format) {
+		input.MediaFormat = transcribetypes.MediaFormat(format)
+	}
+	if a.opts.SpeakerLabels {
+		input.Settings = &transcribetypes.Settings{
+			ShowSpeakerLabels: aws.Bool(true),
+			MaxSpeakerLabels:  aws.Int32(int32(a.opts.MaxSpeakers)),
+		}
+	}
+	if _, err := a.transcribe.StartTranscriptionJob(ctx, input); err != nil {
+		return nil, fmt.Errorf("failed to start aws transcribe job: %v", err)
+	}
+	log.Printf("AWS Transcribe job %s started", jobName)
+	defer func() {
+		_, err := a.transcribe.DeleteTranscriptionJob(context.Background(), &transcribe.DeleteTranscriptionJobInput{TranscriptionJobName: aws.String(jobName)})
+		if err != nil {
+			log.Printf("Failed to delete AWS Transcribe job %s: %v", jobName, err)
+		}
+	}()
+
+	// Poll until it is done
+	var job *transcribetypes.TranscriptionJob
+	for {
+		out, err := a.transcribe.GetTranscriptionJob(ctx, &transcribe.GetTranscriptionJobInput{TranscriptionJobName: aws.String(jobName)})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("aws transcribe job %s not done within %s", jobName, a.timeout)
+			}
+			return nil, fmt.Errorf("failed to check aws transcribe job %s: %v", jobName, err)
+		}
+		job = out.TranscriptionJob
+		if job.TranscriptionJobStatus == transcribetypes.TranscriptionJobStatusFailed {
+			return nil, fmt.Errorf("aws transcribe job failed: %s", aws.ToString(job.FailureReason))
+		}
+		if job.TranscriptionJobStatus == transcribetypes.TranscriptionJobStatusCompleted {
+			break
+		}
+		select {
+		case <-time.After(awsTranscribePollInterval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("aws transcribe job %s not done within %s", jobName, a.timeout)
+		}
+	}
+	if job.Transcript == nil || job.Transcript.TranscriptFileUri == nil {
+		return nil, fmt.Errorf("aws transcribe job %s returned no transcript", jobName)
+	}
+
+	transcript, err := a.fetchTranscript(ctx, *job.Transcript.TranscriptFileUri)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.LanguageCode != "" {
+		language = string(job.LanguageCode) // identified, if it was detected
+	}
+	result := &types.TranscriptionResult{Language: strings.ToLower(language)}
+	if job.IdentifiedLanguageScore != nil {
+		result.LanguageConfidence = float64(*job.IdentifiedLanguageScore)
+	}
+	if len(transcript.Results.Transcripts) > 0 {
+		result.Text = strings.TrimSpace(transcript.Results.Transcripts[0].Transcript)
+	}
+	result.Segments = transcript.segments(a.opts.SpeakerLabels)
+	for _, segment := range result.Segments {
+		if onSegment != nil {
+			onSegment(segment.End)
+		}
+	}
+	if n := len(result.Segments); n > 0 {
+		result.Duration = result.Segments[n-1].End
+	}
+	if info, err := ProbeAudio(audioPath); err == nil && info.Duration > 0 {
+		result.Duration = info.Duration
+	}
+	result.Cost.CloudCostUSD = result.Duration / 60 * a.opts.PricePerMinute
+
+	for _, format := range formats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(result.Segments), result.Duration)
+	return result, nil
+}
+
+// fetchTranscript downloads the transcript JSON from its presigned URL
+func (a *awsTranscribeClient) fetchTranscript(ctx context.Context, uri string) (*awsTranscript, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch aws transcribe transcript: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("aws transcribe transcript download returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	var transcript awsTranscript
+	if err := json.NewDecoder(resp.Body).Decode(&transcript); err != nil {
+		return nil, fmt.Errorf("failed to parse aws transcribe transcript: %v", err)
+	}
+	return &transcript, nil
+}
+
+// segments converts the audio segments, each with the mean confidence of
+// its words; older transcripts without audio segments are split into
+// sentences (and at speaker changes) from the word items
+func (t *awsTranscript) segments(withSpeaker bool) []types.Segment {
+	confidence := make(map[int]float64)
+	for _, item := range t.Results.Items {
+		if item.Type == "pronunciation" && len(item.Alternatives) > 0 {
+			confidence[item.ID], _ = strconv.ParseFloat(item.Alternatives[0].Confidence, 64)
+		}
+	}
+
+	var segments []types.Segment
+	if len(t.Results.AudioSegments) > 0 {
+		for _, s := range t.Results.AudioSegments {
+			segment := types.Segment{
+				Start: parseSeconds(s.StartTime),
+				End:   parseSeconds(s.EndTime),
+				Text:  strings.TrimSpace(s.Transcript),
+			}
+			var sum float64
+			var words int
+			for _, id := range s.Items {
+				if c, ok := confidence[id]; ok {
+					sum += c
+					words++
+				}
+			}
+			if words > 0 {
+				segment.Confidence = sum / float64(words)
+			}
+			if withSpeaker && s.SpeakerLabel != "" {
+				segment.Speaker = awsSpeaker(s.SpeakerLabel)
+			}
+			segments = append(segments, segment)
+		}
+		return segments
+	}
+
+	var (
+		current types.Segment
+		sum     float64
+		words   int
+	)
+	flush := func() {
+		if words == 0 {
+			return
+		}
+		current.Text = strings.TrimSpace(current.Text)
+		current.Confidence = sum / float64(words)
+		segments = append(segments, current)
+		current, sum, words = types.Segment{}, 0, 0
+	}
+	for _, item := range t.Results.Items {
+		if len(item.Alternatives) == 0 {
+			continue
+		}
+		content := item.Alternatives[0].Content
+		if item.Type == "punctuation" {
+			current.Text += content
+			if strings.ContainsAny(content, ".?!") {
+				flush()
+			}
+			continue
+		}
+		speaker := ""
+		if withSpeaker && item.SpeakerLabel != "" {
+			speaker = awsSpeaker(item.SpeakerLabel)
+		}
+		if words > 0 && speaker != current.Speaker {
+			flush()
+		}
+		if words == 0 {
+			current.Start = parseSeconds(item.StartTime)
+			current.Speaker = speaker
+		}
+		current.End = parseSeconds(item.EndTime)
+		current.Text += " " + content
+		c, _ := strconv.ParseFloat(item.Alternatives[0].Confidence, 64)
+		sum += c
+		words++
+	}
+	flush()
+	return segments
+}
+
+// awsSpeaker renames Transcribe's "spk_0" labels to our "speaker_0" form
+func awsSpeaker(label string) string {
+	return "speaker_" + strings.TrimPrefix(label, "spk_")
+}
+
+// parseSeconds reads a decimal seconds string such as "12.34"
+func parseSeconds(value string) float64 {
+	seconds, _ := strconv.ParseFloat(value, 64)
+	return seconds
+}
@@ -0,0 +1,162 @@
+package transcription
+
+// Caption presets — whisper's segments make poor captions as they are:
+// lines run long, cues flash by or linger. A preset bundles a platform's
+// line length, line count, duration, and reading-speed rules, and Apply
+// re-cuts segments into cues that follow them, e.g. the Teletext limits
+// that EBU-STL broadcast subtitles are held to.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// CaptionPreset describes the cues a destination platform accepts
+type CaptionPreset struct {
+	// MaxLineLength is the characters per line; MaxLines the lines per cue
+	MaxLineLength int `yaml:"max_line_length" json:"max_line_length"`
+	MaxLines      int `yaml:"max_lines" json:"max_lines"`
+	// MinDuration and MaxDuration bound how long (seconds) a cue is shown
+	MinDuration float64 `yaml:"min_duration" json:"min_duration"`
+	MaxDuration float64 `yaml:"max_duration" json:"max_duration"`
+	// MaxCPS is the reading speed, in characters per second, that a cue is
+	// kept on screen long enough for when the next cue leaves room
+	MaxCPS float64 `yaml:"max_cps" json:"max_cps"`
+}
+
+var (
+	captionPresetsMu sync.RWMutex
+	captionPresets   = map[string]CaptionPreset{
+		"youtube":   {MaxLineLength: 42, MaxLines: 2, MinDuration: 1, MaxDuration: 7, MaxCPS: 21},
+		"premiere":  {MaxLineLength: 32, MaxLines: 2, MinDuration: 5.0 / 6, MaxDuration: 6, MaxCPS: 17},
+		"broadcast": {MaxLineLength: 37, MaxLines: 2, MinDuration: 1.5, MaxDuration: 7, MaxCPS: 15},
+	}
+)
+
+// RegisterCaptionPreset adds or replaces a named preset
+func RegisterCaptionPreset(name string, preset CaptionPreset) error {
+	switch {
+	case preset.MaxLineLength <= 0 || preset.MaxLines <= 0:
+		return fmt.Errorf("caption preset %q: max_line_length and max_lines are required", name)
+	case preset.MinDuration < 0 || preset.MaxCPS < 0:
+		return fmt.Errorf("caption preset %q: min_duration and max_cps must not be negative", name)
+	case preset.MaxDuration <= preset.MinDuration:
+		return fmt.Errorf("caption preset %q: max_duration must be greater than min_duration", name)
+	}
+	captionPresetsMu.Lock()
+	defer captionPresetsMu.Unlock()
+	captionPresets[name] = preset
+	return nil
+}
+
+// LookupCaptionPreset returns a registered preset by name
+func LookupCaptionPreset(name string) (CaptionPreset, bool) {
+	captionPresetsMu.RLock()
+	defer captionPresetsMu.RUnlock()
+	preset, ok := captionPresets[name]
+	return preset, ok
+}
+
+// CaptionPresetNames lists the registered presets, sorted
+func CaptionPresetNames() []string {
+	captionPresetsMu.RLock()
+	defer captionPresetsMu.RUnlock()
+	names := make([]string, 0, len(captionPresets))
+	for name := range captionPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply re-cuts segments into cues that follow the preset. A segment too
+// long for one cue is split between words, with its time shared out by
+// characters; each cue's lines are wrapped at MaxLineLength. Cues are then
+// stretched toward MinDuration and the reading speed, as far as the next
+// cue allows, and cut at MaxDuration.
+func (p CaptionPreset) Apply(segments []types.Segment) []types.Segment {
+	var cues []types.Segment
+	for _, seg := range segments {
+		cues = append(cues, p.split(seg)...)
+	}
+
+	for i := range cues {
+		cue := &cues[i]
+		want := p.MinDuration
+		if p.MaxCPS > 0 {
+			want = max(want, float64(utf8.RuneCountInString(strings.ReplaceAll(cue.Text, "\n", "")))/p.MaxCPS)
+		}
+		end := max(cue.End, cue.Start+min(want, p.MaxDuration))
+		if i+1 < len(cues) {
+			end = min(end, max(cue.End, cues[i+1].Start))
+		}
+		cue.End = min(end, cue.Start+p.MaxDuration)
+	}
+	return cues
+}
+
+// split breaks a segment into cues that fit the preset's lines and
+// MaxDuration
+func (p CaptionPreset) split(seg types.Segment) []types.Segment {
+	words := strings.Fields(seg.Text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	// Each word's start, sharing the segment's time out by characters
+	total := utf8.RuneCountInString(strings.Join(words, " "))
+	perChar := (seg.End - seg.Start) / float64(max(total, 1))
+	starts := make([]float64, len(words)+1)
+	offset := 0
+	for i, word := range words {
+		starts[i] = seg.Start + float64(offset)*perChar
+		offset += utf8.RuneCountInString(word) + 1
+	}
+	starts[len(words)] = seg.End
+
+	var cues []types.Segment
+	first := 0
+	for first < len(words) {
+		last := first + 1
+		for last < len(words) {
+			lines := wrapWords(words[first:last+1], p.MaxLineLength)
+			if len(lines) > p.MaxLines || starts[last+1]-starts[first] > p.MaxDuration {
+				break
+			}
+			last++
+		}
+		cue := seg
+		cue.Start, cue.End = starts[first], starts[last]
+		cue.Text = strings.Join(wrapWords(words[first:last], p.MaxLineLength), "\n")
+		cues = append(cues, cue)
+		first = last
+	}
+	return cues
+}
+
+// wrapWords fills lines of at most width characters; a word longer than
+// width gets a line of its own
+func wrapWords(words []string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range words {
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,100 @@
+package transcription
+
+// Dual-channel calls — contact-center recordings often put each party on
+// their own channel. Such jobs normalize each channel to its own WAV,
+// transcribe them independently, and merge the two by timestamp with a
+// speaker label per channel, which beats diarizing the mixdown.
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// DefaultChannelLabels name the speakers on a call's first and second
+// channel
+var DefaultChannelLabels = []string{"Caller", "Agent"}
+
+// maxChannelLabelLength bounds a speaker label, in bytes
+const maxChannelLabelLength = 64
+
+// CheckChannelLabels validates the speaker labels of a dual-channel job:
+// one per channel, distinct, and short
+func CheckChannelLabels(labels []string) error {
+	if len(labels) != 2 {
+		return fmt.Errorf("channel_labels needs one label per channel (2), got %d", len(labels))
+	}
+	for _, label := range labels {
+		if strings.TrimSpace(label) == "" || len(label) > maxChannelLabelLength {
+			return fmt.Errorf("channel labels must be 1 to %d bytes", maxChannelLabelLength)
+		}
+	}
+	if labels[0] == labels[1] {
+		return fmt.Errorf("channel labels must differ")
+	}
+	return nil
+}
+
+// channelFilter is the ffmpeg filter that keeps one channel (1-based)
+func channelFilter(channel int) string {
+	return fmt.Sprintf("pan=mono|c0=c%d", channel-1)
+}
+
+// MergeChannels combines the transcripts of a call's channels, in channel
+// order, into one: every segment is labelled with its channel's speaker
+// and the segments are interleaved by start time. Subtitle formats any of
+// them had are re-rendered from the merged segments, and kept raw outputs
+// become a list with one per channel.
+func MergeChannels(results []*types.TranscriptionResult, labels []string) *types.TranscriptionResult {
+	merged := *results[0]
+	merged.Segments = nil
+	merged.Repetitions = nil
+	merged.Resources = types.ResourceUsage{}
+	formats := make(map[string]bool)
+	for i, result := range results {
+		for _, seg := range result.Segments {
+			seg.Speaker = labels[i]
+			merged.Segments = append(merged.Segments, seg)
+		}
+		merged.Repetitions = append(merged.Repetitions, result.Repetitions...)
+		merged.Resources.Add(result.Resources)
+		merged.Duration = max(merged.Duration, result.Duration)
+		for format := range result.Formats {
+			formats[format] = true
+		}
+	}
+	// Stable, so a tie keeps the first channel's segment first
+	slices.SortStableFunc(merged.Segments, func(a, b types.Segment) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+	slices.SortStableFunc(merged.Repetitions, func(a, b types.RepetitionRegion) int {
+		return cmp.Compare(a.Start, b.Start)
+	})
+
+	merged.Text = types.SegmentText(merged.Segments)
+	merged.Formats = nil
+	for _, format := range types.OutputFormats {
+		if formats[format] {
+			if merged.Formats == nil {
+				merged.Formats = make(map[string]string)
+			}
+			merged.Formats[format] = RenderSegments(format, merged.Segments)
+		}
+	}
+
+	merged.RawOutput = nil
+	if results[0].RawOutput != nil {
+		channels := make([]json.RawMessage, len(results))
+		for i, result := range results {
+			channels[i] = result.RawOutput
+		}
+		if raw, err := json.Marshal(channels); err == nil {
+			merged.RawOutput = raw
+		}
+	}
+	return &merged
+}
@@ -0,0 +1,323 @@
+package transcription
+
+// Chunked transcription — multi-hour audio is split into chunks of about
+// chunk_minutes, cut in the silence nearest each boundary, and the chunks
+// are decoded in parallel on the configured devices. Neighbouring chunks
+// overlap a little so no word is lost at a cut; when stitching, each chunk
+// keeps the segments centred on its own side of the cuts. Shorter whisper
+// runs also keep a single long recording from holding a device for hours
+// or outgrowing its memory.
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// ChunkingOptions configures chunked transcription
+type ChunkingOptions struct {
+	// ThresholdMinutes is the audio length above which audio is chunked
+	// (0 = never chunk)
+	ThresholdMinutes float64 `yaml:"threshold_minutes"`
+	// ChunkMinutes is the target chunk length (default 10)
+	ChunkMinutes float64 `yaml:"chunk_minutes"`
+	// OverlapSeconds is how far each chunk reaches into its neighbours
+	// (default 2)
+	OverlapSeconds float64 `yaml:"overlap_seconds"`
+	// SilenceThresholdDB is the level below which audio counts as silence
+	// when looking for cut points (default -35)
+	SilenceThresholdDB float64 `yaml:"silence_threshold_db"`
+}
+
+// Chunking defaults
+const (
+	defaultChunkMinutes       = 10
+	defaultChunkOverlap       = 2
+	defaultSilenceThresholdDB = -35
+
+	// minSilenceSeconds is the shortest pause a chunk may be cut in
+	minSilenceSeconds = 0.3
+)
+
+// SetChunking configures chunked transcription; the python and
+// fasterwhisper backends are the only ones it applies to
+func (wt *WhisperTranscriber) SetChunking(opts ChunkingOptions) error {
+	if opts.ThresholdMinutes < 0 || opts.ChunkMinutes < 0 || opts.OverlapSeconds < 0 {
+		return fmt.Errorf("chunking settings must not be negative")
+	}
+	if opts.ChunkMinutes == 0 {
+		opts.ChunkMinutes = defaultChunkMinutes
+	}
+	if opts.OverlapSeconds == 0 {
+		opts.OverlapSeconds = defaultChunkOverlap
+	}
+	if opts.SilenceThresholdDB == 0 {
+		opts.SilenceThresholdDB = defaultSilenceThresholdDB
+	}
+	if opts.SilenceThresholdDB > 0 {
+		return fmt.Errorf("chunking silence_threshold_db must be below 0")
+	}
+	if opts.OverlapSeconds*4 > opts.ChunkMinutes*60 {
+		return fmt.Errorf("chunking overlap_seconds is too long for %g minute chunks", opts.ChunkMinutes)
+	}
+	wt.chunking = opts
+	return nil
+}
+
+// chunks reports whether audio of the given length is chunked
+func (wt *WhisperTranscriber) chunks(seconds float64) bool {
+	return wt.perDevice() && wt.chunking.ThresholdMinutes > 0 && seconds > wt.chunking.ThresholdMinutes*60
+}
+
+// audioChunk is a section of the audio decoded on its own. Start/End are
+// what is decoded, Keep/KeepEnd the part whose segments it contributes.
+type audioChunk struct {
+	Start, End    float64
+	Keep, KeepEnd float64
+}
+
+// TranscribeLong is TranscribeWithOptions for audio of the given length
+// (seconds), decoding audio over the chunking threshold in chunks. The
+// chunks are spread over the devices starting from opts.Device, and
+// onSegment receives how many seconds of the audio have been decoded.
+func (wt *WhisperTranscriber) TranscribeLong(audioPath string, seconds float64, opts DecodeOptions, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	if !wt.chunks(seconds) {
+		return wt.TranscribeWithOptions(audioPath, opts, onSegment)
+	}
+
+	silences, usage, err := findSilences(audioPath, wt.chunking.SilenceThresholdDB)
+	if err != nil {
+		log.Printf("Warning: silence detection failed, cutting chunks at fixed lengths: %v", err)
+	}
+	chunks := planChunks(seconds, silences, wt.chunking)
+	log.Printf("Transcribing %s in %d chunks of about %g minutes", audioPath, len(chunks), wt.chunking.ChunkMinutes)
+
+	// Decode the first chunk alone so every chunk uses its language
+	progress := newChunkProgress(len(chunks), onSegment)
+	results := make([]*types.TranscriptionResult, len(chunks))
+	if results[0], err = wt.transcribeChunk(audioPath, 0, chunks[0], opts, progress); err != nil {
+		return nil, err
+	}
+	if opts.language() == LanguageAuto && results[0].Language != "" {
+		opts.Language = results[0].Language
+	}
+
+	// The rest in parallel; device slots bound how many actually run
+	first := 0
+	for i, device := range wt.devices {
+		if device == opts.Device {
+			first = i
+		}
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(chunks))
+	for i := 1; i < len(chunks); i++ {
+		run := opts
+		run.Device = wt.devices[(first+i)%len(wt.devices)]
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = wt.transcribeChunk(audioPath, i, chunks[i], run, progress)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := stitchChunks(seconds, chunks, results)
+	result.Resources.Add(usage)
+	result.Task = opts.task()
+	for _, format := range wt.outputFormats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	return result, nil
+}
+
+// transcribeChunk cuts chunk i out of the audio and decodes it, returning
+// its segments on the audio's timeline
+func (wt *WhisperTranscriber) transcribeChunk(audioPath string, i int, chunk audioChunk, opts DecodeOptions, progress *chunkProgress) (*types.TranscriptionResult, error) {
+	base := strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))
+	cutPath, usage, err := NormalizeAudio(audioPath, NormalizeOptions{
+		StartTime:  chunk.Start,
+		EndTime:    chunk.End,
+		OutputPath: filepath.Join("temp", fmt.Sprintf("%s_chunk%d.wav", base, i)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to cut chunk %d: %v", i+1, err)
+	}
+	defer os.Remove(cutPath)
+
+	result, err := wt.transcribe(cutPath, opts, nil, progress.reporter(i))
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d (%.0fs-%.0fs): %v", i+1, chunk.Start, chunk.End, err)
+	}
+	result.Resources.Add(usage)
+	for j := range result.Segments {
+		result.Segments[j].Start += chunk.Start
+		result.Segments[j].End += chunk.Start
+	}
+	progress.done(i, chunk.End-chunk.Start)
+	return result, nil
+}
+
+// silenceLinePattern matches silencedetect's "silence_start: 12.3" and
+// "silence_end: 13.1 | silence_duration: 0.8" lines
+var silenceLinePattern = regexp.MustCompile(`silence_(start|end): (-?[0-9.]+)`)
+
+// silence is a pause in the audio, in seconds
+type silence struct {
+	Start, End float64
+}
+
+// findSilences lists the pauses in the audio, natively for a WAV and with
+// ffmpeg's silencedetect otherwise
+func findSilences(audioPath string, thresholdDB float64) ([]silence, types.ResourceUsage, error) {
+	if silences, err := silencesNative(audioPath, thresholdDB, minSilenceSeconds); err == nil {
+		return silences, types.ResourceUsage{}, nil
+	}
+	filter := fmt.Sprintf("silencedetect=noise=%gdB:d=%g", thresholdDB, minSilenceSeconds)
+	output, usage, err := RunLimited("ffmpeg", "-hide_banner", "-nostats", "-i", audioPath, "-af", filter, "-f", "null", "-")
+	if err != nil {
+		return nil, usage, fmt.Errorf("ffmpeg silencedetect failed: %v", err)
+	}
+
+	var silences []silence
+	for _, match := range silenceLinePattern.FindAllStringSubmatch(string(output), -1) {
+		t, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		if match[1] == "start" {
+			silences = append(silences, silence{Start: max(t, 0), End: -1})
+		} else if n := len(silences); n > 0 && silences[n-1].End < 0 {
+			silences[n-1].End = t
+		}
+	}
+	// Older ffmpeg gives a silence running to the end no end line
+	if n := len(silences); n > 0 && silences[n-1].End < 0 {
+		silences = silences[:n-1]
+	}
+	return silences, usage, nil
+}
+
+// planChunks splits audio of the given length into chunks of about the
+// configured length. Each cut is made in the middle of the silence nearest
+// its target within a fifth of a chunk, or at the target when there is none.
+func planChunks(seconds float64, silences []silence, opts ChunkingOptions) []audioChunk {
+	length := opts.ChunkMinutes * 60
+	window := length / 5
+
+	var cuts []float64
+	for pos := 0.0; seconds-pos > length+window; {
+		target := pos + length
+		cut, best := target, window
+		for _, s := range silences {
+			if mid := (s.Start + s.End) / 2; math.Abs(mid-target) <= best {
+				cut, best = mid, math.Abs(mid-target)
+			}
+		}
+		cuts = append(cuts, cut)
+		pos = cut
+	}
+
+	chunks := make([]audioChunk, len(cuts)+1)
+	keep := 0.0
+	for i := range chunks {
+		keepEnd := seconds
+		if i < len(cuts) {
+			keepEnd = cuts[i]
+		}
+		chunks[i] = audioChunk{
+			Start:   max(keep-opts.OverlapSeconds, 0),
+			End:     min(keepEnd+opts.OverlapSeconds, seconds),
+			Keep:    keep,
+			KeepEnd: keepEnd,
+		}
+		keep = keepEnd
+	}
+	return chunks
+}
+
+// stitchChunks joins the chunks' results for audio of the given length
+// (seconds): each contributes the segments centred in its own part of the
+// audio, the rest decode the overlap its neighbours cover
+func stitchChunks(seconds float64, chunks []audioChunk, results []*types.TranscriptionResult) *types.TranscriptionResult {
+	// The whole audio, not up to the last segment: a silent or music-only
+	// tail is still audio that was transcribed
+	stitched := &types.TranscriptionResult{
+		Language:           results[0].Language,
+		LanguageConfidence: results[0].LanguageConfidence,
+		Duration:           seconds,
+	}
+	last := len(chunks) - 1
+	for i, result := range results {
+		for _, seg := range result.Segments {
+			mid := (seg.Start + seg.End) / 2
+			if mid < chunks[i].Keep || mid >= chunks[i].KeepEnd && i != last {
+				continue
+			}
+			// A segment straddling the cut is kept by the chunk before
+			if n := len(stitched.Segments); n > 0 && mid < stitched.Segments[n-1].End {
+				continue
+			}
+			stitched.Segments = append(stitched.Segments, seg)
+		}
+		stitched.Resources.Add(result.Resources)
+	}
+
+	texts := make([]string, 0, len(stitched.Segments))
+	for _, seg := range stitched.Segments {
+		texts = append(texts, seg.Text)
+	}
+	stitched.Text = strings.Join(texts, " ")
+	return stitched
+}
+
+// chunkProgress adds up how far each chunk has been decoded, reporting the
+// total as seconds of audio decoded
+type chunkProgress struct {
+	mu      sync.Mutex
+	decoded []float64
+	report  func(float64)
+}
+
+func newChunkProgress(n int, report func(float64)) *chunkProgress {
+	return &chunkProgress{decoded: make([]float64, n), report: report}
+}
+
+// reporter is chunk i's onSegment callback; nil when nobody is listening
+func (p *chunkProgress) reporter(i int) func(float64) {
+	if p.report == nil {
+		return nil
+	}
+	return func(end float64) { p.done(i, end) }
+}
+
+// done records that chunk i is decoded up to end (seconds into the chunk)
+func (p *chunkProgress) done(i int, end float64) {
+	if p.report == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.decoded[i] = max(p.decoded[i], end)
+	var total float64
+	for _, d := range p.decoded {
+		total += d
+	}
+	p.report(total)
+}
@@ -0,0 +1,36 @@
+package transcription
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+func TestStitchChunksKeepsSilentTail(t *testing.T) {
+	// 25 minutes of audio whose last 4 minutes are silence
+	const seconds = 25 * 60
+	opts := ChunkingOptions{ChunkMinutes: 10, OverlapSeconds: 2}
+	chunks := planChunks(seconds, nil, opts)
+	if len(chunks) < 2 {
+		t.Fatalf("planChunks made %d chunk(s), want several", len(chunks))
+	}
+
+	results := make([]*types.TranscriptionResult, len(chunks))
+	for i, chunk := range chunks {
+		results[i] = &types.TranscriptionResult{Language: "en"}
+		// Speech every 5 seconds, seen alike by overlapping chunks
+		for start := math.Ceil(chunk.Start/5) * 5; start+5 <= min(chunk.End, 21*60); start += 5 {
+			results[i].Segments = append(results[i].Segments, types.Segment{Start: start, End: start + 5, Text: "words"})
+		}
+	}
+
+	stitched := stitchChunks(seconds, chunks, results)
+	if stitched.Duration != seconds {
+		t.Errorf("Duration = %g, want the audio length %d", stitched.Duration, seconds)
+	}
+	last := stitched.Segments[len(stitched.Segments)-1]
+	if last.End != 21*60 {
+		t.Errorf("last segment ends at %g, want %d", last.End, 21*60)
+	}
+}
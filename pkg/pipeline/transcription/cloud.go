@@ -0,0 +1,62 @@
+package transcription
+
+// Cloud backends — helpers shared by the backends that send audio to a
+// hosted API (Deepgram, AssemblyAI, Google Speech-to-Text, AWS Transcribe)
+// instead of decoding it here.
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+)
+
+// isCloudBackend reports whether backend is a hosted API. These have no
+// sampling temperature or prompt, so only DecodeOptions.Language (and,
+// for some, Vocabulary) applies to them.
+func isCloudBackend(backend string) bool {
+	switch backend {
+	case BackendDeepgram, BackendAssemblyAI, BackendGoogleSTT, BackendAWSTranscribe:
+		return true
+	}
+	return false
+}
+
+// cloudCodecs are the codecs every hosted API decodes itself, so audio in
+// them is uploaded as submitted instead of as a far larger WAV
+var cloudCodecs = []string{"opus", "vorbis", "mp3", "flac", "aac"}
+
+// NativeCodecs lists the codecs the backend takes as they are, for
+// NormalizeOptions.AcceptCodecs; nil when it needs 16kHz mono WAV
+func (wt *WhisperTranscriber) NativeCodecs() []string {
+	if isCloudBackend(wt.backend) {
+		return cloudCodecs
+	}
+	return nil
+}
+
+// localeLanguage picks the language code for a backend configured with a
+// locale such as "en-US": the configured locale when the run doesn't
+// choose a language or chooses that locale's language, otherwise the
+// run's choice (LanguageAuto included)
+func localeLanguage(configured string, opts DecodeOptions) string {
+	language := strings.ToLower(opts.Language)
+	locale := strings.ToLower(configured)
+	if language == "" || language == locale || strings.HasPrefix(locale, language+"-") {
+		return configured
+	}
+	return opts.Language
+}
+
+// resolveAPIKey resolves a secret reference to a service's API key, per
+// request so rotated keys are picked up
+func resolveAPIKey(ref, service string) (string, error) {
+	key, err := secrets.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s API key: %v", service, err)
+	}
+	if key == "" {
+		return "", fmt.Errorf("%s API key is empty", service)
+	}
+	return key, nil
+}
@@ -0,0 +1,77 @@
+package transcription
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloudBackendReceivesOpusUntouched(t *testing.T) {
+	audio := []byte("OggS\x00\x02opus test payload")
+	input := filepath.Join(t.TempDir(), "voice.ogg")
+	if err := os.WriteFile(input, audio, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"metadata":{"duration":1.5},"results":{"channels":[{"alternatives":[{"transcript":"hello"}]}]}}`)
+	}))
+	defer srv.Close()
+
+	wt, err := NewWhisperTranscriber("", 1, "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.SetDeepgram(DeepgramOptions{APIKey: "test", URL: srv.URL})
+	if err := wt.SetBackend(BackendDeepgram); err != nil {
+		t.Fatal(err)
+	}
+
+	normalized, _, err := NormalizeAudio(input, NormalizeOptions{
+		AcceptCodecs: wt.NativeCodecs(),
+		Info:         &AudioInfo{FormatName: "ogg", Codec: "opus", SampleRate: 48000, Channels: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if normalized != input {
+		t.Fatalf("NormalizeAudio re-encoded opus for a cloud backend: got %s", normalized)
+	}
+
+	result, err := wt.TranscribeWithOptions(normalized, DecodeOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(received, audio) {
+		t.Errorf("backend received %d bytes, want the original %d", len(received), len(audio))
+	}
+	if result.Text != "hello" {
+		t.Errorf("Text = %q, want %q", result.Text, "hello")
+	}
+}
+
+func TestWarmupLeavesCloudBackendsAlone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("warmup called the API: %s %s", r.Method, r.URL)
+	}))
+	defer srv.Close()
+
+	wt, err := NewWhisperTranscriber("", 1, "cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.SetDeepgram(DeepgramOptions{APIKey: "test", URL: srv.URL})
+	if err := wt.SetBackend(BackendDeepgram); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.Warmup(); err != nil {
+		t.Fatalf("Warmup: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package transcription
+
+// Native MP3 decoding with go-mp3, which always decodes to 16-bit stereo.
+// The channel count recorded for the source is read from the first frame
+// header instead, so a mono MP3 is reported as mono.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// mp3Decoder reads MPEG-1/2 Layer III files
+type mp3Decoder struct{}
+
+func (mp3Decoder) Name() string { return "mp3" }
+
+func (mp3Decoder) Accepts(header []byte) bool {
+	return SniffAudioFormat(header) == "mp3"
+}
+
+func (mp3Decoder) Open(r io.ReadSeeker) (AudioStream, error) {
+	channels, err := mp3Channels(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	d, err := mp3.NewDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	// The decoder scans a seekable source's frames for its length
+	frames := max(d.Length(), 0) / 4
+	return &mp3Stream{
+		info: AudioInfo{
+			FormatName: "mp3",
+			Codec:      "mp3",
+			SampleRate: d.SampleRate(),
+			Channels:   channels,
+			Duration:   float64(frames) / float64(d.SampleRate()),
+		},
+		d: d,
+	}, nil
+}
+
+// mp3Channels reads the channel mode of the first MPEG audio frame, after
+// any ID3v2 tag
+func mp3Channels(r io.ReadSeeker) (int, error) {
+	var tag [10]byte
+	if _, err := io.ReadFull(r, tag[:]); err != nil {
+		return 0, fmt.Errorf("truncated header")
+	}
+	offset := int64(0)
+	if string(tag[0:3]) == "ID3" {
+		// A syncsafe size, plus the header and any footer
+		size := int64(tag[6])<<21 | int64(tag[7])<<14 | int64(tag[8])<<7 | int64(tag[9])
+		offset = size + 10
+		if tag[5]&0x10 != 0 {
+			offset += 10
+		}
+	}
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 64*1024)
+	n, _ := io.ReadFull(r, buf)
+	for i := 0; i+4 <= n; i++ {
+		h := binary.BigEndian.Uint32(buf[i:])
+		version, layer := (h>>19)&3, (h>>17)&3
+		bitrate, rate := (h>>12)&0xF, (h>>10)&3
+		if h>>21 != 0x7FF || version == 1 || layer != 1 || bitrate == 0xF || rate == 3 {
+			continue
+		}
+		if h>>6&3 == 3 {
+			return 1, nil
+		}
+		return 2, nil
+	}
+	return 0, fmt.Errorf("no Layer III frame found")
+}
+
+// mp3Stream reads an MP3's 16-bit stereo samples; a mono source has the
+// same samples on both channels
+type mp3Stream struct {
+	info AudioInfo
+	d    *mp3.Decoder
+	raw  []byte
+}
+
+func (s *mp3Stream) Info() *AudioInfo {
+	return &s.info
+}
+
+func (s *mp3Stream) Read(p []float32) (int, error) {
+	// Whole frames of the source's channels
+	frames := len(p) / s.info.Channels
+	if need := frames * 4; cap(s.raw) < need {
+		s.raw = make([]byte, need)
+	}
+	raw := s.raw[:frames*4]
+	n, err := io.ReadFull(s.d, raw)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	frames = n / 4
+	for i := range frames {
+		left := float32(int16(binary.LittleEndian.Uint16(raw[4*i:]))) / 32768
+		if s.info.Channels == 1 {
+			p[i] = left
+			continue
+		}
+		p[2*i] = left
+		p[2*i+1] = float32(int16(binary.LittleEndian.Uint16(raw[4*i+2:]))) / 32768
+	}
+	if frames == 0 && err == nil {
+		err = io.EOF
+	}
+	return frames * s.info.Channels, err
+}
@@ -0,0 +1,53 @@
+package transcription
+
+// Native Ogg Vorbis decoding with oggvorbis. Opus and other codecs in an
+// Ogg container are left to ffmpeg.
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// vorbisDecoder reads Ogg Vorbis files
+type vorbisDecoder struct{}
+
+func (vorbisDecoder) Name() string { return "vorbis" }
+
+func (vorbisDecoder) Accepts(header []byte) bool {
+	// The first page holds the Vorbis identification header
+	return SniffAudioFormat(header) == "ogg" && bytes.Contains(header[:min(len(header), 64)], []byte("\x01vorbis"))
+}
+
+func (vorbisDecoder) Open(r io.ReadSeeker) (AudioStream, error) {
+	reader, err := oggvorbis.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	info := AudioInfo{
+		FormatName: "ogg",
+		Codec:      "vorbis",
+		SampleRate: reader.SampleRate(),
+		Channels:   reader.Channels(),
+		BitRate:    int64(reader.Bitrate().Nominal),
+	}
+	if length := reader.Length(); length > 0 {
+		info.Duration = float64(length) / float64(reader.SampleRate())
+	}
+	return &vorbisStream{info: info, reader: reader}, nil
+}
+
+// vorbisStream reads an Ogg Vorbis file's samples
+type vorbisStream struct {
+	info   AudioInfo
+	reader *oggvorbis.Reader
+}
+
+func (s *vorbisStream) Info() *AudioInfo {
+	return &s.info
+}
+
+func (s *vorbisStream) Read(p []float32) (int, error) {
+	return s.reader.Read(p)
+}
@@ -0,0 +1,180 @@
+package transcription
+
+// Native WAV decoding — integer PCM (8 to 32 bits) and IEEE float, plain
+// or WAVE_FORMAT_EXTENSIBLE. Compressed WAV codecs (ADPCM, mu-law, GSM)
+// are left to ffmpeg.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WAV format tags
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavDecoder reads RIFF WAVE files
+type wavDecoder struct{}
+
+func (wavDecoder) Name() string { return "wav" }
+
+func (wavDecoder) Accepts(header []byte) bool {
+	return SniffAudioFormat(header) == "wav"
+}
+
+func (wavDecoder) Open(r io.ReadSeeker) (AudioStream, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("truncated header: %v", err)
+	}
+
+	var (
+		format, channels, bits uint16
+		rate                   uint32
+		haveFormat             bool
+	)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, fmt.Errorf("no data chunk")
+		}
+		id, size := string(chunk[0:4]), binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return nil, fmt.Errorf("bad fmt chunk")
+			}
+			body := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("truncated fmt chunk")
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			channels = binary.LittleEndian.Uint16(body[2:4])
+			rate = binary.LittleEndian.Uint32(body[4:8])
+			bits = binary.LittleEndian.Uint16(body[14:16])
+			if format == wavFormatExtensible && size >= 26 {
+				format = binary.LittleEndian.Uint16(body[24:26]) // the sub-format GUID starts with the tag
+			}
+			haveFormat = true
+			continue
+		case "data":
+			if !haveFormat {
+				return nil, fmt.Errorf("data chunk before fmt chunk")
+			}
+			codec, err := wavCodec(format, bits)
+			if err != nil {
+				return nil, err
+			}
+			if channels == 0 || rate == 0 {
+				return nil, fmt.Errorf("bad fmt chunk")
+			}
+
+			// Streamed WAVs leave the size unset; read to the end instead
+			start, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := r.Seek(start, io.SeekStart); err != nil {
+				return nil, err
+			}
+			dataSize := int64(size)
+			if dataSize == 0 || dataSize == math.MaxUint32 || dataSize > end-start {
+				dataSize = end - start
+			}
+
+			frameSize := int(bits/8) * int(channels)
+			return &wavStream{
+				info: AudioInfo{
+					FormatName: "wav",
+					Codec:      codec,
+					SampleRate: int(rate),
+					Channels:   int(channels),
+					Duration:   float64(dataSize/int64(frameSize)) / float64(rate),
+					BitRate:    int64(rate) * int64(channels) * int64(bits),
+				},
+				r:       bufio.NewReaderSize(io.LimitReader(r, dataSize/int64(frameSize)*int64(frameSize)), 64*1024),
+				format:  format,
+				bytesPS: int(bits / 8),
+			}, nil
+		}
+
+		// Chunks are word-aligned
+		if _, err := r.Seek(int64(size)+int64(size%2), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wavCodec names a WAV sample format the way ffprobe does; an error for
+// formats that aren't decoded natively
+func wavCodec(format, bits uint16) (string, error) {
+	switch {
+	case format == wavFormatPCM && bits == 8:
+		return "pcm_u8", nil
+	case format == wavFormatPCM && (bits == 16 || bits == 24 || bits == 32):
+		return fmt.Sprintf("pcm_s%dle", bits), nil
+	case format == wavFormatFloat && (bits == 32 || bits == 64):
+		return fmt.Sprintf("pcm_f%dle", bits), nil
+	}
+	return "", fmt.Errorf("unsupported WAV format %#x with %d bits", format, bits)
+}
+
+// wavStream reads the samples of a WAV data chunk
+type wavStream struct {
+	info    AudioInfo
+	r       *bufio.Reader
+	format  uint16
+	bytesPS int // bytes per sample
+	raw     []byte
+}
+
+func (s *wavStream) Info() *AudioInfo {
+	return &s.info
+}
+
+func (s *wavStream) Read(p []float32) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if need := len(p) * s.bytesPS; cap(s.raw) < need {
+		s.raw = make([]byte, need)
+	}
+	raw := s.raw[:len(p)*s.bytesPS]
+	n, err := io.ReadFull(s.r, raw)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	samples := n / s.bytesPS
+	for i := range samples {
+		b := raw[i*s.bytesPS:]
+		switch {
+		case s.format == wavFormatFloat && s.bytesPS == 4:
+			p[i] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case s.format == wavFormatFloat:
+			p[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		case s.bytesPS == 1:
+			p[i] = float32(int(b[0])-128) / 128
+		case s.bytesPS == 2:
+			p[i] = float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+		case s.bytesPS == 3:
+			p[i] = float32(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)) / (1 << 31)
+		default:
+			p[i] = float32(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		}
+	}
+	if samples == 0 && err == nil {
+		err = io.EOF
+	}
+	return samples, err
+}
@@ -0,0 +1,402 @@
+package transcription
+
+// Native audio decoders — WAV, MP3, and Ogg Vorbis are decoded in-process
+// in Go, so probing and normalizing the common formats needs neither
+// ffprobe nor ffmpeg, and a small deployment transcribing phone WAVs can
+// run without them. Other formats, and jobs that need an ffmpeg filter
+// (denoise, speedup), still go through ffmpeg. Programs embedding the
+// server can add decoders for more formats with RegisterDecoder.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AudioDecoder decodes one family of audio formats natively
+type AudioDecoder interface {
+	// Name identifies the decoder in logs
+	Name() string
+	// Accepts reports whether the decoder reads files starting with
+	// header (up to SniffHeaderSize bytes)
+	Accepts(header []byte) bool
+	// Open starts decoding r. An error, such as a codec the decoder
+	// doesn't support, sends the file to ffmpeg instead.
+	Open(r io.ReadSeeker) (AudioStream, error)
+}
+
+// AudioStream is audio being decoded
+type AudioStream interface {
+	// Info describes the stream the way ProbeAudio does
+	Info() *AudioInfo
+	// Read decodes the next samples into p, interleaved by channel and
+	// scaled to [-1, 1]; it returns io.EOF at the end of the audio
+	Read(p []float32) (int, error)
+}
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = []AudioDecoder{wavDecoder{}, mp3Decoder{}, vorbisDecoder{}}
+
+	// ffmpegOnly turns native decoding off (see SetFFmpegOnly)
+	ffmpegOnly bool
+)
+
+// RegisterDecoder adds a native decoder. Decoders are tried in
+// registration order, after the built-in ones.
+func RegisterDecoder(d AudioDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders = append(decoders, d)
+}
+
+// SetFFmpegOnly sends every input through ffprobe and ffmpeg, as before
+// native decoding, when on
+func SetFFmpegOnly(on bool) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	ffmpegOnly = on
+}
+
+// errNoNativeDecoder is returned for files no native decoder reads
+var errNoNativeDecoder = errors.New("no native decoder for this format")
+
+// nativeFile is a file opened by a native decoder
+type nativeFile struct {
+	AudioStream
+	decoder string
+	file    *os.File
+}
+
+func (f *nativeFile) Close() error {
+	return f.file.Close()
+}
+
+// openNative opens path with the first native decoder that reads it
+func openNative(path string) (*nativeFile, error) {
+	decodersMu.RLock()
+	off, candidates := ffmpegOnly, decoders
+	decodersMu.RUnlock()
+	if off {
+		return nil, errNoNativeDecoder
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, SniffHeaderSize)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+	header = header[:n]
+
+	for _, d := range candidates {
+		if !d.Accepts(header) {
+			continue
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		stream, err := d.Open(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s decoder: %v", d.Name(), err)
+		}
+		info := stream.Info()
+		if info.BitRate == 0 && info.Duration > 0 {
+			if stat, err := f.Stat(); err == nil {
+				info.BitRate = int64(float64(stat.Size()*8) / info.Duration)
+			}
+		}
+		return &nativeFile{AudioStream: stream, decoder: d.Name(), file: f}, nil
+	}
+	f.Close()
+	return nil, errNoNativeDecoder
+}
+
+// probeNative describes a file a native decoder reads
+func probeNative(path string) (*AudioInfo, error) {
+	f, err := openNative(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Info(), nil
+}
+
+// normalizeNative decodes inputPath natively to a 16kHz mono 16-bit WAV at
+// outputPath, cut to opts.StartTime/EndTime and keeping only opts.Channel
+// if set. It fails with errNoNativeDecoder, leaving ffmpeg to do it, when
+// no native decoder reads the file.
+func normalizeNative(inputPath, outputPath string, opts NormalizeOptions) error {
+	in, err := openNative(inputPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info := in.Info()
+	channels, rate := info.Channels, info.SampleRate
+	if channels < 1 || rate < 1 {
+		return fmt.Errorf("%s decoder reported %d channels at %dHz", in.decoder, channels, rate)
+	}
+	if opts.Channel > channels {
+		return fmt.Errorf("audio has %d channels, no channel %d", channels, opts.Channel)
+	}
+	first := int64(math.Round(opts.StartTime * float64(rate)))
+	last := int64(-1)
+	if opts.EndTime > 0 {
+		last = int64(math.Round(opts.EndTime * float64(rate)))
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Base(outputPath), err)
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+	if err := writeWAVHeader(w, 0); err != nil {
+		return err
+	}
+
+	r := newResampler(rate, sampleRate)
+	buf := make([]float32, 4096*channels)
+	mono := make([]float32, 0, 4096)
+	var (
+		pending int   // samples of a partial frame left at the start of buf
+		frame   int64 // index of the next input frame
+		written int64 // bytes of PCM written
+		pcm     []byte
+	)
+	for last < 0 || frame < last {
+		n, readErr := in.Read(buf[pending:])
+		n += pending
+		frames := n / channels
+		mono = mono[:0]
+		for i := 0; i < frames; i, frame = i+1, frame+1 {
+			if frame < first || last >= 0 && frame >= last {
+				continue
+			}
+			samples := buf[i*channels : (i+1)*channels]
+			if opts.Channel > 0 {
+				mono = append(mono, samples[opts.Channel-1])
+				continue
+			}
+			var sum float32
+			for _, s := range samples {
+				sum += s
+			}
+			mono = append(mono, sum/float32(channels))
+		}
+		pending = copy(buf, buf[frames*channels:n])
+
+		pcm = r.push(mono, pcm[:0])
+		if _, err := w.Write(pcm); err != nil {
+			return fmt.Errorf("failed to write %s: %v", filepath.Base(outputPath), err)
+		}
+		written += int64(len(pcm))
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("%s decoder: %v", in.decoder, readErr)
+		}
+	}
+	pcm = r.flush(pcm[:0])
+	if _, err := w.Write(pcm); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filepath.Base(outputPath), err)
+	}
+	written += int64(len(pcm))
+	if written > math.MaxUint32-36 {
+		return fmt.Errorf("normalized audio is too long for a WAV file")
+	}
+
+	// Now that the length is known, fill it in
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filepath.Base(outputPath), err)
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := writeWAVHeader(out, uint32(written)); err != nil {
+		return err
+	}
+	log.Printf("Normalized %s natively with the %s decoder (%s, %dHz, %dch)",
+		filepath.Base(inputPath), in.decoder, info.Codec, rate, channels)
+	return out.Close()
+}
+
+// resampler converts a stream of mono samples from one rate to another as
+// 16-bit PCM. Downsampling averages the input samples within one output
+// sample's span around it, a box filter: it damps what lies above the new
+// Nyquist frequency but does not remove it, so some aliasing is left.
+// Upsampling interpolates linearly.
+type resampler struct {
+	step float64   // input samples per output sample
+	half float64   // half the averaging window, in input samples
+	pos  float64   // position of the next output sample in buf
+	buf  []float32 // input samples not yet consumed
+}
+
+func newResampler(from, to int) *resampler {
+	step := float64(from) / float64(to)
+	return &resampler{step: step, half: step / 2}
+}
+
+// push adds input samples and appends the output samples they complete
+func (r *resampler) push(in []float32, out []byte) []byte {
+	r.buf = append(r.buf, in...)
+	for r.ready() {
+		out = appendPCM(out, r.sample())
+		r.pos += r.step
+	}
+	// Keep what later output samples still need
+	if drop := int(r.pos-r.half) - 1; drop > 0 {
+		drop = min(drop, len(r.buf))
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.pos -= float64(drop)
+	}
+	return out
+}
+
+// flush appends the output samples left at the end of the input
+func (r *resampler) flush(out []byte) []byte {
+	// Allow for rounding in pos, which would add a sample at the very end
+	for r.pos < float64(len(r.buf))-1e-6 {
+		out = appendPCM(out, r.sample())
+		r.pos += r.step
+	}
+	r.buf, r.pos = r.buf[:0], 0
+	return out
+}
+
+// ready reports whether buf holds all the input the next output needs
+func (r *resampler) ready() bool {
+	if r.step <= 1 {
+		return int(r.pos)+1 < len(r.buf)
+	}
+	return int(r.pos+r.half)+1 < len(r.buf)
+}
+
+// sample computes the output sample at pos
+func (r *resampler) sample() float32 {
+	n := len(r.buf)
+	if r.step <= 1 {
+		i := int(r.pos)
+		if i+1 >= n {
+			return r.buf[n-1]
+		}
+		frac := float32(r.pos - float64(i))
+		return r.buf[i]*(1-frac) + r.buf[i+1]*frac
+	}
+	lo := max(int(math.Ceil(r.pos-r.half)), 0)
+	hi := min(int(r.pos+r.half), n-1)
+	if hi < lo {
+		return r.buf[min(lo, n-1)]
+	}
+	var sum float32
+	for _, s := range r.buf[lo : hi+1] {
+		sum += s
+	}
+	return sum / float32(hi-lo+1)
+}
+
+// appendPCM appends a sample as 16-bit little-endian PCM
+func appendPCM(out []byte, s float32) []byte {
+	v := int16(math.Round(float64(max(min(s, 1), -1)) * math.MaxInt16))
+	return append(out, byte(v), byte(v>>8))
+}
+
+// silencesNative lists the pauses in a WAV the way ffmpeg's silencedetect
+// does: stretches of at least minSilence seconds in which every sample is
+// quieter than thresholdDB. A silence running to the end ends there, as
+// silencedetect reports it when the stream ends.
+func silencesNative(audioPath string, thresholdDB, minSilence float64) ([]silence, error) {
+	f, err := os.Open(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	header := make([]byte, SniffHeaderSize)
+	n, _ := io.ReadFull(f, header)
+	if !(wavDecoder{}).Accepts(header[:n]) {
+		return nil, errNoNativeDecoder
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	stream, err := wavDecoder{}.Open(f)
+	if err != nil {
+		return nil, err
+	}
+
+	info := stream.Info()
+	rate, channels := float64(info.SampleRate), info.Channels
+	noise := float32(math.Pow(10, thresholdDB/20))
+	var (
+		silences   []silence
+		quietSince int64 = -1
+		frame      int64 // index of the next frame
+	)
+	// end closes the silence running before frame, if it is long enough
+	end := func() {
+		if float64(frame-quietSince)/rate >= minSilence {
+			silences = append(silences, silence{Start: float64(quietSince) / rate, End: float64(frame) / rate})
+		}
+		quietSince = -1
+	}
+	check := func(samples []float32) {
+		quiet := true
+		for _, s := range samples {
+			if s >= noise || s <= -noise {
+				quiet = false
+				break
+			}
+		}
+		switch {
+		case quiet && quietSince < 0:
+			quietSince = frame
+		case !quiet && quietSince >= 0:
+			end()
+		}
+		frame++
+	}
+
+	buf := make([]float32, 4096*channels)
+	pending := 0 // samples of a partial frame left at the start of buf
+	for {
+		n, readErr := stream.Read(buf[pending:])
+		n += pending
+		frames := n / channels
+		for i := 0; i < frames; i++ {
+			check(buf[i*channels : (i+1)*channels])
+		}
+		pending = copy(buf, buf[frames*channels:n])
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+	// A file cut short mid-frame still ends with what it has
+	if pending > 0 {
+		check(buf[:pending])
+	}
+	if quietSince >= 0 {
+		end()
+	}
+	return silences, nil
+}
@@ -0,0 +1,31 @@
+package transcription
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSilencesNativeKeepsTrailingSilence(t *testing.T) {
+	// One second of tone, then two of silence to the very end
+	var pcm []byte
+	for i := 0; i < sampleRate; i++ {
+		v := int16(8000)
+		if i%40 < 20 {
+			v = -v
+		}
+		pcm = append(pcm, byte(v), byte(v>>8))
+	}
+	pcm = append(pcm, make([]byte, 2*sampleRate*2)...)
+	path := filepath.Join(t.TempDir(), "tail.wav")
+	if err := writeWAV(path, pcm); err != nil {
+		t.Fatal(err)
+	}
+
+	silences, err := silencesNative(path, -30, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(silences) != 1 || silences[0].Start != 1 || silences[0].End != 3 {
+		t.Fatalf("silences = %+v, want one from 1s to 3s", silences)
+	}
+}
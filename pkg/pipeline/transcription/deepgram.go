@@ -0,0 +1,222 @@
+package transcription
+
+// Deepgram backend — sends each file to Deepgram's pre-recorded
+// transcription API instead of decoding locally, for fast turnaround
+// without a GPU. Utterances become segments; with diarization on, each
+// carries the speaker Deepgram assigned.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+const (
+	deepgramURL          = "https://api.deepgram.com/v1/listen"
+	deepgramDefaultModel = "nova-2"
+)
+
+// DeepgramOptions configures the Deepgram backend
+type DeepgramOptions struct {
+	// APIKey is a secret reference (env:, file:, vault:) or a literal,
+	// resolved per request so rotated keys are picked up
+	APIKey string `yaml:"api_key"`
+	Model  string `yaml:"model"` // default nova-2
+	// Diarize labels each segment with its speaker
+	Diarize bool `yaml:"diarize"`
+	// SmartFormat adds punctuation and writes numbers, dates, and the like
+	// in written form
+	SmartFormat bool `yaml:"smart_format"`
+	// PricePerMinute is the USD rate used to record each job's cloud cost
+	PricePerMinute float64 `yaml:"price_per_minute"`
+	// URL overrides the API endpoint, e.g. for a self-hosted deployment
+	URL            string `yaml:"url"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"` // per request (default 10m)
+}
+
+// SetDeepgram configures the Deepgram backend; call it before
+// SetBackend(BackendDeepgram)
+func (wt *WhisperTranscriber) SetDeepgram(opts DeepgramOptions) {
+	wt.deepgram = opts
+}
+
+// deepgramClient calls the pre-recorded API
+type deepgramClient struct {
+	opts   DeepgramOptions
+	client *http.Client
+}
+
+func newDeepgramClient(opts DeepgramOptions) (*deepgramClient, error) {
+	if opts.APIKey == "" {
+		return nil, errors.New("deepgram backend needs whisper.deepgram.api_key")
+	}
+	if opts.Model == "" {
+		opts.Model = deepgramDefaultModel
+	}
+	if opts.URL == "" {
+		opts.URL = deepgramURL
+	}
+	timeout := 10 * time.Minute
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	d := &deepgramClient{opts: opts, client: &http.Client{Timeout: timeout}}
+	if err := d.check(); err != nil {
+		return nil, err
+	}
+	log.Printf("Transcribing with Deepgram (model: %s, diarize: %t, smart_format: %t)",
+		opts.Model, opts.Diarize, opts.SmartFormat)
+	return d, nil
+}
+
+func (d *deepgramClient) modelName() string {
+	return d.opts.Model
+}
+
+// check reports an API key that can't be resolved
+func (d *deepgramClient) check() error {
+	_, err := d.apiKey()
+	return err
+}
+
+func (d *deepgramClient) apiKey() (string, error) {
+	return resolveAPIKey(d.opts.APIKey, "Deepgram")
+}
+
+func (d *deepgramClient) Close() error {
+	return nil
+}
+
+// deepgramResponse is the part of the API response we use
+type deepgramResponse struct {
+	Metadata struct {
+		Duration float64 `json:"duration"`
+	} `json:"metadata"`
+	Results struct {
+		Channels []struct {
+			DetectedLanguage   string  `json:"detected_language"`
+			LanguageConfidence float64 `json:"language_confidence"`
+			Alternatives       []struct {
+				Transcript string `json:"transcript"`
+			} `json:"alternatives"`
+		} `json:"channels"`
+		Utterances []struct {
+			Start      float64 `json:"start"`
+			End        float64 `json:"end"`
+			Transcript string  `json:"transcript"`
+			Confidence float64 `json:"confidence"`
+			Speaker    *int    `json:"speaker"`
+		} `json:"utterances"`
+	} `json:"results"`
+}
+
+// decode uploads the file and converts the response; Deepgram has no
+// sampling temperature or prompt, so only opts.Language and
+// opts.Vocabulary are used
+func (d *deepgramClient) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %v", err)
+	}
+	key, err := d.apiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"model":        {d.opts.Model},
+		"utterances":   {"true"},
+		"punctuate":    {"true"},
+		"smart_format": {strconv.FormatBool(d.opts.SmartFormat)},
+		"diarize":      {strconv.FormatBool(d.opts.Diarize)},
+	}
+	language := opts.language()
+	if language == LanguageAuto {
+		query.Set("detect_language", "true")
+	} else {
+		query.Set("language", language)
+	}
+	// Nova-3 takes key terms; earlier models boost keywords
+	param := "keywords"
+	if strings.HasPrefix(d.opts.Model, "nova-3") {
+		param = "keyterm"
+	}
+	for _, term := range opts.Vocabulary {
+		query.Add(param, term)
+	}
+	req, err := http.NewRequest(http.MethodPost, d.opts.URL+"?"+query.Encode(), bytes.NewReader(audio))
+	if err != nil {
+		return nil, err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(audioPath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Token "+key)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("deepgram request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("deepgram returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var response deepgramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to parse deepgram response: %v", err)
+	}
+
+	result := &types.TranscriptionResult{
+		Language: language,
+		Duration: response.Metadata.Duration,
+	}
+	if channels := response.Results.Channels; len(channels) > 0 {
+		if channels[0].DetectedLanguage != "" {
+			result.Language = channels[0].DetectedLanguage
+			result.LanguageConfidence = channels[0].LanguageConfidence
+		}
+		if len(channels[0].Alternatives) > 0 {
+			result.Text = strings.TrimSpace(channels[0].Alternatives[0].Transcript)
+		}
+	}
+	for _, u := range response.Results.Utterances {
+		segment := types.Segment{Start: u.Start, End: u.End, Text: strings.TrimSpace(u.Transcript), Confidence: u.Confidence}
+		if d.opts.Diarize && u.Speaker != nil {
+			segment.Speaker = fmt.Sprintf("speaker_%d", *u.Speaker)
+		}
+		result.Segments = append(result.Segments, segment)
+		if onSegment != nil {
+			onSegment(u.End)
+		}
+	}
+	if len(result.Segments) == 0 && result.Text != "" {
+		result.Segments = []types.Segment{{Start: 0, End: result.Duration, Text: result.Text}}
+	}
+	result.Cost.CloudCostUSD = result.Duration / 60 * d.opts.PricePerMinute
+
+	for _, format := range formats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(result.Segments), result.Duration)
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package transcription
+
+// Noise reduction — jobs that ask for it are passed through an ffmpeg
+// denoising filter while they are normalized, which helps whisper with
+// noisy field recordings and phone calls. afftdn (spectral subtraction)
+// needs nothing else; arnndn (RNNoise) needs a model file.
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Denoise filters
+const (
+	DenoiseAFFTDN = "afftdn"
+	DenoiseARNNDN = "arnndn"
+)
+
+// defaultNoiseReductionDB is afftdn's own default reduction
+const defaultNoiseReductionDB = 12
+
+// DenoiseOptions configures the noise reduction filter
+type DenoiseOptions struct {
+	// Filter is "afftdn" (default) or "arnndn"
+	Filter string `yaml:"filter"`
+	// NoiseReductionDB is how far afftdn lowers noise, 0.01-97 (default 12)
+	NoiseReductionDB float64 `yaml:"noise_reduction_db"`
+	// Model is the RNNoise model file arnndn needs (.rnnn)
+	Model string `yaml:"model"`
+}
+
+// CheckDenoise validates the options and fills in defaults
+func CheckDenoise(opts DenoiseOptions) (DenoiseOptions, error) {
+	switch opts.Filter {
+	case "", DenoiseAFFTDN:
+		opts.Filter = DenoiseAFFTDN
+		if opts.NoiseReductionDB == 0 {
+			opts.NoiseReductionDB = defaultNoiseReductionDB
+		}
+		if opts.NoiseReductionDB < 0.01 || opts.NoiseReductionDB > 97 {
+			return opts, fmt.Errorf("denoise noise_reduction_db must be between 0.01 and 97")
+		}
+	case DenoiseARNNDN:
+		if opts.Model == "" {
+			return opts, fmt.Errorf("denoise filter arnndn needs a model file")
+		}
+		if _, err := os.Stat(opts.Model); err != nil {
+			return opts, fmt.Errorf("denoise model: %v", err)
+		}
+	default:
+		return opts, fmt.Errorf("unknown denoise filter %q (want %s or %s)", opts.Filter, DenoiseAFFTDN, DenoiseARNNDN)
+	}
+	return opts, nil
+}
+
+// filter is the ffmpeg audio filter for the options; the zero value is
+// afftdn with its default reduction
+func (o DenoiseOptions) filter() string {
+	if o.Filter == DenoiseARNNDN {
+		// Quoted, so the path may hold ':' and other filtergraph characters
+		return "arnndn=m='" + strings.ReplaceAll(o.Model, "'", `'\''`) + "'"
+	}
+	return fmt.Sprintf("afftdn=nr=%g", cmp.Or(o.NoiseReductionDB, defaultNoiseReductionDB))
+}
@@ -0,0 +1,146 @@
+package transcription
+
+// Devices — where the python and fasterwhisper backends run. A host with
+// several GPUs lists them in whisper.devices; queue workers are pinned to
+// them round-robin (see DeviceFor), and each device runs at most
+// max_jobs_per_device transcriptions at a time, one by default so jobs
+// don't compete for GPU memory. Each device gets its own persistent whisper
+// worker or faster-whisper sidecar. The other backends have a single model
+// instance, so their runs stay serialized on the transcriber's lock.
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// devicePattern matches "cpu", "cuda", and "cuda:<index>"
+var devicePattern = regexp.MustCompile(`^(cpu|cuda(:[0-9]+)?)$`)
+
+// SetDevices sets the devices runs are spread over ("cpu", "cuda", or
+// "cuda:N"; empty means the device given to NewWhisperTranscriber) and how
+// many runs each may take at once (zero means one). Call it before
+// SetBackend, which starts a worker or sidecar per device.
+func (wt *WhisperTranscriber) SetDevices(devices []string, jobsPerDevice int) error {
+	if len(devices) == 0 {
+		devices = []string{wt.device}
+	}
+	if jobsPerDevice < 0 {
+		return fmt.Errorf("max_jobs_per_device must not be negative")
+	}
+	slots := make(map[string]chan struct{}, len(devices))
+	for _, device := range devices {
+		if !devicePattern.MatchString(device) {
+			return fmt.Errorf("invalid device %q; use cpu, cuda, or cuda:<index>", device)
+		}
+		if _, dup := slots[device]; dup {
+			return fmt.Errorf("device %q is listed twice", device)
+		}
+		slots[device] = make(chan struct{}, max(jobsPerDevice, 1))
+	}
+	wt.devices, wt.deviceSlots = devices, slots
+	wt.device = devices[0]
+	return nil
+}
+
+// Devices lists the devices runs are spread over
+func (wt *WhisperTranscriber) Devices() []string {
+	return wt.devices
+}
+
+// DeviceFor pins a queue worker to a device, round-robin by worker ID
+func (wt *WhisperTranscriber) DeviceFor(workerID int) string {
+	return wt.devices[workerID%len(wt.devices)]
+}
+
+// perDevice reports whether the backend runs on the configured devices
+func (wt *WhisperTranscriber) perDevice() bool {
+	return wt.backend == BackendPython || wt.backend == BackendFasterWhisper
+}
+
+// acquire waits for room on the run's device, filling in opts.Device (the
+// first device when unset), and returns the function that frees it.
+// Backends without devices take the transcriber's lock instead.
+func (wt *WhisperTranscriber) acquire(opts *DecodeOptions) (func(), error) {
+	if !wt.perDevice() {
+		wt.mu.Lock()
+		return wt.mu.Unlock, nil
+	}
+	if opts.Device == "" {
+		opts.Device = wt.devices[0]
+	}
+	slot, ok := wt.deviceSlots[opts.Device]
+	if !ok {
+		return nil, fmt.Errorf("unknown device %q (configured: %s)", opts.Device, strings.Join(wt.devices, ", "))
+	}
+	slot <- struct{}{}
+	return func() { <-slot }, nil
+}
+
+// splitDevice splits "cuda:1" into "cuda" and index 1, as faster-whisper
+// takes them; other devices have index 0
+func splitDevice(device string) (string, int) {
+	kind, index, ok := strings.Cut(device, ":")
+	if !ok {
+		return device, 0
+	}
+	n, _ := strconv.Atoi(index)
+	return kind, n
+}
+
+// sidecarPool is a sidecar per device, each run going to its own device's
+type sidecarPool map[string]*sidecar
+
+// startSidecarPool starts a sidecar per device with start
+func startSidecarPool(devices []string, start func(device string) (*sidecar, error)) (sidecarPool, error) {
+	pool := make(sidecarPool, len(devices))
+	for _, device := range devices {
+		s, err := start(device)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool[device] = s
+	}
+	return pool, nil
+}
+
+func (p sidecarPool) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	s, ok := p[opts.Device]
+	if !ok {
+		return nil, fmt.Errorf("no sidecar for device %q", opts.Device)
+	}
+	return s.decode(audioPath, opts, formats, onSegment)
+}
+
+// waitReady waits for every sidecar to serve
+func (p sidecarPool) waitReady(timeout time.Duration) error {
+	for _, s := range p {
+		if _, err := s.waitReady(timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check reports the first sidecar that is down
+func (p sidecarPool) check() error {
+	for _, s := range p {
+		if err := s.check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close stops every sidecar
+func (p sidecarPool) Close() error {
+	for _, s := range p {
+		s.Close()
+	}
+	return nil
+}
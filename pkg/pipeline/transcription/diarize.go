@@ -0,0 +1,233 @@
+package transcription
+
+// Speaker diarization — jobs that ask for it have their audio run through
+// a pyannote.audio pipeline, kept loaded between jobs by a sidecar (see
+// sidecar.go) running diarize_server.py. The speaker turns it finds are
+// merged into the transcript: each segment gets the speaker it overlaps
+// most, labelled speaker_0, speaker_1, ... in order of first appearance,
+// like the cloud backends that diarize.
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+//go:embed diarize_server.py
+var diarizeScript []byte
+
+// defaultDiarizationModel is the pyannote pipeline used when none is
+// configured
+const defaultDiarizationModel = "pyannote/speaker-diarization-3.1"
+
+// MaxSpeakers bounds the speaker counts a job or the config may give
+const MaxSpeakers = 20
+
+// DiarizationOptions configures speaker diarization
+type DiarizationOptions struct {
+	Enabled bool `yaml:"enabled"`
+	// Model is a pyannote pipeline on Hugging Face or a local directory
+	// (default pyannote/speaker-diarization-3.1)
+	Model string `yaml:"model"`
+	// AuthToken is a secret reference (env:, file:, vault:) or a literal,
+	// the Hugging Face token for gated models
+	AuthToken string `yaml:"auth_token"`
+	// Device is where the pipeline runs (default: whisper.device)
+	Device string `yaml:"device"`
+	// MinSpeakers and MaxSpeakers bound the speakers found when a job
+	// doesn't say how many there are (0 leaves it to the pipeline)
+	MinSpeakers int `yaml:"min_speakers"`
+	MaxSpeakers int `yaml:"max_speakers"`
+}
+
+// SetDiarization validates the options and starts the diarization
+// sidecar; call it after SetPython
+func (wt *WhisperTranscriber) SetDiarization(opts DiarizationOptions) error {
+	if !opts.Enabled {
+		return nil
+	}
+	if opts.Model == "" {
+		opts.Model = defaultDiarizationModel
+	}
+	if opts.Device == "" {
+		opts.Device = wt.device
+	}
+	if opts.MinSpeakers < 0 || opts.MaxSpeakers < 0 || opts.MinSpeakers > MaxSpeakers || opts.MaxSpeakers > MaxSpeakers {
+		return fmt.Errorf("diarization min_speakers and max_speakers must be between 0 and %d", MaxSpeakers)
+	}
+	if opts.MaxSpeakers > 0 && opts.MinSpeakers > opts.MaxSpeakers {
+		return fmt.Errorf("diarization min_speakers must not exceed max_speakers")
+	}
+
+	python := wt.python
+	if python.interpreter == "" {
+		python = defaultPython
+	}
+	if opts.AuthToken != "" {
+		token, err := secrets.Resolve(opts.AuthToken)
+		if err != nil {
+			return fmt.Errorf("failed to resolve diarization auth_token: %v", err)
+		}
+		// Through the environment, so the token never shows in ps
+		python.env = append(append([]string(nil), python.env...), "HF_TOKEN="+token)
+	}
+
+	s, err := startSidecar("pyannote", diarizeScript, python, opts.Device,
+		"--model", opts.Model,
+		"--device", opts.Device,
+		"--min-speakers", strconv.Itoa(opts.MinSpeakers),
+		"--max-speakers", strconv.Itoa(opts.MaxSpeakers),
+	)
+	if err != nil {
+		return err
+	}
+	log.Printf("Diarizing with a pyannote sidecar (model: %s, device: %s)", opts.Model, opts.Device)
+	wt.diarizer = s
+	return nil
+}
+
+// CanDiarize reports whether jobs may ask for speaker diarization
+func (wt *WhisperTranscriber) CanDiarize() bool {
+	return wt.diarizer != nil
+}
+
+// CheckSpeakers validates the number of speakers a job says it has; 0
+// leaves it to the pipeline
+func CheckSpeakers(speakers int) error {
+	if speakers < 0 || speakers > MaxSpeakers {
+		return fmt.Errorf("speakers must be between 1 and %d", MaxSpeakers)
+	}
+	return nil
+}
+
+// diarizeRequest asks the sidecar to diarize one file
+type diarizeRequest struct {
+	Audio    string `json:"audio"`
+	Speakers int    `json:"speakers,omitempty"`
+}
+
+// diarizeReply is a speaker turn, or the final done/error line
+type diarizeReply struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Speaker string  `json:"speaker"`
+	Done    bool    `json:"done"`
+	Error   string  `json:"error"`
+}
+
+// Diarize finds who speaks when in wavPath, which result was transcribed
+// from, and labels the result's segments with their speakers. speakers is
+// the number of speakers, if known. Results that already carry speakers,
+// from a backend that diarizes, are left alone.
+func (wt *WhisperTranscriber) Diarize(wavPath string, speakers int, result *types.TranscriptionResult) error {
+	if wt.diarizer == nil {
+		return fmt.Errorf("diarization is not enabled")
+	}
+	for _, seg := range result.Segments {
+		if seg.Speaker != "" {
+			return nil
+		}
+	}
+
+	start := time.Now()
+	turns, err := wt.diarizer.diarize(wavPath, speakers)
+	if err != nil {
+		return err
+	}
+	labelled := AssignSpeakers(result.Segments, turns)
+	for format := range result.Formats {
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	log.Printf("Diarization completed: %d speakers in %d turns (%.1fs)",
+		labelled, len(turns), time.Since(start).Seconds())
+	return nil
+}
+
+// diarize sends one file to the diarization sidecar and collects the
+// speaker turns
+func (s *sidecar) diarize(wavPath string, speakers int) ([]types.SpeakerTurn, error) {
+	absPath, err := filepath.Abs(wavPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+	addr, err := s.waitReady(sidecarStartTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s sidecar: %v", s.name, err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(diarizeRequest{Audio: absPath, Speakers: speakers}); err != nil {
+		return nil, fmt.Errorf("failed to send request to %s sidecar: %v", s.name, err)
+	}
+	var turns []types.SpeakerTurn
+	replies := json.NewDecoder(conn)
+	for {
+		var reply diarizeReply
+		if err := replies.Decode(&reply); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("%s sidecar connection failed: %v", s.name, err)
+		}
+		if reply.Error != "" {
+			return nil, fmt.Errorf("diarization failed: %s", reply.Error)
+		}
+		if reply.Done {
+			return turns, nil
+		}
+		turns = append(turns, types.SpeakerTurn{Speaker: reply.Speaker, Start: reply.Start, End: reply.End})
+	}
+}
+
+// AssignSpeakers labels each segment with the speaker whose turns overlap
+// it most, or the nearest turn's speaker when none overlaps. Speakers are
+// renamed speaker_0, speaker_1, ... in order of first appearance; it
+// returns how many there are.
+func AssignSpeakers(segments []types.Segment, turns []types.SpeakerTurn) int {
+	if len(turns) == 0 {
+		return 0
+	}
+	names := make(map[string]string)
+	for i := range segments {
+		seg := &segments[i]
+		overlap := make(map[string]float64)
+		best, bestOverlap := "", 0.0
+		nearest, nearestGap := "", -1.0
+		for _, turn := range turns {
+			if o := min(seg.End, turn.End) - max(seg.Start, turn.Start); o > 0 {
+				overlap[turn.Speaker] += o
+				if overlap[turn.Speaker] > bestOverlap {
+					best, bestOverlap = turn.Speaker, overlap[turn.Speaker]
+				}
+				continue
+			}
+			gap := max(turn.Start-seg.End, seg.Start-turn.End)
+			if nearestGap < 0 || gap < nearestGap {
+				nearest, nearestGap = turn.Speaker, gap
+			}
+		}
+		if best == "" {
+			best = nearest
+		}
+		name, ok := names[best]
+		if !ok {
+			name = fmt.Sprintf("speaker_%d", len(names))
+			names[best] = name
+		}
+		seg.Speaker = name
+	}
+	return len(names)
+}
@@ -0,0 +1,82 @@
+"""Speaker diarization sidecar for the transcription server.
+
+Keeps a pyannote.audio pipeline loaded between jobs. It speaks the
+faster-whisper sidecar's protocol: it listens on an ephemeral loopback port
+(printed as "LISTENING <port>") and serves one file per connection. The
+request is a JSON line; the reply is a JSON line per speaker turn,
+{"start", "end", "speaker"}, followed by {"done": true} or {"error": ...}.
+The Hugging Face token for gated models is read from HF_TOKEN. Exits when
+its stdin closes, i.e. when the server that started it goes away.
+"""
+
+import argparse
+import json
+import os
+import socket
+import sys
+import threading
+
+import torch
+from pyannote.audio import Pipeline
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument("--model", required=True)
+    parser.add_argument("--device", default="cpu")
+    parser.add_argument("--min-speakers", type=int, default=0)
+    parser.add_argument("--max-speakers", type=int, default=0)
+    args = parser.parse_args()
+
+    # Don't outlive the server
+    threading.Thread(target=lambda: (sys.stdin.read(), os._exit(0)), daemon=True).start()
+
+    token = os.environ.get("HF_TOKEN") or None
+    try:
+        pipeline = Pipeline.from_pretrained(args.model, token=token)
+    except TypeError:
+        # pyannote.audio before 4.0
+        pipeline = Pipeline.from_pretrained(args.model, use_auth_token=token)
+    if pipeline is None:
+        sys.exit("could not load %s; is the model's licence accepted for this token?" % args.model)
+    pipeline.to(torch.device(args.device))
+
+    server = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+    server.bind(("127.0.0.1", 0))
+    server.listen()
+    print("LISTENING %d" % server.getsockname()[1], flush=True)
+
+    while True:
+        conn, _ = server.accept()
+        with conn, conn.makefile("rwb") as stream:
+            serve(pipeline, args, stream)
+
+
+def serve(pipeline, args, stream):
+    def send(reply):
+        stream.write((json.dumps(reply) + "\n").encode())
+        stream.flush()
+
+    try:
+        request = json.loads(stream.readline())
+        options = {}
+        if request.get("speakers"):
+            options["num_speakers"] = request["speakers"]
+        else:
+            if args.min_speakers:
+                options["min_speakers"] = args.min_speakers
+            if args.max_speakers:
+                options["max_speakers"] = args.max_speakers
+
+        output = pipeline(request["audio"], **options)
+        # pyannote.audio 4 wraps the annotation
+        annotation = getattr(output, "speaker_diarization", output)
+        for turn, _, speaker in annotation.itertracks(yield_label=True):
+            send({"start": turn.start, "end": turn.end, "speaker": speaker})
+        send({"done": True})
+    except Exception as e:
+        send({"error": str(e)})
+
+
+if __name__ == "__main__":
+    main()
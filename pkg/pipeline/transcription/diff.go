@@ -0,0 +1,262 @@
+package transcription
+
+// Transcript diffing — a word-level comparison of two versions of a
+// transcript's segments, for reviewing what a different model or a human
+// editor changed. Words are timed by spreading each segment's span over
+// its words by length, so every change points at a stretch of the audio.
+// The comparison is a Myers diff over the words that differ once the
+// common start and end are set aside.
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Diff change types
+const (
+	DiffInsert  = "insert"
+	DiffDelete  = "delete"
+	DiffReplace = "replace"
+)
+
+// maxDiffEdits bounds the Myers search; transcripts further apart than
+// this are reported as one replacement of everything between their common
+// start and end
+const maxDiffEdits = 2000
+
+// DiffOptions controls how words are compared
+type DiffOptions struct {
+	// IgnorePunctuation compares words without case or surrounding
+	// punctuation, so only changed words are reported
+	IgnorePunctuation bool
+}
+
+// DiffChange is one run of changed words. The old and new times span the
+// words removed and added; for an insertion the old times mark where the
+// words went in, and for a deletion the new times mark where they were.
+type DiffChange struct {
+	Type     string  `json:"type"`
+	OldText  string  `json:"old_text,omitempty"`
+	NewText  string  `json:"new_text,omitempty"`
+	OldStart float64 `json:"old_start"`
+	OldEnd   float64 `json:"old_end"`
+	NewStart float64 `json:"new_start"`
+	NewEnd   float64 `json:"new_end"`
+}
+
+// DiffSummary counts the words in and between two versions
+type DiffSummary struct {
+	OldWords  int `json:"old_words"`
+	NewWords  int `json:"new_words"`
+	Unchanged int `json:"unchanged"`
+	Deleted   int `json:"deleted"`
+	Inserted  int `json:"inserted"`
+	// Approximate is set when the versions differ too much for a word by
+	// word comparison and the changes are one coarse replacement
+	Approximate bool `json:"approximate,omitempty"`
+}
+
+// TranscriptDiff is the word-level difference between two versions
+type TranscriptDiff struct {
+	Summary DiffSummary  `json:"summary"`
+	Changes []DiffChange `json:"changes"`
+}
+
+// timedWord is a word with the time it was spoken
+type timedWord struct {
+	text       string
+	start, end float64
+}
+
+// segmentWords splits segments into words, dividing each segment's time
+// between its words by their length
+func segmentWords(segments []types.Segment) []timedWord {
+	var words []timedWord
+	for _, seg := range segments {
+		fields := strings.Fields(seg.Text)
+		total := 0
+		for _, f := range fields {
+			total += utf8.RuneCountInString(f) + 1
+		}
+		span := max(seg.End-seg.Start, 0)
+		done := 0
+		for _, f := range fields {
+			start := seg.Start + span*float64(done)/float64(total)
+			done += utf8.RuneCountInString(f) + 1
+			end := seg.Start + span*float64(done)/float64(total)
+			words = append(words, timedWord{text: f, start: start, end: end})
+		}
+	}
+	return words
+}
+
+// diffKey is what a word is compared by
+func (o DiffOptions) diffKey(word string) string {
+	if !o.IgnorePunctuation {
+		return word
+	}
+	return strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+}
+
+// DiffSegments compares two versions of a transcript word by word
+func DiffSegments(before, after []types.Segment, opts DiffOptions) *TranscriptDiff {
+	a, b := segmentWords(before), segmentWords(after)
+	keysA, keysB := make([]string, len(a)), make([]string, len(b))
+	for i, w := range a {
+		keysA[i] = opts.diffKey(w.text)
+	}
+	for i, w := range b {
+		keysB[i] = opts.diffKey(w.text)
+	}
+
+	keptA, keptB, exact := matchWords(keysA, keysB)
+	diff := &TranscriptDiff{
+		Summary: DiffSummary{OldWords: len(a), NewWords: len(b), Approximate: !exact},
+		Changes: []DiffChange{},
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && keptA[i] && keptB[j] {
+			diff.Summary.Unchanged++
+			i, j = i+1, j+1
+			continue
+		}
+		fromA, fromB := i, j
+		for i < len(a) && !keptA[i] {
+			i++
+		}
+		for j < len(b) && !keptB[j] {
+			j++
+		}
+		diff.Summary.Deleted += i - fromA
+		diff.Summary.Inserted += j - fromB
+		diff.Changes = append(diff.Changes, change(a, fromA, i, b, fromB, j))
+	}
+	return diff
+}
+
+// change describes words a[i:iEnd] replaced by b[j:jEnd]
+func change(a []timedWord, i, iEnd int, b []timedWord, j, jEnd int) DiffChange {
+	c := DiffChange{Type: DiffReplace}
+	switch {
+	case i == iEnd:
+		c.Type = DiffInsert
+	case j == jEnd:
+		c.Type = DiffDelete
+	}
+	c.OldText, c.OldStart, c.OldEnd = wordRun(a, i, iEnd)
+	c.NewText, c.NewStart, c.NewEnd = wordRun(b, j, jEnd)
+	return c
+}
+
+// wordRun joins words[i:end] and spans their times; an empty run is the
+// point between the words either side of it
+func wordRun(words []timedWord, i, end int) (string, float64, float64) {
+	if i == end {
+		switch {
+		case i > 0:
+			return "", words[i-1].end, words[i-1].end
+		case i < len(words):
+			return "", words[i].start, words[i].start
+		}
+		return "", 0, 0
+	}
+	texts := make([]string, 0, end-i)
+	for _, w := range words[i:end] {
+		texts = append(texts, w.text)
+	}
+	return strings.Join(texts, " "), words[i].start, words[end-1].end
+}
+
+// matchWords marks the words a and b have in common, in order. When they
+// differ by more than maxDiffEdits words, only their common start and end
+// are marked and exact is false.
+func matchWords(a, b []string) (keptA, keptB []bool, exact bool) {
+	keptA, keptB = make([]bool, len(a)), make([]bool, len(b))
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		keptA[prefix], keptB[prefix] = true, true
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		keptA[len(a)-1-suffix], keptB[len(b)-1-suffix] = true, true
+		suffix++
+	}
+	return keptA, keptB, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix],
+		keptA[prefix:len(a)-suffix], keptB[prefix:len(b)-suffix])
+}
+
+// myers finds a shortest edit script from a to b, marking the words it
+// keeps; false, marking nothing, when it needs more than maxDiffEdits edits
+func myers(a, b []string, keptA, keptB []bool) bool {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxDiffEdits)
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] holds v[-d..d] after d edits, to walk the path back
+	var trace [][]int32
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1] // down: insert b[y]
+			} else {
+				x = v[offset+k-1] + 1 // right: delete a[x]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, snapshot(v[offset-d:offset+d+1]))
+				backtrack(trace, n, m, keptA, keptB)
+				return true
+			}
+		}
+		trace = append(trace, snapshot(v[offset-d:offset+d+1]))
+	}
+	return false
+}
+
+func snapshot(v []int) []int32 {
+	s := make([]int32, len(v))
+	for i, x := range v {
+		s[i] = int32(x)
+	}
+	return s
+}
+
+// backtrack walks the edit path recorded in trace back from (n, m),
+// marking the words on its diagonals as kept
+func backtrack(trace [][]int32, n, m int, keptA, keptB []bool) {
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return int(prev[k+d-1]) }
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && at(k-1) < at(k+1) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			keptA[x], keptB[y] = true, true
+		}
+		if x == prevX {
+			y-- // b[y] was inserted
+		} else {
+			x-- // a[x] was deleted
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		keptA[x], keptB[y] = true, true
+	}
+}
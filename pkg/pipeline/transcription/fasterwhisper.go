@@ -0,0 +1,44 @@
+package transcription
+
+// faster-whisper backend — a sidecar (see sidecar.go) running
+// fasterwhisper_server.py keeps the model loaded between jobs.
+
+import (
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+)
+
+//go:embed fasterwhisper_server.py
+var fasterWhisperScript []byte
+
+// fasterWhisperModel is the model argument for faster-whisper: a converted
+// model directory at the model path, or else the model size
+func (wt *WhisperTranscriber) fasterWhisperModel() string {
+	if info, err := os.Stat(wt.modelPath); err == nil && info.IsDir() {
+		return wt.modelPath
+	}
+	return wt.modelName
+}
+
+// startFasterWhisper starts the faster-whisper sidecar
+func startFasterWhisper(python pythonRuntime, model, device string, threads int) (*sidecar, error) {
+	kind, index := splitDevice(device)
+	computeType := "int8"
+	if kind == "cuda" {
+		computeType = "float16"
+	}
+	s, err := startSidecar("faster-whisper", fasterWhisperScript, python, device,
+		"--model", model,
+		"--device", kind,
+		"--device-index", fmt.Sprint(index),
+		"--compute-type", computeType,
+		"--threads", fmt.Sprint(threads),
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Transcribing with a faster-whisper sidecar (model: %s, device: %s)", model, device)
+	return s, nil
+}
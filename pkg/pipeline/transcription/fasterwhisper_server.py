@@ -0,0 +1,104 @@
+"""faster-whisper sidecar for the transcription server.
+
+Loads the model once (and any other model a request names, on first use), listens on an ephemeral loopback port (printed as
+"LISTENING <port>"), and serves one transcription per connection: the
+request is a JSON line, the reply is a JSON line per segment followed by
+{"done": ...} or {"error": ...}. Exits when its stdin closes, i.e. when
+the server that started it goes away.
+"""
+
+import argparse
+import json
+import os
+import socket
+import sys
+import threading
+
+from faster_whisper import WhisperModel
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument("--model", required=True)
+    parser.add_argument("--device", default="cuda")
+    parser.add_argument("--device-index", type=int, default=0)
+    parser.add_argument("--compute-type", default="default")
+    parser.add_argument("--threads", type=int, default=0)
+    args = parser.parse_args()
+
+    # Don't outlive the server
+    threading.Thread(target=lambda: (sys.stdin.read(), os._exit(0)), daemon=True).start()
+
+    def load(name):
+        return WhisperModel(name, device=args.device, device_index=args.device_index,
+                            compute_type=args.compute_type, cpu_threads=args.threads)
+
+    models = {"": load(args.model)}
+
+    server = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+    server.bind(("127.0.0.1", 0))
+    server.listen()
+    print("LISTENING %d" % server.getsockname()[1], flush=True)
+
+    while True:
+        conn, _ = server.accept()
+        with conn, conn.makefile("rwb") as stream:
+            serve(models, load, stream)
+
+
+def serve(models, load, stream):
+    def send(message):
+        stream.write((json.dumps(message) + "\n").encode())
+        stream.flush()
+
+    line = stream.readline()
+    if not line:
+        return
+    try:
+        request = json.loads(line)
+        name = request.get("model") or ""
+        if name not in models:
+            models[name] = load(name)
+        model = models[name]
+        options = {
+            "language": request.get("language") or None,
+            "condition_on_previous_text": request.get("condition_on_previous_text", True),
+            "task": request.get("task") or "transcribe",
+            "initial_prompt": request.get("initial_prompt") or None,
+        }
+        for name in ("beam_size", "best_of", "no_speech_threshold", "compression_ratio_threshold"):
+            if request.get(name):
+                options[name] = request[name]
+        if request.get("logprob_threshold"):
+            options["log_prob_threshold"] = request["logprob_threshold"]
+
+        # faster-whisper's fallback ladder is its list of temperatures
+        temperature = request.get("temperature") or 0.0
+        increment = request.get("temperature_increment") or 0.2
+        options["temperature"] = [temperature]
+        if increment > 0:
+            steps = int(round((1.0 - temperature) / increment))
+            options["temperature"] = [round(temperature + i * increment, 4) for i in range(steps + 1)]
+
+        segments, info = model.transcribe(request["audio"], **options)
+        for segment in segments:
+            send({
+                "start": segment.start,
+                "end": segment.end,
+                "text": segment.text,
+                "temperature": segment.temperature,
+                "avg_logprob": segment.avg_logprob,
+                "compression_ratio": segment.compression_ratio,
+            })
+        send({
+            "done": True,
+            "language": info.language,
+            "language_probability": info.language_probability,
+            "duration": info.duration,
+        })
+    except Exception as e:  # reported to the job, the sidecar keeps serving
+        send({"error": str(e)})
+
+
+if __name__ == "__main__":
+    main()
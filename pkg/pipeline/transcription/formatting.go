@@ -0,0 +1,194 @@
+package transcription
+
+// Formatting profiles — whisper writes numbers, times, and amounts the way
+// US English text does ("1,250.5", "3:30 p.m.", "$20"). A profile rewrites
+// them to another documentation standard, e.g. "1.250,5", "15:30", "20 $".
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// FormatProfile describes how numbers, times, and currency amounts are written
+type FormatProfile struct {
+	DecimalSeparator string `yaml:"decimal_separator" json:"decimal_separator"`
+	GroupSeparator   string `yaml:"group_separator" json:"group_separator"`
+	// Clock24h writes "3:30 p.m." as "15:30"
+	Clock24h bool `yaml:"clock_24h" json:"clock_24h"`
+	// CurrencyAfter writes "$20" as "20 $"
+	CurrencyAfter bool `yaml:"currency_after" json:"currency_after"`
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]FormatProfile{
+		"us": {DecimalSeparator: ".", GroupSeparator: ",", Clock24h: false, CurrencyAfter: false},
+		"eu": {DecimalSeparator: ",", GroupSeparator: ".", Clock24h: true, CurrencyAfter: true},
+	}
+)
+
+// RegisterFormatProfile adds or replaces a named profile
+func RegisterFormatProfile(name string, profile FormatProfile) error {
+	if profile.DecimalSeparator == "" {
+		return fmt.Errorf("format profile %q: decimal_separator is required", name)
+	}
+	if profile.DecimalSeparator == profile.GroupSeparator {
+		return fmt.Errorf("format profile %q: decimal and group separators must differ", name)
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[name] = profile
+	return nil
+}
+
+// LookupFormatProfile returns a registered profile by name
+func LookupFormatProfile(name string) (FormatProfile, bool) {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	profile, ok := profiles[name]
+	return profile, ok
+}
+
+// FormatProfileNames lists the registered profiles, sorted
+func FormatProfileNames() []string {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	// clockPattern matches "3:30 p.m.", "3 PM", "11:05am"
+	clockPattern = regexp.MustCompile(`\b(\d{1,2})(?::(\d{2}))?\s?([AaPp])\.?\s?[Mm]\b\.?`)
+
+	// amountPattern matches an optionally currency-prefixed number written
+	// US style: "1,250", "1,250.75", "3.5", "$20"
+	amountPattern = regexp.MustCompile(`([$€£¥]\s?)?(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d+\.\d+|\d+)`)
+)
+
+// Apply rewrites the numbers, times, and amounts in text
+func (p FormatProfile) Apply(text string) string {
+	if p.Clock24h {
+		text = p.formatClocks(text)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range amountPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		// Leave dotted or comma-joined runs alone (versions, IPs, lists)
+		if touchesDigitRun(text, start, end) {
+			continue
+		}
+		b.WriteString(text[last:start])
+
+		number := p.formatNumber(text[loc[4]:loc[5]])
+		if loc[2] < 0 {
+			b.WriteString(number)
+		} else if symbol := strings.TrimSpace(text[loc[2]:loc[3]]); p.CurrencyAfter {
+			b.WriteString(number + " " + symbol)
+		} else {
+			b.WriteString(symbol + number)
+		}
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// formatClocks rewrites 12-hour times as 24-hour ones
+func (p FormatProfile) formatClocks(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range clockPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		hour, _ := strconv.Atoi(text[loc[2]:loc[3]])
+		if hour < 1 || hour > 12 {
+			continue
+		}
+		minutes := "00"
+		if loc[4] >= 0 {
+			minutes = text[loc[4]:loc[5]]
+		}
+		pm := text[loc[6]] == 'p' || text[loc[6]] == 'P'
+		switch {
+		case pm && hour != 12:
+			hour += 12
+		case !pm && hour == 12:
+			hour = 0
+		}
+
+		b.WriteString(text[last:start])
+		fmt.Fprintf(&b, "%02d:%s", hour, minutes)
+		// "p.m." also ends the sentence when it is last or a new one follows
+		if strings.HasSuffix(strings.ToLower(text[start:end]), "m.") && endsSentence(text[end:]) {
+			b.WriteString(".")
+		}
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// endsSentence reports whether rest (the text after a period) starts a new
+// sentence or is empty
+func endsSentence(rest string) bool {
+	trimmed := strings.TrimLeft(rest, " ")
+	if trimmed == "" || strings.HasPrefix(rest, "\n") {
+		return true
+	}
+	return len(trimmed) < len(rest) && trimmed[0] >= 'A' && trimmed[0] <= 'Z'
+}
+
+// touchesDigitRun reports whether text[start:end] is part of a longer
+// token like "1.2.3" or "10,20,30" that is not a single number
+func touchesDigitRun(text string, start, end int) bool {
+	isDigit := func(i int) bool { return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9' }
+	if start >= 2 && (text[start-1] == '.' || text[start-1] == ',') && isDigit(start-2) {
+		return true
+	}
+	return end+1 < len(text) && (text[end] == '.' || text[end] == ',') && isDigit(end+1)
+}
+
+// formatNumber rewrites a US-style number with the profile's separators
+func (p FormatProfile) formatNumber(number string) string {
+	whole, fraction, hasFraction := strings.Cut(strings.ReplaceAll(number, ",", ""), ".")
+
+	// Only numbers that were written grouped get grouped again, so years
+	// and codes ("2024", "90210") stay as they are
+	if strings.Contains(number, ",") && p.GroupSeparator != "" {
+		var grouped []string
+		for len(whole) > 3 {
+			grouped = append([]string{whole[len(whole)-3:]}, grouped...)
+			whole = whole[:len(whole)-3]
+		}
+		whole = strings.Join(append([]string{whole}, grouped...), p.GroupSeparator)
+	}
+	if hasFraction {
+		return whole + p.DecimalSeparator + fraction
+	}
+	return whole
+}
+
+// ApplyFormatProfile rewrites a result's text, segments, and extra
+// renderings with the profile
+func ApplyFormatProfile(result *types.TranscriptionResult, profile FormatProfile) {
+	result.Text = profile.Apply(result.Text)
+	for i := range result.Segments {
+		result.Segments[i].Text = profile.Apply(result.Segments[i].Text)
+	}
+	// Cue timestamps look like numbers, so renderings are rebuilt instead
+	for format := range result.Formats {
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+}
@@ -0,0 +1,399 @@
+package transcription
+
+// Google Cloud Speech-to-Text backend (v2 API) — clips up to a minute are
+// sent inline to Recognize; longer audio is staged in a Cloud Storage
+// bucket and transcribed with long-running BatchRecognize, polling the
+// operation until it finishes. Each recognition result becomes a segment
+// timed by its word offsets.
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	gcs "google.golang.org/api/storage/v1"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+const (
+	// googleSTTInlineLimit is the longest audio Recognize accepts inline
+	googleSTTInlineLimit = time.Minute
+
+	// googleSTTPollInterval is how often a long-running operation is checked
+	googleSTTPollInterval = 5 * time.Second
+
+	googleCloudScope = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// GoogleSTTOptions configures the Google Cloud Speech-to-Text backend
+type GoogleSTTOptions struct {
+	// Credentials is a secret reference to a service account key (JSON);
+	// empty uses Application Default Credentials
+	Credentials string `yaml:"credentials"`
+	ProjectID   string `yaml:"project_id"` // default: the credentials' project
+	Location    string `yaml:"location"`   // default "global"
+	Recognizer  string `yaml:"recognizer"` // default "_" (no stored recognizer)
+	Model       string `yaml:"model"`      // default "long"
+	Language    string `yaml:"language"`   // BCP-47 code, default "en-US"
+	// Bucket stages audio over a minute long for BatchRecognize; objects
+	// are deleted once the transcript is back
+	Bucket string `yaml:"bucket"`
+	// PricePerMinute is the USD rate used to record each job's cloud cost
+	PricePerMinute float64 `yaml:"price_per_minute"`
+	TimeoutSeconds int     `yaml:"timeout_seconds"` // per job (default 30m)
+}
+
+// SetGoogleSTT configures the Google Speech-to-Text backend; call it
+// before SetBackend(BackendGoogleSTT)
+func (wt *WhisperTranscriber) SetGoogleSTT(opts GoogleSTTOptions) {
+	wt.googleSTT = opts
+}
+
+// googleSTTClient calls the Speech-to-Text v2 REST API
+type googleSTTClient struct {
+	opts     GoogleSTTOptions
+	timeout  time.Duration
+	endpoint string // https://[location-]speech.googleapis.com/v2/
+	client   *http.Client
+	storage  *gcs.Service
+}
+
+func newGoogleSTTClient(opts GoogleSTTOptions) (*googleSTTClient, error) {
+	if opts.Location == "" {
+		opts.Location = "global"
+	}
+	if opts.Recognizer == "" {
+		opts.Recognizer = "_"
+	}
+	if opts.Model == "" {
+		opts.Model = "long"
+	}
+	if opts.Language == "" {
+		opts.Language = "en-US"
+	}
+	timeout := 30 * time.Minute
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+
+	creds, err := googleCredentials(opts.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ProjectID == "" {
+		opts.ProjectID = creds.ProjectID
+	}
+	if opts.ProjectID == "" {
+		return nil, errors.New("google_stt backend needs whisper.google_stt.project_id")
+	}
+
+	ctx := context.Background()
+	g := &googleSTTClient{
+		opts:     opts,
+		timeout:  timeout,
+		endpoint: "https://speech.googleapis.com/v2/",
+		client:   oauth2.NewClient(ctx, creds.TokenSource),
+	}
+	if opts.Location != "global" {
+		g.endpoint = fmt.Sprintf("https://%s-speech.googleapis.com/v2/", opts.Location)
+	}
+	if opts.Bucket != "" {
+		if g.storage, err = gcs.NewService(ctx, option.WithTokenSource(creds.TokenSource)); err != nil {
+			return nil, fmt.Errorf("failed to create Cloud Storage client: %v", err)
+		}
+	}
+	log.Printf("Transcribing with Google Speech-to-Text (project: %s, location: %s, model: %s)",
+		opts.ProjectID, opts.Location, opts.Model)
+	return g, nil
+}
+
+// googleCredentials loads a service account key, or the default credentials
+func googleCredentials(ref string) (*google.Credentials, error) {
+	ctx := context.Background()
+	if ref == "" {
+		creds, err := google.FindDefaultCredentials(ctx, googleCloudScope)
+		if err != nil {
+			return nil, fmt.Errorf("no Google credentials: set whisper.google_stt.credentials or GOOGLE_APPLICATION_CREDENTIALS: %v", err)
+		}
+		return creds, nil
+	}
+	key, err := secrets.Resolve(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Google credentials: %v", err)
+	}
+	creds, err := google.CredentialsFromJSON(ctx, []byte(key), googleCloudScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Google credentials: %v", err)
+	}
+	return creds, nil
+}
+
+func (g *googleSTTClient) modelName() string {
+	return g.opts.Model
+}
+
+func (g *googleSTTClient) Close() error {
+	return nil
+}
+
+// recognizer is the recognizer's resource name
+func (g *googleSTTClient) recognizer() string {
+	return fmt.Sprintf("projects/%s/locations/%s/recognizers/%s", g.opts.ProjectID, g.opts.Location, g.opts.Recognizer)
+}
+
+// recognitionConfig asks for word offsets so results can be timed;
+// language may be LanguageAuto, which Google takes as is
+func (g *googleSTTClient) recognitionConfig(language string) map[string]interface{} {
+	return map[string]interface{}{
+		"autoDecodingConfig": map[string]interface{}{},
+		"model":              g.opts.Model,
+		"languageCodes":      []string{language},
+		"features": map[string]interface{}{
+			"enableWordTimeOffsets":      true,
+			"enableWordConfidence":       true,
+			"enableAutomaticPunctuation": true,
+		},
+	}
+}
+
+// googleSTTResult is one recognition result; offsets are durations like "1.5s"
+type googleSTTResult struct {
+	Alternatives []struct {
+		Transcript string  `json:"transcript"`
+		Confidence float64 `json:"confidence"`
+		Words      []struct {
+			StartOffset string `json:"startOffset"`
+			EndOffset   string `json:"endOffset"`
+		} `json:"words"`
+	} `json:"alternatives"`
+	ResultEndOffset string `json:"resultEndOffset"`
+	LanguageCode    string `json:"languageCode"`
+}
+
+// decode transcribes inline or through a staged object by length; Google
+// has no sampling temperature, so only opts.Language is used
+func (g *googleSTTClient) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	var duration float64
+	if info, err := ProbeAudio(audioPath); err == nil {
+		duration = info.Duration
+	}
+
+	var (
+		results []googleSTTResult
+		err     error
+	)
+	inline := duration > 0 && duration <= googleSTTInlineLimit.Seconds()
+	if duration == 0 {
+		// Length unknown: try inline unless there is a bucket to stage in
+		inline = g.storage == nil
+	}
+	language := localeLanguage(g.opts.Language, opts)
+	if inline {
+		results, err = g.recognize(audioPath, language)
+	} else {
+		results, err = g.batchRecognize(audioPath, language)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.TranscriptionResult{Language: strings.ToLower(language), Duration: duration}
+	var texts []string
+	var prevEnd float64
+	for _, r := range results {
+		if len(r.Alternatives) == 0 || strings.TrimSpace(r.Alternatives[0].Transcript) == "" {
+			continue
+		}
+		alt := r.Alternatives[0]
+		segment := types.Segment{
+			Start:      prevEnd,
+			End:        parseOffset(r.ResultEndOffset),
+			Text:       strings.TrimSpace(alt.Transcript),
+			Confidence: alt.Confidence,
+		}
+		if words := alt.Words; len(words) > 0 {
+			segment.Start = parseOffset(words[0].StartOffset)
+			segment.End = parseOffset(words[len(words)-1].EndOffset)
+		}
+		if r.LanguageCode != "" {
+			result.Language = r.LanguageCode
+		}
+		prevEnd = segment.End
+		result.Segments = append(result.Segments, segment)
+		texts = append(texts, segment.Text)
+		if onSegment != nil {
+			onSegment(segment.End)
+		}
+	}
+	result.Text = strings.Join(texts, " ")
+	if result.Duration == 0 {
+		result.Duration = prevEnd
+	}
+	result.Cost.CloudCostUSD = result.Duration / 60 * g.opts.PricePerMinute
+
+	for _, format := range formats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(result.Segments), result.Duration)
+	return result, nil
+}
+
+// recognize sends a short clip inline
+func (g *googleSTTClient) recognize(audioPath, language string) ([]googleSTTResult, error) {
+	audio, err := os.ReadFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %v", err)
+	}
+	request := map[string]interface{}{
+		"config":  g.recognitionConfig(language),
+		"content": base64.StdEncoding.EncodeToString(audio),
+	}
+	var response struct {
+		Results []googleSTTResult `json:"results"`
+	}
+	if err := g.do(http.MethodPost, g.recognizer()+":recognize", request, &response); err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// batchRecognize stages the file in the bucket and runs a long-running
+// recognition on it
+func (g *googleSTTClient) batchRecognize(audioPath, language string) ([]googleSTTResult, error) {
+	if g.storage == nil {
+		return nil, errors.New("google_stt needs whisper.google_stt.bucket to transcribe audio over a minute long")
+	}
+	deadline := time.Now().Add(g.timeout)
+
+	audio, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %v", err)
+	}
+	object := "transcription-staging/" + filepath.Base(audioPath)
+	_, err = g.storage.Objects.Insert(g.opts.Bucket, &gcs.Object{Name: object}).Media(audio).Do()
+	audio.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage audio in gs://%s: %v", g.opts.Bucket, err)
+	}
+	defer func() {
+		if err := g.storage.Objects.Delete(g.opts.Bucket, object).Do(); err != nil {
+			log.Printf("Failed to delete staged audio gs://%s/%s: %v", g.opts.Bucket, object, err)
+		}
+	}()
+
+	uri := fmt.Sprintf("gs://%s/%s", g.opts.Bucket, object)
+	request := map[string]interface{}{
+		"config":                  g.recognitionConfig(language),
+		"files":                   []map[string]string{{"uri": uri}},
+		"recognitionOutputConfig": map[string]interface{}{"inlineResponseConfig": map[string]interface{}{}},
+	}
+
+	type operation struct {
+		Name  string `json:"name"`
+		Done  bool   `json:"done"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Response struct {
+			Results map[string]struct {
+				Error *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+				InlineResult struct {
+					Transcript struct {
+						Results []googleSTTResult `json:"results"`
+					} `json:"transcript"`
+				} `json:"inlineResult"`
+			} `json:"results"`
+		} `json:"response"`
+	}
+	var op operation
+	if err := g.do(http.MethodPost, g.recognizer()+":batchRecognize", request, &op); err != nil {
+		return nil, err
+	}
+	log.Printf("Google Speech-to-Text operation %s started", op.Name)
+
+	for !op.Done {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("google speech-to-text operation %s not done within %s", op.Name, g.timeout)
+		}
+		time.Sleep(googleSTTPollInterval)
+		name := op.Name
+		op = operation{}
+		if err := g.do(http.MethodGet, name, nil, &op); err != nil {
+			return nil, err
+		}
+	}
+	if op.Error != nil {
+		return nil, fmt.Errorf("google speech-to-text failed: %s", op.Error.Message)
+	}
+	fileResult, ok := op.Response.Results[uri]
+	if !ok {
+		return nil, fmt.Errorf("google speech-to-text returned no result for %s", uri)
+	}
+	if fileResult.Error != nil {
+		return nil, fmt.Errorf("google speech-to-text failed: %s", fileResult.Error.Message)
+	}
+	return fileResult.InlineResult.Transcript.Results, nil
+}
+
+// do sends one API request (path relative to the v2 endpoint) and decodes
+// the JSON response into out
+func (g *googleSTTClient) do(method, path string, request, out interface{}) error {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, g.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("google speech-to-text request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("google speech-to-text returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse google speech-to-text response: %v", err)
+	}
+	return nil
+}
+
+// parseOffset reads a protobuf JSON duration such as "12.340s"
+func parseOffset(offset string) float64 {
+	seconds, _ := strconv.ParseFloat(strings.TrimSuffix(offset, "s"), 64)
+	return seconds
+}
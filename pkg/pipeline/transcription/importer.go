@@ -0,0 +1,70 @@
+package transcription
+
+// Transcript import — turns transcripts produced by other tools (plain
+// text, srt/vtt subtitles, or Whisper JSON) into a TranscriptionResult.
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// ImportFormats are the transcript file extensions ParseTranscript accepts
+var ImportFormats = []string{".txt", ".srt", ".vtt", ".json"}
+
+// ParseTranscript reads an existing transcript, choosing the parser by the
+// file extension
+func ParseTranscript(filename string, data []byte) (*types.TranscriptionResult, error) {
+	result := &types.TranscriptionResult{}
+
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".txt":
+		result.Text = strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+
+	case ".srt", ".vtt":
+		segments, err := ParseSubtitles(string(data))
+		if err != nil {
+			return nil, err
+		}
+		result.Segments = segments
+
+	case ".json":
+		var output WhisperOutput
+		if err := json.Unmarshal(data, &output); err != nil {
+			return nil, fmt.Errorf("invalid transcript JSON: %v", err)
+		}
+		result.Text = strings.TrimSpace(output.Text)
+		result.Language = output.Language
+		for _, seg := range output.Segments {
+			result.Segments = append(result.Segments, types.Segment{
+				Start: seg.Start,
+				End:   seg.End,
+				Text:  strings.TrimSpace(seg.Text),
+			})
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported transcript format %q (supported: %s)",
+			ext, strings.Join(ImportFormats, ", "))
+	}
+
+	// Subtitles carry no separate text; rebuild it from the cues
+	if result.Text == "" {
+		texts := make([]string, 0, len(result.Segments))
+		for _, seg := range result.Segments {
+			texts = append(texts, seg.Text)
+		}
+		result.Text = strings.Join(texts, " ")
+	}
+	if result.Text == "" {
+		return nil, fmt.Errorf("transcript is empty")
+	}
+
+	if len(result.Segments) > 0 {
+		result.Duration = result.Segments[len(result.Segments)-1].End
+	}
+	return result, nil
+}
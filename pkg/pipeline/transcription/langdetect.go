@@ -0,0 +1,52 @@
+package transcription
+
+// Language detection for the python CLI — whisper detects the language from
+// the first 30 seconds but prints no probability for it. The CLI is run
+// through a small wrapper that records what detection found, so the score
+// comes from the transcription run itself. The persistent worker reports
+// it directly (see whisper_server.py).
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// whisperCLIScript runs whisper's command line with the arguments after
+// the first, and writes {"language": ..., "probability": ...} to the file
+// named by the first if whisper detected the language
+const whisperCLIScript = `
+import json, sys
+from whisper.model import Whisper
+from whisper.transcribe import cli
+
+detect = Whisper.detect_language
+
+def detect_language(model, mel, tokenizer=None):
+    tokens, probs = detect(model, mel, tokenizer)
+    if isinstance(probs, dict):
+        language = max(probs, key=probs.get)
+        with open(sys.argv[1], "w") as out:
+            json.dump({"language": language, "probability": float(probs[language])}, out)
+    return tokens, probs
+
+Whisper.detect_language = detect_language
+sys.argv = ["whisper"] + sys.argv[2:]
+cli()
+`
+
+// readDetectedLanguage reads the probability whisperCLIScript recorded;
+// ok is false when nothing was detected or the file is unreadable
+func readDetectedLanguage(path string) (language string, probability float64, ok bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, false
+	}
+	var detected struct {
+		Language    string  `json:"language"`
+		Probability float64 `json:"probability"`
+	}
+	if err := json.Unmarshal(data, &detected); err != nil || detected.Language == "" {
+		return "", 0, false
+	}
+	return detected.Language, detected.Probability, true
+}
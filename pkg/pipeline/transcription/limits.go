@@ -0,0 +1,131 @@
+package transcription
+
+// Subprocess resource limits — runs whisper, ffmpeg, and yt-dlp with
+// configurable niceness, CPU affinity, and memory caps, and measures the
+// CPU time and peak memory each invocation used.
+
+import (
+	"context"
+	"io"
+	"log"
+	"os/exec"
+	"sync"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// ResourceLimits constrains every external tool the service launches
+type ResourceLimits struct {
+	Nice        int    // scheduling priority adjustment (0 = unchanged)
+	CPUAffinity string // CPU list, e.g. "0-3" or "0,2" ("" = any CPU)
+	MemoryMB    int    // per-process memory cap (0 = unlimited)
+	// CgroupParent is a delegated cgroup v2 directory; when set, memory is
+	// capped via memory.max in a per-process child cgroup instead of rlimits
+	CgroupParent string
+}
+
+var (
+	limitsMu sync.RWMutex
+	limits   ResourceLimits
+)
+
+// SetResourceLimits configures limits for all subsequently started subprocesses
+func SetResourceLimits(l ResourceLimits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	limits = l
+	if l != (ResourceLimits{}) {
+		log.Printf("Subprocess limits: nice=%d cpus=%q memory=%dMB", l.Nice, l.CPUAffinity, l.MemoryMB)
+	}
+}
+
+func currentLimits() ResourceLimits {
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+	return limits
+}
+
+// onDevice returns the limits for a process running a model on device.
+// CUDA reserves far more address space than it ever uses, so prlimit --as
+// would keep it from initializing; there only a cgroup caps memory.
+func (l ResourceLimits) onDevice(device string) ResourceLimits {
+	if kind, _ := splitDevice(device); kind == "cuda" && l.CgroupParent == "" {
+		l.MemoryMB = 0
+	}
+	return l
+}
+
+// RunLimited runs a command under the configured limits and returns its
+// combined output along with the resources it consumed
+func RunLimited(name string, args ...string) ([]byte, types.ResourceUsage, error) {
+	return runLimited(exec.Command(name, args...))
+}
+
+// RunLimitedContext is RunLimited with cancellation; if progress is non-nil
+// it also receives the command's stdout as it is produced
+func RunLimitedContext(ctx context.Context, progress io.Writer, name string, args ...string) ([]byte, types.ResourceUsage, error) {
+	return runLimitedTee(exec.CommandContext(ctx, name, args...), progress)
+}
+
+// runLimited runs an already-built command (which may carry a context or
+// working directory) under the configured limits
+func runLimited(cmd *exec.Cmd) ([]byte, types.ResourceUsage, error) {
+	return runLimitedTee(cmd, nil)
+}
+
+// runLimitedTee is runLimited with stdout also copied to progress
+func runLimitedTee(cmd *exec.Cmd, progress io.Writer) ([]byte, types.ResourceUsage, error) {
+	return runOnDevice(cmd, "", progress)
+}
+
+// runOnDevice is runLimitedTee for a command that runs a model on device
+func runOnDevice(cmd *exec.Cmd, device string, progress io.Writer) ([]byte, types.ResourceUsage, error) {
+	l := currentLimits().onDevice(device)
+	wrapCommand(cmd, l)
+
+	var output safeBuffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if progress != nil {
+		cmd.Stdout = io.MultiWriter(&output, progress)
+	}
+
+	cleanup, err := startLimited(cmd, l)
+	if err != nil {
+		return nil, types.ResourceUsage{}, err
+	}
+	err = cmd.Wait()
+	cleanup()
+
+	return output.Bytes(), usageOf(cmd), err
+}
+
+// usageOf extracts CPU time and peak RSS from a finished command
+func usageOf(cmd *exec.Cmd) types.ResourceUsage {
+	if cmd.ProcessState == nil {
+		return types.ResourceUsage{}
+	}
+	return types.ResourceUsage{
+		CPUSeconds:   (cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()).Seconds(),
+		PeakMemoryMB: peakMemoryMB(cmd.ProcessState),
+	}
+}
+
+// safeBuffer collects stdout and stderr, which exec may write concurrently
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *safeBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf
+}
@@ -0,0 +1,100 @@
+//go:build linux
+
+package transcription
+
+// Linux enforcement of subprocess limits: nice/taskset/prlimit wrappers
+// applied before exec, or a per-process cgroup v2 for memory caps. The
+// process is started inside its cgroup (clone3, Linux 5.7+), so the cap
+// holds from its first allocation.
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/google/uuid"
+)
+
+// wrapCommand prefixes the command with nice, taskset, and prlimit as needed.
+// Each wrapper execs the next, so limits and rusage apply to the real tool.
+func wrapCommand(cmd *exec.Cmd, l ResourceLimits) {
+	argv := append([]string{cmd.Path}, cmd.Args[1:]...)
+
+	if l.MemoryMB > 0 && l.CgroupParent == "" {
+		bytes := strconv.FormatInt(int64(l.MemoryMB)*1024*1024, 10)
+		argv = append([]string{"prlimit", "--as=" + bytes, "--"}, argv...)
+	}
+	if l.CPUAffinity != "" {
+		argv = append([]string{"taskset", "-c", l.CPUAffinity}, argv...)
+	}
+	if l.Nice != 0 {
+		argv = append([]string{"nice", "-n", strconv.Itoa(l.Nice)}, argv...)
+	}
+
+	if len(argv) == len(cmd.Args) {
+		return
+	}
+
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		log.Printf("WARNING: %s not available, running %s without limits", argv[0], filepath.Base(cmd.Path))
+		return
+	}
+	cmd.Path = path
+	cmd.Args = argv
+}
+
+// startLimited starts the command, inside a fresh child cgroup with
+// memory.max set when memory is capped that way; the returned function
+// removes the cgroup once the process has exited
+func startLimited(cmd *exec.Cmd, l ResourceLimits) (func(), error) {
+	if l.CgroupParent == "" || l.MemoryMB <= 0 {
+		return func() {}, cmd.Start()
+	}
+
+	dir := filepath.Join(l.CgroupParent, "job-"+uuid.New().String())
+	if err := os.Mkdir(dir, 0755); err != nil {
+		log.Printf("WARNING: failed to create cgroup %s: %v", dir, err)
+		return func() {}, cmd.Start()
+	}
+	cleanup := func() {
+		if err := os.Remove(dir); err != nil {
+			log.Printf("WARNING: failed to remove cgroup %s: %v", dir, err)
+		}
+	}
+
+	limit := strconv.FormatInt(int64(l.MemoryMB)*1024*1024, 10)
+	if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(limit), 0644); err != nil {
+		log.Printf("WARNING: failed to set memory.max: %v", err)
+	}
+	group, err := os.Open(dir)
+	if err != nil {
+		log.Printf("WARNING: failed to open cgroup %s: %v", dir, err)
+		cleanup()
+		return func() {}, cmd.Start()
+	}
+	defer group.Close()
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(group.Fd())
+	if err := cmd.Start(); err != nil {
+		cleanup()
+		return func() {}, fmt.Errorf("failed to start in cgroup %s: %v", dir, err)
+	}
+	return cleanup, nil
+}
+
+// peakMemoryMB reads the maximum resident set size (reported in KB on Linux)
+func peakMemoryMB(state *os.ProcessState) float64 {
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return float64(rusage.Maxrss) / 1024
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+
+package transcription
+
+// Subprocess limits are only enforced on Linux; elsewhere commands run
+// unchanged and a warning is logged once if limits were configured.
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+var warnOnce sync.Once
+
+func wrapCommand(cmd *exec.Cmd, l ResourceLimits) {
+	if l != (ResourceLimits{}) {
+		warnOnce.Do(func() {
+			log.Println("WARNING: subprocess resource limits are only supported on Linux")
+		})
+	}
+}
+
+func startLimited(cmd *exec.Cmd, l ResourceLimits) (func(), error) {
+	return func() {}, cmd.Start()
+}
+
+func peakMemoryMB(state *os.ProcessState) float64 {
+	return 0
+}
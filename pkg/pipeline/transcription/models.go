@@ -0,0 +1,121 @@
+package transcription
+
+// Per-job model selection — a job may pick a whisper model size other than
+// the configured one, e.g. tiny for short voice memos and large for
+// interviews. The python CLI and the faster-whisper sidecar download and
+// cache models themselves; whisper.cpp models are ggml files, downloaded
+// next to the configured one on first use. Once loaded, a model stays
+// loaded until the server stops.
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WhisperModels are the model sizes a job may select
+var WhisperModels = []string{"tiny", "base", "small", "medium", "large"}
+
+// ggmlModelURL is where whisper.cpp's ggml models are downloaded from
+const ggmlModelURL = "https://huggingface.co/ggerganov/whisper.cpp/resolve/main/ggml-%s.bin"
+
+// CheckModel validates a model name ("" means the configured model)
+func CheckModel(model string) error {
+	if model != "" && !slices.Contains(WhisperModels, model) {
+		return fmt.Errorf("unknown model %q (available: %s)", model, strings.Join(WhisperModels, ", "))
+	}
+	return nil
+}
+
+// CanSelectModel reports whether jobs may pick the model; only the whisper
+// backends have model sizes
+func (wt *WhisperTranscriber) CanSelectModel() bool {
+	return wt.tunable()
+}
+
+// runModel is the model size a run with opts uses
+func (wt *WhisperTranscriber) runModel(opts DecodeOptions) string {
+	if opts.Model != "" {
+		return opts.Model
+	}
+	return wt.modelName
+}
+
+// cppModelFor returns the whisper.cpp model for a size other than the
+// configured one, downloading and loading it on first use. Callers hold
+// the transcriber lock.
+func (wt *WhisperTranscriber) cppModelFor(model string) (decoder, error) {
+	if loaded, ok := wt.cppModels[model]; ok {
+		return loaded, nil
+	}
+	path := ggmlModelPath(wt.modelPath, model)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := downloadGGMLModel(model, path); err != nil {
+			return nil, err
+		}
+	}
+	loaded, err := loadCppModel(path, wt.threads)
+	if err != nil {
+		return nil, err
+	}
+	if wt.cppModels == nil {
+		wt.cppModels = make(map[string]*cppModel)
+	}
+	wt.cppModels[model] = loaded
+	return loaded, nil
+}
+
+// ggmlModelPath is where the ggml file for a model size is kept: next to
+// the configured model, named like whisper.cpp's downloads
+func ggmlModelPath(configured, model string) string {
+	return filepath.Join(filepath.Dir(configured), "ggml-"+ggmlName(model)+".bin")
+}
+
+// ggmlName is the ggml release a model size stands for
+func ggmlName(model string) string {
+	if model == "large" {
+		return "large-v3"
+	}
+	return model
+}
+
+// downloadGGMLModel fetches a ggml model to path, writing to a temporary
+// file first so an interrupted download isn't mistaken for a model
+func downloadGGMLModel(model, path string) error {
+	url := fmt.Sprintf(ggmlModelURL, ggmlName(model))
+	log.Printf("Downloading whisper.cpp model %s from %s", model, url)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download ggml model %s: %v", model, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download ggml model %s: %s", model, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	partial := path + ".part"
+	f, err := os.Create(partial)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(partial)
+		return fmt.Errorf("failed to download ggml model %s: %v", model, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(partial)
+		return err
+	}
+	log.Printf("Downloaded whisper.cpp model %s to %s", model, path)
+	return os.Rename(partial, path)
+}
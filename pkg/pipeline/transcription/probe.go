@@ -0,0 +1,101 @@
+package transcription
+
+// Audio probing — inspects the container, codec, sample rate, channels,
+// and duration of an input file, natively for the formats decoders.go
+// reads and with ffprobe for the rest.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// AudioInfo describes the first audio stream of a file
+type AudioInfo struct {
+	FormatName string  `json:"format"`
+	Codec      string  `json:"codec"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+	Duration   float64 `json:"duration_seconds"`
+	BitRate    int64   `json:"bit_rate"`
+}
+
+// IsWhisperReady reports whether the audio already matches the normalized
+// format (16kHz mono 16-bit PCM WAV), so re-encoding can be skipped
+func (i *AudioInfo) IsWhisperReady() bool {
+	return i.FormatName == "wav" && i.Codec == "pcm_s16le" && i.SampleRate == 16000 && i.Channels == 1
+}
+
+// Source converts the probe result into the form recorded with a transcript
+func (i *AudioInfo) Source() *types.SourceAudio {
+	return &types.SourceAudio{
+		Format:     i.FormatName,
+		Codec:      i.Codec,
+		SampleRate: i.SampleRate,
+		Channels:   i.Channels,
+		Duration:   i.Duration,
+		BitRate:    i.BitRate,
+	}
+}
+
+// ffprobeOutput matches the subset of ffprobe's JSON output we read
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecName  string `json:"codec_name"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// ProbeAudio inspects a file, with ffprobe unless a native decoder reads
+// it; it fails if there is no audio stream
+func ProbeAudio(path string) (*AudioInfo, error) {
+	if info, err := probeNative(path); err == nil {
+		return info, nil
+	}
+	output, _, err := RunLimited("ffprobe",
+		"-v", "error",
+		"-select_streams", "a:0",
+		"-show_entries", "stream=codec_name,sample_rate,channels,duration,bit_rate:format=format_name,duration,bit_rate",
+		"-of", "json",
+		path,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %v\nOutput: %s", err, string(output))
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+	if len(probe.Streams) == 0 {
+		return nil, fmt.Errorf("no audio stream found")
+	}
+
+	stream := probe.Streams[0]
+	info := &AudioInfo{
+		FormatName: probe.Format.FormatName,
+		Codec:      stream.CodecName,
+		Channels:   stream.Channels,
+	}
+	info.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+
+	// Stream-level values are more precise but not always present
+	if info.Duration, err = strconv.ParseFloat(stream.Duration, 64); err != nil {
+		info.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	}
+	if info.BitRate, err = strconv.ParseInt(stream.BitRate, 10, 64); err != nil {
+		info.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	}
+
+	return info, nil
+}
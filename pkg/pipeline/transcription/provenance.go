@@ -0,0 +1,245 @@
+package transcription
+
+// Provenance — the backend, model, tool versions, and decoding options
+// each job is transcribed with, so a transcript can be reproduced later.
+// Versions are looked up once and model hashes are computed once per
+// model file, since both only change when the server is redeployed.
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// provenanceCache holds the lookups behind Provenance
+type provenanceCache struct {
+	packagesOnce sync.Once
+	packages     map[string]string // python package versions
+
+	mu     sync.Mutex
+	hashes map[string]modelHash // by model file path
+}
+
+// modelHash is a model file's hash, kept while the file is unchanged
+type modelHash struct {
+	size    int64
+	modTime time.Time
+	sha256  string
+}
+
+// backendPackages are the python packages each backend runs on
+var backendPackages = map[string][]string{
+	BackendPython:        {"openai-whisper", "torch"},
+	BackendFasterWhisper: {"faster-whisper", "ctranslate2"},
+}
+
+// packageVersionsScript prints a JSON object of the installed versions of
+// the packages named in its arguments, plus the interpreter's, as its last
+// line
+const packageVersionsScript = `
+import importlib.metadata as metadata, json, platform, sys
+versions = {"python": platform.python_version()}
+for name in sys.argv[1:]:
+    try:
+        versions[name] = metadata.version(name)
+    except metadata.PackageNotFoundError:
+        pass
+print(json.dumps(versions))
+`
+
+// Provenance describes how a run with opts is transcribed: the backend,
+// the model and its hash, and the versions of ffmpeg and the backend's
+// python packages
+func (wt *WhisperTranscriber) Provenance(opts DecodeOptions) *types.Provenance {
+	prov := &types.Provenance{
+		Backend:  wt.backend,
+		Model:    wt.modelName,
+		Versions: map[string]string{},
+		Decoding: types.DecodingOptions{
+			Language:      opts.language(),
+			Task:          opts.task(),
+			InitialPrompt: opts.InitialPrompt,
+			Vocabulary:    opts.Vocabulary,
+		},
+	}
+	if v := ToolVersion("ffmpeg"); v != "" {
+		prov.Versions["ffmpeg"] = v
+	}
+
+	switch {
+	case isCloudBackend(wt.backend):
+		prov.Model = "default"
+		if named, ok := wt.engine.(interface{ modelName() string }); ok && named.modelName() != "" {
+			prov.Model = named.modelName()
+		}
+		return prov
+	case wt.backend == BackendVosk:
+		language := opts.language()
+		if language == LanguageAuto {
+			language = wt.vosk.defaultLanguage()
+		}
+		prov.Model = wt.vosk.Models[language]
+		return prov
+	case wt.backend == BackendWhisperCpp:
+		prov.Model = wt.modelPath
+		if model := wt.runModel(opts); model != wt.modelName {
+			prov.Model = ggmlModelPath(wt.modelPath, model)
+		}
+		prov.ModelSHA256 = wt.modelSHA256(prov.Model)
+	case wt.backend == BackendFasterWhisper:
+		prov.Model = wt.fasterWhisperModel()
+		if model := wt.runModel(opts); model != wt.modelName {
+			prov.Model = model
+		}
+	default:
+		prov.Model = wt.runModel(opts)
+		prov.ModelSHA256 = wt.modelSHA256(whisperCacheFile(prov.Model))
+		prov.Decoding.ExtraArgs = wt.python.extraArgs
+	}
+
+	prov.Device = opts.Device
+	if prov.Device == "" {
+		prov.Device = wt.devices[0]
+	}
+	prov.Decoding.DecodingParams = withDecodingDefaults(opts.DecodingParams, wt.decoding)
+	prov.Decoding.Threads = wt.threads
+	prov.Decoding.RepetitionTemperatures = wt.repetitionTemperatures
+	for name, version := range wt.packageVersions() {
+		prov.Versions[name] = version
+	}
+	return prov
+}
+
+// packageVersions returns the versions of the backend's python packages,
+// looked up the first time it is called
+func (wt *WhisperTranscriber) packageVersions() map[string]string {
+	wt.provenance.packagesOnce.Do(func() {
+		packages, ok := backendPackages[wt.backend]
+		if !ok {
+			return
+		}
+		args := append([]string{"-c", packageVersionsScript}, packages...)
+		output, _, err := runLimited(wt.python.command(context.Background(), args...))
+		if err != nil {
+			log.Printf("Warning: failed to look up python package versions: %v", err)
+			return
+		}
+		lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+		if err := json.Unmarshal(lines[len(lines)-1], &wt.provenance.packages); err != nil {
+			log.Printf("Warning: unexpected package version output: %s", string(output))
+		}
+	})
+	return wt.provenance.packages
+}
+
+// modelSHA256 hashes a model file, reusing the hash while the file is
+// unchanged; "" when the file doesn't exist
+func (wt *WhisperTranscriber) modelSHA256(path string) string {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+
+	wt.provenance.mu.Lock()
+	defer wt.provenance.mu.Unlock()
+	if cached, ok := wt.provenance.hashes[path]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sha256
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		log.Printf("Warning: failed to hash model %s: %v", path, err)
+		return ""
+	}
+	if wt.provenance.hashes == nil {
+		wt.provenance.hashes = make(map[string]modelHash)
+	}
+	wt.provenance.hashes[path] = modelHash{size: info.Size(), modTime: info.ModTime(), sha256: sum}
+	return sum
+}
+
+// whisperCacheFile is where the whisper package keeps a downloaded model;
+// "large" and "turbo" are saved under the name of the release they alias
+func whisperCacheFile(model string) string {
+	switch model {
+	case "large":
+		model = "large-v3"
+	case "turbo":
+		model = "large-v3-turbo"
+	}
+	cache := os.Getenv("XDG_CACHE_HOME")
+	if cache == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		cache = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cache, "whisper", model+".pt")
+}
+
+// toolVersions caches ToolVersion lookups by tool name
+var toolVersions sync.Map
+
+// ToolVersion returns the installed version of a command-line tool such as
+// ffmpeg or yt-dlp, or "" when it can't be run; looked up once per tool
+func ToolVersion(name string) string {
+	if version, ok := toolVersions.Load(name); ok {
+		return version.(string)
+	}
+	version, err := lookupToolVersion(name)
+	if err != nil {
+		log.Printf("Warning: failed to look up the %s version: %v", name, err)
+	}
+	toolVersions.Store(name, version)
+	return version
+}
+
+// lookupToolVersion runs the tool's version flag and keeps the version
+// from the first line, e.g. "6.1.1" from "ffmpeg version 6.1.1 Copyright ..."
+func lookupToolVersion(name string) (string, error) {
+	flag := "--version"
+	if name == "ffmpeg" || name == "ffprobe" {
+		flag = "-version"
+	}
+	output, _, err := RunLimited(name, flag)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	fields := strings.Fields(line)
+	if len(fields) >= 3 && fields[1] == "version" {
+		return fields[2], nil
+	}
+	if len(fields) == 0 {
+		return "", fmt.Errorf("no version in output")
+	}
+	return fields[0], nil
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's contents
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
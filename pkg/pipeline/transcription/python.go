@@ -0,0 +1,138 @@
+package transcription
+
+// Python runtime — the interpreter, virtualenv, environment, and extra
+// whisper CLI flags used by the python and fasterwhisper backends. By
+// default they run "python" from PATH and expect whisper to be importable
+// from it.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/internal/secrets"
+)
+
+// PythonOptions configures how Python subprocesses are started
+type PythonOptions struct {
+	// Interpreter is the python executable, a path or a name looked up in
+	// PATH (default "python")
+	Interpreter string `yaml:"interpreter"`
+	// Virtualenv is a virtualenv directory; its interpreter is used (unless
+	// Interpreter is set) and it is activated for the subprocess
+	Virtualenv string `yaml:"virtualenv"`
+	// ExtraArgs are appended to every whisper CLI invocation, e.g.
+	// ["--beam_size", "5"]
+	ExtraArgs []string `yaml:"extra_args"`
+	// Env adds environment variables to the subprocess; values may be
+	// secret references (env:, file:, vault:)
+	Env map[string]string `yaml:"env"`
+}
+
+// reservedWhisperFlags are set by the transcriber and can't be overridden
+// with extra_args
+var reservedWhisperFlags = []string{"--model", "--output_dir", "--output_format", "--device"}
+
+// pythonRuntime is a resolved PythonOptions
+type pythonRuntime struct {
+	interpreter string
+	env         []string // added to the server's environment; nil inherits it as is
+	extraArgs   []string
+}
+
+// defaultPython runs "python" from PATH in the server's environment
+var defaultPython = pythonRuntime{interpreter: "python"}
+
+// SetPython configures the Python runtime, checking that a configured
+// interpreter or virtualenv exists; call it before SetBackend so the
+// fasterwhisper sidecar uses it
+func (wt *WhisperTranscriber) SetPython(opts PythonOptions) error {
+	py, err := resolvePython(opts)
+	if err != nil {
+		return err
+	}
+	wt.python = py
+	return nil
+}
+
+// resolvePython validates the options and resolves the interpreter and
+// environment
+func resolvePython(opts PythonOptions) (pythonRuntime, error) {
+	py := pythonRuntime{interpreter: opts.Interpreter, extraArgs: opts.ExtraArgs}
+
+	for _, arg := range opts.ExtraArgs {
+		flag, _, _ := strings.Cut(arg, "=")
+		if slices.Contains(reservedWhisperFlags, flag) {
+			return py, fmt.Errorf("python.extra_args may not set %s; it is chosen by the server", flag)
+		}
+	}
+
+	if opts.Virtualenv != "" {
+		venv, err := filepath.Abs(opts.Virtualenv)
+		if err != nil {
+			return py, err
+		}
+		bin := filepath.Join(venv, "bin")
+		if runtime.GOOS == "windows" {
+			bin = filepath.Join(venv, "Scripts")
+		}
+		if py.interpreter == "" {
+			py.interpreter = filepath.Join(bin, "python")
+			if runtime.GOOS == "windows" {
+				py.interpreter += ".exe"
+			}
+		}
+		py.env = append(py.env,
+			"VIRTUAL_ENV="+venv,
+			"PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	if py.interpreter == "" {
+		// Left to PATH at run time, as without any python config
+		py.interpreter = defaultPython.interpreter
+	} else {
+		path, err := exec.LookPath(py.interpreter)
+		if err != nil {
+			return py, fmt.Errorf("python interpreter %s not found: %v", py.interpreter, err)
+		}
+		py.interpreter = path
+	}
+
+	for name, ref := range opts.Env {
+		if name == "" || strings.ContainsAny(name, "=\x00") {
+			return py, fmt.Errorf("invalid python.env variable name %q", name)
+		}
+		value, err := secrets.Resolve(ref)
+		if err != nil {
+			return py, fmt.Errorf("python.env %s: %v", name, err)
+		}
+		py.env = append(py.env, name+"="+value)
+	}
+	return py, nil
+}
+
+// command builds a Python subprocess with the configured environment
+func (p pythonRuntime) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, p.interpreter, args...)
+	if p.env != nil {
+		// Later entries win, so these override the server's own
+		cmd.Env = append(os.Environ(), p.env...)
+	}
+	return cmd
+}
+
+// check reports an interpreter that has gone missing since startup
+func (p pythonRuntime) check() error {
+	if _, err := exec.LookPath(p.interpreter); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("%s not found in PATH", p.interpreter)
+		}
+		return err
+	}
+	return nil
+}
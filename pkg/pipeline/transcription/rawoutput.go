@@ -0,0 +1,37 @@
+package transcription
+
+// Raw output — whisper's JSON for a run, kept so a transcript can be
+// reformatted or post-processed again later without transcribing it anew.
+// The python backend's own JSON file is kept as written; other backends
+// and chunked runs get the same layout built from their segments.
+
+import (
+	"encoding/json"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// RawOutput returns a result's raw output: the JSON the backend wrote,
+// when it kept it, otherwise the result in whisper's JSON layout
+func RawOutput(result *types.TranscriptionResult) (json.RawMessage, error) {
+	if result.RawOutput != nil {
+		return result.RawOutput, nil
+	}
+	out := WhisperOutput{
+		Text:     result.Text,
+		Language: result.Language,
+		Segments: make([]WhisperSegment, len(result.Segments)),
+	}
+	for i, seg := range result.Segments {
+		out.Segments[i] = WhisperSegment{
+			ID:               i,
+			Start:            seg.Start,
+			End:              seg.End,
+			Text:             seg.Text,
+			Temperature:      seg.Temperature,
+			AvgLogprob:       seg.AvgLogprob,
+			CompressionRatio: seg.CompressionRatio,
+		}
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,257 @@
+package transcription
+
+// Repetition loops — a known whisper failure mode where the decoder gets
+// stuck emitting the same segment or phrase over and over. Loops are found
+// in the decoded segments, the affected stretch of audio is decoded again
+// at higher temperatures without conditioning on the looping text, and
+// regions that still loop are flagged on the result.
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Detection thresholds
+const (
+	// minRepeatedSegments consecutive identical segments make a loop
+	minRepeatedSegments = 3
+
+	// a phrase of up to maxLoopPhraseWords words repeated back to back at
+	// least minPhraseRepeats times, covering minLoopWords words, is a loop
+	maxLoopPhraseWords = 8
+	minPhraseRepeats   = 4
+	minLoopWords       = 12
+
+	// loopPadding (seconds) widens a re-decoded region so the decoder gets
+	// some context on either side
+	loopPadding = 1.0
+)
+
+// DefaultRepetitionTemperatures are tried in order when re-decoding a loop
+var DefaultRepetitionTemperatures = []float64{0.4, 0.8}
+
+// SetRepetitionTemperatures sets the temperatures tried when re-decoding a
+// repetition loop; an empty list only flags loops
+func (wt *WhisperTranscriber) SetRepetitionTemperatures(temperatures []float64) error {
+	for _, t := range temperatures {
+		if t <= 0 || t > 1 {
+			return fmt.Errorf("repetition retry temperature %g out of range (0, 1]", t)
+		}
+	}
+	wt.repetitionTemperatures = temperatures
+	return nil
+}
+
+// FindRepetitions returns the looping regions of a transcript, in order
+func FindRepetitions(segments []types.Segment) []types.RepetitionRegion {
+	var regions []types.RepetitionRegion
+
+	// Runs of identical segments
+	for i := 0; i < len(segments); {
+		key := normalizeLoopText(segments[i].Text)
+		j := i + 1
+		for j < len(segments) && key != "" && normalizeLoopText(segments[j].Text) == key {
+			j++
+		}
+		if j-i >= minRepeatedSegments {
+			regions = append(regions, types.RepetitionRegion{
+				Start:   segments[i].Start,
+				End:     segments[j-1].End,
+				Text:    segments[i].Text,
+				Repeats: j - i,
+			})
+		}
+		i = j
+	}
+
+	// Phrases looping inside a segment
+	for _, seg := range segments {
+		if phrase, repeats := repeatedPhrase(strings.Fields(normalizeLoopText(seg.Text))); repeats > 0 {
+			regions = mergeRegion(regions, types.RepetitionRegion{
+				Start:   seg.Start,
+				End:     seg.End,
+				Text:    phrase,
+				Repeats: repeats,
+			})
+		}
+	}
+	return regions
+}
+
+// normalizeLoopText lowercases text and strips punctuation so "Thank you."
+// and "thank you" compare equal
+func normalizeLoopText(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '\'' || r > 127)
+	}), " ")
+}
+
+// repeatedPhrase finds the longest back-to-back run of a short phrase in
+// words, returning it and its repeat count, or 0 repeats if none qualifies
+func repeatedPhrase(words []string) (string, int) {
+	bestPhrase, bestRepeats, bestCover := "", 0, 0
+	for n := 1; n <= maxLoopPhraseWords; n++ {
+		for start := 0; start+2*n <= len(words); start++ {
+			repeats := 1
+			for next := start + n; next+n <= len(words) && sameWords(words[start:start+n], words[next:next+n]); next += n {
+				repeats++
+			}
+			if repeats >= minPhraseRepeats && repeats*n >= minLoopWords && repeats*n > bestCover {
+				bestPhrase = strings.Join(words[start:start+n], " ")
+				bestRepeats, bestCover = repeats, repeats*n
+			}
+		}
+	}
+	return bestPhrase, bestRepeats
+}
+
+// sameWords compares two word slices of equal length
+func sameWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeRegion adds r to regions, folding it into an overlapping region
+func mergeRegion(regions []types.RepetitionRegion, r types.RepetitionRegion) []types.RepetitionRegion {
+	for i := range regions {
+		if r.Start <= regions[i].End && r.End >= regions[i].Start {
+			regions[i].Start = min(regions[i].Start, r.Start)
+			regions[i].End = max(regions[i].End, r.End)
+			return regions
+		}
+	}
+	for i := range regions {
+		if r.Start < regions[i].Start {
+			return append(regions[:i], append([]types.RepetitionRegion{r}, regions[i:]...)...)
+		}
+	}
+	return append(regions, r)
+}
+
+// RepairRepetitions re-decodes each looping region of result from audioPath
+// (the audio the result was decoded from) and splices in the first attempt
+// that no longer loops. Regions that keep looping are recorded in
+// result.Repetitions. Returns the resources the extra runs used.
+func (wt *WhisperTranscriber) RepairRepetitions(audioPath string, opts DecodeOptions, result *types.TranscriptionResult) types.ResourceUsage {
+	var usage types.ResourceUsage
+	regions := FindRepetitions(result.Segments)
+	if len(regions) == 0 {
+		return usage
+	}
+
+	// Backends without a temperature can only flag loops
+	temperatures := wt.repetitionTemperatures
+	if !wt.tunable() {
+		temperatures = nil
+	}
+
+	// Re-decode as the first pass did, in its language and task, but
+	// without earlier text or the job's prompt: either can feed a loop
+	base := opts
+	base.Language, base.Task = result.Language, result.Task
+	base.InitialPrompt, base.Vocabulary = "", nil
+	noContext := false
+	base.ConditionOnPreviousText = &noContext
+
+	var persistent []types.RepetitionRegion
+	for _, region := range regions {
+		log.Printf("Repetition loop at %.1fs-%.1fs (%q x%d)", region.Start, region.End, region.Text, region.Repeats)
+
+		repaired := false
+		for _, temperature := range temperatures {
+			run := base
+			run.Temperature = temperature
+			segments, runUsage, err := wt.redecode(audioPath, region, run)
+			usage.Add(runUsage)
+			if err != nil {
+				log.Printf("Re-decoding %.1fs-%.1fs at temperature %g failed: %v", region.Start, region.End, temperature, err)
+				continue
+			}
+			if len(FindRepetitions(segments)) > 0 {
+				continue
+			}
+			spliceSegments(result, region, segments)
+			log.Printf("Repetition loop at %.1fs-%.1fs resolved at temperature %g", region.Start, region.End, temperature)
+			repaired = true
+			break
+		}
+		if !repaired {
+			persistent = append(persistent, region)
+		}
+	}
+	result.Repetitions = persistent
+	return usage
+}
+
+// redecode transcribes the padded region with opts, returning its
+// segments on the original timeline
+func (wt *WhisperTranscriber) redecode(audioPath string, region types.RepetitionRegion, opts DecodeOptions) ([]types.Segment, types.ResourceUsage, error) {
+	start := max(region.Start-loopPadding, 0)
+	cutPath, usage, err := NormalizeAudio(audioPath, NormalizeOptions{
+		StartTime:  start,
+		EndTime:    region.End + loopPadding,
+		OutputPath: filepath.Join("temp", fmt.Sprintf("loop_%s.wav", uuid.New().String())),
+	})
+	if err != nil {
+		return nil, usage, err
+	}
+	defer os.Remove(cutPath)
+
+	redone, err := wt.transcribe(cutPath, opts, nil, nil)
+	if err != nil {
+		return nil, usage, err
+	}
+	usage.Add(redone.Resources)
+
+	for i := range redone.Segments {
+		redone.Segments[i].Start += start
+		redone.Segments[i].End += start
+	}
+	return redone.Segments, usage, nil
+}
+
+// spliceSegments replaces the segments inside region with the replacement
+// segments centred in it (the rest decode the padding, which neighbouring
+// segments already cover) and rebuilds the text and extra renderings
+func spliceSegments(result *types.TranscriptionResult, region types.RepetitionRegion, replacement []types.Segment) {
+	var inside []types.Segment
+	for _, seg := range replacement {
+		if mid := (seg.Start + seg.End) / 2; mid >= region.Start && mid <= region.End {
+			inside = append(inside, seg)
+		}
+	}
+
+	var segments []types.Segment
+	inserted := false
+	for _, seg := range result.Segments {
+		if seg.End > region.Start && seg.Start < region.End {
+			if !inserted {
+				segments = append(segments, inside...)
+				inserted = true
+			}
+			continue
+		}
+		segments = append(segments, seg)
+	}
+	result.Segments = segments
+
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		texts = append(texts, seg.Text)
+	}
+	result.Text = strings.Join(texts, " ")
+	for format := range result.Formats {
+		result.Formats[format] = RenderSegments(format, segments)
+	}
+}
@@ -0,0 +1,146 @@
+package transcription
+
+// Replacement dictionaries — whisper mishears product names and jargon the
+// same way every time ("acme gpt" for AcmeGPT), so a dictionary of fixes
+// is applied to each transcript after decoding. Matching ignores case and
+// the spacing between words, and only matches whole words; where terms
+// overlap, the longest wins, so the result never depends on map order.
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// replacementTerm is one dictionary entry
+type replacementTerm struct {
+	from, to string
+}
+
+// ReplacementDictionary rewrites misheard terms in transcripts
+type ReplacementDictionary struct {
+	terms   []replacementTerm
+	pattern *regexp.Regexp
+}
+
+// NewReplacementDictionary compiles dictionaries of heard term ->
+// replacement into one, a later dictionary's entry replacing an earlier
+// one's for the same term; nil when they are all empty
+func NewReplacementDictionary(dictionaries ...map[string]string) (*ReplacementDictionary, error) {
+	byKey := make(map[string]replacementTerm)
+	for _, entries := range dictionaries {
+		seen := make(map[string]string, len(entries))
+		for from, to := range entries {
+			key := strings.ToLower(strings.Join(strings.Fields(from), " "))
+			if key == "" {
+				return nil, fmt.Errorf("replacement for %q has an empty term", to)
+			}
+			if other, ok := seen[key]; ok {
+				return nil, fmt.Errorf("replacement terms %q and %q are the same", other, from)
+			}
+			seen[key] = from
+			byKey[key] = replacementTerm{from: from, to: to}
+		}
+	}
+	if len(byKey) == 0 {
+		return nil, nil
+	}
+	d := &ReplacementDictionary{terms: slices.Collect(maps.Values(byKey))}
+	// Longest first, so the longest of overlapping terms matches
+	slices.SortFunc(d.terms, func(a, b replacementTerm) int {
+		if n := cmp.Compare(utf8.RuneCountInString(b.from), utf8.RuneCountInString(a.from)); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.from, b.from)
+	})
+
+	alternatives := make([]string, len(d.terms))
+	for i, term := range d.terms {
+		alternatives[i] = "(" + termPattern(term.from) + ")"
+	}
+	var err error
+	if d.pattern, err = regexp.Compile("(?i)" + strings.Join(alternatives, "|")); err != nil {
+		return nil, fmt.Errorf("invalid replacement dictionary: %v", err)
+	}
+	return d, nil
+}
+
+// termPattern matches a term as whole words, with any spacing between them
+func termPattern(term string) string {
+	term = strings.TrimSpace(term)
+	words := strings.Fields(term)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	pattern := strings.Join(words, `\s+`)
+	if first, _ := utf8.DecodeRuneInString(term); isWordRune(first) {
+		pattern = `\b` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(term); isWordRune(last) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+// isWordRune reports whether \b treats r as part of a word
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// replace rewrites every term in text, adding to counts (by term index)
+// when counts is not nil
+func (d *ReplacementDictionary) replace(text string, counts []int) string {
+	matches := d.pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m[0]])
+		for i := range d.terms {
+			if m[2+2*i] >= 0 {
+				b.WriteString(d.terms[i].to)
+				if counts != nil {
+					counts[i]++
+				}
+				break
+			}
+		}
+		last = m[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Apply rewrites a result's text, segments, and extra renderings, and
+// records the terms replaced in result.Replacements. Counts come from the
+// segments, or from the text when there are none.
+func (d *ReplacementDictionary) Apply(result *types.TranscriptionResult) {
+	counts := make([]int, len(d.terms))
+	if len(result.Segments) == 0 {
+		result.Text = d.replace(result.Text, counts)
+	} else {
+		result.Text = d.replace(result.Text, nil)
+		for i := range result.Segments {
+			result.Segments[i].Text = d.replace(result.Segments[i].Text, counts)
+		}
+		for format := range result.Formats {
+			result.Formats[format] = RenderSegments(format, result.Segments)
+		}
+	}
+
+	result.Replacements = nil
+	for i, term := range d.terms {
+		if counts[i] > 0 {
+			result.Replacements = append(result.Replacements, types.Replacement{From: term.from, To: term.to, Count: counts[i]})
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package transcription
+
+// Synthetic audio samples — writes short 16kHz mono PCM WAV files used to
+// warm up the Whisper backend without shipping binary fixtures, and reads
+// normalized WAVs back as samples for in-process decoding.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+const sampleRate = 16000
+
+// WriteSilenceWAV writes a 16kHz mono 16-bit PCM WAV of the given length
+func WriteSilenceWAV(path string, seconds float64) error {
+	return writeWAV(path, make([]byte, int(seconds*sampleRate)*2))
+}
+
+// writeWAV writes 16-bit PCM samples as a 16kHz mono WAV
+func writeWAV(path string, pcm []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create sample: %v", err)
+	}
+	defer f.Close()
+
+	if err := writeWAVHeader(f, uint32(len(pcm))); err != nil {
+		return err
+	}
+	if _, err := f.Write(pcm); err != nil {
+		return fmt.Errorf("failed to write sample data: %v", err)
+	}
+	return f.Close()
+}
+
+// writeWAVHeader writes the header of a 16kHz mono 16-bit PCM WAV holding
+// dataSize bytes of samples
+func writeWAVHeader(w io.Writer, dataSize uint32) error {
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(36 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16),             // fmt chunk size
+		uint16(1),              // PCM
+		uint16(1),              // mono
+		uint32(sampleRate),     // sample rate
+		uint32(sampleRate * 2), // byte rate
+		uint16(2),              // block align
+		uint16(16),             // bits per sample
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+	for _, field := range header {
+		if err := binary.Write(w, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("failed to write WAV header: %v", err)
+		}
+	}
+	return nil
+}
+
+// readWAVSamples reads a 16kHz mono 16-bit PCM WAV (the normalized format)
+// as float32 samples in [-1, 1)
+func readWAVSamples(path string) ([]float32, error) {
+	pcm, err := readWAVPCM(path)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]float32, len(pcm)/2)
+	for i := range samples {
+		samples[i] = float32(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / 32768
+	}
+	return samples, nil
+}
+
+// readWAVPCM reads the 16-bit little-endian samples of a 16kHz mono PCM WAV
+func readWAVPCM(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%s is not a WAV file", path)
+	}
+
+	var format struct {
+		codec, channels, bits uint16
+		rate                  uint32
+	}
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8 : min(pos+8+size, len(data))]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, fmt.Errorf("%s: truncated fmt chunk", path)
+			}
+			format.codec = binary.LittleEndian.Uint16(body[0:2])
+			format.channels = binary.LittleEndian.Uint16(body[2:4])
+			format.rate = binary.LittleEndian.Uint32(body[4:8])
+			format.bits = binary.LittleEndian.Uint16(body[14:16])
+		case "data":
+			if format.codec != 1 || format.channels != 1 || format.bits != 16 || format.rate != sampleRate {
+				return nil, fmt.Errorf("%s: expected 16kHz mono 16-bit PCM, got codec %d, %d channels, %d bits, %dHz",
+					path, format.codec, format.channels, format.bits, format.rate)
+			}
+			return body[:len(body)/2*2], nil
+		}
+		pos += 8 + size + size%2 // chunks are word-aligned
+	}
+	return nil, fmt.Errorf("%s: no data chunk", path)
+}
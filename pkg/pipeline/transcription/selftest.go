@@ -0,0 +1,92 @@
+package transcription
+
+// Backend self-test — transcribes a short generated sample at startup so a
+// broken Python/Whisper install is reported immediately, with a hint on
+// how to fix it, instead of failing the first user job.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// selfTestSeconds is the length of the generated self-test sample
+const selfTestSeconds = 2
+
+// errSelfTestPending is reported by SelfTestStatus until the test has run
+var errSelfTestPending = errors.New("self-test has not finished yet")
+
+// selfTestState holds the outcome of the last self-test
+type selfTestState struct {
+	mu  sync.Mutex
+	ran bool
+	err error
+}
+
+// remediations map fragments of backend output to a suggested fix
+var remediations = []struct{ match, hint string }{
+	{"not found in PATH", "install Python 3 and make sure it is on PATH"},
+	{"executable file not found", "install Python 3 and make sure it is on PATH"},
+	{"No module named whisper", "install Whisper with: pip install -U openai-whisper"},
+	{"No module named 'torch'", "install PyTorch: https://pytorch.org/get-started/locally/"},
+	{"CUDA", `set whisper.device to "cpu" or install a CUDA-enabled PyTorch build`},
+	{"ffmpeg", "install ffmpeg; Whisper uses it to decode audio"},
+	{"No module named 'faster_whisper'", "install faster-whisper with: pip install -U faster-whisper"},
+	{"no whisper.cpp support", "rebuild with -tags whispercpp against libwhisper, or set whisper.backend to \"python\""},
+	{"ggml model not found", "set whisper.model_path to a ggml model file, e.g. ./models/ggml-small.bin"},
+	{"no vosk support", "rebuild with -tags vosk against libvosk, or set whisper.backend to \"python\""},
+	{"no vosk model configured", "set whisper.vosk.models to a model directory per language, from https://alphacephei.com/vosk/models"},
+	{"deepgram returned 401", "check whisper.deepgram.api_key; the key was rejected"},
+	{"/v2/upload returned 401", "check whisper.assemblyai.api_key; the key was rejected"},
+}
+
+// SelfTest transcribes a bundled silent sample end to end. The error names
+// a likely fix when the failure is recognised.
+func (wt *WhisperTranscriber) SelfTest() error {
+	err := wt.runSelfTest()
+
+	wt.selfTest.mu.Lock()
+	wt.selfTest.ran, wt.selfTest.err = true, err
+	wt.selfTest.mu.Unlock()
+	return err
+}
+
+// SelfTestStatus returns the outcome of the startup self-test, for /health
+func (wt *WhisperTranscriber) SelfTestStatus() error {
+	wt.selfTest.mu.Lock()
+	defer wt.selfTest.mu.Unlock()
+	if !wt.selfTest.ran {
+		return errSelfTestPending
+	}
+	return wt.selfTest.err
+}
+
+func (wt *WhisperTranscriber) runSelfTest() error {
+	if err := wt.CheckAvailable(); err != nil {
+		return withRemediation(err)
+	}
+
+	samplePath := filepath.Join("temp", "selftest_sample.wav")
+	if err := WriteSilenceWAV(samplePath, selfTestSeconds); err != nil {
+		return fmt.Errorf("failed to write self-test sample: %v", err)
+	}
+	defer os.Remove(samplePath)
+
+	if _, err := wt.Transcribe(samplePath); err != nil {
+		return withRemediation(err)
+	}
+	return nil
+}
+
+// withRemediation appends a suggested fix to a recognised backend failure
+func withRemediation(err error) error {
+	for _, r := range remediations {
+		if strings.Contains(err.Error(), r.match) {
+			return fmt.Errorf("%v\nRemediation: %s", err, r.hint)
+		}
+	}
+	return fmt.Errorf("%v\nRemediation: check the install with: python -m whisper --help", err)
+}
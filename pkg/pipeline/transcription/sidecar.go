@@ -0,0 +1,361 @@
+package transcription
+
+// Sidecars — long-running Python server processes that keep a model
+// loaded between jobs, used by the fasterwhisper backend (see
+// fasterwhisper.go) and by the python backend's persistent worker (see
+// whisper_worker.go). The server starts each one, restarts it with backoff
+// if it dies, and sends it one transcription per loopback connection as
+// newline-delimited JSON.
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+const (
+	// sidecarStartTimeout is how long a job waits for the sidecar to come
+	// up, which includes loading (or first downloading) the model
+	sidecarStartTimeout = 5 * time.Minute
+
+	// sidecarMaxBackoff caps the delay between restarts of a crashing sidecar
+	sidecarMaxBackoff = time.Minute
+
+	// sidecarErrorTail is how much of a dead sidecar's stderr is kept for
+	// the error reported to jobs
+	sidecarErrorTail = 2048
+)
+
+var (
+	errSidecarStopped = errors.New("sidecar is stopped")
+
+	// errSidecarDecode marks a transcription the sidecar ran and failed, as
+	// opposed to a sidecar that could not be reached or died mid-job
+	errSidecarDecode = errors.New("transcription failed")
+)
+
+// sidecar supervises a Python server process
+type sidecar struct {
+	name   string // for logs and errors, e.g. "faster-whisper"
+	python pythonRuntime
+	args   []string
+	device string // what the model runs on, for the memory cap
+
+	mu      sync.Mutex
+	addr    string // loopback address while the process is serving
+	lastErr error  // why the process last exited, until it serves again
+	cmd     *exec.Cmd
+	stopped bool
+	stop    chan struct{}
+}
+
+// startSidecar writes out a server script and starts supervising it with
+// the given arguments, running a model on device
+func startSidecar(name string, script []byte, python pythonRuntime, device string, args ...string) (*sidecar, error) {
+	path := filepath.Join("temp", strings.ReplaceAll(name, "-", "")+"_server.py")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, script, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s server: %v", name, err)
+	}
+
+	s := &sidecar{
+		name:   name,
+		python: python,
+		args:   append([]string{"-u", path}, args...),
+		device: device,
+		stop:   make(chan struct{}),
+	}
+	go s.supervise()
+	return s, nil
+}
+
+// supervise runs the process, restarting it whenever it exits
+func (s *sidecar) supervise() {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := s.run()
+		if s.isStopped() {
+			return
+		}
+
+		// A process that ran for a while earns a quick restart
+		if time.Since(started) > sidecarMaxBackoff {
+			backoff = time.Second
+		}
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+		log.Printf("%s sidecar exited (%v), restarting in %s", s.name, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-s.stop:
+			return
+		}
+		backoff = min(backoff*2, sidecarMaxBackoff)
+	}
+}
+
+// run starts the process and waits for it to exit
+func (s *sidecar) run() error {
+	cmd := s.python.command(context.Background(), s.args...)
+	l := currentLimits().onDevice(s.device)
+	wrapCommand(cmd, l)
+
+	// The sidecar exits when its stdin closes, so it can't outlive us
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	defer stdin.Close()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr := &tailBuffer{max: sidecarErrorTail}
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return errSidecarStopped
+	}
+	cleanup, err := startLimited(cmd, l)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.cmd = cmd
+	s.mu.Unlock()
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if port, ok := strings.CutPrefix(line, "LISTENING "); ok {
+			s.setAddr("127.0.0.1:" + port)
+			log.Printf("%s sidecar ready on port %s", s.name, port)
+			continue
+		}
+		log.Printf("%s: %s", s.name, line)
+	}
+
+	err = cmd.Wait()
+	cleanup()
+	s.setAddr("")
+	if tail := strings.TrimSpace(stderr.String()); tail != "" {
+		err = fmt.Errorf("%v: %s", err, tail)
+	}
+	return err
+}
+
+func (s *sidecar) setAddr(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addr = addr
+	if addr != "" {
+		s.lastErr = nil
+	}
+}
+
+func (s *sidecar) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped
+}
+
+// waitReady returns the sidecar's address once it is serving
+func (s *sidecar) waitReady(timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		s.mu.Lock()
+		addr := s.addr
+		s.mu.Unlock()
+		if addr != "" {
+			return addr, nil
+		}
+		// Stopped, or crashed and not back yet: fail now rather than wait
+		// out a broken install
+		if err := s.check(); err != nil {
+			return "", err
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("%s sidecar did not start within %s", s.name, timeout)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// check reports a sidecar that is stopped, or crashed and not yet back
+func (s *sidecar) check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.stopped:
+		return fmt.Errorf("%s %w", s.name, errSidecarStopped)
+	case s.lastErr != nil:
+		return fmt.Errorf("%s sidecar is down: %v", s.name, s.lastErr)
+	}
+	return nil
+}
+
+// Close stops the process and its supervision
+func (s *sidecar) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil
+	}
+	s.stopped = true
+	close(s.stop)
+	if s.cmd != nil && s.cmd.Process != nil {
+		return s.cmd.Process.Kill()
+	}
+	return nil
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		b.buf = b.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+// sidecarRequest is one transcription request
+type sidecarRequest struct {
+	Audio    string `json:"audio"`
+	Language string `json:"language"`
+	types.DecodingParams
+	Task          string `json:"task,omitempty"`
+	InitialPrompt string `json:"initial_prompt,omitempty"`
+	Model         string `json:"model,omitempty"` // empty for the sidecar's own model
+}
+
+// sidecarReply is a segment, or the final done/error line
+type sidecarReply struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+
+	// Temperature, AvgLogprob, and CompressionRatio are how the segment
+	// fared on the fallback ladder
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+
+	// Progress is how far (seconds) decoding got, from sidecars that
+	// send their segments only once done
+	Progress float64 `json:"progress"`
+
+	Done                bool    `json:"done"`
+	Language            string  `json:"language"`
+	LanguageProbability float64 `json:"language_probability"`
+	Duration            float64 `json:"duration"`
+	Error               string  `json:"error"`
+}
+
+// decode sends one file to the sidecar and collects its segments
+func (s *sidecar) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	absPath, err := filepath.Abs(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+	addr, err := s.waitReady(sidecarStartTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s sidecar: %v", s.name, err)
+	}
+	defer conn.Close()
+
+	request := sidecarRequest{Audio: absPath, Language: opts.language(), DecodingParams: opts.DecodingParams, Task: opts.Task,
+		InitialPrompt: opts.prompt(), Model: opts.Model}
+	if request.Language == LanguageAuto {
+		request.Language = "" // the sidecar detects when none is given
+	}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return nil, fmt.Errorf("failed to send request to %s sidecar: %v", s.name, err)
+	}
+
+	result := &types.TranscriptionResult{}
+	var texts []string
+	replies := json.NewDecoder(conn)
+	for {
+		var reply sidecarReply
+		if err := replies.Decode(&reply); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("%s sidecar connection failed: %v", s.name, err)
+		}
+		if reply.Error != "" {
+			return nil, fmt.Errorf("%s %w: %s", s.name, errSidecarDecode, reply.Error)
+		}
+		if reply.Progress > 0 {
+			if onSegment != nil {
+				onSegment(reply.Progress)
+			}
+			continue
+		}
+		if reply.Done {
+			result.Language, result.Duration = reply.Language, reply.Duration
+			if request.Language == "" {
+				// faster-whisper reports 1 for a language it was given
+				result.LanguageConfidence = reply.LanguageProbability
+			}
+			break
+		}
+
+		text := strings.TrimSpace(reply.Text)
+		result.Segments = append(result.Segments, types.Segment{Start: reply.Start, End: reply.End, Text: text,
+			Temperature: reply.Temperature, Fallback: fallbackLevel(reply.Temperature, opts.DecodingParams),
+			AvgLogprob: reply.AvgLogprob, CompressionRatio: reply.CompressionRatio})
+		texts = append(texts, text)
+		if onSegment != nil {
+			onSegment(reply.End)
+		}
+	}
+
+	result.Text = strings.Join(texts, " ")
+	for _, format := range formats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(result.Segments), result.Duration)
+	return result, nil
+}
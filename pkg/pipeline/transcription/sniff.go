@@ -0,0 +1,141 @@
+package transcription
+
+// Content sniffing — recognizes audio/video containers from their leading
+// bytes, so downloads can be rejected before they reach ffmpeg, and
+// detects a saved file's real format from its content (with a native
+// decoder or ffprobe when either reads it) rather than trusting its
+// extension.
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+)
+
+// SniffHeaderSize is how many leading bytes SniffAudioFormat looks at
+const SniffHeaderSize = 512
+
+// SniffAudioFormat names the audio/video container that header (the start
+// of a file) belongs to, or returns "" if it is not a recognized format
+func SniffAudioFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return "mp3"
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return "flac"
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return "ogg"
+	case len(header) >= 12 && bytes.HasPrefix(header, []byte("RIFF")) && string(header[8:12]) == "WAVE":
+		return "wav"
+	case len(header) >= 12 && bytes.HasPrefix(header, []byte("FORM")) && string(header[8:12]) == "AIFF":
+		return "aiff"
+	case len(header) >= 8 && string(header[4:8]) == "ftyp":
+		return "mp4"
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "webm"
+	case bytes.HasPrefix(header, []byte{0x30, 0x26, 0xB2, 0x75, 0x8E, 0x66, 0xCF, 0x11}):
+		return "asf"
+	case bytes.HasPrefix(header, []byte("#!AMR")):
+		return "amr"
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xF6 == 0xF0:
+		return "aac" // ADTS frame sync
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		return "mp3" // MPEG audio frame sync
+	}
+	return ""
+}
+
+// nonAudioSignatures name common file types that are never audio, so they
+// are rejected without probing
+var nonAudioSignatures = []struct {
+	magic []byte
+	kind  string
+}{
+	{[]byte("MZ"), "Windows executable"},
+	{[]byte("\x7fELF"), "ELF executable"},
+	{[]byte{0xCF, 0xFA, 0xED, 0xFE}, "Mach-O executable"},
+	{[]byte("#!"), "script"},
+	{[]byte("%PDF"), "PDF document"},
+	{[]byte("PK\x03\x04"), "ZIP archive"},
+	{[]byte{0x1F, 0x8B}, "gzip archive"},
+	{[]byte("7z\xBC\xAF\x27\x1C"), "7-Zip archive"},
+	{[]byte("Rar!"), "RAR archive"},
+	{[]byte("\x89PNG"), "PNG image"},
+	{[]byte{0xFF, 0xD8, 0xFF}, "JPEG image"},
+	{[]byte("GIF8"), "GIF image"},
+}
+
+// sniffNonAudio names the kind of non-audio file header starts, or ""
+func sniffNonAudio(header []byte) string {
+	for _, sig := range nonAudioSignatures {
+		if bytes.HasPrefix(header, sig.magic) {
+			return sig.kind
+		}
+	}
+	return ""
+}
+
+// NotAudioError reports a file whose content is not audio, whatever its
+// extension says
+type NotAudioError struct {
+	Reason string
+}
+
+func (e *NotAudioError) Error() string {
+	return e.Reason
+}
+
+// DetectAudioFormat identifies a file's real container from its content.
+// When a native decoder reads the file, or ffprobe is installed, the file
+// must also have a decodable audio stream, and the codec, duration, and so
+// on are filled in; otherwise magic bytes alone decide and only FormatName
+// is set. Content that is not audio
+// fails with a *NotAudioError.
+func DetectAudioFormat(path string) (*AudioInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, SniffHeaderSize)
+	n, err := io.ReadFull(f, header)
+	f.Close()
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	header = header[:n]
+
+	if n == 0 {
+		return nil, &NotAudioError{Reason: "file is empty"}
+	}
+	container := SniffAudioFormat(header)
+	if kind := sniffNonAudio(header); container == "" && kind != "" {
+		return nil, &NotAudioError{Reason: fmt.Sprintf("file is a %s, not audio", kind)}
+	}
+
+	if info, err := probeNative(path); err == nil {
+		return info, nil
+	}
+	if CheckFFprobe() == nil {
+		info, err := ProbeAudio(path)
+		if err != nil {
+			return nil, &NotAudioError{Reason: fmt.Sprintf("file has no decodable audio stream (detected %s)", http.DetectContentType(header))}
+		}
+		return info, nil
+	}
+
+	if container == "" {
+		return nil, &NotAudioError{Reason: fmt.Sprintf("file is not a recognized audio format (detected %s)", http.DetectContentType(header))}
+	}
+	return &AudioInfo{FormatName: container}, nil
+}
+
+// CheckFFprobe verifies ffprobe is installed
+func CheckFFprobe() error {
+	if _, err := exec.LookPath("ffprobe"); err != nil {
+		return fmt.Errorf("ffprobe not found in PATH")
+	}
+	return nil
+}
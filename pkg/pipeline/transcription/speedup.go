@@ -0,0 +1,53 @@
+package transcription
+
+// Audio speed-up — jobs that ask for it are played faster (ffmpeg's
+// atempo, which keeps the pitch) while they are normalized, so whisper has
+// less audio to decode. Long lectures come back much sooner for a little
+// accuracy. The timestamps whisper reports are in the sped-up audio and
+// are scaled back to the original.
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// MaxSpeedup is the fastest a job may be played; beyond it whisper's
+// accuracy falls off quickly
+const MaxSpeedup = 2.0
+
+// CheckSpeedup validates a speed-up factor; 0 and 1 leave the speed alone
+func CheckSpeedup(factor float64) error {
+	if factor != 0 && (factor < 1 || factor > MaxSpeedup) {
+		return fmt.Errorf("speedup must be between 1 and %g", MaxSpeedup)
+	}
+	return nil
+}
+
+// speedupFilter is the ffmpeg filter that plays audio factor times faster
+func speedupFilter(factor float64) string {
+	return "atempo=" + strconv.FormatFloat(factor, 'f', -1, 64)
+}
+
+// RestoreSpeed scales the timestamps of a result transcribed from audio
+// sped up by factor back to the original audio. Subtitle formats are
+// re-rendered from the scaled segments.
+func RestoreSpeed(result *types.TranscriptionResult, factor float64) {
+	if factor <= 1 {
+		return
+	}
+	for i := range result.Segments {
+		seg := &result.Segments[i]
+		seg.Start, seg.End = seg.Start*factor, seg.End*factor
+	}
+	for i := range result.Repetitions {
+		region := &result.Repetitions[i]
+		region.Start, region.End = region.Start*factor, region.End*factor
+	}
+	for format := range result.Formats {
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	result.Duration *= factor
+	result.Speedup = factor
+}
@@ -0,0 +1,158 @@
+package transcription
+
+// Subtitle handling — moves the cue times of whisper's srt, vtt, and tsv
+// renderings by a fixed offset (e.g. the start of a trimmed range), renders
+// segments in those formats when they have been edited after decoding or
+// labelled with speakers, and parses srt/vtt files back into segments for
+// imports.
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// cueTimestamp matches HH:MM:SS,mmm (srt) and [HH:]MM:SS.mmm (vtt)
+var cueTimestamp = regexp.MustCompile(`(?:(\d+):)?(\d{2}):(\d{2})([.,])(\d{3})`)
+
+// ShiftTimestamps adds offset seconds to every cue time in a rendering.
+// Unknown formats are returned unchanged.
+func ShiftTimestamps(format, content string, offset float64) string {
+	if offset == 0 {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		switch format {
+		case "srt", "vtt":
+			if strings.Contains(line, "-->") {
+				lines[i] = cueTimestamp.ReplaceAllStringFunc(line, func(ts string) string {
+					return shiftCueTimestamp(ts, offset)
+				})
+			}
+		case "tsv":
+			lines[i] = shiftTSVLine(line, offset)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cueMillis converts a cueTimestamp submatch to milliseconds
+func cueMillis(m []string) int64 {
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, _ := strconv.Atoi(m[3])
+	millis, _ := strconv.Atoi(m[5])
+	return int64(((hours*60+minutes)*60+seconds)*1000 + millis)
+}
+
+// shiftCueTimestamp shifts one srt/vtt timestamp, keeping its separator
+func shiftCueTimestamp(ts string, offset float64) string {
+	m := cueTimestamp.FindStringSubmatch(ts)
+	total := cueMillis(m) + int64(offset*1000+0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		total/3600000, total/60000%60, total/1000%60, m[4], total%1000)
+}
+
+// shiftTSVLine shifts the start/end millisecond columns of a tsv row,
+// leaving the header and malformed rows alone
+func shiftTSVLine(line string, offset float64) string {
+	cols := strings.SplitN(line, "\t", 3)
+	if len(cols) < 3 {
+		return line
+	}
+	start, err1 := strconv.ParseInt(cols[0], 10, 64)
+	end, err2 := strconv.ParseInt(cols[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return line
+	}
+	shift := int64(offset*1000 + 0.5)
+	return fmt.Sprintf("%d\t%d\t%s", start+shift, end+shift, cols[2])
+}
+
+// RenderSegments writes segments in one of types.OutputFormats, laid out
+// like whisper's own files. Unknown formats render as "".
+func RenderSegments(format string, segments []types.Segment) string {
+	var b strings.Builder
+	switch format {
+	case "srt":
+		for i, seg := range segments {
+			text := seg.Text
+			if seg.Speaker != "" {
+				text = types.SpeakerName(seg.Speaker) + ": " + strings.TrimSpace(text)
+			}
+			fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1,
+				cueClock(seg.Start, ","), cueClock(seg.End, ","), text)
+		}
+	case "vtt":
+		b.WriteString("WEBVTT\n\n")
+		for _, seg := range segments {
+			text := seg.Text
+			if seg.Speaker != "" {
+				// A voice span, which players can style per speaker
+				text = "<v " + types.SpeakerName(seg.Speaker) + ">" + strings.TrimSpace(text)
+			}
+			fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+				cueClock(seg.Start, "."), cueClock(seg.End, "."), text)
+		}
+	case "tsv":
+		b.WriteString("start\tend\ttext\n")
+		for _, seg := range segments {
+			fmt.Fprintf(&b, "%d\t%d\t%s\n",
+				int64(seg.Start*1000+0.5), int64(seg.End*1000+0.5), seg.Text)
+		}
+	}
+	return b.String()
+}
+
+// cueClock formats seconds as HH:MM:SS<sep>mmm
+func cueClock(seconds float64, sep string) string {
+	total := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		total/3600000, total/60000%60, total/1000%60, sep, total%1000)
+}
+
+// voiceSpan matches the vtt voice span starting a cue, "<v Alice>" or
+// "<v.loud Alice>"
+var voiceSpan = regexp.MustCompile(`^<v(?:\.[^ >]*)? ([^>]+)>`)
+
+// ParseSubtitles reads the cues of an srt or vtt file as segments; a vtt
+// voice span gives the segment its speaker
+func ParseSubtitles(content string) ([]types.Segment, error) {
+	content = strings.ReplaceAll(strings.TrimPrefix(content, "\ufeff"), "\r\n", "\n")
+
+	var segments []types.Segment
+	var current *types.Segment
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, "-->"):
+			times := cueTimestamp.FindAllStringSubmatch(line, 2)
+			if len(times) < 2 {
+				return nil, fmt.Errorf("malformed cue timing %q", line)
+			}
+			segments = append(segments, types.Segment{
+				Start: float64(cueMillis(times[0])) / 1000,
+				End:   float64(cueMillis(times[1])) / 1000,
+			})
+			current = &segments[len(segments)-1]
+		case line == "":
+			current = nil
+		case current != nil:
+			if m := voiceSpan.FindStringSubmatch(line); m != nil && current.Text == "" {
+				current.Speaker = strings.TrimSpace(m[1])
+				line = strings.TrimSuffix(line[len(m[0]):], "</v>")
+			}
+			current.Text = strings.TrimSpace(current.Text + " " + line)
+		}
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no subtitle cues found")
+	}
+	return segments, nil
+}
@@ -0,0 +1,326 @@
+package transcription
+
+// Silence removal — an optional voice activity detection pass after
+// normalization cuts long pauses out of the audio, so sparse recordings
+// (a meeting room left running, a day of radio traffic) decode in a
+// fraction of the time. The speech kept is recorded in a speech map next
+// to the normalized WAV, and segment timestamps are mapped back through it
+// onto the original recording once the audio is transcribed. Detection
+// runs vad.py with webrtcvad or silero in the configured python runtime.
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+//go:embed vad.py
+var vadScript []byte
+
+// VAD backends
+const (
+	VADWebRTC = "webrtcvad"
+	VADSilero = "silero"
+)
+
+// VAD defaults
+const (
+	defaultVADMinSilence = 2
+	defaultVADPadding    = 0.3
+	defaultVADThreshold  = 0.5
+)
+
+// VADOptions configures silence removal
+type VADOptions struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend is the detector, "webrtcvad" (default) or "silero"
+	Backend string `yaml:"backend"`
+	// MinSilenceSeconds is the shortest pause that is removed (default 2)
+	MinSilenceSeconds float64 `yaml:"min_silence_seconds"`
+	// PaddingSeconds of audio are kept either side of speech so words are
+	// not clipped (default 0.3)
+	PaddingSeconds float64 `yaml:"padding_seconds"`
+	// Aggressiveness is webrtcvad's filtering mode, from 0 (default, keeps
+	// the most audio) to 3
+	Aggressiveness int `yaml:"aggressiveness"`
+	// Threshold is the speech probability silero needs (default 0.5)
+	Threshold float64 `yaml:"threshold"`
+
+	python pythonRuntime
+}
+
+// SetVAD configures silence removal
+func (wt *WhisperTranscriber) SetVAD(opts VADOptions) error {
+	if opts.Backend == "" {
+		opts.Backend = VADWebRTC
+	}
+	if opts.Backend != VADWebRTC && opts.Backend != VADSilero {
+		return fmt.Errorf("unknown vad backend %q (want %s or %s)", opts.Backend, VADWebRTC, VADSilero)
+	}
+	if opts.MinSilenceSeconds < 0 || opts.PaddingSeconds < 0 {
+		return fmt.Errorf("vad settings must not be negative")
+	}
+	if opts.MinSilenceSeconds == 0 {
+		opts.MinSilenceSeconds = defaultVADMinSilence
+	}
+	if opts.PaddingSeconds == 0 {
+		opts.PaddingSeconds = defaultVADPadding
+	}
+	if opts.PaddingSeconds*2 >= opts.MinSilenceSeconds {
+		return fmt.Errorf("vad padding_seconds must be under half of min_silence_seconds")
+	}
+	if opts.Aggressiveness < 0 || opts.Aggressiveness > 3 {
+		return fmt.Errorf("vad aggressiveness must be 0-3")
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = defaultVADThreshold
+	}
+	if opts.Threshold < 0 || opts.Threshold >= 1 {
+		return fmt.Errorf("vad threshold must be between 0 and 1")
+	}
+	wt.vad = opts
+	return nil
+}
+
+// VAD returns the silence removal settings to normalize with; nil when it
+// is off
+func (wt *WhisperTranscriber) VAD() *VADOptions {
+	if !wt.vad.Enabled {
+		return nil
+	}
+	opts := wt.vad
+	opts.python = wt.python
+	return &opts
+}
+
+// speechRange is a stretch of audio, in seconds
+type speechRange struct {
+	Start, End float64
+}
+
+// detect runs the detector on a normalized WAV, returning the stretches
+// that contain speech
+func (o VADOptions) detect(wavPath string) ([]speechRange, types.ResourceUsage, error) {
+	script, err := os.CreateTemp("temp", "vad_*.py")
+	if err != nil {
+		return nil, types.ResourceUsage{}, err
+	}
+	defer os.Remove(script.Name())
+	_, err = script.Write(vadScript)
+	if closeErr := script.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, types.ResourceUsage{}, fmt.Errorf("failed to write vad script: %v", err)
+	}
+
+	python := o.python
+	if python.interpreter == "" {
+		python = defaultPython
+	}
+	output, usage, err := runLimited(python.command(context.Background(), script.Name(), wavPath,
+		"--backend", o.Backend,
+		"--aggressiveness", strconv.Itoa(o.Aggressiveness),
+		"--threshold", strconv.FormatFloat(o.Threshold, 'f', -1, 64),
+	))
+	if err != nil {
+		return nil, usage, fmt.Errorf("%s failed: %v\nOutput: %s", o.Backend, err, string(output))
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if encoded, ok := strings.CutPrefix(strings.TrimSpace(line), "SPEECH "); ok {
+			var spans [][2]float64
+			if err := json.Unmarshal([]byte(encoded), &spans); err != nil {
+				return nil, usage, fmt.Errorf("unreadable %s output: %v", o.Backend, err)
+			}
+			speech := make([]speechRange, len(spans))
+			for i, span := range spans {
+				speech[i] = speechRange{Start: span[0], End: span[1]}
+			}
+			return speech, usage, nil
+		}
+	}
+	return nil, usage, fmt.Errorf("%s printed no speech", o.Backend)
+}
+
+// removeSilence cuts the long pauses out of the normalized WAV at wavPath,
+// writing the condensed audio and its speech map to outputPath. It returns
+// the path to transcribe: wavPath itself when there is nothing to remove,
+// or when detection fails, so the audio is transcribed whole.
+func removeSilence(wavPath, outputPath string, opts VADOptions) (string, types.ResourceUsage, error) {
+	speech, usage, err := opts.detect(wavPath)
+	if err != nil {
+		log.Printf("Warning: voice activity detection failed, transcribing all of %s: %v", filepath.Base(wavPath), err)
+		return wavPath, usage, nil
+	}
+	pcm, err := readWAVPCM(wavPath)
+	if err != nil {
+		return "", usage, err
+	}
+
+	duration := float64(len(pcm)/2) / sampleRate
+	m := planSpeech(speech, duration, opts)
+	if m == nil {
+		return wavPath, usage, nil
+	}
+
+	condensed := make([]byte, 0, int(m.Condensed()*sampleRate)*2)
+	for _, span := range m.Spans {
+		condensed = append(condensed, pcm[sampleOffset(span.Start):sampleOffset(span.End)]...)
+	}
+	if err := writeWAV(outputPath, condensed); err != nil {
+		return "", usage, err
+	}
+	if err := m.save(SpeechMapPath(outputPath)); err != nil {
+		os.Remove(outputPath)
+		return "", usage, err
+	}
+	log.Printf("Removed %.0fs of silence from %s (%.0fs of speech kept)",
+		duration-m.Condensed(), filepath.Base(wavPath), m.Condensed())
+	return outputPath, usage, nil
+}
+
+// sampleOffset is the byte offset of a time in 16-bit 16kHz PCM
+func sampleOffset(seconds float64) int {
+	return int(seconds*sampleRate+0.5) * 2
+}
+
+// planSpeech pads the detected speech and keeps everything but the pauses
+// of at least opts.MinSilenceSeconds between it; nil when no such pause is
+// found, or no speech at all
+func planSpeech(speech []speechRange, duration float64, opts VADOptions) *SpeechMap {
+	var kept []speechRange
+	for _, s := range speech {
+		start := max(s.Start-opts.PaddingSeconds, 0)
+		end := min(s.End+opts.PaddingSeconds, duration)
+		if end <= start {
+			continue
+		}
+		if n := len(kept); n > 0 && start-kept[n-1].End < opts.MinSilenceSeconds {
+			kept[n-1].End = max(kept[n-1].End, end)
+			continue
+		}
+		kept = append(kept, speechRange{Start: start, End: end})
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	// Short pauses at either end are kept too
+	if kept[0].Start < opts.MinSilenceSeconds {
+		kept[0].Start = 0
+	}
+	if n := len(kept); duration-kept[n-1].End < opts.MinSilenceSeconds {
+		kept[n-1].End = duration
+	}
+	if len(kept) == 1 && kept[0].Start == 0 && kept[0].End == duration {
+		return nil
+	}
+
+	// Align to samples, so offsets match the condensed audio exactly
+	m := &SpeechMap{Duration: duration}
+	offset := 0
+	for _, k := range kept {
+		start, end := sampleOffset(k.Start)/2, sampleOffset(k.End)/2
+		m.Spans = append(m.Spans, SpeechSpan{
+			Start:  float64(start) / sampleRate,
+			End:    float64(end) / sampleRate,
+			Offset: float64(offset) / sampleRate,
+		})
+		offset += end - start
+	}
+	return m
+}
+
+// SpeechSpan is a stretch of the original audio kept by silence removal
+type SpeechSpan struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	// Offset is where the span starts in the condensed audio
+	Offset float64 `json:"offset"`
+}
+
+// SpeechMap maps times in audio condensed by silence removal back to the
+// audio it was cut from
+type SpeechMap struct {
+	Spans []SpeechSpan `json:"spans"`
+	// Duration is the length of the audio before silence removal
+	Duration float64 `json:"duration"`
+}
+
+// SpeechMapPath is where the speech map of a condensed WAV is kept
+func SpeechMapPath(wavPath string) string {
+	return wavPath + ".speech.json"
+}
+
+// LoadSpeechMap reads the speech map of a normalized WAV; nil, with no
+// error, when no silence was removed from it
+func LoadSpeechMap(wavPath string) (*SpeechMap, error) {
+	data, err := os.ReadFile(SpeechMapPath(wavPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m SpeechMap
+	if err := json.Unmarshal(data, &m); err != nil || len(m.Spans) == 0 {
+		return nil, fmt.Errorf("corrupt speech map for %s", filepath.Base(wavPath))
+	}
+	return &m, nil
+}
+
+func (m *SpeechMap) save(path string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write speech map: %v", err)
+	}
+	return nil
+}
+
+// Condensed is the length of the condensed audio
+func (m *SpeechMap) Condensed() float64 {
+	last := m.Spans[len(m.Spans)-1]
+	return last.Offset + last.End - last.Start
+}
+
+// Original maps a time in the condensed audio back to the original. A time
+// on a cut belongs to the span after it, or with end set to the one before,
+// so a segment never stretches over the pause removed there.
+func (m *SpeechMap) Original(t float64, end bool) float64 {
+	span := m.Spans[0]
+	for _, s := range m.Spans[1:] {
+		if s.Offset > t || end && s.Offset == t {
+			break
+		}
+		span = s
+	}
+	return min(span.Start+max(t-span.Offset, 0), span.End)
+}
+
+// Restore moves a result transcribed from the condensed audio onto the
+// original timeline, re-rendering its subtitle formats
+func (m *SpeechMap) Restore(result *types.TranscriptionResult) {
+	for i := range result.Segments {
+		seg := &result.Segments[i]
+		seg.Start, seg.End = m.Original(seg.Start, false), m.Original(seg.End, true)
+	}
+	for i := range result.Repetitions {
+		region := &result.Repetitions[i]
+		region.Start, region.End = m.Original(region.Start, false), m.Original(region.End, true)
+	}
+	for format := range result.Formats {
+		result.Formats[format] = RenderSegments(format, result.Segments)
+	}
+	result.Duration = m.Duration
+}
@@ -0,0 +1,70 @@
+"""Voice activity detection for the transcription server.
+
+Reads a 16kHz mono 16-bit WAV (the normalized format) and prints the
+stretches that contain speech as one line, "SPEECH [[start, end], ...]",
+in seconds. Libraries may log to the same output, so the server only
+reads that line.
+"""
+
+import argparse
+import json
+import sys
+import wave
+
+
+def webrtc_speech(path, aggressiveness):
+    import webrtcvad
+
+    vad = webrtcvad.Vad(aggressiveness)
+    with wave.open(path, "rb") as audio:
+        rate = audio.getframerate()
+        pcm = audio.readframes(audio.getnframes())
+
+    # webrtcvad takes 10, 20 or 30 ms frames of 16-bit samples
+    frame = int(rate * 0.03) * 2
+    spans, start = [], None
+    for pos in range(0, len(pcm) - frame + 1, frame):
+        t = pos / 2 / rate
+        if vad.is_speech(pcm[pos:pos + frame], rate):
+            if start is None:
+                start = t
+        elif start is not None:
+            spans.append([start, t])
+            start = None
+    if start is not None:
+        spans.append([start, len(pcm) / 2 / rate])
+    return spans
+
+
+def silero_speech(path, threshold):
+    try:
+        from silero_vad import get_speech_timestamps, load_silero_vad, read_audio
+        model = load_silero_vad()
+    except ImportError:
+        import torch
+        model, utils = torch.hub.load("snakers4/silero-vad", "silero_vad", trust_repo=True)
+        get_speech_timestamps, read_audio = utils[0], utils[2]
+
+    audio = read_audio(path, sampling_rate=16000)
+    stamps = get_speech_timestamps(audio, model, sampling_rate=16000, threshold=threshold)
+    return [[s["start"] / 16000, s["end"] / 16000] for s in stamps]
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument("audio")
+    parser.add_argument("--backend", default="webrtcvad", choices=["webrtcvad", "silero"])
+    parser.add_argument("--aggressiveness", type=int, default=0)
+    parser.add_argument("--threshold", type=float, default=0.5)
+    args = parser.parse_args()
+
+    if args.backend == "silero":
+        spans = silero_speech(args.audio, args.threshold)
+    else:
+        spans = webrtc_speech(args.audio, args.aggressiveness)
+    print("SPEECH " + json.dumps(spans))
+    sys.stdout.flush()
+
+
+if __name__ == "__main__":
+    main()
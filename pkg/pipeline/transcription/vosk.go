@@ -0,0 +1,90 @@
+package transcription
+
+// Vosk backend options — the small Kaldi models Vosk runs are a fraction
+// of whisper-tiny's size and decode in real time on ARM boards. Each
+// language has its own model directory. The recognizer itself needs
+// libvosk and a -tags vosk build (see vosk_engine.go).
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// VoskOptions configures the Vosk backend
+type VoskOptions struct {
+	// Models maps a language code to its model directory, e.g.
+	// {"en": "./models/vosk-model-small-en-us-0.15"}
+	Models map[string]string `yaml:"models"`
+	// Language picks the model for jobs that don't choose a language
+	// (default "en"); it is loaded at startup, others on first use
+	Language string `yaml:"language"`
+}
+
+// SetVosk configures the Vosk backend; call it before
+// SetBackend(BackendVosk)
+func (wt *WhisperTranscriber) SetVosk(opts VoskOptions) {
+	wt.vosk = opts
+}
+
+// defaultLanguage is the language of jobs that don't choose one
+func (o VoskOptions) defaultLanguage() string {
+	if o.Language == "" {
+		return "en"
+	}
+	return strings.ToLower(o.Language)
+}
+
+// modelDir returns the model directory for language, checking that it
+// exists
+func (o VoskOptions) modelDir(language string) (string, error) {
+	if language == LanguageAuto {
+		return "", fmt.Errorf("vosk can't detect the language; choose one of its models' languages")
+	}
+	dir, ok := o.Models[language]
+	if !ok || dir == "" {
+		return "", fmt.Errorf("no vosk model configured for language %q (set whisper.vosk.models.%s)", language, language)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("vosk model directory %s not found", dir)
+	}
+	return dir, nil
+}
+
+// voskResult is one utterance as returned by a recognizer with word
+// timings enabled
+type voskResult struct {
+	Text   string `json:"text"`
+	Result []struct {
+		Word  string  `json:"word"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Conf  float64 `json:"conf"`
+	} `json:"result"`
+}
+
+// parseVoskResult turns a recognizer result into a segment; ok is false
+// for an utterance with no words (silence)
+func parseVoskResult(raw string) (types.Segment, bool, error) {
+	var r voskResult
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		return types.Segment{}, false, fmt.Errorf("failed to parse vosk result: %v", err)
+	}
+	if len(r.Result) == 0 || strings.TrimSpace(r.Text) == "" {
+		return types.Segment{}, false, nil
+	}
+
+	var confidence float64
+	for _, w := range r.Result {
+		confidence += w.Conf
+	}
+	return types.Segment{
+		Start:      r.Result[0].Start,
+		End:        r.Result[len(r.Result)-1].End,
+		Text:       strings.TrimSpace(r.Text),
+		Confidence: confidence / float64(len(r.Result)),
+	}, true, nil
+}
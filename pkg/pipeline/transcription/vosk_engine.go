@@ -0,0 +1,145 @@
+//go:build vosk
+
+package transcription
+
+// Vosk recognizer — decodes in-process through the Vosk Go bindings.
+// Built with -tags vosk against libvosk (see README).
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	vosk "github.com/alphacep/vosk-api/go"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// voskChunkSamples is how much audio is fed to the recognizer at a time
+// (250ms), which is also how often progress is reported
+const voskChunkSamples = sampleRate / 4
+
+// voskModel holds the Vosk models loaded so far, one per language, and
+// shares them between runs
+type voskModel struct {
+	opts     VoskOptions
+	language string // the default language
+	models   map[string]*vosk.VoskModel
+}
+
+// loadVoskModel loads the model for the default language
+func loadVoskModel(opts VoskOptions) (*voskModel, error) {
+	vosk.SetLogLevel(-1) // Kaldi logs every decoder step otherwise
+	m := &voskModel{opts: opts, language: opts.defaultLanguage(), models: make(map[string]*vosk.VoskModel)}
+	if _, err := m.model(m.language); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// model returns the model for language, loading it on first use
+func (m *voskModel) model(language string) (*vosk.VoskModel, error) {
+	if model, ok := m.models[language]; ok {
+		return model, nil
+	}
+	dir, err := m.opts.modelDir(language)
+	if err != nil {
+		return nil, err
+	}
+	model, err := vosk.NewModel(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vosk model %s: %v", dir, err)
+	}
+	log.Printf("Loaded vosk model %s (%s)", dir, language)
+	m.models[language] = model
+	return model, nil
+}
+
+// Close frees the models
+func (m *voskModel) Close() error {
+	for _, model := range m.models {
+		model.Free()
+	}
+	return nil
+}
+
+// modelName reports the default language, since Vosk models are picked
+// by it
+func (m *voskModel) modelName() string {
+	return m.language
+}
+
+// decode transcribes a normalized WAV with the model for opts.Language.
+// Vosk has no sampling temperature, so the other options are ignored.
+// Callers hold the transcriber lock, which guards the model map.
+func (m *voskModel) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	language := m.language
+	if opts.Language != "" {
+		language = strings.ToLower(opts.Language)
+	}
+	model, err := m.model(language)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := readWAVSamples(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("vosk needs normalized audio: %v", err)
+	}
+
+	rec, err := vosk.NewRecognizer(model, sampleRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vosk recognizer: %v", err)
+	}
+	defer rec.Free()
+	rec.SetWords(1)
+
+	var segments []types.Segment
+	var texts []string
+	collect := func(raw string) error {
+		seg, ok, err := parseVoskResult(raw)
+		if err != nil || !ok {
+			return err
+		}
+		segments = append(segments, seg)
+		texts = append(texts, seg.Text)
+		return nil
+	}
+
+	// The recognizer takes 16-bit PCM, which the samples were read from
+	buf := make([]byte, 2*voskChunkSamples)
+	for start := 0; start < len(samples); start += voskChunkSamples {
+		chunk := samples[start:min(start+voskChunkSamples, len(samples))]
+		for i, s := range chunk {
+			binary.LittleEndian.PutUint16(buf[2*i:], uint16(int16(math.Round(float64(s)*32768))))
+		}
+		if rec.AcceptWaveform(buf[:2*len(chunk)]) == 1 {
+			if err := collect(rec.Result()); err != nil {
+				return nil, err
+			}
+		}
+		if onSegment != nil {
+			onSegment(float64(start+len(chunk)) / sampleRate)
+		}
+	}
+	if err := collect(rec.FinalResult()); err != nil {
+		return nil, err
+	}
+
+	result := &types.TranscriptionResult{
+		Text:     strings.Join(texts, " "),
+		Language: language,
+		Duration: float64(len(samples)) / sampleRate,
+		Segments: segments,
+	}
+	for _, format := range formats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, segments)
+	}
+
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(segments), result.Duration)
+	return result, nil
+}
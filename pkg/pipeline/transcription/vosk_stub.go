@@ -0,0 +1,28 @@
+//go:build !vosk
+
+package transcription
+
+// Builds without the vosk tag have no Vosk recognizer; choosing the
+// backend fails at startup with a hint on how to rebuild.
+
+import (
+	"errors"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+var errNoVosk = errors.New("this build has no vosk support")
+
+type voskModel struct{}
+
+func loadVoskModel(opts VoskOptions) (*voskModel, error) {
+	return nil, errNoVosk
+}
+
+func (m *voskModel) Close() error {
+	return nil
+}
+
+func (m *voskModel) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	return nil, errNoVosk
+}
@@ -0,0 +1,860 @@
+package transcription
+
+// Whisper integration — invokes OpenAI Whisper via Python CLI with
+// configurable model size and CUDA GPU device selection, or through another
+// backend: whisper.cpp or Vosk in-process (see whispercpp.go and vosk.go),
+// a persistent faster-whisper sidecar (see fasterwhisper.go), or a cloud
+// API (see deepgram.go, assemblyai.go, googlestt.go, and awstranscribe.go).
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// WhisperTranscriber wraps Python's OpenAI Whisper for transcription
+type WhisperTranscriber struct {
+	modelName string
+	modelPath string
+	python    pythonRuntime // see SetPython
+	device    string
+	threads   int
+	mu        sync.Mutex // serializes runs on backends without devices
+
+	// devices are where the python and fasterwhisper backends run, each
+	// with a slot per concurrent run (see devices.go)
+	devices     []string
+	deviceSlots map[string]chan struct{}
+
+	// backend names the selected backend; engine runs it, nil meaning the
+	// Python Whisper CLI (see SetBackend)
+	backend string
+	engine  decoder
+
+	// worker is the python backend's persistent whisper processes, one per
+	// device; nil when each run starts the CLI (see whisper_worker.go and
+	// SetPersistent)
+	worker     sidecarPool
+	persistent bool
+
+	// cppModels holds the whisper.cpp models jobs picked besides the
+	// configured one, by size (see models.go)
+	cppModels map[string]*cppModel
+
+	// deepgram, assemblyAI, googleSTT, and awsTranscribe configure the
+	// cloud backends (see SetDeepgram, SetAssemblyAI, SetGoogleSTT,
+	// SetAWSTranscribe)
+	deepgram      DeepgramOptions
+	assemblyAI    AssemblyAIOptions
+	googleSTT     GoogleSTTOptions
+	awsTranscribe AWSTranscribeOptions
+
+	// vosk configures the Vosk backend (see SetVosk)
+	vosk VoskOptions
+
+	// outputFormats are extra renderings (srt, vtt, tsv) kept with each result
+	outputFormats []string
+
+	// decoding holds the configured decoding parameters (see SetDecoding)
+	decoding types.DecodingParams
+
+	// repetitionTemperatures are the sampling temperatures tried, in order,
+	// when re-decoding a repetition loop (see repetition.go)
+	repetitionTemperatures []float64
+
+	// chunking splits long audio into chunks decoded in parallel (see
+	// chunking.go)
+	chunking ChunkingOptions
+
+	// vad removes long silences before transcription (see vad.go)
+	vad VADOptions
+
+	// diarizer is the pyannote sidecar labelling speakers for jobs that
+	// ask for it; nil when diarization is off (see diarize.go)
+	diarizer *sidecar
+
+	// selfTest records the outcome of the startup self-test (see selftest.go)
+	selfTest selfTestState
+
+	// provenance caches tool versions and model hashes (see provenance.go)
+	provenance provenanceCache
+}
+
+// NewWhisperTranscriber creates a new transcriber using Python Whisper
+func NewWhisperTranscriber(modelPath string, threads int, device string) (*WhisperTranscriber, error) {
+	// For Python Whisper, we use the model name instead of path
+	// Extract model name from path (e.g., "ggml-small.bin" -> "small")
+	modelName := "small" // Default to small
+
+	if strings.Contains(modelPath, "tiny") {
+		modelName = "tiny"
+	} else if strings.Contains(modelPath, "base") {
+		modelName = "base"
+	} else if strings.Contains(modelPath, "small") {
+		modelName = "small"
+	} else if strings.Contains(modelPath, "medium") {
+		modelName = "medium"
+	} else if strings.Contains(modelPath, "large") {
+		modelName = "large"
+	}
+
+	log.Printf("Initializing Python Whisper with model: %s (device: %s)", modelName, device)
+	log.Printf("Whisper will be called via: python -m whisper")
+	log.Printf("Note: Whisper availability will be verified on first transcription")
+
+	return &WhisperTranscriber{
+		modelName:              modelName,
+		modelPath:              modelPath,
+		backend:                BackendPython,
+		python:                 defaultPython,
+		device:                 device,
+		devices:                []string{device},
+		deviceSlots:            map[string]chan struct{}{device: make(chan struct{}, 1)},
+		threads:                threads,
+		repetitionTemperatures: DefaultRepetitionTemperatures,
+	}, nil
+}
+
+// Transcription backends
+const (
+	// BackendPython runs `python -m whisper` for every job (default)
+	BackendPython = "python"
+
+	// BackendWhisperCpp decodes in-process with whisper.cpp and the ggml
+	// model at the configured model path; needs a -tags whispercpp build
+	BackendWhisperCpp = "whispercpp"
+
+	// BackendFasterWhisper sends jobs to a faster-whisper server process
+	// that keeps the model loaded between jobs
+	BackendFasterWhisper = "fasterwhisper"
+
+	// BackendDeepgram sends jobs to Deepgram's pre-recorded API
+	BackendDeepgram = "deepgram"
+
+	// BackendAssemblyAI uploads jobs to AssemblyAI and polls for the result
+	BackendAssemblyAI = "assemblyai"
+
+	// BackendGoogleSTT sends jobs to Google Cloud Speech-to-Text (v2)
+	BackendGoogleSTT = "google_stt"
+
+	// BackendAWSTranscribe stages jobs in S3 and runs AWS Transcribe on them
+	BackendAWSTranscribe = "aws_transcribe"
+
+	// BackendVosk decodes in-process with a small Vosk model per language,
+	// for devices where even whisper tiny is too heavy; needs a -tags vosk
+	// build
+	BackendVosk = "vosk"
+)
+
+// decoder is a backend other than the Python Whisper CLI
+type decoder interface {
+	decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error)
+	io.Closer
+}
+
+// SetBackend selects the transcription backend ("" means BackendPython).
+// Backends that load a model do it here, so a bad setup fails at startup.
+func (wt *WhisperTranscriber) SetBackend(backend string) error {
+	var engine decoder
+	var worker sidecarPool
+	switch backend {
+	case "", BackendPython:
+		backend = BackendPython
+		if wt.persistent {
+			var err error
+			if worker, err = startSidecarPool(wt.devices, wt.startWhisperWorker); err != nil {
+				return err
+			}
+		}
+	case BackendWhisperCpp:
+		model, err := loadCppModel(wt.modelPath, wt.threads)
+		if err != nil {
+			return withRemediation(err)
+		}
+		engine = model
+		log.Printf("Transcribing in-process with whisper.cpp (%s, %d threads)", wt.modelPath, wt.threads)
+	case BackendFasterWhisper:
+		pool, err := startSidecarPool(wt.devices, func(device string) (*sidecar, error) {
+			return startFasterWhisper(wt.python, wt.fasterWhisperModel(), device, wt.threads)
+		})
+		if err != nil {
+			return err
+		}
+		engine = pool
+	case BackendDeepgram:
+		client, err := newDeepgramClient(wt.deepgram)
+		if err != nil {
+			return err
+		}
+		engine = client
+	case BackendAssemblyAI:
+		client, err := newAssemblyAIClient(wt.assemblyAI)
+		if err != nil {
+			return err
+		}
+		engine = client
+	case BackendGoogleSTT:
+		client, err := newGoogleSTTClient(wt.googleSTT)
+		if err != nil {
+			return err
+		}
+		engine = client
+	case BackendAWSTranscribe:
+		client, err := newAWSTranscribeClient(wt.awsTranscribe)
+		if err != nil {
+			return err
+		}
+		engine = client
+	case BackendVosk:
+		model, err := loadVoskModel(wt.vosk)
+		if err != nil {
+			return withRemediation(err)
+		}
+		engine = model
+	default:
+		return fmt.Errorf("unknown whisper backend %q (use %q, %q, %q, %q, %q, %q, %q, or %q)",
+			backend, BackendPython, BackendWhisperCpp, BackendFasterWhisper, BackendDeepgram, BackendAssemblyAI,
+			BackendGoogleSTT, BackendAWSTranscribe, BackendVosk)
+	}
+
+	if err := wt.Close(); err != nil {
+		log.Printf("Failed to stop the %s backend: %v", wt.backend, err)
+	}
+	wt.backend, wt.engine, wt.worker = backend, engine, worker
+	return nil
+}
+
+// Close releases the backend's models or server process
+func (wt *WhisperTranscriber) Close() error {
+	for model, loaded := range wt.cppModels {
+		loaded.Close()
+		delete(wt.cppModels, model)
+	}
+	if wt.worker != nil {
+		wt.worker.Close()
+		wt.worker = nil
+	}
+	if wt.diarizer != nil {
+		wt.diarizer.Close()
+		wt.diarizer = nil
+	}
+	if wt.engine == nil {
+		return nil
+	}
+	return wt.engine.Close()
+}
+
+// ModelName identifies the backend and configured model, e.g.
+// "whisper-small", "whispercpp-small", or "deepgram-nova-2"
+func (wt *WhisperTranscriber) ModelName() string {
+	return wt.ModelNameFor(DecodeOptions{})
+}
+
+// ModelNameFor is ModelName for a run with opts, which may pick another
+// model size
+func (wt *WhisperTranscriber) ModelNameFor(opts DecodeOptions) string {
+	if wt.backend == BackendPython {
+		return "whisper-" + wt.runModel(opts)
+	}
+	// Cloud backends name their own models
+	if named, ok := wt.engine.(interface{ modelName() string }); ok {
+		return wt.backend + "-" + named.modelName()
+	}
+	return wt.backend + "-" + wt.runModel(opts)
+}
+
+// tunable reports whether the backend honours the sampling settings in
+// DecodeOptions; cloud APIs and Vosk have no sampling temperature
+func (wt *WhisperTranscriber) tunable() bool {
+	return !isCloudBackend(wt.backend) && wt.backend != BackendVosk
+}
+
+// CanTranslate reports whether the backend can translate speech to
+// English; only whisper models can, so the cloud backends and Vosk can't
+func (wt *WhisperTranscriber) CanTranslate() bool {
+	return wt.tunable()
+}
+
+// CanPrompt reports whether the backend takes an initial prompt; only
+// whisper models do
+func (wt *WhisperTranscriber) CanPrompt() bool {
+	return wt.tunable()
+}
+
+// CanBoostVocabulary reports whether the backend takes a list of domain
+// terms: the whisper backends, through the prompt, and Deepgram and
+// AssemblyAI, which boost them
+func (wt *WhisperTranscriber) CanBoostVocabulary() bool {
+	return wt.tunable() || wt.backend == BackendDeepgram || wt.backend == BackendAssemblyAI
+}
+
+// SetOutputFormats requests extra renderings from every transcription run;
+// each must be one of types.OutputFormats
+func (wt *WhisperTranscriber) SetOutputFormats(formats []string) error {
+	for _, f := range formats {
+		if !slices.Contains(types.OutputFormats, f) {
+			return fmt.Errorf("unsupported whisper output format %q (supported: %s)",
+				f, strings.Join(types.OutputFormats, ", "))
+		}
+	}
+	wt.outputFormats = formats
+	return nil
+}
+
+// CheckAvailable verifies the Whisper interpreter can be found
+func (wt *WhisperTranscriber) CheckAvailable() error {
+	if wt.engine != nil {
+		// Loaded by SetBackend; engines that can fail later report it
+		if checker, ok := wt.engine.(interface{ check() error }); ok {
+			return checker.check()
+		}
+		return nil
+	}
+	return wt.python.check()
+}
+
+// Warmup gets the model downloaded and loaded before the first real job
+// arrives, the way the backend loads it. Sidecars (faster-whisper and the
+// persistent python worker) load it before they serve, so they are waited
+// for on every device. The python CLI loads it for each run, so a short
+// transcription at least downloads it. whisper.cpp and Vosk loaded theirs
+// in SetBackend, and cloud backends have none.
+func (wt *WhisperTranscriber) Warmup() error {
+	switch {
+	case wt.worker != nil:
+		return wt.worker.waitReady(sidecarStartTimeout)
+	case wt.backend == BackendFasterWhisper:
+		if pool, ok := wt.engine.(sidecarPool); ok {
+			return pool.waitReady(sidecarStartTimeout)
+		}
+		return nil
+	case wt.backend != BackendPython:
+		return nil
+	}
+
+	samplePath := filepath.Join("temp", "warmup_sample.wav")
+	if err := WriteSilenceWAV(samplePath, 1); err != nil {
+		return err
+	}
+	defer os.Remove(samplePath)
+
+	if _, err := wt.Transcribe(samplePath); err != nil {
+		return fmt.Errorf("warmup transcription failed: %v", err)
+	}
+	return nil
+}
+
+// Transcribe processes an audio file and returns the transcript
+func (wt *WhisperTranscriber) Transcribe(audioPath string) (*types.TranscriptionResult, error) {
+	return wt.TranscribeWithProgress(audioPath, nil)
+}
+
+// TranscribeWithProgress is Transcribe that also calls onSegment with the
+// end time (seconds) of each segment as whisper decodes it
+func (wt *WhisperTranscriber) TranscribeWithProgress(audioPath string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	return wt.TranscribeWithOptions(audioPath, DecodeOptions{}, onSegment)
+}
+
+// TranscribeWithOptions is TranscribeWithProgress with decoding settings,
+// such as the spoken language, chosen for this run
+func (wt *WhisperTranscriber) TranscribeWithOptions(audioPath string, opts DecodeOptions, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	result, err := wt.transcribe(audioPath, opts, wt.outputFormats, onSegment)
+	if err != nil {
+		return nil, err
+	}
+	result.Task = opts.task()
+	return result, nil
+}
+
+// LanguageAuto asks the backend to detect the spoken language
+const LanguageAuto = "auto"
+
+// Tasks a whisper model can perform
+const (
+	TaskTranscribe = "transcribe" // text in the spoken language (default)
+	TaskTranslate  = "translate"  // English translation of the speech
+)
+
+// languagePattern matches language codes like "en", "pt-br", or "zh-hant"
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// IsLanguageCode reports whether code looks like a language code
+func IsLanguageCode(code string) bool {
+	return languagePattern.MatchString(code)
+}
+
+// CheckLanguage validates a job's language: a language code, or "" or
+// LanguageAuto to have it detected
+func CheckLanguage(language string) error {
+	if language != "" && language != LanguageAuto && !IsLanguageCode(language) {
+		return fmt.Errorf("invalid language %q; use a code like \"en\" or \"pt-br\", or \"auto\"", language)
+	}
+	return nil
+}
+
+// CheckTask validates a job's task; "" is TaskTranscribe
+func CheckTask(task string) error {
+	switch task {
+	case "", TaskTranscribe, TaskTranslate:
+		return nil
+	}
+	return fmt.Errorf("invalid task %q; use \"transcribe\" or \"translate\"", task)
+}
+
+// DecodeOptions overrides whisper's decoding settings for one run
+type DecodeOptions struct {
+	// Language is the spoken language, a code like "en" or "de", or
+	// LanguageAuto; empty also detects it, except on backends configured
+	// with a default language (Google, AWS, Vosk). Every backend honours it.
+	Language string
+
+	// DecodingParams override the configured whisper.decoding for this
+	// run, field by field (see SetDecoding). A zero temperature keeps
+	// whisper's default schedule: greedy first, raising it on fallback.
+	types.DecodingParams
+
+	// Task is TaskTranscribe (or empty) or TaskTranslate; only the whisper
+	// backends translate (see CanTranslate)
+	Task string
+
+	// InitialPrompt is text the model takes as coming before the audio,
+	// steering its spelling and style; only the whisper backends take one
+	// (see CanPrompt)
+	InitialPrompt string
+
+	// Vocabulary lists domain terms to favour. The whisper backends add
+	// them to the prompt; Deepgram and AssemblyAI boost them (see
+	// CanBoostVocabulary).
+	Vocabulary []string
+
+	// Model is one of WhisperModels, overriding the configured model size
+	// for this run; only the whisper backends have sizes (see
+	// CanSelectModel)
+	Model string
+
+	// Device is the device to run on, one of Devices (see DeviceFor);
+	// empty for the first. Only the python and fasterwhisper backends
+	// have devices.
+	Device string
+}
+
+// language returns the language to transcribe, LanguageAuto included
+func (o DecodeOptions) language() string {
+	if o.Language == "" {
+		return LanguageAuto
+	}
+	return o.Language
+}
+
+// task returns the task to perform, TaskTranscribe by default
+func (o DecodeOptions) task() string {
+	if o.Task == "" {
+		return TaskTranscribe
+	}
+	return o.Task
+}
+
+// prompt combines the initial prompt and the vocabulary into whisper's
+// initial prompt, e.g. "Cardiology follow-up. Glossary: stent, troponin."
+func (o DecodeOptions) prompt() string {
+	prompt := strings.TrimSpace(o.InitialPrompt)
+	if len(o.Vocabulary) == 0 {
+		return prompt
+	}
+	glossary := "Glossary: " + strings.Join(o.Vocabulary, ", ") + "."
+	if prompt == "" {
+		return glossary
+	}
+	return prompt + " " + glossary
+}
+
+// args renders the options as whisper CLI flags
+func (o DecodeOptions) args() []string {
+	var args []string
+	if language := o.language(); language != LanguageAuto {
+		args = append(args, "--language", language)
+	}
+	if o.Temperature > 0 {
+		args = append(args, "--temperature", strconv.FormatFloat(o.Temperature, 'f', -1, 64))
+	}
+	if o.BeamSize > 0 {
+		args = append(args, "--beam_size", strconv.Itoa(o.BeamSize))
+	}
+	if o.BestOf > 0 {
+		args = append(args, "--best_of", strconv.Itoa(o.BestOf))
+	}
+	if o.ConditionOnPreviousText != nil {
+		args = append(args, "--condition_on_previous_text", pythonBool(*o.ConditionOnPreviousText))
+	}
+	if o.NoSpeechThreshold > 0 {
+		args = append(args, "--no_speech_threshold", strconv.FormatFloat(o.NoSpeechThreshold, 'f', -1, 64))
+	}
+	switch {
+	case o.TemperatureIncrement < 0:
+		args = append(args, "--temperature_increment_on_fallback", "None")
+	case o.TemperatureIncrement > 0:
+		args = append(args, "--temperature_increment_on_fallback", strconv.FormatFloat(o.TemperatureIncrement, 'f', -1, 64))
+	}
+	if o.CompressionRatioThreshold > 0 {
+		args = append(args, "--compression_ratio_threshold", strconv.FormatFloat(o.CompressionRatioThreshold, 'f', -1, 64))
+	}
+	if o.LogprobThreshold < 0 {
+		args = append(args, "--logprob_threshold", strconv.FormatFloat(o.LogprobThreshold, 'f', -1, 64))
+	}
+	if o.Task == TaskTranslate {
+		args = append(args, "--task", TaskTranslate)
+	}
+	if prompt := o.prompt(); prompt != "" {
+		args = append(args, "--initial_prompt", prompt)
+	}
+	return args
+}
+
+// pythonBool renders a flag value the whisper CLI parses as a boolean
+func pythonBool(v bool) string {
+	if v {
+		return "True"
+	}
+	return "False"
+}
+
+// SetDecoding configures the decoding parameters for every run; runs may
+// override each one (see DecodeOptions)
+func (wt *WhisperTranscriber) SetDecoding(params types.DecodingParams) error {
+	if err := CheckDecoding(params); err != nil {
+		return fmt.Errorf("whisper.decoding: %v", err)
+	}
+	wt.decoding = params
+	return nil
+}
+
+// CheckDecoding validates decoding parameters
+func CheckDecoding(p types.DecodingParams) error {
+	switch {
+	case p.Temperature < 0 || p.Temperature > 1:
+		return fmt.Errorf("temperature must be between 0 and 1")
+	case p.BeamSize < 0 || p.BeamSize > maxCandidates:
+		return fmt.Errorf("beam_size must be between 1 and %d", maxCandidates)
+	case p.BestOf < 0 || p.BestOf > maxCandidates:
+		return fmt.Errorf("best_of must be between 1 and %d", maxCandidates)
+	case p.NoSpeechThreshold < 0 || p.NoSpeechThreshold > 1:
+		return fmt.Errorf("no_speech_threshold must be between 0 and 1")
+	case p.TemperatureIncrement > 1:
+		return fmt.Errorf("temperature_increment must be at most 1 (negative turns fallback off)")
+	case p.CompressionRatioThreshold < 0:
+		return fmt.Errorf("compression_ratio_threshold must be positive")
+	case p.LogprobThreshold > 0:
+		return fmt.Errorf("logprob_threshold must be negative")
+	}
+	return nil
+}
+
+// maxCandidates caps beam_size and best_of; each candidate costs another
+// decoding pass
+const maxCandidates = 16
+
+// withDecodingDefaults fills the parameters a run left unset from the
+// configured ones
+func withDecodingDefaults(p, defaults types.DecodingParams) types.DecodingParams {
+	if p.Temperature == 0 {
+		p.Temperature = defaults.Temperature
+	}
+	if p.BeamSize == 0 {
+		p.BeamSize = defaults.BeamSize
+	}
+	if p.BestOf == 0 {
+		p.BestOf = defaults.BestOf
+	}
+	if p.ConditionOnPreviousText == nil {
+		p.ConditionOnPreviousText = defaults.ConditionOnPreviousText
+	}
+	if p.NoSpeechThreshold == 0 {
+		p.NoSpeechThreshold = defaults.NoSpeechThreshold
+	}
+	if p.TemperatureIncrement == 0 {
+		p.TemperatureIncrement = defaults.TemperatureIncrement
+	}
+	if p.CompressionRatioThreshold == 0 {
+		p.CompressionRatioThreshold = defaults.CompressionRatioThreshold
+	}
+	if p.LogprobThreshold == 0 {
+		p.LogprobThreshold = defaults.LogprobThreshold
+	}
+	return p
+}
+
+// defaultTemperatureIncrement is whisper's own fallback step
+const defaultTemperatureIncrement = 0.2
+
+// fallbackLevel is how many steps up the fallback ladder a segment
+// sampled at temperature was decoded
+func fallbackLevel(temperature float64, p types.DecodingParams) int {
+	step := p.TemperatureIncrement
+	if step == 0 {
+		step = defaultTemperatureIncrement
+	}
+	if step < 0 || temperature <= p.Temperature {
+		return 0
+	}
+	return int(math.Round((temperature - p.Temperature) / step))
+}
+
+// CanTuneDecoding reports whether the backend takes decoding parameters;
+// cloud APIs and Vosk have none
+func (wt *WhisperTranscriber) CanTuneDecoding() bool {
+	return wt.tunable()
+}
+
+// transcribe runs whisper once with the given decoding options, collecting
+// the requested extra renderings
+func (wt *WhisperTranscriber) transcribe(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	release, err := wt.acquire(&opts)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if opts.Task == TaskTranslate && !wt.CanTranslate() {
+		return nil, fmt.Errorf("the %s backend can't translate", wt.backend)
+	}
+	if opts.InitialPrompt != "" && !wt.CanPrompt() {
+		return nil, fmt.Errorf("the %s backend doesn't take an initial prompt", wt.backend)
+	}
+	if len(opts.Vocabulary) > 0 && !wt.CanBoostVocabulary() {
+		return nil, fmt.Errorf("the %s backend doesn't take a vocabulary", wt.backend)
+	}
+	if opts.DecodingParams != (types.DecodingParams{}) && !wt.CanTuneDecoding() {
+		return nil, fmt.Errorf("the %s backend doesn't take decoding parameters", wt.backend)
+	}
+	if err := CheckModel(opts.Model); err != nil {
+		return nil, err
+	}
+	if opts.Model != "" && !wt.CanSelectModel() {
+		return nil, fmt.Errorf("the %s backend has no model sizes to pick from", wt.backend)
+	}
+	if opts.Model == wt.modelName {
+		opts.Model = "" // the loaded model
+	}
+	if wt.tunable() {
+		opts.DecodingParams = withDecodingDefaults(opts.DecodingParams, wt.decoding)
+	}
+
+	if wt.engine != nil {
+		engine := wt.engine
+		if wt.backend == BackendWhisperCpp && opts.Model != "" {
+			loaded, err := wt.cppModelFor(opts.Model)
+			if err != nil {
+				return nil, err
+			}
+			engine = loaded
+		}
+		log.Printf("Transcribing with %s: %s", wt.backend, audioPath)
+		return engine.decode(audioPath, opts, formats, onSegment)
+	}
+
+	if result, ok, err := wt.decodeWithWorker(audioPath, opts, formats, onSegment); ok {
+		return result, err
+	}
+
+	log.Printf("Transcribing with Python Whisper: %s", audioPath)
+
+	// Create a temp directory for Whisper output; runs on other devices
+	// may be writing theirs at the same time
+	os.MkdirAll("temp", 0755)
+	tempDir, err := os.MkdirTemp("temp", "whisper_output_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create whisper output directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir) // Clean up after
+
+	// Get absolute path for audio file
+	absAudioPath, err := filepath.Abs(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+
+	// JSON is always needed for segments; "all" also writes txt/srt/vtt/tsv
+	outputFormat := "json"
+	if len(formats) > 0 {
+		outputFormat = "all"
+	}
+
+	// Whisper's verbose output prints each segment as it is decoded
+	var progress io.Writer
+	if onSegment != nil {
+		progress = &segmentProgress{report: onSegment}
+	}
+
+	// Half precision only works on a GPU; whisper warns and falls back to
+	// fp32 on the CPU anyway
+	fp16 := "False"
+	if kind, _ := splitDevice(opts.Device); kind == "cuda" {
+		fp16 = "True"
+	}
+
+	// Python Whisper's command line, through the wrapper that records the
+	// detected language (-u so segment lines arrive unbuffered)
+	// Output formats: txt, json, srt, vtt, tsv
+	baseName := strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))
+	languagePath := filepath.Join(tempDir, baseName+".language.json")
+	args := []string{"-u", "-c", whisperCLIScript, languagePath,
+		absAudioPath,
+		"--model", wt.runModel(opts),
+		"--output_dir", tempDir,
+		"--output_format", outputFormat,
+		"--device", opts.Device, // cpu, cuda, or cuda:N (see devices.go)
+		"--fp16", fp16, // whisper's flag parser only takes True or False
+	}
+	if wt.threads > 0 {
+		args = append(args, "--threads", strconv.Itoa(wt.threads))
+	}
+	args = append(args, wt.python.extraArgs...)
+	args = append(args, opts.args()...)
+	output, usage, err := runOnDevice(wt.python.command(context.Background(), args...), opts.Device, progress)
+	if err != nil {
+		return nil, fmt.Errorf("whisper transcription failed: %v\nOutput: %s", err, string(output))
+	}
+
+	log.Printf("Whisper output: %s", string(output))
+
+	// Read the JSON output file
+	jsonPath := filepath.Join(tempDir, baseName+".json")
+
+	jsonData, err := os.ReadFile(jsonPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read whisper output: %v", err)
+	}
+
+	// Parse Whisper JSON output
+	var whisperOutput WhisperOutput
+	if err := json.Unmarshal(jsonData, &whisperOutput); err != nil {
+		return nil, fmt.Errorf("failed to parse whisper JSON: %v", err)
+	}
+
+	// Convert to our format
+	segments := make([]types.Segment, len(whisperOutput.Segments))
+	for i, seg := range whisperOutput.Segments {
+		segments[i] = types.Segment{
+			Start:            seg.Start,
+			End:              seg.End,
+			Text:             strings.TrimSpace(seg.Text),
+			Temperature:      seg.Temperature,
+			Fallback:         fallbackLevel(seg.Temperature, opts.DecodingParams),
+			AvgLogprob:       seg.AvgLogprob,
+			CompressionRatio: seg.CompressionRatio,
+		}
+	}
+
+	// Calculate duration (last segment end time)
+	var duration float64
+	if len(segments) > 0 {
+		duration = segments[len(segments)-1].End
+	}
+
+	var languageConfidence float64
+	if language, confidence, ok := readDetectedLanguage(languagePath); ok {
+		log.Printf("Detected language %s (%.0f%%)", language, confidence*100)
+		languageConfidence = confidence
+	}
+
+	result := &types.TranscriptionResult{
+		Text:               strings.TrimSpace(whisperOutput.Text),
+		Language:           whisperOutput.Language,
+		LanguageConfidence: languageConfidence,
+		Duration:           duration,
+		Segments:           segments,
+		Resources:          usage,
+		RawOutput:          jsonData,
+	}
+
+	// Collect the extra renderings written alongside the JSON
+	for _, format := range formats {
+		data, err := os.ReadFile(filepath.Join(tempDir, baseName+"."+format))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read whisper %s output: %v", format, err)
+		}
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = string(data)
+	}
+
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(segments), duration)
+	return result, nil
+}
+
+// segmentLinePattern matches whisper's verbose lines like
+// "[00:12.480 --> 00:15.920]  Hello there" (hours appear past the first hour)
+var segmentLinePattern = regexp.MustCompile(`^\[[0-9:.]+ --> ([0-9:.]+)\]`)
+
+// segmentProgress parses whisper's verbose stdout and reports each
+// segment's end time
+type segmentProgress struct {
+	report  func(float64)
+	partial []byte
+}
+
+func (p *segmentProgress) Write(b []byte) (int, error) {
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := p.partial[:i]
+		p.partial = p.partial[i+1:]
+
+		m := segmentLinePattern.FindSubmatch(bytes.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		if end, ok := parseClock(string(m[1])); ok {
+			p.report(end)
+		}
+	}
+	return len(b), nil
+}
+
+// parseClock converts "MM:SS.mmm" or "HH:MM:SS.mmm" to seconds
+func parseClock(clock string) (float64, bool) {
+	var seconds float64
+	for _, part := range strings.Split(clock, ":") {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		seconds = seconds*60 + v
+	}
+	return seconds, true
+}
+
+// WhisperOutput matches Python Whisper's JSON output format
+type WhisperOutput struct {
+	Text     string           `json:"text"`
+	Language string           `json:"language"`
+	Segments []WhisperSegment `json:"segments"`
+}
+
+// WhisperSegment represents a timestamped segment from Whisper
+type WhisperSegment struct {
+	ID               int     `json:"id"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+}
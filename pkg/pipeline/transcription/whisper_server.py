@@ -0,0 +1,177 @@
+"""Persistent openai-whisper worker for the transcription server.
+
+Keeps whisper models loaded between jobs, which `python -m whisper` loads
+again for every file: the configured model always, and the last other
+model a job asked for. It speaks the faster-whisper sidecar's protocol: it
+listens on an ephemeral loopback port (printed as "LISTENING <port>") and
+serves one transcription per connection. The request is a JSON line; the
+reply is {"progress": seconds} lines while decoding, then a JSON line per
+segment followed by {"done": ...} or {"error": ...}. Exits when its stdin
+closes, i.e. when the server that started it goes away.
+"""
+
+import argparse
+import collections
+import contextlib
+import json
+import os
+import re
+import socket
+import sys
+import threading
+
+import numpy as np
+import torch
+import whisper
+
+# Models loaded for jobs that ask for another than the default, kept
+# least recently used first; the default is never unloaded
+EXTRA_MODELS = 1
+
+# whisper's verbose lines, e.g. "[00:12.480 --> 00:15.920]  Hello there"
+SEGMENT_LINE = re.compile(r"^\[[0-9:.]+ --> ([0-9:.]+)\]")
+
+
+def main():
+    parser = argparse.ArgumentParser()
+    parser.add_argument("--model", required=True)
+    parser.add_argument("--device", default="cuda")
+    parser.add_argument("--threads", type=int, default=0)
+    args = parser.parse_args()
+
+    # Don't outlive the server
+    threading.Thread(target=lambda: (sys.stdin.read(), os._exit(0)), daemon=True).start()
+
+    if args.threads > 0:
+        torch.set_num_threads(args.threads)
+
+    def load(name):
+        return whisper.load_model(name, device=args.device)
+
+    models = Models(args.model, load)
+
+    server = socket.socket(socket.AF_INET, socket.SOCK_STREAM)
+    server.bind(("127.0.0.1", 0))
+    server.listen()
+    print("LISTENING %d" % server.getsockname()[1], flush=True)
+
+    while True:
+        conn, _ = server.accept()
+        with conn, conn.makefile("rwb") as stream:
+            serve(models, stream)
+
+
+class Models:
+    """The default model plus the last EXTRA_MODELS others jobs asked for"""
+
+    def __init__(self, default, load):
+        self.name = default
+        self.default = load(default)
+        self.load = load
+        self.extra = collections.OrderedDict()
+
+    def get(self, name):
+        if not name or name == self.name:
+            return self.default
+        if name in self.extra:
+            self.extra.move_to_end(name)
+            return self.extra[name]
+        while len(self.extra) >= EXTRA_MODELS:
+            self.extra.popitem(last=False)
+            if torch.cuda.is_available():
+                torch.cuda.empty_cache()
+        model = self.load(name)
+        self.extra[name] = model
+        return model
+
+
+class Progress:
+    """Turns whisper's verbose segment lines into progress replies"""
+
+    def __init__(self, send):
+        self.send = send
+        self.partial = ""
+
+    def write(self, text):
+        self.partial += text
+        *lines, self.partial = self.partial.split("\n")
+        for line in lines:
+            match = SEGMENT_LINE.match(line.strip())
+            if match:
+                seconds = 0.0
+                for part in match.group(1).split(":"):
+                    seconds = seconds * 60 + float(part)
+                self.send({"progress": seconds})
+
+    def flush(self):
+        pass
+
+
+def serve(models, stream):
+    def send(message):
+        stream.write((json.dumps(message) + "\n").encode())
+        stream.flush()
+
+    line = stream.readline()
+    if not line:
+        return
+    try:
+        request = json.loads(line)
+        model = models.get(request.get("model"))
+        audio = whisper.load_audio(request["audio"])
+
+        # Detect the language here, like the CLI would, but keep its score
+        language, probability = request.get("language") or None, 1.0
+        if language is None:
+            mel = whisper.log_mel_spectrogram(whisper.pad_or_trim(audio), model.dims.n_mels).to(model.device)
+            _, probs = model.detect_language(mel)
+            language = max(probs, key=probs.get)
+            probability = float(probs[language])
+
+        # The CLI's defaults: beams of 5, and fallback to higher
+        # temperatures in steps of 0.2 (none for a negative step)
+        temperature = request.get("temperature") or 0.0
+        increment = request.get("temperature_increment") or 0.2
+        temperatures = (temperature,)
+        if increment > 0:
+            temperatures = tuple(np.arange(temperature, 1.0 + 1e-6, increment))
+        options = {
+            "language": language,
+            "task": request.get("task") or "transcribe",
+            "temperature": temperatures,
+            "condition_on_previous_text": request.get("condition_on_previous_text", True),
+            "initial_prompt": request.get("initial_prompt") or None,
+            "beam_size": request.get("beam_size") or 5,
+            "best_of": request.get("best_of") or 5,
+            # Half precision only where it runs; whisper would warn and
+            # fall back on CPU
+            "fp16": model.device.type == "cuda",
+            "verbose": True,
+        }
+        for name in ("no_speech_threshold", "compression_ratio_threshold", "logprob_threshold"):
+            if request.get(name):
+                options[name] = request[name]
+
+        with contextlib.redirect_stdout(Progress(send)):
+            result = model.transcribe(audio, **options)
+        for segment in result["segments"]:
+            send({
+                "start": segment["start"],
+                "end": segment["end"],
+                "text": segment["text"],
+                "temperature": segment["temperature"],
+                "avg_logprob": segment["avg_logprob"],
+                "compression_ratio": segment["compression_ratio"],
+            })
+        send({
+            "done": True,
+            "language": result["language"],
+            "language_probability": probability,
+            "duration": len(audio) / whisper.audio.SAMPLE_RATE,
+        })
+    except Exception as e:  # reported to the job, the worker keeps serving
+        send({"error": str(e)})
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,57 @@
+package transcription
+
+// Persistent whisper worker — for the python backend, a sidecar (see
+// sidecar.go) running whisper_server.py keeps the model loaded, so short
+// clips aren't dominated by `python -m whisper` loading it for every job.
+// Runs go to the worker while it is up; when it is down, or dies mid-run,
+// they fall back to the one-shot CLI. whisper.python.extra_args has no
+// equivalent in the worker, so with extra args set every run is one-shot.
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+//go:embed whisper_server.py
+var whisperWorkerScript []byte
+
+// SetPersistent keeps a whisper process loaded for the python backend;
+// call it before SetBackend
+func (wt *WhisperTranscriber) SetPersistent(persistent bool) {
+	wt.persistent = persistent
+}
+
+// startWhisperWorker starts the python backend's persistent worker on a
+// device
+func (wt *WhisperTranscriber) startWhisperWorker(device string) (*sidecar, error) {
+	worker, err := startSidecar("whisper", whisperWorkerScript, wt.python, device,
+		"--model", wt.modelName,
+		"--device", device,
+		"--threads", fmt.Sprint(wt.threads),
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Keeping a whisper worker process loaded (model: %s, device: %s)", wt.modelName, device)
+	return worker, nil
+}
+
+// decodeWithWorker runs a transcription on the persistent worker. It
+// reports false, with no error, when the run should fall back to the
+// one-shot CLI instead.
+func (wt *WhisperTranscriber) decodeWithWorker(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, bool, error) {
+	if wt.worker == nil || len(wt.python.extraArgs) > 0 {
+		return nil, false, nil
+	}
+	log.Printf("Transcribing with the whisper worker: %s", audioPath)
+	result, err := wt.worker.decode(audioPath, opts, formats, onSegment)
+	if err == nil || errors.Is(err, errSidecarDecode) {
+		return result, true, err
+	}
+	log.Printf("Whisper worker unavailable, running whisper once instead: %v", err)
+	return nil, false, nil
+}
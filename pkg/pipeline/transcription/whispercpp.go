@@ -0,0 +1,124 @@
+//go:build whispercpp
+
+package transcription
+
+// whisper.cpp backend — decodes in-process through the whisper.cpp Go
+// bindings with a ggml model, so jobs don't start a Python interpreter and
+// reload the model each time. Built with -tags whispercpp against a
+// compiled libwhisper (see README).
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	whisper "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// cppModel is a ggml model loaded once and shared by every run
+type cppModel struct {
+	model   whisper.Model
+	threads int
+}
+
+// loadCppModel loads the ggml model at modelPath
+func loadCppModel(modelPath string, threads int) (*cppModel, error) {
+	if _, err := os.Stat(modelPath); err != nil {
+		return nil, fmt.Errorf("ggml model not found: %v", err)
+	}
+	model, err := whisper.New(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ggml model %s: %v", modelPath, err)
+	}
+	log.Printf("Loaded whisper.cpp model %s", modelPath)
+	return &cppModel{model: model, threads: threads}, nil
+}
+
+// Close frees the model
+func (m *cppModel) Close() error {
+	return m.model.Close()
+}
+
+// decode transcribes a normalized WAV. Callers hold the transcriber lock:
+// a whisper.cpp context is not safe for concurrent use.
+func (m *cppModel) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	samples, err := readWAVSamples(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("whisper.cpp needs normalized audio: %v", err)
+	}
+
+	ctx, err := m.model.NewContext()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create whisper.cpp context: %v", err)
+	}
+	// whisper.cpp takes "auto" for detection too
+	if err := ctx.SetLanguage(opts.language()); err != nil {
+		return nil, fmt.Errorf("failed to set language: %v", err)
+	}
+	if m.threads > 0 {
+		ctx.SetThreads(uint(m.threads))
+	}
+	if opts.Temperature > 0 {
+		ctx.SetTemperature(float32(opts.Temperature))
+	}
+	// A zero step turns whisper.cpp's fallback off
+	if opts.TemperatureIncrement != 0 {
+		ctx.SetTemperatureFallback(float32(max(opts.TemperatureIncrement, 0)))
+	}
+	// The greedy decoder takes no beam_size; best_of,
+	// no_speech_threshold, and the fallback thresholds aren't exposed by
+	// the bindings
+	if opts.ConditionOnPreviousText != nil && !*opts.ConditionOnPreviousText {
+		ctx.SetMaxContext(0)
+	}
+	ctx.SetTranslate(opts.Task == TaskTranslate)
+	if prompt := opts.prompt(); prompt != "" {
+		ctx.SetInitialPrompt(prompt)
+	}
+
+	// A segment callback would force single-segment decoding, so progress
+	// is reported as the share of the audio decoded instead
+	duration := float64(len(samples)) / sampleRate
+	var onProgress whisper.ProgressCallback
+	if onSegment != nil {
+		onProgress = func(percent int) { onSegment(float64(percent) / 100 * duration) }
+	}
+	if err := ctx.Process(samples, nil, nil, onProgress); err != nil {
+		return nil, fmt.Errorf("whisper.cpp transcription failed: %v", err)
+	}
+
+	var segments []types.Segment
+	var texts []string
+	for {
+		seg, err := ctx.NextSegment()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read whisper.cpp segments: %v", err)
+		}
+		text := strings.TrimSpace(seg.Text)
+		segments = append(segments, types.Segment{Start: seg.Start.Seconds(), End: seg.End.Seconds(), Text: text})
+		texts = append(texts, text)
+	}
+
+	result := &types.TranscriptionResult{
+		Text:     strings.Join(texts, " "),
+		Language: ctx.DetectedLanguage(),
+		Duration: duration,
+		Segments: segments,
+	}
+	for _, format := range formats {
+		if result.Formats == nil {
+			result.Formats = make(map[string]string)
+		}
+		result.Formats[format] = RenderSegments(format, segments)
+	}
+
+	log.Printf("Transcription completed: %d segments, %.2fs duration", len(segments), result.Duration)
+	return result, nil
+}
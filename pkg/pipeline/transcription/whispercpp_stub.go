@@ -0,0 +1,28 @@
+//go:build !whispercpp
+
+package transcription
+
+// Builds without the whispercpp tag have no in-process backend; choosing
+// it fails at startup with a hint on how to rebuild.
+
+import (
+	"errors"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+var errNoWhisperCpp = errors.New("this build has no whisper.cpp support")
+
+type cppModel struct{}
+
+func loadCppModel(modelPath string, threads int) (*cppModel, error) {
+	return nil, errNoWhisperCpp
+}
+
+func (m *cppModel) Close() error {
+	return nil
+}
+
+func (m *cppModel) decode(audioPath string, opts DecodeOptions, formats []string, onSegment func(end float64)) (*types.TranscriptionResult, error) {
+	return nil, errNoWhisperCpp
+}
@@ -0,0 +1,330 @@
+// Package types defines shared constants and data structures used
+// across the transcription service (job status, source types, results).
+package types
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Job status constants
+const (
+	StatusDownloading = "DOWNLOADING" // source is still being fetched
+	StatusQueued      = "QUEUED"
+	StatusProcessing  = "PROCESSING"
+	StatusCompleted   = "COMPLETED"
+	StatusFailed      = "FAILED"
+	StatusCancelled   = "CANCELLED"
+
+	// StatusPartial is a job group whose jobs finished with some, but not
+	// all, completed
+	StatusPartial = "PARTIAL"
+)
+
+// Source type constants
+const (
+	SourceUpload  = "upload"
+	SourceGDrive  = "gdrive"
+	SourceYouTube = "youtube"
+	SourceStream  = "stream"
+	SourceImport  = "import"
+	// SourceRecording is audio uploaded in chunks (see /recordings)
+	SourceRecording = "recording"
+)
+
+// SourceTypes lists every source type
+var SourceTypes = []string{SourceUpload, SourceGDrive, SourceYouTube, SourceStream, SourceImport, SourceRecording}
+
+// TranscriptionResult represents the output from Whisper
+type TranscriptionResult struct {
+	JobID       string
+	Text        string
+	Language    string
+	Duration    float64
+	Segments    []Segment
+	WordCount   int
+	ProcessedAt time.Time
+	LocalPath   string
+	GDriveURL   string
+	Metadata    map[string]interface{}
+	Labels      map[string]string
+	Cost        JobCost
+	Resources   ResourceUsage
+	Trim        *TrimRange
+	SourceAudio *SourceAudio
+
+	// LanguageConfidence is the backend's probability (0-1) for a language
+	// it detected; zero when the language was chosen or no score was given
+	LanguageConfidence float64
+	// Task is "transcribe" or "translate"; a translation's Text is English
+	// while Language stays the spoken language
+	Task string
+	// Formats holds extra renderings produced by the backend (see
+	// OutputFormats), keyed by format
+	Formats map[string]string
+	// Repetitions flags regions where the decoder looped and re-decoding
+	// did not fix it
+	Repetitions []RepetitionRegion
+	// Provenance records what produced the transcript; nil for imported
+	// transcripts
+	Provenance *Provenance
+	// Replacements audits the replacement dictionary terms applied to the
+	// text, with how often each was replaced
+	Replacements []Replacement
+	// Speedup is how much faster than recorded the audio was played to
+	// whisper; zero when it was not sped up
+	Speedup float64
+	// RawOutput is whisper's JSON output before any post-processing, on
+	// the timeline of the audio whisper was given; nil unless kept
+	RawOutput json.RawMessage
+}
+
+// Translated reports whether Text is an English translation rather than
+// the spoken language
+func (r *TranscriptionResult) Translated() bool {
+	return r.Task == "translate"
+}
+
+// TranscriptText is the text of a result's txt file: a paragraph per
+// speaker turn when the backend diarized, otherwise the plain text
+func (r *TranscriptionResult) TranscriptText() string {
+	if paragraphs := SpeakerParagraphs(r.Segments); paragraphs != "" {
+		return paragraphs
+	}
+	return r.Text
+}
+
+// OutputFormats are the extra transcript renderings that can be requested
+// from the backend and stored next to the .txt transcript
+var OutputFormats = []string{"srt", "vtt", "tsv"}
+
+// RepetitionRegion is a stretch of transcript where the same segment or
+// phrase repeats back to back, a sign the decoder got stuck
+type RepetitionRegion struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`    // the repeated segment or phrase
+	Repeats int     `json:"repeats"` // how many times it repeats
+}
+
+// Replacement is a dictionary term replaced in a transcript
+type Replacement struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// Provenance records the backend, model, tool versions, and decoding
+// options a transcript was produced with, so it can be reproduced
+type Provenance struct {
+	Backend string `json:"backend"`
+	Model   string `json:"model"` // model size or path, or the cloud API's model
+	// ModelSHA256 is the hash of the local model file, when there is one
+	ModelSHA256 string `json:"model_sha256,omitempty"`
+	Device      string `json:"device,omitempty"`
+	// Versions maps tools and packages (ffmpeg, openai-whisper, yt-dlp, ...)
+	// to the versions installed when the job ran; missing ones are left out
+	Versions map[string]string `json:"versions,omitempty"`
+	Decoding DecodingOptions   `json:"decoding"`
+}
+
+// DecodingOptions are the decoding settings a transcript was produced with
+type DecodingOptions struct {
+	Language string `json:"language"` // as requested; "auto" when detected
+	Task     string `json:"task"`
+	DecodingParams
+	Threads   int      `json:"threads,omitempty"`
+	ExtraArgs []string `json:"extra_args,omitempty"`
+	// InitialPrompt and Vocabulary are the job's prompt and domain terms
+	InitialPrompt string   `json:"initial_prompt,omitempty"`
+	Vocabulary    []string `json:"vocabulary,omitempty"`
+	// RepetitionTemperatures are the temperatures tried when re-decoding a
+	// repetition loop
+	RepetitionTemperatures []float64 `json:"repetition_temperatures,omitempty"`
+}
+
+// DecodingParams tune whisper's decoding, trading accuracy for speed. They
+// are configured under whisper.decoding and can be overridden per job;
+// zero values (nil for ConditionOnPreviousText) keep the backend's default.
+type DecodingParams struct {
+	// Temperature to sample at; whisper still raises it on fallback
+	Temperature float64 `json:"temperature,omitempty" yaml:"temperature"`
+	// BeamSize is the number of beams searched at temperature zero
+	BeamSize int `json:"beam_size,omitempty" yaml:"beam_size"`
+	// BestOf is the number of candidates sampled at non-zero temperature
+	BestOf int `json:"best_of,omitempty" yaml:"best_of"`
+	// ConditionOnPreviousText feeds the model its earlier output as context
+	ConditionOnPreviousText *bool `json:"condition_on_previous_text,omitempty" yaml:"condition_on_previous_text"`
+	// NoSpeechThreshold is the probability above which a silent-looking
+	// window is skipped
+	NoSpeechThreshold float64 `json:"no_speech_threshold,omitempty" yaml:"no_speech_threshold"`
+
+	// The fallback ladder: a window whose text compresses better than
+	// CompressionRatioThreshold (a sign of repetition) or whose average
+	// token log probability is below LogprobThreshold is decoded again at
+	// a temperature TemperatureIncrement higher, up to 1. A negative
+	// increment turns fallback off.
+	TemperatureIncrement      float64 `json:"temperature_increment,omitempty" yaml:"temperature_increment"`
+	CompressionRatioThreshold float64 `json:"compression_ratio_threshold,omitempty" yaml:"compression_ratio_threshold"`
+	LogprobThreshold          float64 `json:"logprob_threshold,omitempty" yaml:"logprob_threshold"`
+}
+
+// SourceAudio describes the submitted audio before normalization
+type SourceAudio struct {
+	Format     string `json:"format"`
+	Codec      string `json:"codec"`
+	SampleRate int    `json:"sample_rate"`
+	Channels   int    `json:"channels"`
+	// Duration (seconds) and BitRate (bits/s) are of the whole recording
+	Duration float64 `json:"duration_seconds,omitempty"`
+	BitRate  int64   `json:"bit_rate,omitempty"`
+}
+
+// TrimRange records the section of the recording that was transcribed.
+// Segment timestamps are relative to the original recording, not the cut.
+type TrimRange struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time,omitempty"` // zero means to the end
+	// Truncated is set when the end was moved in to fit the configured
+	// maximum duration rather than chosen by the client
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// JobCost records the resources a job consumed, for chargeback
+type JobCost struct {
+	NormalizeSeconds  float64 `json:"normalize_seconds"`  // ffmpeg wall-clock time
+	TranscribeSeconds float64 `json:"transcribe_seconds"` // transcription backend wall-clock time
+	ComputeSeconds    float64 `json:"compute_seconds"`    // normalize + transcribe
+	AudioMinutes      float64 `json:"audio_minutes"`
+	CloudCostUSD      float64 `json:"cloud_cost_usd"`  // spend reported by cloud backends, if any
+	Model             string  `json:"model,omitempty"` // backend/model that did the work
+}
+
+// Segment represents a timestamped segment of transcription
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+	// Speaker identifies who is talking, for backends that diarize
+	Speaker string `json:"speaker,omitempty"`
+	// Confidence (0-1) is reported by backends that score their output
+	Confidence float64 `json:"confidence,omitempty"`
+	// Temperature is what the segment was sampled at and Fallback how many
+	// steps up the fallback ladder that was (0: the first attempt passed);
+	// AvgLogprob and CompressionRatio are what the thresholds were checked
+	// against. Whisper backends other than whisper.cpp report them.
+	Temperature      float64 `json:"temperature,omitempty"`
+	Fallback         int     `json:"fallback,omitempty"`
+	AvgLogprob       float64 `json:"avg_logprob,omitempty"`
+	CompressionRatio float64 `json:"compression_ratio,omitempty"`
+}
+
+// SpeakerTurn is a stretch of audio where one speaker talks
+type SpeakerTurn struct {
+	Speaker string  `json:"speaker"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+}
+
+// SpeakerTurns merges consecutive segments by the same speaker into turns;
+// nil when no segment has a speaker
+func SpeakerTurns(segments []Segment) []SpeakerTurn {
+	var turns []SpeakerTurn
+	for _, seg := range segments {
+		if seg.Speaker == "" {
+			continue
+		}
+		if n := len(turns); n > 0 && turns[n-1].Speaker == seg.Speaker {
+			turns[n-1].End = seg.End
+			continue
+		}
+		turns = append(turns, SpeakerTurn{Speaker: seg.Speaker, Start: seg.Start, End: seg.End})
+	}
+	return turns
+}
+
+// SpeakerName is how a speaker label reads in a transcript: the generic
+// labels of diarization ("speaker_0", or "speaker_A" from AssemblyAI)
+// become "Speaker 1" or "Speaker A", and names (channel labels, renamed
+// speakers) are kept as they are
+func SpeakerName(speaker string) string {
+	id, ok := strings.CutPrefix(speaker, "speaker_")
+	if !ok || id == "" {
+		return speaker
+	}
+	if n, err := strconv.Atoi(id); err == nil && n >= 0 {
+		return "Speaker " + strconv.Itoa(n+1)
+	}
+	return "Speaker " + id
+}
+
+// SpeakerParagraphs writes segments as one paragraph per speaker turn, each
+// led by the speaker's name ("Speaker 1: Hello there."); "" when no segment
+// has a speaker. A segment without a speaker continues the paragraph before it.
+func SpeakerParagraphs(segments []Segment) string {
+	if SpeakerTurns(segments) == nil {
+		return ""
+	}
+	var paragraphs []string
+	speaker := ""
+	for _, seg := range segments {
+		text := strings.TrimSpace(seg.Text)
+		switch {
+		case text == "":
+			continue
+		case len(paragraphs) == 0 || seg.Speaker != "" && seg.Speaker != speaker:
+			speaker = seg.Speaker
+			if speaker != "" {
+				text = SpeakerName(speaker) + ": " + text
+			}
+			paragraphs = append(paragraphs, text)
+		default:
+			paragraphs[len(paragraphs)-1] += " " + text
+		}
+	}
+	return strings.Join(paragraphs, "\n\n") + "\n"
+}
+
+// MeanConfidence is the segments' confidence weighted by their length;
+// zero when no segment is scored
+func MeanConfidence(segments []Segment) float64 {
+	var sum, weight float64
+	for _, seg := range segments {
+		if seg.Confidence <= 0 {
+			continue
+		}
+		// Zero-length segments still count, as if a tenth of a second long
+		length := max(seg.End-seg.Start, 0.1)
+		sum += seg.Confidence * length
+		weight += length
+	}
+	if weight == 0 {
+		return 0
+	}
+	return sum / weight
+}
+
+// ResourceUsage summarizes the subprocess resources a job consumed
+type ResourceUsage struct {
+	CPUSeconds   float64 `json:"cpu_seconds"`    // user + system time
+	PeakMemoryMB float64 `json:"peak_memory_mb"` // largest resident set of any subprocess
+}
+
+// Add folds another measurement into u (CPU time sums, peak memory maxes)
+func (u *ResourceUsage) Add(other ResourceUsage) {
+	u.CPUSeconds += other.CPUSeconds
+	if other.PeakMemoryMB > u.PeakMemoryMB {
+		u.PeakMemoryMB = other.PeakMemoryMB
+	}
+}
+
+// SegmentText is the segments' text joined by spaces
+func SegmentText(segments []Segment) string {
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		texts = append(texts, seg.Text)
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,61 @@
+package stages
+
+// Services — where the worker pool sends finished work besides storage:
+// webhook events, signed result links, a search index, and post-processing
+// hooks. The server plugs in its own implementations through the worker
+// pool's setters; programs embedding the pipeline can plug in theirs.
+
+import (
+	"time"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Webhooks delivers an event ("job.completed", "worker.stalled", ...) with
+// its JSON payload
+type Webhooks interface {
+	Notify(event string, payload interface{}) error
+}
+
+// LinkSigner signs expiring download links for a job's stored artifacts
+// ("txt", "meta", "srt", ...), returning them by artifact
+type LinkSigner interface {
+	Sign(jobID string, artifacts []string) (map[string]string, time.Time, error)
+}
+
+// Document is the indexed form of a transcript
+type Document struct {
+	JobID       string                 `json:"job_id"`
+	RequestName string                 `json:"request_name"`
+	SourceType  string                 `json:"source_type"`
+	Text        string                 `json:"text"`
+	Language    string                 `json:"language"`
+	Duration    float64                `json:"duration_seconds"`
+	WordCount   int                    `json:"word_count"`
+	Segments    []types.Segment        `json:"segments"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Labels      map[string]string      `json:"labels,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	GDriveURL   string                 `json:"gdrive_url,omitempty"`
+	Description string                 `json:"description,omitempty"`
+}
+
+// Indexer writes completed transcripts to a search index, keyed by job ID
+type Indexer interface {
+	Index(doc Document) error
+}
+
+// HookRequest is the JSON document sent to every post-processing hook
+type HookRequest struct {
+	JobID       string                     `json:"job_id"`
+	RequestName string                     `json:"request_name"`
+	SourceType  string                     `json:"source_type"`
+	Result      *types.TranscriptionResult `json:"result"`
+}
+
+// Hooks passes a transcript through post-processing hooks, which may
+// rewrite it in place. skipped lists hooks that failed without failing
+// the job.
+type Hooks interface {
+	Run(req HookRequest) (skipped []error, err error)
+}
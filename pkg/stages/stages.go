@@ -0,0 +1,120 @@
+// Package stages defines the extension points of the transcription
+// pipeline. Go programs that embed the server register their own stages
+// at compile time, typically from an init function:
+//
+//	func init() {
+//		stages.RegisterPostProcessor(myRedactor{})
+//	}
+//
+// Pre-processors see the source audio before normalization, post-processors
+// see the transcript before it is stored, and notifiers hear about every
+// job that reaches a final status.
+package stages
+
+import (
+	"context"
+	"sync"
+
+	"github.com/codebuildervaibhav/audio-transcription/pkg/pipeline/types"
+)
+
+// Result is the transcript produced by the pipeline
+type Result = types.TranscriptionResult
+
+// Segment is one timestamped span of a Result
+type Segment = types.Segment
+
+// Job describes the job a stage is running for
+type Job struct {
+	ID          string
+	RequestName string
+	SourceType  string
+	Metadata    map[string]interface{}
+	Labels      map[string]string
+}
+
+// PreProcessor transforms source audio before normalization. It returns
+// the path of the audio to continue with, which may be the input path; a
+// new file is removed by the pipeline once the job is done.
+type PreProcessor interface {
+	Name() string
+	PreProcess(ctx context.Context, job Job, audioPath string) (string, error)
+}
+
+// PostProcessor inspects or rewrites a transcript before it is stored.
+// An error fails the job.
+type PostProcessor interface {
+	Name() string
+	PostProcess(ctx context.Context, job Job, result *Result) error
+}
+
+// Event names passed to notifiers
+const (
+	EventJobCompleted = "job.completed"
+	EventJobFailed    = "job.failed"
+	EventJobCancelled = "job.cancelled"
+)
+
+// Event reports a job reaching a final status
+type Event struct {
+	Type   string
+	Job    Job
+	Result *Result // set for completed jobs
+	Error  string  // set for failed jobs
+}
+
+// Notifier is told about finished jobs. It runs in the background; errors
+// are logged and do not affect the job.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+var (
+	mu             sync.RWMutex
+	preProcessors  []PreProcessor
+	postProcessors []PostProcessor
+	notifiers      []Notifier
+)
+
+// RegisterPreProcessor adds a pre-processor; stages run in registration order
+func RegisterPreProcessor(p PreProcessor) {
+	mu.Lock()
+	defer mu.Unlock()
+	preProcessors = append(preProcessors, p)
+}
+
+// RegisterPostProcessor adds a post-processor; stages run in registration order
+func RegisterPostProcessor(p PostProcessor) {
+	mu.Lock()
+	defer mu.Unlock()
+	postProcessors = append(postProcessors, p)
+}
+
+// RegisterNotifier adds a notifier
+func RegisterNotifier(n Notifier) {
+	mu.Lock()
+	defer mu.Unlock()
+	notifiers = append(notifiers, n)
+}
+
+// PreProcessors returns the registered pre-processors
+func PreProcessors() []PreProcessor {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]PreProcessor(nil), preProcessors...)
+}
+
+// PostProcessors returns the registered post-processors
+func PostProcessors() []PostProcessor {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]PostProcessor(nil), postProcessors...)
+}
+
+// Notifiers returns the registered notifiers
+func Notifiers() []Notifier {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Notifier(nil), notifiers...)
+}